@@ -14,7 +14,6 @@ import (
 
     "recipe-generator/internal/api/config"
     "recipe-generator/internal/api/service"
-    "recipe-generator/internal/api/handler"
 )
 
 func main() {
@@ -29,7 +28,7 @@ func main() {
     base64Image := getBase64Image(config)
     
     // grab prompt. Toss this in an env variable when completed.
-    aiPrompt := `I need the name, a description, prep time, cook time and servings made in addition to the ingredients. I also need in json format. The json should be an array of recipes with those fields stated above. In each recipe object, there should also be an ingredients array which is an array of objects, and a procedure step array which is just an array of strings. In the ingredients object, there should be unit of measurement field, unit amount field, and ingredient name. Any units of measurement that are fractions should be converted to decimal. The procedure array should be named "procedure_steps". Each procedure step should be exactly one action or at most two actions. Also for the json names, use snake case instead of camel case. Lastly, if the unit of measurement is not a "real" unit of measurement, i.e. a whole, or a pinch or handful or to taste, add an extra field to say so. Add a field called true_ingredient name. If the ingredient name has anything other than the ingredient name in the text, and that as the ingredient name and add the true ingredient name with just the ingredient name with no extra text. Also for each recipe, I need a combined confidence level of the accuracy of the optical character recognition. 0 being the least confident and 10 being the most. Do not infer any json fields if they don't exist. Put null if the field I asked for doesn't exist. Also, only return the json. Don't return other text.`
+    aiPrompt := `I need the name, a description, prep time, cook time and servings made in addition to the ingredients. I also need in json format. The json should be an array of recipes with those fields stated above. In each recipe object, there should also be an ingredients array which is an array of objects, and a procedure step array which is just an array of strings. In the ingredients object, there should be unit of measurement field, unit amount field, and ingredient name. Any units of measurement that are fractions should be converted to decimal. The procedure array should be named "procedure_steps". Each procedure step should be exactly one action or at most two actions. Also for the json names, use snake case instead of camel case. Lastly, if the unit of measurement is not a "real" unit of measurement, i.e. a whole, or a pinch or handful or to taste, add an extra field to say so. Add a field called true_ingredient name. If the ingredient name has anything other than the ingredient name in the text, and that as the ingredient name and add the true ingredient name with just the ingredient name with no extra text. Also for each recipe, I need a combined confidence level of the accuracy of the optical character recognition. 0 being the least confident and 10 being the most. If the page says where the recipe comes from, add a "source" object with "author" for the original author, "cookbook_title" and "page_number" for the cookbook it is from and the page it is on, "source_url" for a website it is from, and "family_member" for a family member it is credited to, such as "Grandma's" or "from Aunt Linda". Also add "notes" for any free-form notes or tips, "make_ahead" for what can be prepared ahead of time, "storage" for how to store leftovers, and "freezing" for how to freeze and thaw it. Add "difficulty", which must be one of easy, medium or hard, "cuisine", such as italian or thai, and "course", which must be one of breakfast, appetizer, soup, salad, main, side, dessert, snack, bread, sauce or drink. Only fill these in if the page states them. Do not infer any json fields if they don't exist. Put null if the field I asked for doesn't exist. Also, only return the json. Don't return other text.`
     
     // body of request
     body := constructBody(base64Image, aiPrompt)
@@ -105,7 +104,7 @@ func getBase64Image(config *config.Config) string {
     fileBytes, err := downsizeImageByBytes(imagePath, imagePath, 1000000)
     
     if err != nil {
-    	log.Printf("Error downsizing image to \n %n", imagePath)
+    	log.Printf("Error downsizing image to \n %s", imagePath)
     	log.Fatal(err)
     }
     
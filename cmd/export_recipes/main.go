@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// exports recipes to read offline, as a self-contained static HTML site with search and index pages by tag and
+// cuisine, as an EPUB 3 e-book, or both. Every recipe is exported unless -collection or -tags picks some. The
+// output is written under a directory, or into a zip archive if its name ends in .zip; the site's files go at
+// the top and the e-book is named after the title.
+//
+// usage: export_recipes [-format site,epub] [-title title] [-collection id] [-tags tag,...] -out directory|file.zip
+func main() {
+	formats := flag.String("format", "site,epub", "what to export: site, epub, or both separated by a comma")
+	title := flag.String("title", "", "title of the site and e-book (default the collection's name, or Recipes)")
+	collectionID := flag.Int("collection", 0, "only export this collection's recipes, in its order")
+	tags := flag.String("tags", "", "only export recipes with every one of these comma-separated tags")
+	out := flag.String("out", "", "directory to write to, or a .zip file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-format site,epub] [-title title] [-collection id] [-tags tag,...] -out directory|file.zip\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	site, epub := false, false
+	for _, format := range strings.Split(*formats, ",") {
+		switch strings.TrimSpace(format) {
+		case "site":
+			site = true
+		case "epub":
+			epub = true
+		default:
+			log.Fatalf("Unknown format %q", format)
+		}
+	}
+
+	if *out == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("An error was encountered loading application config: %v\n", err)
+	}
+
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	cookbook, err := loadCookbook(ctx, pool, *title, *collectionID, model.NormalizeTags(strings.Split(*tags, ",")))
+	if err != nil {
+		log.Fatalf("Failed to load recipes: %v", err)
+	}
+
+	var writer service.ExportWriter
+	var archive *service.ZipExportWriter
+	var file *os.File
+
+	if strings.EqualFold(filepath.Ext(*out), ".zip") {
+		file, err = os.Create(*out)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *out, err)
+		}
+		archive = service.NewZipExportWriter(file)
+		writer = archive
+	} else {
+		writer = service.NewDirectoryExportWriter(*out)
+	}
+
+	if site {
+		if err := service.WriteSite(writer, cookbook); err != nil {
+			log.Fatalf("Failed to export site: %v", err)
+		}
+	}
+
+	if epub {
+		var book bytes.Buffer
+		if err := service.WriteEpub(&book, cookbook); err != nil {
+			log.Fatalf("Failed to export e-book: %v", err)
+		}
+		if err := writer.WriteFile(epubName(cookbook.Title), book.Bytes()); err != nil {
+			log.Fatalf("Failed to write e-book: %v", err)
+		}
+	}
+
+	if archive != nil {
+		if err := archive.Close(); err != nil {
+			log.Fatalf("Failed to write %s: %v", *out, err)
+		}
+		if err := file.Close(); err != nil {
+			log.Fatalf("Failed to write %s: %v", *out, err)
+		}
+	}
+
+	log.Printf("Exported %d recipes to %s", len(cookbook.Recipes), *out)
+}
+
+// loadCookbook reads the recipes to export with their primary photos: a collection's recipes in its order, or
+// every recipe with all of tags, or otherwise every recipe, by name.
+func loadCookbook(ctx context.Context, pool *pgxpool.Pool, title string, collectionID int, tags []string) (model.Cookbook, error) {
+	recipeRepository := repository.NewRecipeRepository(pool)
+	collectionRepository := repository.NewCollectionRepository(pool)
+	foodImageRepository := repository.NewFoodImageRepository(pool)
+
+	cookbook := model.Cookbook{Title: title, Photos: map[int][]byte{}}
+
+	if collectionID != 0 {
+		collection, err := collectionRepository.Get(ctx, collectionID)
+		if err != nil {
+			return cookbook, err
+		}
+		if cookbook.Title == "" {
+			cookbook.Title = collection.CollectionName
+		}
+
+		entries, err := collectionRepository.GetRecipes(ctx, collectionID)
+		if err != nil {
+			return cookbook, err
+		}
+		recipeIDs := make([]int, 0, len(entries))
+		for _, entry := range entries {
+			recipeIDs = append(recipeIDs, entry.RecipeId)
+		}
+
+		recipes, err := recipeRepository.GetByIds(ctx, recipeIDs)
+		if err != nil {
+			return cookbook, err
+		}
+		for _, recipeID := range recipeIDs {
+			if recipe, ok := recipes[recipeID]; ok && hasTags(recipe, tags) {
+				cookbook.Recipes = append(cookbook.Recipes, recipe)
+			}
+		}
+	} else {
+		recipes, err := recipeRepository.GetAll(ctx)
+		if err != nil {
+			return cookbook, err
+		}
+		for _, recipe := range recipes {
+			if hasTags(recipe, tags) {
+				cookbook.Recipes = append(cookbook.Recipes, recipe)
+			}
+		}
+		sort.SliceStable(cookbook.Recipes, func(i, j int) bool {
+			return strings.ToLower(cookbook.Recipes[i].RecipeName) < strings.ToLower(cookbook.Recipes[j].RecipeName)
+		})
+	}
+
+	if cookbook.Title == "" {
+		cookbook.Title = "Recipes"
+	}
+
+	recipeIDs := make([]int, 0, len(cookbook.Recipes))
+	for _, recipe := range cookbook.Recipes {
+		recipeIDs = append(recipeIDs, recipe.ID)
+	}
+
+	photos, err := foodImageRepository.GetPrimaryByRecipeIds(ctx, recipeIDs)
+	if err != nil {
+		return cookbook, err
+	}
+	for recipeID, photo := range photos {
+		contents, err := os.ReadFile(photo.Filepath)
+		if err != nil {
+			log.Printf("Leaving out the photo of recipe %d: %v", recipeID, err)
+			continue
+		}
+		cookbook.Photos[recipeID] = contents
+	}
+
+	return cookbook, nil
+}
+
+// hasTags reports whether a recipe has every one of tags.
+func hasTags(recipe *model.Recipe, tags []string) bool {
+	recipeTags := map[string]bool{}
+	for _, tag := range model.NormalizeTags(recipe.Tags) {
+		recipeTags[tag] = true
+	}
+
+	for _, tag := range tags {
+		if !recipeTags[tag] {
+			return false
+		}
+	}
+	return true
+}
+
+// epubName is the file name of the e-book, after its title.
+func epubName(title string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(title))
+
+	if name == "" {
+		name = "Recipes"
+	}
+	return name + ".epub"
+}
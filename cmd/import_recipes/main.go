@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/handler"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// imports the recipes exported from other recipe managers: MealMaster .mmf files, Paprika .paprikarecipes
+// archives, Mealie and Tandoor export zips, and Cooklang .cook files. Prints a JSON report of what happened
+// to each recipe.
+//
+// usage: import_recipes [-dry-run] [-strict] [-user id] file...
+func main() {
+	dryRun := flag.Bool("dry-run", false, "check the recipes without saving anything")
+	strict := flag.Bool("strict", false, "reject recipes that look like duplicates of existing ones (default STRICT_DUPLICATE_CHECK)")
+	userID := flag.Int("user", middleware.DefaultUserID, "ID of the user to save the recipes as")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-dry-run] [-strict] [-user id] file...\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("An error was encountered loading application config: %v\n", err)
+	}
+
+	files := make([]model.ImportFile, 0, flag.NArg())
+	for _, name := range flag.Args() {
+		contents, err := os.ReadFile(name)
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", name, err)
+		}
+		files = append(files, model.ImportFile{Name: filepath.Base(name), Contents: contents})
+	}
+
+	pool, err := pgxpool.New(context.Background(), cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	recommendations := service.NewRecommendationService(repository.NewRecipeRepository(pool).GetAll)
+	recipeHandler := handler.NewRecipeHandler(pool, cfg, recommendations)
+
+	options := model.ImportOptions{
+		UserId: *userID,
+		Strict: cfg.StrictDuplicateCheck,
+		DryRun: *dryRun,
+	}
+	// only override the setting if the flag was given.
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "strict" {
+			options.Strict = *strict
+		}
+	})
+
+	report := recipeHandler.ImportFiles(context.Background(), files, options)
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	fmt.Println(string(output))
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/repository"
 	"recipe-generator/internal/api/router"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -34,6 +35,14 @@ func main() {
 	}
 	log.Printf("Database connection established successfully")
 
+	// cookbook jobs the last run left pending or running will never be generated, so fail them.
+	failedCookbooks, err := repository.NewCookbookRepository(connectionPool).FailUnfinished(context.Background(), "the server restarted before the cookbook was generated")
+	if err != nil {
+		log.Printf("Failed to clean up unfinished cookbooks: %v", err)
+	} else if failedCookbooks > 0 {
+		log.Printf("Marked %d unfinished cookbooks as failed", failedCookbooks)
+	}
+
 	// close the database when this function is exited
 	defer func() {
 		log.Printf("Closing database connection...")
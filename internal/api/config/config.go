@@ -3,6 +3,8 @@ package config
 
 import (
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -21,6 +23,16 @@ type Config struct {
 	CertFile        string        // Path to SSL certificate file
 	KeyFile         string        // Path to SSL key file
 
+	// recipe submission settings
+	StrictDuplicateCheck bool  // Reject new recipes that look like duplicates instead of only warning
+	AdminUserIds         []int // Users allowed to use the admin endpoints; the dummy user when unset
+
+	// cooking session settings
+	CookingSessionIdleTimeout time.Duration // How long a cooking session lasts without activity; 2 hours when unset
+
+	// recipe export settings
+	RecipeTemplatesDirectory string // Directory of recipe.html and recipe.md templates that replace the built-in recipe cards
+
 	// anthropic api/image location constants
 	AnthropicApiUrl         string
 	RecipeImagesLocation string
@@ -53,9 +65,35 @@ func Load() (*Config, error) {
 		CertFile:        viper.GetString("CERT_FILE"),
 		KeyFile:         viper.GetString("KEY_FILE"),
 
+		// recipe submission settings
+		StrictDuplicateCheck: viper.GetBool("STRICT_DUPLICATE_CHECK"),
+		AdminUserIds:         parseUserIds(viper.GetString("ADMIN_USER_IDS")),
+
+		// cooking session settings
+		CookingSessionIdleTimeout: viper.GetDuration("COOKING_SESSION_IDLE_TIMEOUT"),
+
+		// recipe export settings
+		RecipeTemplatesDirectory: viper.GetString("RECIPE_TEMPLATES_DIR"),
+
 		// anthropic api/image location constants
 		AnthropicApiUrl:         viper.GetString("ANTHROPIC_API_URL"),
 		AnthropicApiKey:      viper.GetString("ANTHROPIC_API_KEY"),
 		RecipeImagesLocation: viper.GetString("RECIPE_IMAGES_LOCATION"),
 	}, nil
 }
+
+// parseUserIds parses a comma separated list of user IDs, skipping anything that isn't a positive integer.
+// An empty list makes the dummy user, 1, the only admin, since there is no real authentication yet.
+func parseUserIds(value string) []int {
+	userIds := []int{}
+	for _, field := range strings.Split(value, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(field)); err == nil && id > 0 {
+			userIds = append(userIds, id)
+		}
+	}
+
+	if len(userIds) == 0 {
+		return []int{1}
+	}
+	return userIds
+}
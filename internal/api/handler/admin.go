@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// AdminHandler manages HTTP requests for the admin endpoints.
+// Only the users in the ADMIN_USER_IDS setting may use them.
+type AdminHandler struct {
+	// RecipeRepository handles database operations for recipes
+	RecipeRepository *repository.RecipeRepository
+	// IngredientsRepository handles database operations for ingredients
+	IngredientsRepository *repository.IngredientsRepository
+	// RecommendationService recommends similar recipes, refreshed when recipes are merged
+	RecommendationService *service.RecommendationService
+	// ComponentService checks that merging doesn't make a recipe use itself as a component
+	ComponentService *service.ComponentService
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewAdminHandler creates a new AdminHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//   - recommendations: Similar recipe recommendations, refreshed when recipes are merged
+//
+// Returns:
+//   - *AdminHandler: A new admin handler instance
+func NewAdminHandler(pool *pgxpool.Pool, config *config.Config, recommendations *service.RecommendationService) *AdminHandler {
+	return &AdminHandler{
+		RecipeRepository:      repository.NewRecipeRepository(pool),
+		IngredientsRepository: repository.NewIngredientsRepository(pool),
+		RecommendationService: recommendations,
+		ComponentService:      service.NewComponentService(),
+		Config:                config,
+	}
+}
+
+// DuplicateReport returns an HTTP handler function that reports clusters of existing recipes that are likely
+// duplicates of each other, with the pairwise scores that put them together. ?threshold= sets the lowest
+// score treated as a duplicate, from 0 to 1 (0.65 by default).
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes duplicate report requests
+func (ah *AdminHandler) DuplicateReport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := ah.requireAdmin(r); err != nil {
+			writeModelError(w, ah.Config, "Error checking admin access", err)
+			return
+		}
+
+		threshold, err := queryFloat(r, "threshold", service.DefaultDuplicateThreshold)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			writeModelError(w, ah.Config, "Invalid threshold", model.ErrInvalidField("threshold"))
+			return
+		}
+
+		recipes, err := ah.IngredientsRepository.GetAllRecipesWithIngredients(r.Context())
+		if err != nil {
+			writeModelError(w, ah.Config, "Error retrieving recipes", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, service.DuplicateClusters(recipes, threshold))
+	}
+}
+
+// MergeDuplicates returns an HTTP handler function that merges duplicate recipes into one. The kept recipe
+// gets the most complete ingredient list and procedure among them, along with their tags, reviews, cook logs,
+// meal plan entries, collection entries and images, and the duplicates are deleted.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes duplicate merge requests
+func (ah *AdminHandler) MergeDuplicates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := ah.requireAdmin(r); err != nil {
+			writeModelError(w, ah.Config, "Error checking admin access", err)
+			return
+		}
+
+		var request model.DuplicateMergeRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := request.Validate(); err != nil {
+			writeModelError(w, ah.Config, "Merge request validation failed", err)
+			return
+		}
+
+		recipes, err := ah.RecipeRepository.GetByIds(r.Context(), append([]int{request.KeepId}, request.RecipeIds...))
+		if err != nil {
+			writeModelError(w, ah.Config, "Error retrieving recipes", err)
+			return
+		}
+
+		keep, ok := recipes[request.KeepId]
+		if !ok {
+			writeModelError(w, ah.Config, "Error retrieving recipes", model.ErrNotFound("recipe"))
+			return
+		}
+
+		duplicates := make([]*model.Recipe, 0, len(request.RecipeIds))
+		for _, recipeID := range request.RecipeIds {
+			duplicate, ok := recipes[recipeID]
+			if !ok {
+				writeModelError(w, ah.Config, "Error retrieving recipes", model.ErrNotFound("recipe"))
+				return
+			}
+			duplicates = append(duplicates, duplicate)
+		}
+
+		merged := service.MergeRecipes(keep, duplicates)
+
+		if err := ah.checkMergedComponents(r, merged, request.RecipeIds); err != nil {
+			writeModelError(w, ah.Config, "Error merging recipes", err)
+			return
+		}
+
+		if err := ah.RecipeRepository.Merge(r.Context(), merged, request.RecipeIds, middleware.UserID(r.Context())); err != nil {
+			writeModelError(w, ah.Config, "Error merging recipes", err)
+			return
+		}
+		ah.RecommendationService.Invalidate()
+
+		recipes, err = ah.RecipeRepository.GetByIds(r.Context(), []int{merged.ID})
+		if err != nil {
+			writeModelError(w, ah.Config, "Error retrieving merged recipe", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, recipes[merged.ID])
+	}
+}
+
+// private functions
+
+// checkMergedComponents checks that merging duplicates into a recipe doesn't make it use itself as a component.
+// Recipes that use a duplicate as a component will use the merged recipe instead, so a recipe that is both a
+// component of a duplicate and uses it, directly or through other components, would lead back to itself.
+func (ah *AdminHandler) checkMergedComponents(r *http.Request, merged *model.Recipe, duplicateIDs []int) error {
+	components, err := ah.ComponentService.LoadComponents(r.Context(), []*model.Recipe{merged}, ah.RecipeRepository.GetByIds)
+	if err != nil {
+		return err
+	}
+
+	aliases := make(map[int]int, len(duplicateIDs))
+	for _, duplicateID := range duplicateIDs {
+		aliases[duplicateID] = merged.ID
+	}
+
+	return ah.ComponentService.CheckCycles(merged.ID, merged.Ingredients, components, aliases)
+}
+
+// requireAdmin checks that the current user is one of the admins in the ADMIN_USER_IDS setting.
+func (ah *AdminHandler) requireAdmin(r *http.Request) error {
+	if !slices.Contains(ah.Config.AdminUserIds, middleware.UserID(r.Context())) {
+		return model.ErrPermissionDenied("admin only")
+	}
+	return nil
+}
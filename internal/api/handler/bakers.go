@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"recipe-generator/internal/api/model"
+)
+
+// BakersPercentages returns an HTTP handler function that expresses a recipe as baker's percentages: each
+// ingredient's weight as a percentage of the total flour weight, along with the dough's hydration. Ingredients
+// measured by volume are weighed through the density table; those that can't be weighed are listed separately.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes baker's percentage requests
+func (rh *RecipeHandler) BakersPercentages() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipe, err := rh.pathRecipe(r)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving recipe", err)
+			return
+		}
+
+		formula, err := rh.BakersService.Percentages(recipe)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error working out baker's percentages", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, formula)
+	}
+}
+
+// BakersFormula returns an HTTP handler function that works out the ingredient amounts, in grams, for a dough
+// of a target flour or dough weight from a recipe's baker's percentages, optionally with some percentages
+// changed. The formula is returned as a preview and not saved.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes dough formula requests
+func (rh *RecipeHandler) BakersFormula() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipe, err := rh.pathRecipe(r)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving recipe", err)
+			return
+		}
+
+		var request model.BakersFormulaRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := request.Validate(); err != nil {
+			writeModelError(w, rh.Config, "Dough formula request validation failed", err)
+			return
+		}
+
+		formula, err := rh.BakersService.Percentages(recipe)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error working out baker's percentages", err)
+			return
+		}
+
+		generated, err := rh.BakersService.Generate(formula, request)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error generating dough formula", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, generated)
+	}
+}
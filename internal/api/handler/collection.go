@@ -0,0 +1,535 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+)
+
+// CollectionHandler manages HTTP requests related to collections.
+// It provides methods for creating, sharing and ordering a user's collections of recipes.
+type CollectionHandler struct {
+	// CollectionRepository handles database operations for collections
+	CollectionRepository *repository.CollectionRepository
+	// RecipeRepository handles database operations for recipes
+	RecipeRepository *repository.RecipeRepository
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewCollectionHandler creates a new CollectionHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *CollectionHandler: A new collection handler instance
+func NewCollectionHandler(pool *pgxpool.Pool, config *config.Config) *CollectionHandler {
+	return &CollectionHandler{
+		CollectionRepository: repository.NewCollectionRepository(pool),
+		RecipeRepository:     repository.NewRecipeRepository(pool),
+		Config:               config,
+	}
+}
+
+// collectionRequest is the request body for creating or updating a collection.
+type collectionRequest struct {
+	CollectionName string `json:"collectionName"`
+	Description    string `json:"description"`
+}
+
+// collectionRecipeRequest is the request body for adding a recipe to a collection or updating its notes.
+type collectionRecipeRequest struct {
+	RecipeId int    `json:"recipeId"`
+	Notes    string `json:"notes"`
+}
+
+// reorderRequest is the request body for reordering a collection's recipes.
+type reorderRequest struct {
+	RecipeIds []int `json:"recipeIds"`
+}
+
+// Create returns an HTTP handler function that creates a collection owned by the current user.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes collection creation requests
+func (ch *CollectionHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request collectionRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		collection := model.NewCollection(request.CollectionName, middleware.UserID(r.Context()))
+		collection.Description = request.Description
+
+		if err := collection.Validate(); err != nil {
+			writeModelError(w, ch.Config, "Collection validation failed", err)
+			return
+		}
+
+		saved, err := ch.CollectionRepository.Insert(r.Context(), collection)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error creating collection", err)
+			return
+		}
+
+		saved.Permission = model.CollectionPermissionOwner
+		writeJSON(w, http.StatusCreated, saved)
+	}
+}
+
+// List returns an HTTP handler function that lists the collections the current user owns or has been shared.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes collection list requests
+func (ch *CollectionHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collections, err := ch.CollectionRepository.ListForUser(r.Context(), middleware.UserID(r.Context()))
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving collections", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, collections)
+	}
+}
+
+// Get returns an HTTP handler function that returns a collection with its recipes fully hydrated.
+// The recipes, their ingredients and their procedure steps are loaded with one query per table
+// rather than one round trip per recipe.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes collection retrieval requests
+func (ch *CollectionHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid collection ID", err)
+			return
+		}
+
+		permission, err := ch.authorize(r.Context(), collectionID, false)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error checking collection permission", err)
+			return
+		}
+
+		collection, err := ch.CollectionRepository.Get(r.Context(), collectionID)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving collection", err)
+			return
+		}
+		collection.Permission = permission
+
+		entries, err := ch.CollectionRepository.GetRecipes(r.Context(), collectionID)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving collection recipes", err)
+			return
+		}
+
+		recipeIDs := make([]int, 0, len(entries))
+		for _, entry := range entries {
+			recipeIDs = append(recipeIDs, entry.RecipeId)
+		}
+
+		recipes, err := ch.RecipeRepository.GetByIds(r.Context(), recipeIDs)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving collection recipes", err)
+			return
+		}
+
+		for i := range entries {
+			entries[i].Recipe = recipes[entries[i].RecipeId]
+		}
+		collection.Recipes = entries
+
+		// only the owner gets to see who else can see the collection.
+		if permission == model.CollectionPermissionOwner {
+			collection.Shares, err = ch.CollectionRepository.GetShares(r.Context(), collectionID)
+			if err != nil {
+				writeModelError(w, ch.Config, "Error retrieving collection shares", err)
+				return
+			}
+		}
+
+		writeJSON(w, http.StatusOK, collection)
+	}
+}
+
+// Update returns an HTTP handler function that renames a collection or changes its description.
+// The owner and users with edit access can update a collection.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes collection update requests
+func (ch *CollectionHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid collection ID", err)
+			return
+		}
+
+		var request collectionRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		permission, err := ch.authorize(r.Context(), collectionID, true)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error checking collection permission", err)
+			return
+		}
+
+		collection, err := ch.CollectionRepository.Get(r.Context(), collectionID)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving collection", err)
+			return
+		}
+
+		collection.CollectionName = request.CollectionName
+		collection.Description = request.Description
+		collection.UpdatedBy = middleware.UserID(r.Context())
+		collection.UpdatedDate = time.Now()
+		collection.Permission = permission
+
+		if err := collection.Validate(); err != nil {
+			writeModelError(w, ch.Config, "Collection validation failed", err)
+			return
+		}
+
+		if err := ch.CollectionRepository.Update(r.Context(), collection); err != nil {
+			writeModelError(w, ch.Config, "Error updating collection", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, collection)
+	}
+}
+
+// Delete returns an HTTP handler function that deletes a collection. Only the owner can delete it.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes collection deletion requests
+func (ch *CollectionHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid collection ID", err)
+			return
+		}
+
+		if err := ch.authorizeOwner(r.Context(), collectionID); err != nil {
+			writeModelError(w, ch.Config, "Error checking collection permission", err)
+			return
+		}
+
+		if err := ch.CollectionRepository.Delete(r.Context(), collectionID); err != nil {
+			writeModelError(w, ch.Config, "Error deleting collection", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AddRecipe returns an HTTP handler function that appends a recipe, with optional notes, to a collection.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes add-recipe requests
+func (ch *CollectionHandler) AddRecipe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid collection ID", err)
+			return
+		}
+
+		var request collectionRecipeRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if request.RecipeId == 0 {
+			writeModelError(w, ch.Config, "Collection recipe validation failed", model.ErrMissingRequiredField("recipeId"))
+			return
+		}
+
+		if _, err := ch.authorize(r.Context(), collectionID, true); err != nil {
+			writeModelError(w, ch.Config, "Error checking collection permission", err)
+			return
+		}
+
+		entry := &model.CollectionRecipe{RecipeId: request.RecipeId, Notes: request.Notes}
+
+		entry, err = ch.CollectionRepository.AddRecipe(r.Context(), collectionID, entry, middleware.UserID(r.Context()))
+		if err != nil {
+			writeModelError(w, ch.Config, "Error adding recipe to collection", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, entry)
+	}
+}
+
+// UpdateRecipe returns an HTTP handler function that replaces the notes on a recipe in a collection.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes recipe note update requests
+func (ch *CollectionHandler) UpdateRecipe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid collection ID", err)
+			return
+		}
+
+		recipeID, err := pathID(r, "recipeId")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		var request collectionRecipeRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if _, err := ch.authorize(r.Context(), collectionID, true); err != nil {
+			writeModelError(w, ch.Config, "Error checking collection permission", err)
+			return
+		}
+
+		err = ch.CollectionRepository.UpdateRecipeNotes(r.Context(), collectionID, recipeID, request.Notes, middleware.UserID(r.Context()))
+		if err != nil {
+			writeModelError(w, ch.Config, "Error updating collection recipe", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RemoveRecipe returns an HTTP handler function that removes a recipe from a collection.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes remove-recipe requests
+func (ch *CollectionHandler) RemoveRecipe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid collection ID", err)
+			return
+		}
+
+		recipeID, err := pathID(r, "recipeId")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		if _, err := ch.authorize(r.Context(), collectionID, true); err != nil {
+			writeModelError(w, ch.Config, "Error checking collection permission", err)
+			return
+		}
+
+		if err := ch.CollectionRepository.RemoveRecipe(r.Context(), collectionID, recipeID); err != nil {
+			writeModelError(w, ch.Config, "Error removing recipe from collection", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Reorder returns an HTTP handler function that reorders a collection's recipes.
+// The request body must list every recipe ID in the collection in the new order.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes reorder requests
+func (ch *CollectionHandler) Reorder() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid collection ID", err)
+			return
+		}
+
+		var request reorderRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if _, err := ch.authorize(r.Context(), collectionID, true); err != nil {
+			writeModelError(w, ch.Config, "Error checking collection permission", err)
+			return
+		}
+
+		err = ch.CollectionRepository.Reorder(r.Context(), collectionID, request.RecipeIds, middleware.UserID(r.Context()))
+		if err != nil {
+			writeModelError(w, ch.Config, "Error reordering collection", err)
+			return
+		}
+
+		entries, err := ch.CollectionRepository.GetRecipes(r.Context(), collectionID)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving collection recipes", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, entries)
+	}
+}
+
+// Share returns an HTTP handler function that shares a collection with another user as read-only or editable.
+// Only the owner can share a collection.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes share requests
+func (ch *CollectionHandler) Share() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid collection ID", err)
+			return
+		}
+
+		userID, err := pathID(r, "userId")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid user ID", err)
+			return
+		}
+
+		var share model.CollectionShare
+		if err := decodeJSON(r, &share); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		share.UserId = userID
+
+		if err := share.Validate(); err != nil {
+			writeModelError(w, ch.Config, "Collection share validation failed", err)
+			return
+		}
+
+		if err := ch.authorizeOwner(r.Context(), collectionID); err != nil {
+			writeModelError(w, ch.Config, "Error checking collection permission", err)
+			return
+		}
+
+		if userID == middleware.UserID(r.Context()) {
+			writeModelError(w, ch.Config, "Collection share validation failed", model.ErrInvalidField("userId"))
+			return
+		}
+
+		if err := ch.CollectionRepository.Share(r.Context(), collectionID, &share, middleware.UserID(r.Context())); err != nil {
+			writeModelError(w, ch.Config, "Error sharing collection", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, share)
+	}
+}
+
+// Unshare returns an HTTP handler function that revokes a user's access to a collection.
+// Only the owner can unshare a collection.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes unshare requests
+func (ch *CollectionHandler) Unshare() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collectionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid collection ID", err)
+			return
+		}
+
+		userID, err := pathID(r, "userId")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid user ID", err)
+			return
+		}
+
+		if err := ch.authorizeOwner(r.Context(), collectionID); err != nil {
+			writeModelError(w, ch.Config, "Error checking collection permission", err)
+			return
+		}
+
+		if err := ch.CollectionRepository.Unshare(r.Context(), collectionID, userID); err != nil {
+			writeModelError(w, ch.Config, "Error unsharing collection", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// private functions
+
+// authorize checks that the current user can view, or if needEdit is set, edit the collection.
+//
+// Parameters:
+//   - ctx: The request context carrying the current user
+//   - collectionID: The collection being accessed
+//   - needEdit: Whether the caller needs edit access rather than read access
+//
+// Returns:
+//   - string: The current user's permission on the collection
+//   - error: model.ErrNotFound or model.ErrPermissionDenied if access is not allowed
+func (ch *CollectionHandler) authorize(ctx context.Context, collectionID int, needEdit bool) (string, error) {
+	permission, err := ch.CollectionRepository.GetPermission(ctx, collectionID, middleware.UserID(ctx))
+	if err != nil {
+		return "", err
+	}
+
+	if permission == "" {
+		return "", model.ErrPermissionDenied("collection is not shared with you")
+	}
+
+	if needEdit && !model.CanEditCollection(permission) {
+		return "", model.ErrPermissionDenied("collection is shared with you as read-only")
+	}
+
+	return permission, nil
+}
+
+// authorizeOwner checks that the current user owns the collection.
+//
+// Parameters:
+//   - ctx: The request context carrying the current user
+//   - collectionID: The collection being accessed
+//
+// Returns:
+//   - error: model.ErrNotFound or model.ErrPermissionDenied if the user is not the owner
+func (ch *CollectionHandler) authorizeOwner(ctx context.Context, collectionID int) error {
+	permission, err := ch.authorize(ctx, collectionID, false)
+	if err != nil {
+		return err
+	}
+
+	if permission != model.CollectionPermissionOwner {
+		return model.ErrPermissionDenied("only the collection owner can do this")
+	}
+
+	return nil
+}
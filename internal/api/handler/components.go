@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+
+	"recipe-generator/internal/api/model"
+)
+
+// private functions
+
+// resolveComponents loads the component recipes a recipe being saved uses, fills in their ingredients' names
+// and units, and checks that the recipe doesn't use itself through them.
+func (rh *RecipeHandler) resolveComponents(ctx context.Context, recipe *model.Recipe) error {
+	components, err := rh.ComponentService.LoadComponents(ctx, []*model.Recipe{recipe}, rh.RecipeRepository.GetByIds)
+	if err != nil {
+		return err
+	}
+
+	if err := rh.ComponentService.Resolve(recipe.Ingredients, components); err != nil {
+		return err
+	}
+
+	return rh.ComponentService.CheckCycles(recipe.ID, recipe.Ingredients, components, nil)
+}
+
+// expandComponents returns a copy of a recipe with its component recipes inline, scaled to the amounts used.
+func (rh *RecipeHandler) expandComponents(ctx context.Context, recipe *model.Recipe) (*model.Recipe, error) {
+	components, err := rh.ComponentService.LoadComponents(ctx, []*model.Recipe{recipe}, rh.RecipeRepository.GetByIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return rh.ComponentService.Expand(recipe, components), nil
+}
@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// cookbookTimeout is how long a cookbook has to be generated before its job fails.
+const cookbookTimeout = 10 * time.Minute
+
+// cookbookWorkers is how many cookbooks are generated at once. Each holds its recipes' photos in memory, so
+// the other jobs stay pending until one finishes.
+const cookbookWorkers = 2
+
+// CookbookHandler manages HTTP requests related to PDF cookbooks.
+// It generates printable cookbooks from a collection or a tag filter in the background and serves them once
+// they are ready.
+type CookbookHandler struct {
+	// CookbookRepository handles database operations for cookbook jobs
+	CookbookRepository *repository.CookbookRepository
+	// CollectionRepository handles database operations for collections
+	CollectionRepository *repository.CollectionRepository
+	// RecipeRepository handles database operations for recipes
+	RecipeRepository *repository.RecipeRepository
+	// FoodImageRepository handles database operations for photos of recipes
+	FoodImageRepository *repository.FoodImageRepository
+	// Config contains application configuration
+	Config *config.Config
+	// workers holds a slot for each cookbook being generated, up to cookbookWorkers
+	workers chan struct{}
+}
+
+// NewCookbookHandler creates a new CookbookHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *CookbookHandler: A new cookbook handler instance
+func NewCookbookHandler(pool *pgxpool.Pool, config *config.Config) *CookbookHandler {
+	return &CookbookHandler{
+		CookbookRepository:   repository.NewCookbookRepository(pool),
+		CollectionRepository: repository.NewCollectionRepository(pool),
+		RecipeRepository:     repository.NewRecipeRepository(pool),
+		FoodImageRepository:  repository.NewFoodImageRepository(pool),
+		Config:               config,
+		workers:              make(chan struct{}, cookbookWorkers),
+	}
+}
+
+// Create returns an HTTP handler function that starts generating a PDF cookbook of a collection the current
+// user can see, or of the recipes with all of some tags. It responds 202 Accepted with the pending job; poll
+// GET /cookbooks/{id} until its status is done and download it from its downloadUrl.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cookbook requests
+func (ch *CookbookHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request model.CookbookRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := request.Validate(); err != nil {
+			writeModelError(w, ch.Config, "Cookbook validation failed", err)
+			return
+		}
+
+		if ch.Config.RecipeImagesLocation == "" {
+			writeInternalError(w, ch.Config, "Error generating cookbook", errors.New("RECIPE_IMAGES_LOCATION is not set"))
+			return
+		}
+
+		job := &model.CookbookJob{
+			UserId:       middleware.UserID(r.Context()),
+			Title:        request.Title,
+			CollectionId: request.CollectionId,
+			Tags:         model.NormalizeTags(request.Tags),
+			Layout:       request.Layout,
+			Status:       model.CookbookStatusPending,
+		}
+		if job.Layout == "" {
+			job.Layout = model.CookbookLayoutSingle
+		}
+
+		if job.CollectionId != 0 {
+			permission, err := ch.CollectionRepository.GetPermission(r.Context(), job.CollectionId, job.UserId)
+			if err != nil {
+				writeModelError(w, ch.Config, "Error retrieving collection", err)
+				return
+			}
+			if permission == "" {
+				writeModelError(w, ch.Config, "Error retrieving collection", model.ErrPermissionDenied("collection is not shared with you"))
+				return
+			}
+
+			if job.Title == "" {
+				collection, err := ch.CollectionRepository.Get(r.Context(), job.CollectionId)
+				if err != nil {
+					writeModelError(w, ch.Config, "Error retrieving collection", err)
+					return
+				}
+				job.Title = collection.CollectionName
+			}
+		}
+
+		job, err := ch.CookbookRepository.Insert(r.Context(), job)
+		if err != nil {
+			writeInternalError(w, ch.Config, "Error saving cookbook", err)
+			return
+		}
+
+		go ch.generate(*job)
+
+		writeJSON(w, http.StatusAccepted, job)
+	}
+}
+
+// Get returns an HTTP handler function that retrieves one of the current user's cookbook jobs, with the link
+// to download the PDF once it is done.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cookbook job requests
+func (ch *CookbookHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := ch.getOwnJob(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cookbook", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, job)
+	}
+}
+
+// Download returns an HTTP handler function that serves one of the current user's generated cookbooks as a
+// PDF attachment. It responds 409 Conflict if the cookbook isn't done.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cookbook download requests
+func (ch *CookbookHandler) Download() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := ch.getOwnJob(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cookbook", err)
+			return
+		}
+
+		if job.Status != model.CookbookStatusDone {
+			writeModelError(w, ch.Config, "Error downloading cookbook", model.ErrConflict("the cookbook is "+job.Status))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("cookbook-%d.pdf", job.ID)))
+		http.ServeFile(w, r, job.Filepath)
+	}
+}
+
+// private functions
+
+// getOwnJob retrieves the cookbook job named by the {id} path value, setting its download link if it is done.
+//
+// Returns:
+//   - *model.CookbookJob: The job
+//   - error: model.ErrNotFound, or model.ErrPermissionDenied if it belongs to another user
+func (ch *CookbookHandler) getOwnJob(r *http.Request) (*model.CookbookJob, error) {
+	jobID, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := ch.CookbookRepository.Get(r.Context(), jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.UserId != middleware.UserID(r.Context()) {
+		return nil, model.ErrPermissionDenied("cookbook belongs to another user")
+	}
+
+	if job.Status == model.CookbookStatusDone {
+		job.DownloadUrl = fmt.Sprintf("/cookbooks/%d/download", job.ID)
+	}
+
+	return job, nil
+}
+
+// generate builds a cookbook job's PDF under RECIPE_IMAGES_LOCATION/cookbooks and records whether it is done
+// or failed. It runs in the background, after the request that started it has been answered, and waits for
+// one of the cookbookWorkers to be free.
+func (ch *CookbookHandler) generate(job model.CookbookJob) {
+	ch.workers <- struct{}{}
+	defer func() { <-ch.workers }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cookbookTimeout)
+	defer cancel()
+
+	job.Status = model.CookbookStatusRunning
+	if err := ch.CookbookRepository.Update(ctx, &job); err != nil {
+		log.Printf("Error starting cookbook %d: %v", job.ID, err)
+
+		// if this fails too the job is failed when the server next starts.
+		job.Status = model.CookbookStatusFailed
+		job.Error = "the cookbook couldn't be started"
+		ch.CookbookRepository.Update(ctx, &job)
+		return
+	}
+
+	if err := ch.build(ctx, &job); err != nil {
+		log.Printf("Error generating cookbook %d: %v", job.ID, err)
+		job.Status = model.CookbookStatusFailed
+		job.Error = err.Error()
+	} else {
+		log.Printf("Generated cookbook %d with %d recipes on %d pages", job.ID, job.RecipeCount, job.PageCount)
+		job.Status = model.CookbookStatusDone
+	}
+
+	if err := ch.CookbookRepository.Update(ctx, &job); err != nil && job.Filepath != "" {
+		os.Remove(job.Filepath)
+	}
+}
+
+// build loads a cookbook job's recipes and their photos, lays them out and writes the PDF, setting the job's
+// counts and file.
+func (ch *CookbookHandler) build(ctx context.Context, job *model.CookbookJob) error {
+	recipeIDs := []int{}
+
+	if job.CollectionId != 0 {
+		entries, err := ch.CollectionRepository.GetRecipes(ctx, job.CollectionId)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			recipeIDs = append(recipeIDs, entry.RecipeId)
+		}
+	} else {
+		recipes, _, err := ch.RecipeRepository.List(ctx, repository.RecipeListOptions{
+			Tags:  job.Tags,
+			Sort:  repository.RecipeSortName,
+			Limit: model.MaxCookbookRecipes,
+		})
+		if err != nil {
+			return err
+		}
+		for _, recipe := range recipes {
+			recipeIDs = append(recipeIDs, recipe.ID)
+		}
+	}
+
+	if len(recipeIDs) == 0 {
+		return errors.New("no recipes match the cookbook")
+	}
+	if len(recipeIDs) > model.MaxCookbookRecipes {
+		recipeIDs = recipeIDs[:model.MaxCookbookRecipes]
+	}
+
+	recipesByID, err := ch.RecipeRepository.GetByIds(ctx, recipeIDs)
+	if err != nil {
+		return err
+	}
+
+	photos, err := ch.FoodImageRepository.GetPrimaryByRecipeIds(ctx, recipeIDs)
+	if err != nil {
+		return err
+	}
+
+	cookbook := model.Cookbook{Title: job.Title, Layout: job.Layout, Photos: map[int][]byte{}}
+	for _, recipeID := range recipeIDs {
+		recipe, ok := recipesByID[recipeID]
+		if !ok {
+			continue
+		}
+		cookbook.Recipes = append(cookbook.Recipes, recipe)
+
+		if photo, ok := photos[recipeID]; ok {
+			contents, err := os.ReadFile(photo.Filepath)
+			if err != nil {
+				log.Printf("Leaving the photo of recipe %d out of cookbook %d: %v", recipeID, job.ID, err)
+				continue
+			}
+			cookbook.Photos[recipeID] = contents
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	contents, pages, err := service.BuildCookbook(cookbook)
+	if err != nil {
+		return err
+	}
+
+	directory := filepath.Join(ch.Config.RecipeImagesLocation, "cookbooks")
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(directory, fmt.Sprintf("%d_%d.pdf", job.ID, time.Now().UnixNano()))
+	if err := os.WriteFile(path, contents, 0o644); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	job.Filepath = path
+	job.SizeBytes = int64(len(contents))
+	job.RecipeCount = len(cookbook.Recipes)
+	job.PageCount = pages
+	return nil
+}
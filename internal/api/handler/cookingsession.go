@@ -0,0 +1,597 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// defaultCookingSessionIdleTimeout is how long a cooking session lasts without activity when
+// COOKING_SESSION_IDLE_TIMEOUT isn't set.
+const defaultCookingSessionIdleTimeout = 2 * time.Hour
+
+// cookingSessionSweepInterval is how often expired cooking sessions are looked for, so the devices following
+// an idle session hear that it expired.
+const cookingSessionSweepInterval = time.Minute
+
+// CookingSessionHandler manages HTTP requests related to cooking sessions.
+type CookingSessionHandler struct {
+	// CookingSessionRepository handles database operations for cooking sessions and their timers
+	CookingSessionRepository *repository.CookingSessionRepository
+	// RecipeRepository handles database operations for recipes
+	RecipeRepository *repository.RecipeRepository
+	// HouseholdRepository handles database operations for households
+	HouseholdRepository *repository.HouseholdRepository
+	// EventBroker streams changes to cooking sessions to the devices following them
+	EventBroker *service.EventBroker
+	// TimerClock counts down the running timers and reports them when they go off
+	TimerClock *service.TimerClock
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewCookingSessionHandler creates a new CookingSessionHandler instance with the provided database connection pool and configuration.
+// It starts sweeping expired sessions in the background.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//   - broker: Event broker shared by every handler that streams events
+//
+// Returns:
+//   - *CookingSessionHandler: A new cooking session handler instance
+func NewCookingSessionHandler(pool *pgxpool.Pool, config *config.Config, broker *service.EventBroker) *CookingSessionHandler {
+	ch := &CookingSessionHandler{
+		CookingSessionRepository: repository.NewCookingSessionRepository(pool),
+		RecipeRepository:         repository.NewRecipeRepository(pool),
+		HouseholdRepository:      repository.NewHouseholdRepository(pool),
+		EventBroker:              broker,
+		Config:                   config,
+	}
+	ch.TimerClock = service.NewTimerClock(ch.publishTicks, ch.finishTimer)
+	go ch.sweepExpired()
+	return ch
+}
+
+// cookingStepRequest is the request body for moving a cooking session to another step.
+type cookingStepRequest struct {
+	Step int `json:"step"`
+}
+
+// cookingTimerRequest is the request body for starting a timer. Every field is optional: the step defaults to
+// the session's current step, and a missing duration is read from the step's text, picking the suggestion
+// with the given name if there is one.
+type cookingTimerRequest struct {
+	TimerName       string `json:"timerName"`
+	DurationSeconds int    `json:"durationSeconds"`
+	Step            int    `json:"step"`
+}
+
+// Start returns an HTTP handler function that starts cooking a recipe at its first step.
+// Pass ?householdId= to start a session the whole household can join from their own devices.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cooking session creation requests
+func (ch *CookingSessionHandler) Start() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		scope, err := mealPlanScope(r, ch.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error resolving household", err)
+			return
+		}
+
+		recipes, err := ch.RecipeRepository.GetByIds(r.Context(), []int{recipeID})
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving recipe", err)
+			return
+		}
+		recipe, ok := recipes[recipeID]
+		if !ok {
+			writeModelError(w, ch.Config, "Error retrieving recipe", model.ErrNotFound("recipe"))
+			return
+		}
+		if len(recipe.Procedure) == 0 {
+			writeModelError(w, ch.Config, "Cooking session validation failed", model.ErrInvalidField("procedure: the recipe has no steps"))
+			return
+		}
+
+		session := model.NewCookingSession(recipeID, scope, middleware.UserID(r.Context()))
+		if err := session.Validate(); err != nil {
+			writeModelError(w, ch.Config, "Cooking session validation failed", err)
+			return
+		}
+
+		created, err := ch.CookingSessionRepository.Insert(r.Context(), session)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error starting cooking session", err)
+			return
+		}
+
+		created.Timers = []model.CookingTimer{}
+		ch.describe(created, recipe)
+
+		writeJSON(w, http.StatusCreated, created)
+	}
+}
+
+// List returns an HTTP handler function that lists the current user's cooking sessions, or a household's with
+// ?householdId=, newest first, so another device can join one. Expired sessions are left out.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cooking session list requests
+func (ch *CookingSessionHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, ch.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error resolving household", err)
+			return
+		}
+
+		sessions, err := ch.CookingSessionRepository.ListForScope(r.Context(), scope)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cooking sessions", err)
+			return
+		}
+
+		active := []model.CookingSession{}
+		for i := range sessions {
+			session := &sessions[i]
+			if err := ch.refresh(r.Context(), session); err != nil {
+				var notFound model.ErrNotFound
+				if errors.As(err, &notFound) {
+					continue
+				}
+				writeModelError(w, ch.Config, "Error retrieving cooking sessions", err)
+				return
+			}
+			active = append(active, *session)
+		}
+
+		writeJSON(w, http.StatusOK, active)
+	}
+}
+
+// Get returns an HTTP handler function that returns a cooking session with its current step, the timers that
+// step suggests and every timer started so far. A device that reloads gets the whole session back from here or
+// from the event stream.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cooking session retrieval requests
+func (ch *CookingSessionHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, err := ch.getAccessibleSession(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cooking session", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, session)
+	}
+}
+
+// SetStep returns an HTTP handler function that moves a cooking session to another step, from 1.
+// Every device following the session receives a step_changed event with the session.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes step change requests
+func (ch *CookingSessionHandler) SetStep() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, err := ch.getAccessibleSession(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cooking session", err)
+			return
+		}
+
+		var request cookingStepRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if request.Step < 1 || request.Step > session.StepCount {
+			writeModelError(w, ch.Config, "Cooking session validation failed", model.ErrInvalidField("step"))
+			return
+		}
+
+		if err := ch.CookingSessionRepository.SetStep(r.Context(), session.ID, request.Step, middleware.UserID(r.Context())); err != nil {
+			writeModelError(w, ch.Config, "Error updating cooking session", err)
+			return
+		}
+
+		session, err = ch.getAccessibleSession(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cooking session", err)
+			return
+		}
+
+		ch.publish(session.ID, model.CookingSessionEventStepChanged, session)
+
+		writeJSON(w, http.StatusOK, session)
+	}
+}
+
+// End returns an HTTP handler function that ends a cooking session and its timers.
+// Devices following the session receive a session_ended event and their streams end.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cooking session deletion requests
+func (ch *CookingSessionHandler) End() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, err := ch.getAccessibleSession(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cooking session", err)
+			return
+		}
+
+		if err := ch.CookingSessionRepository.Delete(r.Context(), session.ID); err != nil {
+			writeModelError(w, ch.Config, "Error ending cooking session", err)
+			return
+		}
+
+		ch.TimerClock.Stop(session.ID)
+		ch.publish(session.ID, model.CookingSessionEventEnded, map[string]int{"id": session.ID})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// StartTimer returns an HTTP handler function that starts a named timer in a cooking session.
+// Without a duration, the timer is one the step's text calls for, such as 20 minutes for "Simmer for 20-25
+// minutes"; the first one unless timerName picks another.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes timer start requests
+func (ch *CookingSessionHandler) StartTimer() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, err := ch.getAccessibleSession(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cooking session", err)
+			return
+		}
+
+		var request cookingTimerRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		step := request.Step
+		if step == 0 {
+			step = session.CurrentStep
+		}
+		if step < 1 || step > session.StepCount {
+			writeModelError(w, ch.Config, "Cooking timer validation failed", model.ErrInvalidField("step"))
+			return
+		}
+
+		name := strings.TrimSpace(request.TimerName)
+		duration := request.DurationSeconds
+		if duration == 0 {
+			suggestion, err := ch.suggestion(r.Context(), session, step, name)
+			if err != nil {
+				writeModelError(w, ch.Config, "Cooking timer validation failed", err)
+				return
+			}
+			name, duration = suggestion.TimerName, suggestion.DurationSeconds
+		}
+		if name == "" {
+			name = fmt.Sprintf("Step %d", step)
+		}
+
+		timer := model.NewCookingTimer(session.ID, name, step, duration, middleware.UserID(r.Context()))
+		if err := timer.Validate(); err != nil {
+			writeModelError(w, ch.Config, "Cooking timer validation failed", err)
+			return
+		}
+
+		created, err := ch.CookingSessionRepository.InsertTimer(r.Context(), timer)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error starting cooking timer", err)
+			return
+		}
+
+		ch.syncClock(r.Context(), session.ID)
+		ch.publish(session.ID, model.CookingSessionEventTimerStarted, created)
+
+		writeJSON(w, http.StatusCreated, created)
+	}
+}
+
+// PauseTimer returns an HTTP handler function that pauses a running timer with the time it has left.
+// Pausing a timer that isn't running fails with 409 Conflict, so of two devices pausing at once one wins.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes timer pause requests
+func (ch *CookingSessionHandler) PauseTimer() http.HandlerFunc {
+	return ch.updateTimer(model.CookingSessionEventTimerPaused, (*model.CookingTimer).Pause)
+}
+
+// ResumeTimer returns an HTTP handler function that starts a paused timer again from the time it had left.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes timer resume requests
+func (ch *CookingSessionHandler) ResumeTimer() http.HandlerFunc {
+	return ch.updateTimer(model.CookingSessionEventTimerResumed, (*model.CookingTimer).Resume)
+}
+
+// CancelTimer returns an HTTP handler function that cancels a running or paused timer.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes timer cancel requests
+func (ch *CookingSessionHandler) CancelTimer() http.HandlerFunc {
+	return ch.updateTimer(model.CookingSessionEventTimerCancelled, (*model.CookingTimer).Cancel)
+}
+
+// Events returns an HTTP handler function that streams a cooking session as server-sent events.
+// The stream starts with a session event holding the whole session, so a device that reloads or joins later
+// catches up from it, followed by step and timer changes, a tick event every second while timers are running
+// and a timer_finished event when one goes off. The stream ends when the session ends or expires.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes event stream requests
+func (ch *CookingSessionHandler) Events() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid cooking session ID", err)
+			return
+		}
+
+		// subscribe before loading the session so nothing published in between is missed.
+		events, unsubscribe := ch.EventBroker.Subscribe(cookingSessionTopic(sessionID))
+		defer unsubscribe()
+
+		session, err := ch.getAccessibleSession(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cooking session", err)
+			return
+		}
+
+		replay := []service.Event{{Type: model.CookingSessionEventSnapshot, Data: session}}
+
+		streamEvents(w, r, replay, events, model.CookingSessionEventEnded, model.CookingSessionEventExpired)
+	}
+}
+
+// private functions
+
+// getAccessibleSession retrieves the cooking session in the {id} path wildcard, checks that the current user
+// owns it or belongs to its household, and brings it up to date with refresh.
+func (ch *CookingSessionHandler) getAccessibleSession(r *http.Request) (*model.CookingSession, error) {
+	sessionID, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := ch.CookingSessionRepository.Get(r.Context(), sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeScope(r.Context(), ch.HouseholdRepository, session.Scope()); err != nil {
+		return nil, err
+	}
+
+	if err := ch.refresh(r.Context(), session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// refresh brings a loaded cooking session up to date. A session idle for longer than the idle timeout is
+// deleted and reported as model.ErrNotFound. Timers that went off while nobody was counting them down, such
+// as across a restart, are finished, the running ones are handed to the timer clock, and the current step's
+// text and suggested timers are filled in from the recipe.
+func (ch *CookingSessionHandler) refresh(ctx context.Context, session *model.CookingSession) error {
+	now := time.Now()
+
+	session.ExpiresAt = session.LastActive().Add(ch.idleTimeout())
+	if now.After(session.ExpiresAt) {
+		log.Printf("Cooking session %d expired at %v", session.ID, session.ExpiresAt)
+		if err := ch.CookingSessionRepository.Delete(ctx, session.ID); err != nil {
+			var notFound model.ErrNotFound
+			if !errors.As(err, &notFound) {
+				return err
+			}
+		}
+		ch.TimerClock.Stop(session.ID)
+		ch.publish(session.ID, model.CookingSessionEventExpired, map[string]int{"id": session.ID})
+		return model.ErrNotFound("cooking session")
+	}
+
+	for i := range session.Timers {
+		timer := &session.Timers[i]
+		if timer.Status != model.TimerStatusRunning || timer.EndsAt == nil || timer.EndsAt.After(now) {
+			timer.RemainingSeconds = timer.Remaining(now)
+			continue
+		}
+
+		finished, err := ch.CookingSessionRepository.FinishTimer(ctx, timer.ID, now)
+		if err != nil {
+			return err
+		}
+		if finished != nil {
+			*timer = *finished
+			ch.publish(session.ID, model.CookingSessionEventTimerFinished, finished)
+		}
+	}
+	ch.TimerClock.Set(session.ID, session.Timers)
+
+	recipes, err := ch.RecipeRepository.GetByIds(ctx, []int{session.RecipeId})
+	if err != nil {
+		return err
+	}
+	if recipe, ok := recipes[session.RecipeId]; ok {
+		ch.describe(session, recipe)
+	}
+
+	return nil
+}
+
+// describe fills in the parts of a cooking session that come from its recipe: the recipe's name, the number
+// of steps, the current step's text and the timers it suggests.
+func (ch *CookingSessionHandler) describe(session *model.CookingSession, recipe *model.Recipe) {
+	session.RecipeName = recipe.RecipeName
+	session.StepCount = len(recipe.Procedure)
+	session.SuggestedTimers = []model.TimerSuggestion{}
+
+	if session.ExpiresAt.IsZero() {
+		session.ExpiresAt = session.LastActive().Add(ch.idleTimeout())
+	}
+
+	if session.CurrentStep <= len(recipe.Procedure) {
+		session.Instruction = recipe.Procedure[session.CurrentStep-1]
+		session.SuggestedTimers = service.SuggestTimers(session.CurrentStep, session.Instruction)
+	}
+}
+
+// suggestion returns the timer a step of the session's recipe calls for, the one named name if it is given
+// and matches, otherwise the first. Returns model.ErrMissingRequiredField if the step's text gives no duration.
+func (ch *CookingSessionHandler) suggestion(ctx context.Context, session *model.CookingSession, step int, name string) (model.TimerSuggestion, error) {
+	recipes, err := ch.RecipeRepository.GetByIds(ctx, []int{session.RecipeId})
+	if err != nil {
+		return model.TimerSuggestion{}, err
+	}
+	recipe, ok := recipes[session.RecipeId]
+	if !ok || step > len(recipe.Procedure) {
+		return model.TimerSuggestion{}, model.ErrNotFound("recipe")
+	}
+
+	suggestions := service.SuggestTimers(step, recipe.Procedure[step-1])
+	if len(suggestions) == 0 {
+		return model.TimerSuggestion{}, model.ErrMissingRequiredField("durationSeconds: the step doesn't say how long")
+	}
+
+	for _, suggestion := range suggestions {
+		if name != "" && strings.EqualFold(suggestion.TimerName, name) {
+			return suggestion, nil
+		}
+	}
+
+	if name != "" {
+		suggestions[0].TimerName = name
+	}
+	return suggestions[0], nil
+}
+
+// updateTimer returns an HTTP handler function that applies a change to the timer in the {timerId} path
+// wildcard and publishes it to the devices following the session as an event of the given type.
+func (ch *CookingSessionHandler) updateTimer(eventType string, change func(timer *model.CookingTimer, now time.Time, userID int) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, err := ch.getAccessibleSession(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cooking session", err)
+			return
+		}
+
+		timerID, err := pathID(r, "timerId")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid cooking timer ID", err)
+			return
+		}
+
+		userID := middleware.UserID(r.Context())
+
+		timer, err := ch.CookingSessionRepository.UpdateTimer(r.Context(), session.ID, timerID, userID, func(timer *model.CookingTimer, now time.Time) error {
+			return change(timer, now, userID)
+		})
+		if err != nil {
+			writeModelError(w, ch.Config, "Error updating cooking timer", err)
+			return
+		}
+
+		ch.syncClock(r.Context(), session.ID)
+		ch.publish(session.ID, eventType, timer)
+
+		writeJSON(w, http.StatusOK, timer)
+	}
+}
+
+// syncClock hands a session's timers to the timer clock after a change.
+func (ch *CookingSessionHandler) syncClock(ctx context.Context, sessionID int) {
+	session, err := ch.CookingSessionRepository.Get(ctx, sessionID)
+	if err != nil {
+		log.Printf("Error retrieving cooking session %d for its timers: %v", sessionID, err)
+		return
+	}
+	ch.TimerClock.Set(sessionID, session.Timers)
+}
+
+// sweepExpired removes the cooking sessions that have been idle for longer than the idle timeout every
+// cookingSessionSweepInterval, and tells the devices following them, so their event streams end even if
+// nobody opens the session again.
+func (ch *CookingSessionHandler) sweepExpired() {
+	ticker := time.NewTicker(cookingSessionSweepInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		sessionIDs, err := ch.CookingSessionRepository.DeleteExpired(context.Background(), now.Add(-ch.idleTimeout()))
+		if err != nil {
+			continue
+		}
+
+		for _, sessionID := range sessionIDs {
+			log.Printf("Cooking session %d expired", sessionID)
+			ch.TimerClock.Stop(sessionID)
+			ch.publish(sessionID, model.CookingSessionEventExpired, map[string]int{"id": sessionID})
+		}
+	}
+}
+
+// publishTicks sends the remaining time of a session's running timers to the devices following it.
+func (ch *CookingSessionHandler) publishTicks(sessionID int, ticks []model.CookingTimerTick) {
+	ch.publish(sessionID, model.CookingSessionEventTick, ticks)
+}
+
+// finishTimer records a timer that went off and tells the devices following its session.
+// It runs on the timer clock, outside any request.
+func (ch *CookingSessionHandler) finishTimer(sessionID int, timerID int) {
+	timer, err := ch.CookingSessionRepository.FinishTimer(context.Background(), timerID, time.Now())
+	if err != nil {
+		log.Printf("Error finishing timer %d of cooking session %d: %v", timerID, sessionID, err)
+		return
+	}
+	if timer != nil {
+		ch.publish(sessionID, model.CookingSessionEventTimerFinished, timer)
+	}
+}
+
+// publish sends a cooking session event to the devices following the session. Session events aren't stored;
+// a device that reconnects catches up from the session event its stream starts with.
+func (ch *CookingSessionHandler) publish(sessionID int, eventType string, data interface{}) {
+	ch.EventBroker.Publish(cookingSessionTopic(sessionID), service.Event{Type: eventType, Data: data})
+}
+
+// idleTimeout returns how long a cooking session lasts without activity.
+func (ch *CookingSessionHandler) idleTimeout() time.Duration {
+	if ch.Config != nil && ch.Config.CookingSessionIdleTimeout > 0 {
+		return ch.Config.CookingSessionIdleTimeout
+	}
+	return defaultCookingSessionIdleTimeout
+}
+
+// cookingSessionTopic returns the event broker topic of a cooking session.
+func cookingSessionTopic(sessionID int) string {
+	return fmt.Sprintf("cooking-session:%d", sessionID)
+}
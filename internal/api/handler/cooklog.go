@@ -0,0 +1,495 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// maxCookLogPhotoBytes is the largest photo that can be attached to a cook log entry,
+// and maxCookLogUploadBytes the largest upload request, which may hold several photos.
+const (
+	maxCookLogPhotoBytes  = 10 << 20
+	maxCookLogUploadBytes = 5 * maxCookLogPhotoBytes
+)
+
+// mostCookedLimit is how many recipes the "most cooked this year" statistic returns.
+const mostCookedLimit = 10
+
+// CookLogHandler manages HTTP requests related to the cook log.
+// It records when users cook recipes and reports their cooking history and statistics.
+type CookLogHandler struct {
+	// ConnectionPool is the PostgreSQL connection pool
+	ConnectionPool *pgxpool.Pool
+	// CookLogRepository handles database operations for the cook log
+	CookLogRepository *repository.CookLogRepository
+	// PantryRepository handles database operations for pantry items
+	PantryRepository *repository.PantryRepository
+	// HouseholdRepository handles database operations for households
+	HouseholdRepository *repository.HouseholdRepository
+	// IngredientsRepository handles database operations for ingredients
+	IngredientsRepository *repository.IngredientsRepository
+	// PantryService matches recipe ingredients against the pantry
+	PantryService *service.PantryService
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewCookLogHandler creates a new CookLogHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *CookLogHandler: A new cook log handler instance
+func NewCookLogHandler(pool *pgxpool.Pool, config *config.Config) *CookLogHandler {
+	return &CookLogHandler{
+		ConnectionPool:        pool,
+		CookLogRepository:     repository.NewCookLogRepository(pool),
+		PantryRepository:      repository.NewPantryRepository(pool),
+		HouseholdRepository:   repository.NewHouseholdRepository(pool),
+		IngredientsRepository: repository.NewIngredientsRepository(pool),
+		PantryService:         service.NewPantryService(),
+		Config:                config,
+	}
+}
+
+// cookLogRequest is the request body for logging a recipe as cooked.
+type cookLogRequest struct {
+	CookedDate   model.Date `json:"cookedDate"`
+	ServingsMade int        `json:"servingsMade"`
+	Tweaks       string     `json:"tweaks"`
+	Rating       int        `json:"rating"`
+}
+
+// Create returns an HTTP handler function that logs a recipe as cooked by the current user.
+// The cooked date defaults to today when it is not given.
+// The ingredients used, scaled to the servings made, are taken out of the pantry (a household's with
+// ?householdId=), and the response reports how each matched. Pass ?deductPantry=false to leave the pantry alone.
+// If the pantry can't be updated the entry isn't saved either.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cook log requests
+func (ch *CookLogHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		var request cookLogRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if request.CookedDate.IsZero() {
+			request.CookedDate = model.Today()
+		}
+
+		cookLog := model.NewCookLog(recipeID, middleware.UserID(r.Context()), request.CookedDate)
+		cookLog.ServingsMade = request.ServingsMade
+		cookLog.Tweaks = request.Tweaks
+		cookLog.Rating = request.Rating
+
+		if err := cookLog.Validate(); err != nil {
+			writeModelError(w, ch.Config, "Cook log validation failed", err)
+			return
+		}
+
+		// the pantry is checked before saving so a bad householdId doesn't leave a cook log behind.
+		deductPantry := r.URL.Query().Get("deductPantry") != "false"
+
+		var pantryScope model.MealPlanScope
+		if deductPantry {
+			pantryScope, err = mealPlanScope(r, ch.HouseholdRepository)
+			if err != nil {
+				writeModelError(w, ch.Config, "Error resolving household", err)
+				return
+			}
+		}
+
+		// the entry and the deduction are saved together, so a failed deduction can be retried without
+		// logging the cook twice.
+		tx, err := ch.ConnectionPool.Begin(r.Context())
+		if err != nil {
+			writeInternalError(w, ch.Config, "Error starting transaction", err)
+			return
+		}
+		defer tx.Rollback(r.Context()) // Rollback if we don't commit
+
+		saved, err := ch.CookLogRepository.Insert(r.Context(), cookLog, tx)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error saving cook log", err)
+			return
+		}
+
+		if deductPantry {
+			saved.Pantry, err = ch.deductPantry(r.Context(), pantryScope, recipeID, saved.ServingsMade, tx)
+			if err != nil {
+				writeModelError(w, ch.Config, "Error deducting from pantry", err)
+				return
+			}
+		}
+
+		if err := tx.Commit(r.Context()); err != nil {
+			writeInternalError(w, ch.Config, "Error saving cook log", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, saved)
+	}
+}
+
+// ListForRecipe returns an HTTP handler function that returns the cook history of a recipe, most recent first.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes recipe cook log requests
+func (ch *CookLogHandler) ListForRecipe() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		limit, offset, err := pagination(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid pagination", err)
+			return
+		}
+
+		cookLogs, total, err := ch.CookLogRepository.GetByRecipeId(r.Context(), recipeID, limit, offset)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cook log", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, pageResponse[model.CookLog]{
+			Items:  cookLogs,
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
+}
+
+// ListMine returns an HTTP handler function that returns the current user's cook timeline, most recent first.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes user cook log requests
+func (ch *CookLogHandler) ListMine() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, offset, err := pagination(r)
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid pagination", err)
+			return
+		}
+
+		cookLogs, total, err := ch.CookLogRepository.GetByUser(r.Context(), middleware.UserID(r.Context()), limit, offset)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cook log", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, pageResponse[model.CookLog]{
+			Items:  cookLogs,
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
+}
+
+// Stats returns an HTTP handler function that returns the current user's cooking statistics:
+// the recipes they have cooked most this year, and the recipes they have cooked before but not
+// within the last few months. The months query parameter sets that window and defaults to 6.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cook log statistics requests
+func (ch *CookLogHandler) Stats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		months, err := queryInt(r, "months", 6)
+		if err != nil || months <= 0 {
+			writeModelError(w, ch.Config, "Invalid months", model.ErrInvalidField("months"))
+			return
+		}
+
+		userID := middleware.UserID(r.Context())
+		today := model.Today()
+		startOfYear := model.NewDate(today.Year(), time.January, 1)
+		recentWindowStart := model.Date{Time: today.AddDate(0, -months, 0)}
+
+		mostCooked, err := ch.CookLogRepository.MostCookedSince(r.Context(), userID, startOfYear, mostCookedLimit)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cook log statistics", err)
+			return
+		}
+
+		notCooked, err := ch.CookLogRepository.NotCookedSince(r.Context(), userID, recentWindowStart)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cook log statistics", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, model.CookLogStats{
+			MostCookedThisYear: mostCooked,
+			NotCookedRecently:  notCooked,
+		})
+	}
+}
+
+// Delete returns an HTTP handler function that deletes one of the current user's cook log entries and its photos.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes cook log deletion requests
+func (ch *CookLogHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookLogID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid cook log ID", err)
+			return
+		}
+
+		if _, err := ch.getOwnCookLog(r, cookLogID); err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cook log", err)
+			return
+		}
+
+		photos, err := ch.CookLogRepository.Delete(r.Context(), cookLogID)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error deleting cook log", err)
+			return
+		}
+
+		for _, photo := range photos {
+			if err := os.Remove(photo.Filepath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				log.Printf("Error removing cook log photo %s: %v", photo.Filepath, err)
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AddPhotos returns an HTTP handler function that attaches photos to one of the current user's cook log entries.
+// Photos are sent as multipart form files under the "photos" field and must be JPEG or PNG images.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes photo upload requests
+func (ch *CookLogHandler) AddPhotos() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookLogID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid cook log ID", err)
+			return
+		}
+
+		if _, err := ch.getOwnCookLog(r, cookLogID); err != nil {
+			writeModelError(w, ch.Config, "Error retrieving cook log", err)
+			return
+		}
+
+		if ch.Config.RecipeImagesLocation == "" {
+			writeInternalError(w, ch.Config, "Error saving photo", errors.New("RECIPE_IMAGES_LOCATION is not set"))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxCookLogUploadBytes)
+
+		if err := r.ParseMultipartForm(maxCookLogPhotoBytes); err != nil {
+			log.Printf("Error parsing photo upload: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid photo upload")
+			return
+		}
+
+		files := r.MultipartForm.File["photos"]
+		if len(files) == 0 {
+			writeModelError(w, ch.Config, "Photo upload validation failed", model.ErrMissingRequiredField("photos"))
+			return
+		}
+
+		photos := make([]model.CookLogPhoto, 0, len(files))
+
+		for _, file := range files {
+			photo, err := ch.savePhoto(r, cookLogID, file)
+			if err != nil {
+				writeModelError(w, ch.Config, "Error saving photo", err)
+				return
+			}
+			photos = append(photos, *photo)
+		}
+
+		writeJSON(w, http.StatusCreated, photos)
+	}
+}
+
+// GetPhoto returns an HTTP handler function that serves a cook log photo.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes photo download requests
+func (ch *CookLogHandler) GetPhoto() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		photoID, err := pathID(r, "photoId")
+		if err != nil {
+			writeModelError(w, ch.Config, "Invalid photo ID", err)
+			return
+		}
+
+		photo, err := ch.CookLogRepository.GetPhoto(r.Context(), photoID)
+		if err != nil {
+			writeModelError(w, ch.Config, "Error retrieving photo", err)
+			return
+		}
+
+		http.ServeFile(w, r, photo.Filepath)
+	}
+}
+
+// private functions
+
+// deductPantry takes the ingredients a cooked recipe used out of the pantry, within the transaction the cook
+// log entry is saved in. Only certain matches are taken; uncertain ones are returned for the user to adjust by hand.
+func (ch *CookLogHandler) deductPantry(ctx context.Context, scope model.MealPlanScope, recipeID int, servingsMade int, tx pgx.Tx) ([]model.PantryMatch, error) {
+	recipes, err := ch.IngredientsRepository.GetRecipesWithIngredients(ctx, []int{recipeID})
+	if err != nil {
+		return nil, err
+	}
+
+	recipe, ok := recipes[recipeID]
+	if !ok {
+		return nil, model.ErrNotFound("recipe")
+	}
+
+	pantry, err := ch.PantryRepository.ListForScope(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, taken := ch.PantryService.Match(service.RecipeRequirements(recipe, servingsMade), pantry)
+
+	if err := ch.PantryRepository.Deduct(ctx, taken, middleware.UserID(ctx), tx); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// getOwnCookLog retrieves a cook log entry and checks that it belongs to the current user.
+//
+// Parameters:
+//   - r: The HTTP request carrying the current user
+//   - cookLogID: The ID of the cook log entry
+//
+// Returns:
+//   - *model.CookLog: The cook log entry
+//   - error: model.ErrNotFound or model.ErrPermissionDenied if the entry can't be used
+func (ch *CookLogHandler) getOwnCookLog(r *http.Request, cookLogID int) (*model.CookLog, error) {
+	cookLog, err := ch.CookLogRepository.Get(r.Context(), cookLogID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cookLog.UserId != middleware.UserID(r.Context()) {
+		return nil, model.ErrPermissionDenied("cook log belongs to another user")
+	}
+
+	return cookLog, nil
+}
+
+// savePhoto writes an uploaded photo under RECIPE_IMAGES_LOCATION/cooklog and records it in the database.
+//
+// Parameters:
+//   - r: The HTTP request carrying the current user
+//   - cookLogID: The cook log entry the photo belongs to
+//   - header: The uploaded file
+//
+// Returns:
+//   - *model.CookLogPhoto: The saved photo
+//   - error: model.ErrInvalidField if the file is not an image, or an error if saving fails
+func (ch *CookLogHandler) savePhoto(r *http.Request, cookLogID int, header *multipart.FileHeader) (*model.CookLogPhoto, error) {
+	if header.Size > maxCookLogPhotoBytes {
+		return nil, model.ErrInvalidField("photos")
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// sniff the content rather than trusting the file name or the client's content type.
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	var extension string
+	switch http.DetectContentType(sniff[:n]) {
+	case "image/jpeg":
+		extension = ".jpg"
+	case "image/png":
+		extension = ".png"
+	default:
+		return nil, model.ErrInvalidField("photos")
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	directory := filepath.Join(ch.Config.RecipeImagesLocation, "cooklog")
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(directory, fmt.Sprintf("%d_%d%s", cookLogID, time.Now().UnixNano(), extension))
+
+	destination, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	defer destination.Close()
+
+	size, err := io.Copy(destination, file)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	photo := &model.CookLogPhoto{
+		CookLogId:    cookLogID,
+		Filepath:     path,
+		OriginalName: strings.TrimSpace(filepath.Base(header.Filename)),
+		SizeBytes:    size,
+	}
+
+	saved, err := ch.CookLogRepository.InsertPhoto(r.Context(), photo, middleware.UserID(r.Context()))
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	return saved, nil
+}
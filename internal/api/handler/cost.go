@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// Cost returns an HTTP handler function that estimates what a recipe costs from the current ingredient
+// prices, in total and per serving, with a breakdown by ingredient and the ingredients that could not be
+// priced. ?servings= costs a different number of servings, and ?store= prefers the prices seen at a store.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes recipe cost requests
+func (rh *RecipeHandler) Cost() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipe, err := rh.pathRecipe(r)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving recipe", err)
+			return
+		}
+
+		servings, err := queryInt(r, "servings", 0)
+		if err != nil || servings < 0 {
+			writeModelError(w, rh.Config, "Invalid servings", model.ErrInvalidField("servings"))
+			return
+		}
+
+		prices, err := rh.PriceRepository.GetCurrent(r.Context(), r.URL.Query().Get("store"))
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving ingredient prices", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, rh.CostService.Estimate(recipe, servings, prices))
+	}
+}
+
+// private functions
+
+// estimateAllCosts estimates the cost of every recipe as written from the current ingredient prices.
+//
+// Parameters:
+//   - ctx: The context for database operations
+//   - ingredients: Repository used to load the recipes with their ingredients
+//   - prices: Repository used to load the current prices
+//   - costs: Service that works out the estimates
+//   - store: Store whose prices are preferred, or empty for the most recent price anywhere
+//
+// Returns:
+//   - map[int]model.RecipeCost: The estimate of each recipe by ID
+//   - error: An error if the recipes or prices can't be loaded
+func estimateAllCosts(ctx context.Context, ingredients *repository.IngredientsRepository, prices *repository.PriceRepository, costs *service.CostService, store string) (map[int]model.RecipeCost, error) {
+	recipes, err := ingredients.GetAllRecipesWithIngredients(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := prices.GetCurrent(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return costs.EstimateAll(recipes, current), nil
+}
+
+// setEstimatedCost copies a recipe's estimate onto it, unless nothing in it could be priced.
+func setEstimatedCost(recipe *model.Recipe, cost model.RecipeCost) {
+	if !cost.Priced() {
+		return
+	}
+
+	total := cost.TotalCost
+	recipe.EstimatedCost = &total
+	recipe.CostPerServing = cost.CostPerServing
+}
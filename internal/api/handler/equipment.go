@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// EquipmentHandler manages HTTP requests related to the equipment recipes need and the equipment in users'
+// kitchens.
+type EquipmentHandler struct {
+	// RecipeRepository handles database operations for recipes
+	RecipeRepository *repository.RecipeRepository
+	// EquipmentRepository handles database operations for recipe and kitchen equipment
+	EquipmentRepository *repository.EquipmentRepository
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewEquipmentHandler creates a new EquipmentHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *EquipmentHandler: A new equipment handler instance
+func NewEquipmentHandler(pool *pgxpool.Pool, config *config.Config) *EquipmentHandler {
+	return &EquipmentHandler{
+		RecipeRepository:    repository.NewRecipeRepository(pool),
+		EquipmentRepository: repository.NewEquipmentRepository(pool),
+		Config:              config,
+	}
+}
+
+// Catalog returns an HTTP handler function that lists the equipment that can be detected in procedures.
+// Equipment outside the catalog can still be set by hand.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes equipment catalog requests
+func (eh *EquipmentHandler) Catalog() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, model.EquipmentList{Equipment: service.EquipmentCatalog()})
+	}
+}
+
+// GetRecipeEquipment returns an HTTP handler function that returns the equipment a recipe needs, along with
+// the equipment its procedure mentions, to help edit it.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes recipe equipment requests
+func (eh *EquipmentHandler) GetRecipeEquipment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipe, err := eh.getRecipe(r)
+		if err != nil {
+			writeModelError(w, eh.Config, "Error retrieving recipe", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, recipeEquipmentResponse(recipe))
+	}
+}
+
+// SetRecipeEquipment returns an HTTP handler function that replaces the equipment a recipe needs.
+// Names the catalog knows are stored by their catalog name, so "Dutch Ovens" becomes "dutch oven".
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes recipe equipment updates
+func (eh *EquipmentHandler) SetRecipeEquipment() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipe, err := eh.getRecipe(r)
+		if err != nil {
+			writeModelError(w, eh.Config, "Error retrieving recipe", err)
+			return
+		}
+
+		var request model.EquipmentList
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if request.Equipment != nil {
+			request.Equipment = service.NormalizeEquipment(request.Equipment)
+		}
+		if err := request.Validate(); err != nil {
+			writeModelError(w, eh.Config, "Equipment validation failed", err)
+			return
+		}
+
+		if err := eh.EquipmentRepository.Replace(r.Context(), recipe.ID, request.Equipment, middleware.UserID(r.Context())); err != nil {
+			writeModelError(w, eh.Config, "Error updating recipe equipment", err)
+			return
+		}
+
+		recipe.Equipment = request.Equipment
+		writeJSON(w, http.StatusOK, recipeEquipmentResponse(recipe))
+	}
+}
+
+// GetKitchen returns an HTTP handler function that returns the equipment in the current user's kitchen.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes kitchen equipment requests
+func (eh *EquipmentHandler) GetKitchen() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		equipment, err := eh.EquipmentRepository.GetKitchen(r.Context(), middleware.UserID(r.Context()))
+		if err != nil {
+			writeModelError(w, eh.Config, "Error retrieving kitchen equipment", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, model.EquipmentList{Equipment: equipment})
+	}
+}
+
+// SetKitchen returns an HTTP handler function that replaces the equipment in the current user's kitchen.
+// Recipe searches and random picks with ?myKitchen=true leave out recipes that need anything else.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes kitchen equipment updates
+func (eh *EquipmentHandler) SetKitchen() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request model.EquipmentList
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if request.Equipment != nil {
+			request.Equipment = service.NormalizeEquipment(request.Equipment)
+		}
+		if err := request.Validate(); err != nil {
+			writeModelError(w, eh.Config, "Equipment validation failed", err)
+			return
+		}
+
+		if err := eh.EquipmentRepository.ReplaceKitchen(r.Context(), middleware.UserID(r.Context()), request.Equipment); err != nil {
+			writeModelError(w, eh.Config, "Error updating kitchen equipment", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, request)
+	}
+}
+
+// private functions
+
+// getRecipe retrieves the recipe in the {id} path wildcard with its procedure and equipment.
+func (eh *EquipmentHandler) getRecipe(r *http.Request) (*model.Recipe, error) {
+	recipeID, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	recipes, err := eh.RecipeRepository.GetByIds(r.Context(), []int{recipeID})
+	if err != nil {
+		return nil, err
+	}
+
+	recipe, ok := recipes[recipeID]
+	if !ok {
+		return nil, model.ErrNotFound("recipe")
+	}
+
+	return recipe, nil
+}
+
+// recipeEquipmentResponse returns the equipment a recipe needs and the equipment its procedure mentions.
+func recipeEquipmentResponse(recipe *model.Recipe) model.RecipeEquipment {
+	equipment := recipe.Equipment
+	if equipment == nil {
+		equipment = []string{}
+	}
+
+	return model.RecipeEquipment{
+		RecipeId:  recipe.ID,
+		Equipment: equipment,
+		Detected:  service.DetectEquipment(recipe.Procedure),
+	}
+}
+
+// recipeEquipment returns the equipment a recipe being saved needs: what it lists, normalized, or what its
+// procedure mentions if it lists nothing. An empty list, rather than none, means it needs nothing.
+func recipeEquipment(recipe *model.Recipe) []string {
+	if recipe.Equipment == nil {
+		return service.DetectEquipment(recipe.Procedure)
+	}
+	return service.NormalizeEquipment(recipe.Equipment)
+}
+
+// kitchenFilter returns the equipment in the current user's kitchen when the request asks for ?myKitchen=true,
+// to leave out recipes that need anything else, or nil otherwise.
+func kitchenFilter(r *http.Request, equipment *repository.EquipmentRepository) ([]string, error) {
+	if r.URL.Query().Get("myKitchen") != "true" {
+		return nil, nil
+	}
+	return equipment.GetKitchen(r.Context(), middleware.UserID(r.Context()))
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/service"
+)
+
+// Formats a recipe can be exported in, chosen with the format query parameter.
+const (
+	recipeFormatJson     = "json"
+	recipeFormatJsonLd   = "jsonld"
+	recipeFormatCooklang = "cooklang"
+	recipeFormatMarkdown = "markdown"
+	recipeFormatHtml     = "html"
+)
+
+// recipeFormatMediaTypes maps the media types of an Accept header to the recipe formats they ask for.
+var recipeFormatMediaTypes = map[string]string{
+	"application/json":      recipeFormatJson,
+	"application/ld+json":   recipeFormatJsonLd,
+	"text/markdown":         recipeFormatMarkdown,
+	"text/x-markdown":       recipeFormatMarkdown,
+	"text/html":             recipeFormatHtml,
+	"application/xhtml+xml": recipeFormatHtml,
+}
+
+// private functions
+
+// writeRecipe writes a recipe in the format the request asks for with ?format=: the API's own JSON by default,
+// jsonld for a schema.org Recipe, cooklang for a Cooklang file, or markdown or html for a recipe card. Without
+// ?format= the format is negotiated from the Accept header.
+func (rh *RecipeHandler) writeRecipe(w http.ResponseWriter, r *http.Request, recipe *model.Recipe) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = negotiateRecipeFormat(r.Header.Get("Accept"))
+		w.Header().Add("Vary", "Accept")
+	}
+
+	switch format {
+	case recipeFormatJson:
+		writeJSON(w, http.StatusOK, recipe)
+	case recipeFormatJsonLd:
+		writeJsonLd(w, service.RecipeToJsonLd(recipe))
+	case recipeFormatCooklang:
+		writeText(w, "text/plain; charset=utf-8", service.WriteCooklang(recipe))
+	case recipeFormatMarkdown, recipeFormatHtml:
+		rh.writeRecipeCard(w, r, recipe, format)
+	default:
+		writeModelError(w, rh.Config, "Invalid format", model.ErrInvalidField("format"))
+	}
+}
+
+// negotiateRecipeFormat picks the recipe format an Accept header prefers most, by quality and then by order,
+// or JSON if it doesn't accept any of them by name, as with */*.
+func negotiateRecipeFormat(accept string) string {
+	format := recipeFormatJson
+	best := 0.0
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		candidate, ok := recipeFormatMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > best {
+			format, best = candidate, quality
+		}
+	}
+
+	return format
+}
+
+// writeJsonLd writes a JSON-LD document with the application/ld+json content type.
+func writeJsonLd(w http.ResponseWriter, document interface{}) {
+	w.Header().Set("Content-Type", "application/ld+json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		log.Printf("Error encoding JSON-LD response: %v", err)
+	}
+}
+
+// writeText writes a text response with the given content type.
+func writeText(w http.ResponseWriter, contentType string, text string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.WriteString(w, text); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
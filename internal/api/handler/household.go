@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+)
+
+// HouseholdHandler manages HTTP requests related to households.
+// Members of a household share its meal plan.
+type HouseholdHandler struct {
+	// HouseholdRepository handles database operations for households
+	HouseholdRepository *repository.HouseholdRepository
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewHouseholdHandler creates a new HouseholdHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *HouseholdHandler: A new household handler instance
+func NewHouseholdHandler(pool *pgxpool.Pool, config *config.Config) *HouseholdHandler {
+	return &HouseholdHandler{
+		HouseholdRepository: repository.NewHouseholdRepository(pool),
+		Config:              config,
+	}
+}
+
+// householdRequest is the request body for creating a household.
+type householdRequest struct {
+	HouseholdName string `json:"householdName"`
+}
+
+// Create returns an HTTP handler function that creates a household with the current user as its first member.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes household creation requests
+func (hh *HouseholdHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request householdRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		household := model.NewHousehold(request.HouseholdName, middleware.UserID(r.Context()))
+
+		if err := household.Validate(); err != nil {
+			writeModelError(w, hh.Config, "Household validation failed", err)
+			return
+		}
+
+		created, err := hh.HouseholdRepository.Insert(r.Context(), household)
+		if err != nil {
+			writeModelError(w, hh.Config, "Error saving household", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, created)
+	}
+}
+
+// ListMine returns an HTTP handler function that lists the households the current user belongs to.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes household list requests
+func (hh *HouseholdHandler) ListMine() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		households, err := hh.HouseholdRepository.ListForUser(r.Context(), middleware.UserID(r.Context()))
+		if err != nil {
+			writeModelError(w, hh.Config, "Error retrieving households", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, households)
+	}
+}
+
+// AddMember returns an HTTP handler function that adds a user to a household.
+// Only members can add other users.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes add member requests
+func (hh *HouseholdHandler) AddMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		householdID, userID, err := householdMemberPath(r)
+		if err != nil {
+			writeModelError(w, hh.Config, "Invalid household member", err)
+			return
+		}
+
+		if err := hh.authorize(r.Context(), householdID); err != nil {
+			writeModelError(w, hh.Config, "Error retrieving household", err)
+			return
+		}
+
+		if err := hh.HouseholdRepository.AddMember(r.Context(), householdID, userID, middleware.UserID(r.Context())); err != nil {
+			writeModelError(w, hh.Config, "Error adding household member", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RemoveMember returns an HTTP handler function that removes a user from a household.
+// Only members can remove users, including themselves.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes remove member requests
+func (hh *HouseholdHandler) RemoveMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		householdID, userID, err := householdMemberPath(r)
+		if err != nil {
+			writeModelError(w, hh.Config, "Invalid household member", err)
+			return
+		}
+
+		if err := hh.authorize(r.Context(), householdID); err != nil {
+			writeModelError(w, hh.Config, "Error retrieving household", err)
+			return
+		}
+
+		if err := hh.HouseholdRepository.RemoveMember(r.Context(), householdID, userID); err != nil {
+			writeModelError(w, hh.Config, "Error removing household member", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// private functions
+
+// authorize checks that the current user is a member of the household.
+func (hh *HouseholdHandler) authorize(ctx context.Context, householdID int) error {
+	isMember, err := hh.HouseholdRepository.IsMember(ctx, householdID, middleware.UserID(ctx))
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return model.ErrPermissionDenied("you are not a member of this household")
+	}
+	return nil
+}
+
+// householdMemberPath reads the {id} and {userId} path wildcards.
+func householdMemberPath(r *http.Request) (int, int, error) {
+	householdID, err := pathID(r, "id")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return householdID, userID, nil
+}
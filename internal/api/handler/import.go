@@ -0,0 +1,535 @@
+package handler
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/service"
+)
+
+// maxImportBytes is the largest document or upload a single recipe or Cooklang tar can be imported from,
+// and maxArchiveImportBytes the largest export of another recipe manager, which may hold photos.
+const (
+	maxImportBytes        = 10 << 20
+	maxArchiveImportBytes = 256 << 20
+)
+
+// cooklangExtension is the file extension of Cooklang files.
+const cooklangExtension = ".cook"
+
+// ImportJsonLd returns an HTTP handler function that imports a recipe published in the schema.org Recipe
+// format. The request body, or the file field of a multipart upload, is either a JSON-LD document or an HTML
+// page with one in a <script type="application/ld+json"> element. The recipe is checked and saved like a
+// submitted one, including the duplicate check. With ?dryRun=true it is checked but not saved.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes JSON-LD import requests
+func (rh *RecipeHandler) ImportJsonLd() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		document, _, err := readImport(w, r, maxImportBytes)
+		if err != nil {
+			writeModelError(w, rh.Config, "Invalid import", err)
+			return
+		}
+
+		recipe, err := service.ParseJsonLd(document)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error reading JSON-LD", err)
+			return
+		}
+
+		saved, duplicates, err := rh.importRecipe(r.Context(), recipe, &importBatch{options: rh.importOptions(r)})
+		rh.writeImport(w, saved, duplicates, err)
+	}
+}
+
+// ImportCooklang returns an HTTP handler function that imports recipes written in Cooklang. The request body,
+// or the file field of a multipart upload, is either one .cook file or a tar of them, which may be gzipped.
+// A recipe without a title is named after its file. Each recipe is checked and saved like a submitted one.
+// A single file responds like a submission; a tar responds with a report of what happened to each file.
+// With ?dryRun=true the recipes are checked but nothing is saved.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes Cooklang import requests
+func (rh *RecipeHandler) ImportCooklang() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		document, filename, err := readImport(w, r, maxImportBytes)
+		if err != nil {
+			writeModelError(w, rh.Config, "Invalid import", err)
+			return
+		}
+
+		files, archived, err := cooklangFiles(document, filename)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error reading Cooklang archive", err)
+			return
+		}
+
+		batch := &importBatch{options: rh.importOptions(r)}
+
+		if !archived {
+			saved, duplicates, err := rh.importRecipe(r.Context(), cooklangRecipe(files[0]), batch)
+			rh.writeImport(w, saved, duplicates, err)
+			return
+		}
+
+		entries := make([]model.ImportEntry, 0, len(files))
+		for _, file := range files {
+			entries = append(entries, model.ImportEntry{Name: file.Name, Recipe: cooklangRecipe(file)})
+		}
+
+		report := model.ImportReport{Format: model.ImportFormatCooklang, DryRun: batch.options.DryRun, Results: []model.ImportResult{}}
+		rh.importEntries(r.Context(), filename, entries, batch, &report)
+
+		log.Printf("Imported %d Cooklang recipes, %d failed", report.Imported, report.Failed)
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// ImportArchive returns an HTTP handler function that imports the recipes exported from another recipe
+// manager. The request body, or the file field of a multipart upload, is a MealMaster .mmf file, a Paprika
+// .paprikarecipes or .paprikarecipe file, a Mealie export zip or recipe JSON, or a Tandoor export zip or
+// recipe.json; the format is detected from its contents. Each recipe is checked and saved like a submitted
+// one and its photos are kept as food images. With ?dryRun=true the recipes are checked but nothing is saved.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes archive import requests, responding with a report
+//     of what happened to each recipe
+func (rh *RecipeHandler) ImportArchive() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		document, filename, err := readImport(w, r, maxArchiveImportBytes)
+		if err != nil {
+			writeModelError(w, rh.Config, "Invalid import", err)
+			return
+		}
+
+		file := model.ImportFile{Name: filename, Contents: document}
+		format, entries, err := service.ParseImportFile(file)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error reading import", err)
+			return
+		}
+
+		batch := &importBatch{options: rh.importOptions(r)}
+		report := model.ImportReport{Format: format, DryRun: batch.options.DryRun, Results: []model.ImportResult{}}
+		rh.importEntries(r.Context(), filename, entries, batch, &report)
+
+		log.Printf("Imported %d %s recipes, %d failed", report.Imported, format, report.Failed)
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// ImportFiles imports the recipes in files exported from other recipe managers, detecting each file's format
+// like ImportArchive. A file that can't be read is reported as failed and the rest are still imported.
+//
+// Parameters:
+//   - ctx: Context for the database operations
+//   - files: The files to import
+//   - options: The user to save the recipes as, whether to reject likely duplicates, and whether to only
+//     check the recipes without saving anything
+//
+// Returns:
+//   - model.ImportReport: What happened to each recipe, with the format of the files if they all share one
+func (rh *RecipeHandler) ImportFiles(ctx context.Context, files []model.ImportFile, options model.ImportOptions) model.ImportReport {
+	report := model.ImportReport{DryRun: options.DryRun, Results: []model.ImportResult{}}
+	formats := map[string]bool{}
+	batch := &importBatch{options: options}
+
+	for _, file := range files {
+		format, entries, err := service.ParseImportFile(file)
+		if err != nil {
+			report.Add(model.ImportResult{File: file.Name, Status: model.ImportStatusFailed, Error: importError(err)})
+			continue
+		}
+
+		formats[format] = true
+		rh.importEntries(ctx, file.Name, entries, batch, &report)
+	}
+
+	if len(formats) == 1 {
+		for format := range formats {
+			report.Format = format
+		}
+	}
+
+	log.Printf("Imported %d recipes from %d files, %d failed", report.Imported, len(files), report.Failed)
+	return report
+}
+
+// private functions
+
+// importBatch is the recipes imported by one request. In a dry run nothing is saved, so the recipes already
+// checked are kept to check later ones against, as the saved recipes would be in the database.
+type importBatch struct {
+	options model.ImportOptions
+	checked []*model.Recipe // Recipes checked in a dry run, with their ingredients
+}
+
+// readImport reads the document recipes are imported from, up to limit bytes: the file field of a multipart
+// upload, or else the request body. It returns the uploaded file's name, which is blank for a request body.
+func readImport(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, string, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+	var reader io.Reader = r.Body
+	filename := ""
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxImportBytes); err != nil {
+			log.Printf("Error parsing import upload: %v", err)
+			return nil, "", model.ErrInvalidField("file")
+		}
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", model.ErrMissingRequiredField("file")
+		}
+		defer file.Close()
+
+		reader = file
+		filename = header.Filename
+	}
+
+	document, err := io.ReadAll(reader)
+	if err != nil {
+		log.Printf("Error reading import: %v", err)
+		return nil, "", model.ErrInvalidField("file")
+	}
+	if len(bytes.TrimSpace(document)) == 0 {
+		return nil, "", model.ErrMissingRequiredField("file")
+	}
+
+	return document, filename, nil
+}
+
+// cooklangFiles returns the .cook files of a tar, gzipped or not, in the order they are archived, or the
+// document itself if it isn't a tar. It reports whether the document was a tar.
+func cooklangFiles(document []byte, filename string) ([]model.ImportFile, bool, error) {
+	if len(document) > 2 && document[0] == 0x1f && document[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(document))
+		if err != nil {
+			return nil, false, model.ErrInvalidField("file")
+		}
+
+		document, err = io.ReadAll(io.LimitReader(reader, maxImportBytes*10))
+		if err != nil {
+			return nil, false, model.ErrInvalidField("file")
+		}
+	}
+
+	// a tar's first header has "ustar" at offset 257.
+	if len(document) < 262 || string(document[257:262]) != "ustar" {
+		return []model.ImportFile{{Name: filename, Contents: document}}, false, nil
+	}
+
+	files := []model.ImportFile{}
+	archive := tar.NewReader(bytes.NewReader(document))
+
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("Error reading tar: %v", err)
+			return nil, true, model.ErrInvalidField("file")
+		}
+
+		base := path.Base(header.Name)
+		if header.Typeflag != tar.TypeReg || !strings.EqualFold(path.Ext(base), cooklangExtension) || strings.HasPrefix(base, ".") {
+			continue
+		}
+
+		contents, err := io.ReadAll(archive)
+		if err != nil {
+			log.Printf("Error reading %s from tar: %v", header.Name, err)
+			return nil, true, model.ErrInvalidField("file")
+		}
+
+		files = append(files, model.ImportFile{Name: header.Name, Contents: contents})
+	}
+
+	if len(files) == 0 {
+		return nil, true, model.ErrMissingRequiredField("file: the tar has no .cook files")
+	}
+
+	return files, true, nil
+}
+
+// cooklangRecipe reads the recipe in a Cooklang file, naming it after the file if it doesn't have a title.
+func cooklangRecipe(file model.ImportFile) *model.Recipe {
+	recipe := service.ParseCooklang(string(file.Contents))
+	if recipe.RecipeName == "" && file.Name != "" {
+		base := path.Base(file.Name)
+		recipe.RecipeName = strings.TrimSpace(strings.TrimSuffix(base, path.Ext(base)))
+	}
+	return recipe
+}
+
+// importOptions returns the options an import request sets: the acting user, strict mode as for a
+// submission, and ?dryRun=true to check the recipes without saving anything.
+func (rh *RecipeHandler) importOptions(r *http.Request) model.ImportOptions {
+	return model.ImportOptions{
+		UserId: middleware.UserID(r.Context()),
+		Strict: rh.strictDuplicateCheck(r),
+		DryRun: r.URL.Query().Get("dryRun") == "true",
+	}
+}
+
+// importEntries imports the recipes read from a file, adding the outcome of each to a report. A recipe is
+// reported under the file within an archive it was found in, or else the imported file's name.
+func (rh *RecipeHandler) importEntries(ctx context.Context, filename string, entries []model.ImportEntry, batch *importBatch, report *model.ImportReport) {
+	for _, entry := range entries {
+		result := model.ImportResult{File: filename, Status: model.ImportStatusFailed}
+		if entry.Name != "" {
+			result.File = entry.Name
+		}
+
+		if entry.Recipe == nil || entry.Error != "" {
+			result.Error = entry.Error
+			if entry.Recipe != nil {
+				result.RecipeName = entry.Recipe.RecipeName
+			}
+			report.Add(result)
+			continue
+		}
+
+		result.RecipeName = entry.Recipe.RecipeName
+		saved, duplicates, err := rh.importRecipe(ctx, entry.Recipe, batch)
+		result.PossibleDuplicates = duplicates
+		if err != nil {
+			result.Error = importError(err)
+			report.Add(result)
+			continue
+		}
+
+		result.Status = model.ImportStatusImported
+		if batch.options.DryRun {
+			result.Status = model.ImportStatusValid
+		}
+		result.RecipeId = saved.ID
+		result.Images, result.Warnings = rh.importImages(ctx, saved.ID, entry.Images, batch.options)
+
+		report.Add(result)
+	}
+}
+
+// importRecipe checks and saves an imported recipe the way a submitted one is: it is validated, its
+// ingredients are checked, likely duplicates are reported or, in strict mode, rejected with ErrConflict, and
+// it is saved with its ingredients, procedure, tags and equipment. In a dry run it is checked but not saved:
+// it is also checked against the recipes checked before it in the batch, and rejected with ErrAlreadyExists
+// if its name is taken, as saving it would be.
+//
+// Parameters:
+//   - ctx: Context for the database operations
+//   - recipe: The imported recipe
+//   - batch: The import it is part of, with the user to save the recipe as, strict mode and whether it is a
+//     dry run
+//
+// Returns:
+//   - *model.Recipe: The saved recipe, the checked recipe without an ID in a dry run, or nil if it wasn't valid
+//   - []model.DuplicateMatch: Existing recipes it looks like, and in a dry run recipes checked before it
+//   - error: A model error if it isn't valid, is rejected as a duplicate or its name is taken, or an error if
+//     saving fails
+func (rh *RecipeHandler) importRecipe(ctx context.Context, recipe *model.Recipe, batch *importBatch) (*model.Recipe, []model.DuplicateMatch, error) {
+	options := batch.options
+	now := time.Now()
+	recipe.CreatedBy = options.UserId
+	recipe.CreatedDate = now
+	recipe.UpdatedBy = options.UserId
+	recipe.UpdatedDate = now
+
+	if err := recipe.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	for i := range recipe.Ingredients {
+		if err := recipe.Ingredients[i].Validate(); err != nil {
+			return nil, nil, fmt.Errorf("ingredient %d: %w", i+1, err)
+		}
+	}
+
+	duplicates, err := rh.findDuplicates(ctx, recipe)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if options.DryRun && len(batch.checked) > 0 {
+		duplicates = append(duplicates, service.FindDuplicates(recipe, batch.checked, service.DefaultDuplicateThreshold)...)
+		sort.SliceStable(duplicates, func(i, j int) bool {
+			return duplicates[i].Score > duplicates[j].Score
+		})
+	}
+
+	if len(duplicates) > 0 && options.Strict {
+		log.Printf("Rejecting imported recipe %s as a likely duplicate of %d recipes", recipe.RecipeName, len(duplicates))
+		return nil, duplicates, model.ErrConflict("recipe looks like a duplicate of an existing recipe")
+	}
+
+	if options.DryRun {
+		taken, err := rh.recipeNameTaken(ctx, recipe.RecipeName, batch)
+		if err != nil {
+			return nil, duplicates, err
+		}
+		if taken {
+			return nil, duplicates, model.ErrAlreadyExists("recipe")
+		}
+
+		batch.checked = append(batch.checked, recipe)
+		return recipe, duplicates, nil
+	}
+
+	saved, err := rh.saveRecipe(ctx, recipe)
+	if err != nil {
+		return nil, duplicates, err
+	}
+
+	log.Printf("Imported recipe %s with ID %d", saved.RecipeName, saved.ID)
+	return saved, duplicates, nil
+}
+
+// recipeNameTaken reports whether a recipe already has a name, or in a dry run, whether a recipe checked
+// before it in the batch does.
+func (rh *RecipeHandler) recipeNameTaken(ctx context.Context, recipeName string, batch *importBatch) (bool, error) {
+	for _, checked := range batch.checked {
+		if checked.RecipeName == recipeName {
+			return true, nil
+		}
+	}
+
+	return rh.RecipeRepository.NameExists(ctx, recipeName)
+}
+
+// importImages keeps the photos imported with a recipe as its food images, written under
+// RECIPE_IMAGES_LOCATION/recipes. In a dry run they are only checked. A photo that can't be kept is a warning
+// rather than failing the recipe.
+//
+// Returns:
+//   - int: The number of photos kept, or that would be kept in a dry run
+//   - []string: Why any photos weren't kept
+func (rh *RecipeHandler) importImages(ctx context.Context, recipeID int, images []model.ImportImage, options model.ImportOptions) (int, []string) {
+	kept := 0
+	warnings := []string{}
+
+	if len(images) > 0 && rh.Config.RecipeImagesLocation == "" {
+		return 0, []string{"photos weren't kept: RECIPE_IMAGES_LOCATION is not set"}
+	}
+
+	for _, image := range images {
+		extension := imageExtension(image.Contents)
+		if extension == "" {
+			warnings = append(warnings, fmt.Sprintf("photo %s isn't a JPEG, PNG, WebP or GIF image", image.Name))
+			continue
+		}
+
+		if !options.DryRun {
+			if err := rh.saveImportImage(ctx, recipeID, image, extension, options.UserId); err != nil {
+				log.Printf("Error keeping photo %s for recipe %d: %v", image.Name, recipeID, err)
+				warnings = append(warnings, fmt.Sprintf("photo %s couldn't be saved", image.Name))
+				continue
+			}
+		}
+		kept++
+	}
+
+	if len(warnings) == 0 {
+		return kept, nil
+	}
+	return kept, warnings
+}
+
+// saveImportImage writes an imported photo to disk and records it as a food image of a recipe.
+func (rh *RecipeHandler) saveImportImage(ctx context.Context, recipeID int, image model.ImportImage, extension string, userID int) error {
+	directory := filepath.Join(rh.Config.RecipeImagesLocation, "recipes")
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(directory, fmt.Sprintf("%d_%d%s", recipeID, time.Now().UnixNano(), extension))
+	if err := os.WriteFile(path, image.Contents, 0o644); err != nil {
+		return err
+	}
+
+	foodImage := &model.FoodImage{
+		RecipeId:     recipeID,
+		Filepath:     path,
+		OriginalName: strings.TrimSpace(filepath.Base(image.Name)),
+		SizeBytes:    int64(len(image.Contents)),
+	}
+
+	if _, err := rh.FoodImageRepository.Insert(ctx, foodImage, userID); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return nil
+}
+
+// imageExtension returns the file extension for an image's sniffed content type, or "" if it isn't a JPEG,
+// PNG, WebP or GIF.
+func imageExtension(contents []byte) string {
+	switch http.DetectContentType(contents) {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/webp":
+		return ".webp"
+	case "image/gif":
+		return ".gif"
+	}
+	return ""
+}
+
+// writeImport writes the response to importing a single recipe: the saved recipe with its likely duplicates
+// like a submission, or the checked recipe with a 200 in a dry run, a 409 listing the duplicates it was
+// rejected for, or the error.
+func (rh *RecipeHandler) writeImport(w http.ResponseWriter, saved *model.Recipe, duplicates []model.DuplicateMatch, err error) {
+	var conflict model.ErrConflict
+
+	switch {
+	case err != nil && errors.As(err, &conflict) && len(duplicates) > 0:
+		writeJSON(w, http.StatusConflict, duplicateRecipeResponse{
+			Error:      "Recipe looks like a duplicate of an existing recipe",
+			Duplicates: duplicates,
+		})
+	case err != nil:
+		writeModelError(w, rh.Config, "Error importing recipe", err)
+	case saved.ID == 0:
+		writeJSON(w, http.StatusOK, submittedRecipeResponse{Recipe: saved, PossibleDuplicates: duplicates})
+	default:
+		writeJSON(w, http.StatusCreated, submittedRecipeResponse{Recipe: saved, PossibleDuplicates: duplicates})
+	}
+}
+
+// importError returns the message reported for a recipe in a file of several that couldn't be imported,
+// keeping the details of unexpected errors out of the response like writeModelError does.
+func importError(err error) string {
+	var notFound model.ErrNotFound
+	var missingField model.ErrMissingRequiredField
+	var invalidField model.ErrInvalidField
+	var alreadyExists model.ErrAlreadyExists
+	var conflict model.ErrConflict
+
+	if errors.As(err, &notFound) || errors.As(err, &missingField) || errors.As(err, &invalidField) ||
+		errors.As(err, &alreadyExists) || errors.As(err, &conflict) {
+		return err.Error()
+	}
+
+	log.Printf("Error importing recipe: %v", err)
+	return "Internal server error"
+}
@@ -0,0 +1,541 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// maxMealPlanRangeDays is the longest date range the calendar and auto-fill endpoints accept.
+const maxMealPlanRangeDays = 366
+
+// maxAutoFillCandidates is the most recipes auto-fill considers for a plan.
+const maxAutoFillCandidates = 1000
+
+// MealPlanHandler manages HTTP requests related to meal planning.
+// Every endpoint works on the current user's personal plan, or on a household's shared plan
+// when the householdId query parameter is given.
+type MealPlanHandler struct {
+	// MealPlanRepository handles database operations for meal plan entries
+	MealPlanRepository *repository.MealPlanRepository
+	// HouseholdRepository handles database operations for households
+	HouseholdRepository *repository.HouseholdRepository
+	// RecipeRepository handles database operations for recipes
+	RecipeRepository *repository.RecipeRepository
+	// IngredientsRepository handles database operations for ingredients
+	IngredientsRepository *repository.IngredientsRepository
+	// PriceRepository handles database operations for ingredient prices
+	PriceRepository *repository.PriceRepository
+	// MealPlanService handles auto-fill logic
+	MealPlanService *service.MealPlanService
+	// CostService estimates recipe costs from ingredient prices
+	CostService *service.CostService
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewMealPlanHandler creates a new MealPlanHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *MealPlanHandler: A new meal plan handler instance
+func NewMealPlanHandler(pool *pgxpool.Pool, config *config.Config) *MealPlanHandler {
+	return &MealPlanHandler{
+		MealPlanRepository:    repository.NewMealPlanRepository(pool),
+		HouseholdRepository:   repository.NewHouseholdRepository(pool),
+		RecipeRepository:      repository.NewRecipeRepository(pool),
+		IngredientsRepository: repository.NewIngredientsRepository(pool),
+		PriceRepository:       repository.NewPriceRepository(pool),
+		MealPlanService:       service.NewMealPlanService(),
+		CostService:           service.NewCostService(),
+		Config:                config,
+	}
+}
+
+// mealPlanEntryRequest is the request body for creating or updating a meal plan entry.
+type mealPlanEntryRequest struct {
+	PlanDate model.Date `json:"planDate"`
+	MealSlot string     `json:"mealSlot"`
+	RecipeId int        `json:"recipeId"`
+	Servings int        `json:"servings"`
+	Notes    string     `json:"notes"`
+}
+
+// copyWeekRequest is the request body for copying a week of a meal plan.
+type copyWeekRequest struct {
+	FromWeekStart model.Date `json:"fromWeekStart"`
+	ToWeekStart   model.Date `json:"toWeekStart"`
+	Replace       bool       `json:"replace"`
+}
+
+// GetRange returns an HTTP handler function that returns the meal plan entries between the
+// start and end query parameters, inclusive, in calendar order. Each entry has the estimated cost of
+// its servings when any of the recipe's ingredients have a price.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes calendar range requests
+func (mh *MealPlanHandler) GetRange() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, mh.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error resolving meal plan", err)
+			return
+		}
+
+		start, end, err := dateRange(r)
+		if err != nil {
+			writeModelError(w, mh.Config, "Invalid date range", err)
+			return
+		}
+
+		entries, err := mh.MealPlanRepository.GetRange(r.Context(), scope, start, end)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error retrieving meal plan", err)
+			return
+		}
+
+		if err := mh.setEstimatedCosts(r.Context(), entries); err != nil {
+			writeModelError(w, mh.Config, "Error estimating meal plan costs", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, entries)
+	}
+}
+
+// CreateEntry returns an HTTP handler function that plans a recipe for a meal slot on a date.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes meal plan entry creation requests
+func (mh *MealPlanHandler) CreateEntry() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, mh.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error resolving meal plan", err)
+			return
+		}
+
+		var request mealPlanEntryRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		entry := model.NewMealPlanEntry(scope, request.PlanDate, request.MealSlot, request.RecipeId, middleware.UserID(r.Context()))
+		entry.Servings = request.Servings
+		entry.Notes = request.Notes
+
+		if err := entry.Validate(); err != nil {
+			writeModelError(w, mh.Config, "Meal plan entry validation failed", err)
+			return
+		}
+
+		saved, err := mh.MealPlanRepository.Insert(r.Context(), []model.MealPlanEntry{*entry})
+		if err != nil {
+			writeModelError(w, mh.Config, "Error saving meal plan entry", err)
+			return
+		}
+
+		created, err := mh.MealPlanRepository.Get(r.Context(), saved[0].ID)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error retrieving meal plan entry", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, created)
+	}
+}
+
+// UpdateEntry returns an HTTP handler function that changes a meal plan entry's date, slot, recipe, servings or notes.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes meal plan entry update requests
+func (mh *MealPlanHandler) UpdateEntry() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entryID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, mh.Config, "Invalid meal plan entry ID", err)
+			return
+		}
+
+		var request mealPlanEntryRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		entry, err := mh.getAccessibleEntry(r.Context(), entryID)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error retrieving meal plan entry", err)
+			return
+		}
+
+		entry.PlanDate = request.PlanDate
+		entry.MealSlot = request.MealSlot
+		entry.RecipeId = request.RecipeId
+		entry.Servings = request.Servings
+		entry.Notes = request.Notes
+		entry.UpdatedBy = middleware.UserID(r.Context())
+		entry.UpdatedDate = time.Now()
+
+		if err := entry.Validate(); err != nil {
+			writeModelError(w, mh.Config, "Meal plan entry validation failed", err)
+			return
+		}
+
+		if err := mh.MealPlanRepository.Update(r.Context(), entry); err != nil {
+			writeModelError(w, mh.Config, "Error updating meal plan entry", err)
+			return
+		}
+
+		updated, err := mh.MealPlanRepository.Get(r.Context(), entryID)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error retrieving meal plan entry", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, updated)
+	}
+}
+
+// DeleteEntry returns an HTTP handler function that removes a meal plan entry.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes meal plan entry deletion requests
+func (mh *MealPlanHandler) DeleteEntry() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entryID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, mh.Config, "Invalid meal plan entry ID", err)
+			return
+		}
+
+		if _, err := mh.getAccessibleEntry(r.Context(), entryID); err != nil {
+			writeModelError(w, mh.Config, "Error retrieving meal plan entry", err)
+			return
+		}
+
+		if err := mh.MealPlanRepository.Delete(r.Context(), entryID); err != nil {
+			writeModelError(w, mh.Config, "Error deleting meal plan entry", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CopyWeek returns an HTTP handler function that copies the seven days starting at fromWeekStart
+// to the seven days starting at toWeekStart. With replace set, the destination week is cleared first.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes copy-week requests
+func (mh *MealPlanHandler) CopyWeek() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, mh.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error resolving meal plan", err)
+			return
+		}
+
+		var request copyWeekRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if request.FromWeekStart.IsZero() {
+			writeModelError(w, mh.Config, "Copy week validation failed", model.ErrMissingRequiredField("fromWeekStart"))
+			return
+		}
+		if request.ToWeekStart.IsZero() {
+			writeModelError(w, mh.Config, "Copy week validation failed", model.ErrMissingRequiredField("toWeekStart"))
+			return
+		}
+		if request.FromWeekStart.Equal(request.ToWeekStart.Time) {
+			writeModelError(w, mh.Config, "Copy week validation failed", model.ErrInvalidField("toWeekStart"))
+			return
+		}
+
+		entries, err := mh.MealPlanRepository.CopyRange(
+			r.Context(),
+			scope,
+			request.FromWeekStart,
+			request.ToWeekStart,
+			7,
+			request.Replace,
+			middleware.UserID(r.Context()),
+		)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error copying meal plan week", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, entries)
+	}
+}
+
+// AutoFill returns an HTTP handler function that fills the empty slots in a date range with recipes.
+// Picked recipes carry every requested dietary label, fit the weeknight time limit Monday to Friday,
+// are not planned within noRepeatDays of another plan of the same recipe, and, with maxCostPerServing,
+// have an estimated cost per serving within the limit.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes auto-fill requests
+func (mh *MealPlanHandler) AutoFill() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, mh.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error resolving meal plan", err)
+			return
+		}
+
+		var request model.AutoFillRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := request.Validate(); err != nil {
+			writeModelError(w, mh.Config, "Auto-fill validation failed", err)
+			return
+		}
+
+		if daysBetweenDates(request.StartDate, request.EndDate) >= maxMealPlanRangeDays {
+			writeModelError(w, mh.Config, "Auto-fill validation failed", model.ErrInvalidField("endDate"))
+			return
+		}
+
+		// look around the range too so recipes planned just before or after it count as repeats.
+		existing, err := mh.MealPlanRepository.GetRange(
+			r.Context(),
+			scope,
+			request.StartDate.AddDays(-request.NoRepeatDays),
+			request.EndDate.AddDays(request.NoRepeatDays),
+		)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error retrieving meal plan", err)
+			return
+		}
+
+		options := repository.RecipeListOptions{
+			Tags:  request.Labels,
+			Sort:  repository.RecipeSortRating,
+			Limit: maxAutoFillCandidates,
+		}
+
+		if request.MaxCostPerServing > 0 {
+			costs, err := estimateAllCosts(r.Context(), mh.IngredientsRepository, mh.PriceRepository, mh.CostService, "")
+			if err != nil {
+				writeModelError(w, mh.Config, "Error estimating recipe costs", err)
+				return
+			}
+			options.RecipeIds = mh.CostService.Within(costs, 0, request.MaxCostPerServing)
+		}
+
+		candidates, _, err := mh.RecipeRepository.List(r.Context(), options)
+		if err != nil {
+			writeModelError(w, mh.Config, "Error retrieving recipes", err)
+			return
+		}
+
+		result := mh.MealPlanService.AutoFill(request, scope, existing, candidates, middleware.UserID(r.Context()))
+
+		if len(result.Created) > 0 {
+			result.Created, err = mh.MealPlanRepository.Insert(r.Context(), result.Created)
+			if err != nil {
+				writeModelError(w, mh.Config, "Error saving meal plan entries", err)
+				return
+			}
+		}
+
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// private functions
+
+// mealPlanScope returns the meal plan a request works on: the household in the householdId query
+// parameter if one is given and the current user belongs to it, otherwise the user's personal plan.
+//
+// Parameters:
+//   - r: The HTTP request
+//   - households: Repository used to check household membership
+//
+// Returns:
+//   - model.MealPlanScope: The meal plan to use
+//   - error: model.ErrPermissionDenied if the user is not in the household
+func mealPlanScope(r *http.Request, households *repository.HouseholdRepository) (model.MealPlanScope, error) {
+	userID := middleware.UserID(r.Context())
+
+	householdID, err := queryInt(r, "householdId", 0)
+	if err != nil {
+		return model.MealPlanScope{}, err
+	}
+
+	if householdID == 0 {
+		return model.MealPlanScope{UserId: userID}, nil
+	}
+
+	isMember, err := households.IsMember(r.Context(), householdID, userID)
+	if err != nil {
+		return model.MealPlanScope{}, err
+	}
+	if !isMember {
+		return model.MealPlanScope{}, model.ErrPermissionDenied("you are not a member of this household")
+	}
+
+	return model.MealPlanScope{HouseholdId: householdID}, nil
+}
+
+// getAccessibleEntry retrieves a meal plan entry and checks that the current user can change it.
+//
+// Parameters:
+//   - ctx: The request context carrying the current user
+//   - entryID: The ID of the entry
+//
+// Returns:
+//   - *model.MealPlanEntry: The entry
+//   - error: model.ErrNotFound or model.ErrPermissionDenied if the entry can't be used
+func (mh *MealPlanHandler) getAccessibleEntry(ctx context.Context, entryID int) (*model.MealPlanEntry, error) {
+	entry, err := mh.MealPlanRepository.Get(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeScope(ctx, mh.HouseholdRepository, entry.Scope()); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// authorizeScope checks that the current user owns a personal meal plan or shopping list,
+// or belongs to the household that owns a shared one.
+//
+// Parameters:
+//   - ctx: The request context carrying the current user
+//   - households: Repository used to check household membership
+//   - scope: The owner to check
+//
+// Returns:
+//   - error: model.ErrPermissionDenied if the user can't use the scope
+func authorizeScope(ctx context.Context, households *repository.HouseholdRepository, scope model.MealPlanScope) error {
+	userID := middleware.UserID(ctx)
+
+	if scope.HouseholdId == 0 {
+		if scope.UserId != userID {
+			return model.ErrPermissionDenied("this belongs to another user")
+		}
+		return nil
+	}
+
+	isMember, err := households.IsMember(ctx, scope.HouseholdId, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return model.ErrPermissionDenied("you are not a member of this household")
+	}
+
+	return nil
+}
+
+// setEstimatedCosts sets the estimated cost of each entry's servings from the current ingredient prices.
+// Entries whose recipes have no priced ingredients are left without an estimate.
+//
+// Parameters:
+//   - ctx: The context for database operations
+//   - entries: The entries to estimate, updated in place
+//
+// Returns:
+//   - error: An error if the recipes or prices can't be loaded
+func (mh *MealPlanHandler) setEstimatedCosts(ctx context.Context, entries []model.MealPlanEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	recipeIDs := make([]int, 0, len(entries))
+	for _, entry := range entries {
+		recipeIDs = append(recipeIDs, entry.RecipeId)
+	}
+
+	recipes, err := mh.IngredientsRepository.GetRecipesWithIngredients(ctx, recipeIDs)
+	if err != nil {
+		return err
+	}
+
+	prices, err := mh.PriceRepository.GetCurrent(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		recipe, ok := recipes[entry.RecipeId]
+		if !ok {
+			continue
+		}
+
+		cost := mh.CostService.Estimate(recipe, entry.Servings, prices)
+		if cost.Priced() {
+			entries[i].EstimatedCost = &cost.TotalCost
+		}
+	}
+
+	return nil
+}
+
+// dateRange reads the required start and end query parameters as an inclusive date range.
+//
+// Parameters:
+//   - r: The HTTP request
+//
+// Returns:
+//   - model.Date: The start date
+//   - model.Date: The end date
+//   - error: A model error if either date is missing or invalid, or the range is too long
+func dateRange(r *http.Request) (model.Date, model.Date, error) {
+	query := r.URL.Query()
+
+	if query.Get("start") == "" {
+		return model.Date{}, model.Date{}, model.ErrMissingRequiredField("start")
+	}
+	start, err := model.ParseDate(query.Get("start"))
+	if err != nil {
+		return model.Date{}, model.Date{}, model.ErrInvalidField("start")
+	}
+
+	if query.Get("end") == "" {
+		return model.Date{}, model.Date{}, model.ErrMissingRequiredField("end")
+	}
+	end, err := model.ParseDate(query.Get("end"))
+	if err != nil {
+		return model.Date{}, model.Date{}, model.ErrInvalidField("end")
+	}
+
+	if end.Before(start.Time) || daysBetweenDates(start, end) >= maxMealPlanRangeDays {
+		return model.Date{}, model.Date{}, model.ErrInvalidField("end")
+	}
+
+	return start, end, nil
+}
+
+// daysBetweenDates returns the number of days from start to end.
+func daysBetweenDates(start model.Date, end model.Date) int {
+	return int(end.Sub(start.Time).Hours() / 24)
+}
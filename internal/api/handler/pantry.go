@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// defaultMaxMissing is how many ingredients a recipe can be short of and still be suggested by "what can I cook".
+const defaultMaxMissing = 2
+
+// maxCanCookCandidates is the most recipes "what can I cook" checks against the pantry.
+const maxCanCookCandidates = 1000
+
+// PantryHandler manages HTTP requests related to the pantry.
+// Every endpoint works on the current user's pantry, or a household's with ?householdId=.
+type PantryHandler struct {
+	// PantryRepository handles database operations for pantry items
+	PantryRepository *repository.PantryRepository
+	// HouseholdRepository handles database operations for households
+	HouseholdRepository *repository.HouseholdRepository
+	// RecipeRepository handles database operations for recipes
+	RecipeRepository *repository.RecipeRepository
+	// IngredientsRepository handles database operations for ingredients
+	IngredientsRepository *repository.IngredientsRepository
+	// PantryService matches recipe ingredients against the pantry
+	PantryService *service.PantryService
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewPantryHandler creates a new PantryHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *PantryHandler: A new pantry handler instance
+func NewPantryHandler(pool *pgxpool.Pool, config *config.Config) *PantryHandler {
+	return &PantryHandler{
+		PantryRepository:      repository.NewPantryRepository(pool),
+		HouseholdRepository:   repository.NewHouseholdRepository(pool),
+		RecipeRepository:      repository.NewRecipeRepository(pool),
+		IngredientsRepository: repository.NewIngredientsRepository(pool),
+		PantryService:         service.NewPantryService(),
+		Config:                config,
+	}
+}
+
+// pantryItemRequest is the request body for adding or updating a pantry item.
+type pantryItemRequest struct {
+	IngredientName string      `json:"ingredientName"`
+	Quantity       float64     `json:"quantity"`
+	Unit           string      `json:"unit"`
+	ExpiryDate     *model.Date `json:"expiryDate"`
+}
+
+// List returns an HTTP handler function that lists the pantry, soonest to expire first.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes pantry list requests
+func (ph *PantryHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, ph.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error resolving household", err)
+			return
+		}
+
+		items, err := ph.PantryRepository.ListForScope(r.Context(), scope)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error retrieving pantry", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, items)
+	}
+}
+
+// Create returns an HTTP handler function that adds an item to the pantry.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes pantry item creation requests
+func (ph *PantryHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, ph.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error resolving household", err)
+			return
+		}
+
+		var request pantryItemRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		item := model.NewPantryItem(
+			scope,
+			strings.TrimSpace(request.IngredientName),
+			request.Quantity,
+			strings.TrimSpace(request.Unit),
+			middleware.UserID(r.Context()),
+		)
+		item.ExpiryDate = request.ExpiryDate
+
+		if err := item.Validate(); err != nil {
+			writeModelError(w, ph.Config, "Pantry item validation failed", err)
+			return
+		}
+
+		created, err := ph.PantryRepository.Insert(r.Context(), item)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error saving pantry item", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, created)
+	}
+}
+
+// Update returns an HTTP handler function that changes a pantry item's name, quantity, unit or expiry date.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes pantry item update requests
+func (ph *PantryHandler) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		item, err := ph.getAccessibleItem(r)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error retrieving pantry item", err)
+			return
+		}
+
+		var request pantryItemRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		item.IngredientName = strings.TrimSpace(request.IngredientName)
+		item.Quantity = request.Quantity
+		item.Unit = strings.TrimSpace(request.Unit)
+		item.ExpiryDate = request.ExpiryDate
+		item.UpdatedBy = middleware.UserID(r.Context())
+		item.UpdatedDate = time.Now()
+
+		if err := item.Validate(); err != nil {
+			writeModelError(w, ph.Config, "Pantry item validation failed", err)
+			return
+		}
+
+		if err := ph.PantryRepository.Update(r.Context(), item); err != nil {
+			writeModelError(w, ph.Config, "Error updating pantry item", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, item)
+	}
+}
+
+// Delete returns an HTTP handler function that removes an item from the pantry.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes pantry item deletion requests
+func (ph *PantryHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		item, err := ph.getAccessibleItem(r)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error retrieving pantry item", err)
+			return
+		}
+
+		if err := ph.PantryRepository.Delete(r.Context(), item.ID); err != nil {
+			writeModelError(w, ph.Config, "Error deleting pantry item", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CanCook returns an HTTP handler function that answers "what can I cook": recipes the pantry covers,
+// along with those short of at most maxMissing ingredients (2 by default), fewest missing first.
+// Each recipe lists how every ingredient matched, including uncertain matches to check by hand.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes "what can I cook" requests
+func (ph *PantryHandler) CanCook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, ph.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error resolving household", err)
+			return
+		}
+
+		maxMissing, err := queryInt(r, "maxMissing", defaultMaxMissing)
+		if err != nil || maxMissing < 0 {
+			writeModelError(w, ph.Config, "Invalid maxMissing", model.ErrInvalidField("maxMissing"))
+			return
+		}
+
+		pantry, err := ph.PantryRepository.ListForScope(r.Context(), scope)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error retrieving pantry", err)
+			return
+		}
+
+		candidates, _, err := ph.RecipeRepository.List(r.Context(), repository.RecipeListOptions{
+			Sort:  repository.RecipeSortRating,
+			Limit: maxCanCookCandidates,
+		})
+		if err != nil {
+			writeModelError(w, ph.Config, "Error retrieving recipes", err)
+			return
+		}
+
+		recipeIDs := make([]int, 0, len(candidates))
+		for _, candidate := range candidates {
+			recipeIDs = append(recipeIDs, candidate.ID)
+		}
+
+		recipes, err := ph.IngredientsRepository.GetRecipesWithIngredients(r.Context(), recipeIDs)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error retrieving ingredients", err)
+			return
+		}
+
+		results := []model.CanCookRecipe{}
+
+		for _, recipeID := range recipeIDs {
+			recipe, ok := recipes[recipeID]
+			if !ok || len(recipe.Ingredients) == 0 {
+				continue
+			}
+
+			matches, _ := ph.PantryService.Match(service.RecipeRequirements(recipe, 0), pantry)
+
+			result := model.CanCookRecipe{RecipeId: recipe.ID, RecipeName: recipe.RecipeName, Matches: matches}
+			for _, match := range matches {
+				if !service.Covers(match) {
+					result.Missing++
+				}
+				if match.Status == model.PantryMatchUncertain {
+					result.Uncertain++
+				}
+			}
+
+			if result.Missing <= maxMissing {
+				results = append(results, result)
+			}
+		}
+
+		// recipes stay in rating order among those missing the same number of ingredients.
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Missing < results[j].Missing
+		})
+
+		writeJSON(w, http.StatusOK, results)
+	}
+}
+
+// private functions
+
+// getAccessibleItem retrieves the pantry item in the {id} path wildcard and checks that the current user
+// owns it or belongs to its household.
+func (ph *PantryHandler) getAccessibleItem(r *http.Request) (*model.PantryItem, error) {
+	itemID, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := ph.PantryRepository.Get(r.Context(), itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeScope(r.Context(), ph.HouseholdRepository, item.Scope()); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// PriceHandler manages HTTP requests related to ingredient prices.
+// Prices are shared by everyone, so any user's observations improve everyone's cost estimates.
+type PriceHandler struct {
+	// PriceRepository handles database operations for ingredient prices
+	PriceRepository *repository.PriceRepository
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewPriceHandler creates a new PriceHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *PriceHandler: A new price handler instance
+func NewPriceHandler(pool *pgxpool.Pool, config *config.Config) *PriceHandler {
+	return &PriceHandler{
+		PriceRepository: repository.NewPriceRepository(pool),
+		Config:          config,
+	}
+}
+
+// ingredientPriceRequest is the request body for recording an ingredient price.
+type ingredientPriceRequest struct {
+	IngredientName string      `json:"ingredientName"`
+	Price          float64     `json:"price"`
+	Quantity       float64     `json:"quantity"`
+	Unit           string      `json:"unit"`
+	Store          string      `json:"store"`
+	ObservedDate   *model.Date `json:"observedDate"`
+}
+
+// Create returns an HTTP handler function that records a price seen for an ingredient, such as 3.49 for
+// 2 lb of chicken thighs. The observed date defaults to today. Earlier prices of the ingredient are kept
+// as its price history.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes price creation requests
+func (ph *PriceHandler) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request ingredientPriceRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		price := model.NewIngredientPrice(
+			service.CanonicalIngredientName(request.IngredientName),
+			request.Price,
+			request.Quantity,
+			strings.TrimSpace(request.Unit),
+			middleware.UserID(r.Context()),
+		)
+		price.Store = strings.TrimSpace(request.Store)
+		price.ObservedDate = model.Today()
+		if request.ObservedDate != nil {
+			price.ObservedDate = *request.ObservedDate
+		}
+
+		if err := price.Validate(); err != nil {
+			writeModelError(w, ph.Config, "Ingredient price validation failed", err)
+			return
+		}
+
+		created, err := ph.PriceRepository.Insert(r.Context(), price)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error saving ingredient price", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, created)
+	}
+}
+
+// Current returns an HTTP handler function that lists the current price of every ingredient, which is the
+// most recently observed one. ?store= prefers the prices seen at a store.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes current price requests
+func (ph *PriceHandler) Current() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		current, err := ph.PriceRepository.GetCurrent(r.Context(), r.URL.Query().Get("store"))
+		if err != nil {
+			writeModelError(w, ph.Config, "Error retrieving ingredient prices", err)
+			return
+		}
+
+		prices := make([]model.IngredientPrice, 0, len(current))
+		for _, price := range current {
+			prices = append(prices, price)
+		}
+		slices.SortFunc(prices, func(a, b model.IngredientPrice) int {
+			return strings.Compare(a.IngredientName, b.IngredientName)
+		})
+
+		writeJSON(w, http.StatusOK, prices)
+	}
+}
+
+// History returns an HTTP handler function that lists every price observed for the ingredient in the
+// ingredient query parameter, most recent first.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes price history requests
+func (ph *PriceHandler) History() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ingredientName := service.CanonicalIngredientName(r.URL.Query().Get("ingredient"))
+		if ingredientName == "" {
+			writeModelError(w, ph.Config, "Invalid ingredient", model.ErrMissingRequiredField("ingredient"))
+			return
+		}
+
+		prices, err := ph.PriceRepository.GetHistory(r.Context(), ingredientName)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error retrieving price history", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, prices)
+	}
+}
+
+// Delete returns an HTTP handler function that removes a mistaken price. Only the user who recorded the
+// price or an admin may remove it.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes price deletion requests
+func (ph *PriceHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		priceID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, ph.Config, "Invalid price ID", err)
+			return
+		}
+
+		price, err := ph.PriceRepository.Get(r.Context(), priceID)
+		if err != nil {
+			writeModelError(w, ph.Config, "Error retrieving ingredient price", err)
+			return
+		}
+
+		userID := middleware.UserID(r.Context())
+		if price.CreatedBy != userID && !slices.Contains(ph.Config.AdminUserIds, userID) {
+			writeModelError(w, ph.Config, "Error deleting ingredient price", model.ErrPermissionDenied("this price was recorded by another user"))
+			return
+		}
+
+		if err := ph.PriceRepository.Delete(r.Context(), priceID); err != nil {
+			writeModelError(w, ph.Config, "Error deleting ingredient price", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
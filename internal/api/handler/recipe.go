@@ -15,11 +15,15 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
 	"recipe-generator/internal/api/model"
 	"recipe-generator/internal/api/repository"
 	"recipe-generator/internal/api/service"
 )
 
+// defaultSimilarLimit is how many similar recipes are recommended by default.
+const defaultSimilarLimit = 10
+
 // RecipeHandler manages HTTP requests related to recipes.
 // It provides methods for creating, retrieving, and managing recipes
 // and their associated ingredients and procedures.
@@ -34,6 +38,34 @@ type RecipeHandler struct {
 	IngredientsRepository *repository.IngredientsRepository
 	// ProcedureRepository handles database operations for procedures
 	ProcedureRepository *repository.ProcedureRepository
+	// TagRepository handles database operations for recipe tags
+	TagRepository *repository.TagRepository
+	// EquipmentRepository handles database operations for recipe and kitchen equipment
+	EquipmentRepository *repository.EquipmentRepository
+	// FoodImageRepository handles database operations for photos of recipes' dishes
+	FoodImageRepository *repository.FoodImageRepository
+	// PantryRepository handles database operations for pantry items
+	PantryRepository *repository.PantryRepository
+	// HouseholdRepository handles database operations for households
+	HouseholdRepository *repository.HouseholdRepository
+	// PantryService matches recipe ingredients against the pantry
+	PantryService *service.PantryService
+	// SubstitutionService proposes and makes ingredient substitutions
+	SubstitutionService *service.SubstitutionService
+	// PriceRepository handles database operations for ingredient prices
+	PriceRepository *repository.PriceRepository
+	// CostService estimates recipe costs from ingredient prices
+	CostService *service.CostService
+	// ScalingService scales recipes by servings, yield or pan size
+	ScalingService *service.ScalingService
+	// BakersService works out baker's percentages and dough formulas
+	BakersService *service.BakersService
+	// ComponentService expands and checks recipes used as ingredients of other recipes
+	ComponentService *service.ComponentService
+	// RecipeCardService renders recipes as Markdown and printable HTML recipe cards
+	RecipeCardService *service.RecipeCardService
+	// RecommendationService recommends similar recipes; shared so every handler that changes recipes can refresh it
+	RecommendationService *service.RecommendationService
 	// Config contains application configuration
 	Config *config.Config
 }
@@ -44,10 +76,11 @@ type RecipeHandler struct {
 // Parameters:
 //   - pool: PostgreSQL connection pool for database operations
 //   - config: Application configuration
+//   - recommendations: Similar recipe recommendations, refreshed when recipes are added
 //
 // Returns:
 //   - *RecipeHandler: A new recipe handler instance
-func NewRecipeHandler(pool *pgxpool.Pool, config *config.Config) *RecipeHandler {
+func NewRecipeHandler(pool *pgxpool.Pool, config *config.Config, recommendations *service.RecommendationService) *RecipeHandler {
 	
 	return &RecipeHandler{
 		ConnectionPool:        pool,
@@ -55,15 +88,47 @@ func NewRecipeHandler(pool *pgxpool.Pool, config *config.Config) *RecipeHandler
 		RecipeRepository:      repository.NewRecipeRepository(pool),
 		IngredientsRepository: repository.NewIngredientsRepository(pool),
 		ProcedureRepository:   repository.NewProcedureRepository(pool),
+		TagRepository:         repository.NewTagRepository(pool),
+		EquipmentRepository:   repository.NewEquipmentRepository(pool),
+		FoodImageRepository:   repository.NewFoodImageRepository(pool),
+		PantryRepository:      repository.NewPantryRepository(pool),
+		HouseholdRepository:   repository.NewHouseholdRepository(pool),
+		PantryService:         service.NewPantryService(),
+		SubstitutionService:   service.NewSubstitutionService(),
+		PriceRepository:       repository.NewPriceRepository(pool),
+		CostService:           service.NewCostService(),
+		ScalingService:        service.NewScalingService(),
+		BakersService:         service.NewBakersService(),
+		ComponentService:      service.NewComponentService(),
+		RecipeCardService:     service.NewRecipeCardService(config.RecipeTemplatesDirectory),
+		RecommendationService: recommendations,
 		Config:                config,
 	}
 }
 
 
+// submittedRecipeResponse is the response body of a recipe submission: the saved recipe, along with any
+// existing recipes it looks like a duplicate of.
+type submittedRecipeResponse struct {
+	*model.Recipe
+	PossibleDuplicates []model.DuplicateMatch `json:"possibleDuplicates,omitempty"`
+}
+
+// duplicateRecipeResponse is the response body when a submission is rejected as a likely duplicate.
+type duplicateRecipeResponse struct {
+	Error      string                 `json:"error"`
+	Duplicates []model.DuplicateMatch `json:"duplicates"`
+}
+
 // Post returns an HTTP handler function that processes POST requests for creating new recipes.
 // The handler validates the recipe data, creates a database transaction, and inserts the recipe,
 // its ingredients, and procedure steps into the database.
 //
+// Existing recipes that look like the same recipe, by name trigram similarity and ingredient overlap,
+// are listed in the response's possibleDuplicates. In strict mode the recipe is rejected with a 409
+// listing them instead; strict mode is set by STRICT_DUPLICATE_CHECK and can be overridden per request
+// with ?strict=true or ?strict=false.
+//
 // Returns:
 //   - http.HandlerFunc: A handler function that processes recipe creation requests
 func (rh *RecipeHandler) Post() http.HandlerFunc {
@@ -105,10 +170,34 @@ func (rh *RecipeHandler) Post() http.HandlerFunc {
 			return
 		}
 
+		// ingredients can be other recipes, such as a pizza's dough; they must exist and not lead back here.
+		// their names and units are filled in from the component recipes before validating.
+		if err := rh.resolveComponents(r.Context(), recipe); err != nil {
+			writeModelError(w, rh.Config, "Ingredient validation failed", err)
+			return
+		}
+
 		if err := rh.validateIngredients(w, ingredients); err != nil {
 			return
 		}
 
+		// look for likely duplicates, such as the same recipe scanned twice under a slightly different name.
+		duplicates, err := rh.findDuplicates(r.Context(), recipe)
+		if err != nil {
+			log.Printf("Error checking for duplicate recipes: %v", err)
+			rh.handleRecipeSubmissionError(w, err)
+			return
+		}
+
+		if len(duplicates) > 0 && rh.strictDuplicateCheck(r) {
+			log.Printf("Rejecting recipe %s as a likely duplicate of %d recipes", recipe.RecipeName, len(duplicates))
+			writeJSON(w, http.StatusConflict, duplicateRecipeResponse{
+				Error:      "Recipe looks like a duplicate of an existing recipe",
+				Duplicates: duplicates,
+			})
+			return
+		}
+
 		// Start a new transaction
 		tx, err := rh.ConnectionPool.Begin(r.Context())
 		if err != nil {
@@ -122,7 +211,7 @@ func (rh *RecipeHandler) Post() http.HandlerFunc {
 		// Insert the recipe into the database using the transaction
 		savedRecipe, err := rh.submitRecipe(r.Context(), recipe, tx)
 		if err != nil {
-			rh.handleRecipeSubmissionError(w, err)
+			writeModelError(w, rh.Config, "Error when submitting a recipe to the database", err)
 			return
 		}
 
@@ -133,12 +222,26 @@ func (rh *RecipeHandler) Post() http.HandlerFunc {
 			return
 		}
 
-		err = rh.submitProcedure(r.Context(), procedure, savedRecipe.ID, tx)
+		err = rh.submitProcedure(r.Context(), procedure, recipe.StepTimings, savedRecipe.ID, tx)
 		if err != nil {
 			rh.handleProcedureSubmissionError(w, err)
 			return
 		}
 
+		recipe.Tags = model.NormalizeTags(recipe.Tags)
+		err = rh.TagRepository.Insert(r.Context(), savedRecipe.ID, recipe.Tags, recipe.CreatedBy, tx)
+		if err != nil {
+			rh.handleRecipeSubmissionError(w, err)
+			return
+		}
+
+		recipe.Equipment = recipeEquipment(recipe)
+		err = rh.EquipmentRepository.Insert(r.Context(), savedRecipe.ID, recipe.Equipment, recipe.CreatedBy, tx)
+		if err != nil {
+			rh.handleRecipeSubmissionError(w, err)
+			return
+		}
+
 		// Commit the transaction
 		if err := tx.Commit(r.Context()); err != nil {
 			log.Printf("Error committing transaction: %v", err)
@@ -147,7 +250,9 @@ func (rh *RecipeHandler) Post() http.HandlerFunc {
 		}
 
 		log.Printf("Successfully inserted recipe: %s with ID: %d", recipe.RecipeName, recipe.ID)
-		err = json.NewEncoder(w).Encode(recipe)
+		rh.RecommendationService.Invalidate()
+
+		err = json.NewEncoder(w).Encode(submittedRecipeResponse{Recipe: recipe, PossibleDuplicates: duplicates})
 
 		// these error functions need to go in their own struct
 		if err != nil {
@@ -190,7 +295,9 @@ func (rh *RecipeHandler) Get() http.HandlerFunc {
 }
 
 // GetRandom returns an HTTP handler function that selects and returns a random recipe from the database.
-// The handler responds with a randomly selected recipe in JSON format.
+// The handler responds with a randomly selected recipe in JSON format. Passing favorNotRecent=true
+// weights the pick toward recipes the current user has not cooked recently, and myKitchen=true only picks
+// recipes that need no equipment missing from the current user's kitchen.
 //
 // Returns:
 //   - http.HandlerFunc: A handler function that processes random recipe retrieval requests
@@ -200,8 +307,26 @@ func (rh *RecipeHandler) GetRandom() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 
-			// get a random recipe id from the database
-			recipeID, err := rh.RecipeRepository.GetRandomRecipeId(r.Context())
+			// get a random recipe id from the database. with favorNotRecent=true, recipes the current
+			// user hasn't cooked lately are more likely to come up.
+			var recipeID int
+
+			ownedEquipment, err := kitchenFilter(r, rh.EquipmentRepository)
+			if err != nil {
+				writeModelError(w, rh.Config, "Error retrieving kitchen equipment", err)
+				return
+			}
+
+			if r.URL.Query().Get("favorNotRecent") == "true" {
+				recipeID, err = rh.RecipeRepository.GetRandomRecipeIdFavoringNotRecentlyCooked(r.Context(), middleware.UserID(r.Context()), ownedEquipment)
+			} else {
+				recipeID, err = rh.RecipeRepository.GetRandomRecipeId(r.Context(), ownedEquipment)
+			}
+
+			if err == nil && recipeID == 0 {
+				writeModelError(w, rh.Config, "Error getting random recipe ID", model.ErrNotFound("recipe"))
+				return
+			}
 
 			if err != nil {
 				log.Printf("Error getting random recipe ID: %v", err)
@@ -210,6 +335,7 @@ func (rh *RecipeHandler) GetRandom() http.HandlerFunc {
 				json.NewEncoder(w).Encode(map[string]string{
 					"err": err.Error(),
 				})
+				return
 			}
 
 			recipe, err := rh.RecipeRepository.Get(r.Context(), recipeID)
@@ -221,6 +347,7 @@ func (rh *RecipeHandler) GetRandom() http.HandlerFunc {
 				json.NewEncoder(w).Encode(map[string]string{
 					"err": err.Error(),
 				})
+				return
 			}
 
 			// get ingredients and procedure steps next
@@ -233,6 +360,7 @@ func (rh *RecipeHandler) GetRandom() http.HandlerFunc {
 				json.NewEncoder(w).Encode(map[string]string{
 					"err": err.Error(),
 				})
+				return
 			}
 
 			procedureSteps, err := rh.ProcedureRepository.GetProcedureByRecipeId(r.Context(), recipeID)
@@ -244,6 +372,7 @@ func (rh *RecipeHandler) GetRandom() http.HandlerFunc {
 				json.NewEncoder(w).Encode(map[string]string{
 					"err": err.Error(),
 				})
+				return
 			}
 
 			recipe.Ingredients = ingredients
@@ -273,8 +402,266 @@ func (rh *RecipeHandler) GetRandom() http.HandlerFunc {
 	}
 }
 
+// GetById returns an HTTP handler function that returns a single recipe, with its ingredients,
+// procedure steps and rating summary, by the ID in the request path. ?expand=components includes the
+// recipes used as ingredients inline, scaled to the amounts used, and ?format=jsonld or ?format=cooklang
+// returns it as a schema.org Recipe or a Cooklang file. ?format=markdown and ?format=html return a recipe
+// card, scaled with ?servings=. Without ?format= the format is picked from the Accept header.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes recipe retrieval requests
+func (rh *RecipeHandler) GetById() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, rh.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		recipe, err := rh.getRecipe(r.Context(), recipeID)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving recipe", err)
+			return
+		}
+
+		if r.URL.Query().Get("expand") == "components" {
+			recipe, err = rh.expandComponents(r.Context(), recipe)
+			if err != nil {
+				writeModelError(w, rh.Config, "Error retrieving component recipes", err)
+				return
+			}
+		}
+
+		rh.writeRecipe(w, r, recipe)
+	}
+}
+
+// List returns an HTTP handler function that lists recipes with limit/offset pagination.
+// Repeating the tag query parameter only returns recipes with all of the given tags.
+// The sort query parameter accepts name (the default), newest, rating, cost or cost-per-serving. Sorting by
+// rating uses a Bayesian average so recipes with only a handful of reviews don't top the list.
+// ?maxCost= and ?maxCostPerServing= only return recipes whose estimated cost is within the limit, and
+// ?store= prefers the prices seen at a store. Cost sorts and filters add the estimates to the listed
+// recipes; recipes with no priced ingredients have no estimate, sort last and never pass a cost filter.
+// ?myKitchen=true leaves out recipes that need equipment missing from the current user's kitchen.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes recipe list requests
+func (rh *RecipeHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, offset, err := pagination(r)
+		if err != nil {
+			writeModelError(w, rh.Config, "Invalid pagination", err)
+			return
+		}
+
+		options := repository.RecipeListOptions{
+			Tags:   r.URL.Query()["tag"],
+			Sort:   r.URL.Query().Get("sort"),
+			Limit:  limit,
+			Offset: offset,
+		}
+
+		options.OwnedEquipment, err = kitchenFilter(r, rh.EquipmentRepository)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving kitchen equipment", err)
+			return
+		}
+
+		maxCost, err := queryFloat(r, "maxCost", 0)
+		if err != nil || maxCost < 0 {
+			writeModelError(w, rh.Config, "Invalid maxCost", model.ErrInvalidField("maxCost"))
+			return
+		}
+
+		maxCostPerServing, err := queryFloat(r, "maxCostPerServing", 0)
+		if err != nil || maxCostPerServing < 0 {
+			writeModelError(w, rh.Config, "Invalid maxCostPerServing", model.ErrInvalidField("maxCostPerServing"))
+			return
+		}
+
+		var costs map[int]model.RecipeCost
+
+		sortByCost := options.Sort == repository.RecipeSortCost || options.Sort == repository.RecipeSortCostPerServing
+		if sortByCost || maxCost > 0 || maxCostPerServing > 0 {
+			costs, err = estimateAllCosts(r.Context(), rh.IngredientsRepository, rh.PriceRepository, rh.CostService, r.URL.Query().Get("store"))
+			if err != nil {
+				writeModelError(w, rh.Config, "Error estimating recipe costs", err)
+				return
+			}
+
+			if maxCost > 0 || maxCostPerServing > 0 {
+				options.RecipeIds = rh.CostService.Within(costs, maxCost, maxCostPerServing)
+			}
+			if sortByCost {
+				options.CostOrder = rh.CostService.Cheapest(costs, options.Sort == repository.RecipeSortCostPerServing)
+			}
+		}
+
+		recipes, total, err := rh.RecipeRepository.List(r.Context(), options)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error listing recipes", err)
+			return
+		}
+
+		for i := range recipes {
+			if cost, ok := costs[recipes[i].ID]; ok {
+				setEstimatedCost(&recipes[i], cost)
+			}
+		}
+
+		writeJSON(w, http.StatusOK, pageResponse[model.Recipe]{
+			Items:  recipes,
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
+}
+
+// Similar returns an HTTP handler function that recommends recipes like the one in the request path, best
+// first, each with the parts of its score and a "because it shares ..." explanation. ?limit= sets how many
+// are returned, 10 by default and at most 50.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes similar recipe requests
+func (rh *RecipeHandler) Similar() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, rh.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		limit, err := queryInt(r, "limit", defaultSimilarLimit)
+		if err != nil || limit < 1 || limit > service.MaxSimilarRecipes {
+			writeModelError(w, rh.Config, "Invalid limit", model.ErrInvalidField("limit"))
+			return
+		}
+
+		similar, err := rh.RecommendationService.Similar(r.Context(), recipeID, limit)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error finding similar recipes", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, similar)
+	}
+}
+
 // private functions
 
+// getRecipe retrieves a single recipe with its ingredients and procedure steps.
+//
+// Parameters:
+//   - ctx: The context for database operations
+//   - recipeID: The ID of the recipe to retrieve
+//
+// Returns:
+//   - *model.Recipe: The hydrated recipe
+//   - error: model.ErrNotFound if the recipe does not exist, or an error if the retrieval fails
+func (rh *RecipeHandler) getRecipe(ctx context.Context, recipeID int) (*model.Recipe, error) {
+	recipes, err := rh.RecipeRepository.GetByIds(ctx, []int{recipeID})
+	if err != nil {
+		return nil, err
+	}
+
+	recipe, ok := recipes[recipeID]
+	if !ok {
+		return nil, model.ErrNotFound("recipe")
+	}
+
+	return recipe, nil
+}
+
+// findDuplicates returns the existing recipes that are likely duplicates of a recipe being submitted. Only the
+// recipes with names similar enough to reach the threshold are retrieved and scored.
+//
+// Parameters:
+//   - ctx: The context for database operations
+//   - recipe: The submitted recipe with its ingredients
+//
+// Returns:
+//   - []model.DuplicateMatch: The likely duplicates, most alike first
+//   - error: An error if the existing recipes can't be retrieved
+func (rh *RecipeHandler) findDuplicates(ctx context.Context, recipe *model.Recipe) ([]model.DuplicateMatch, error) {
+	minSimilarity := service.MinDuplicateNameSimilarity(service.DefaultDuplicateThreshold)
+	candidates, err := rh.IngredientsRepository.GetRecipesWithSimilarNames(ctx, recipe.RecipeName, minSimilarity)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.FindDuplicates(recipe, candidates, service.DefaultDuplicateThreshold), nil
+}
+
+// strictDuplicateCheck reports whether likely duplicates should be rejected rather than only reported:
+// the ?strict query parameter if it is given, otherwise the STRICT_DUPLICATE_CHECK setting.
+func (rh *RecipeHandler) strictDuplicateCheck(r *http.Request) bool {
+	switch r.URL.Query().Get("strict") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return rh.Config.StrictDuplicateCheck
+}
+
+// saveRecipe inserts a recipe with its ingredients, procedure steps and tags in a single transaction.
+//
+// Parameters:
+//   - ctx: The context for database operations
+//   - recipe: The recipe to insert
+//
+// Returns:
+//   - *model.Recipe: The saved recipe with its database ID
+//   - error: An error if any insertion fails
+func (rh *RecipeHandler) saveRecipe(ctx context.Context, recipe *model.Recipe) (*model.Recipe, error) {
+	tx, err := rh.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	savedRecipe, err := rh.submitRecipe(ctx, recipe, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rh.submitIngredients(ctx, recipe.Ingredients, savedRecipe.ID, tx); err != nil {
+		return nil, err
+	}
+
+	if err := rh.submitProcedure(ctx, recipe.Procedure, recipe.StepTimings, savedRecipe.ID, tx); err != nil {
+		return nil, err
+	}
+
+	recipe.Tags = model.NormalizeTags(recipe.Tags)
+	if err := rh.TagRepository.Insert(ctx, savedRecipe.ID, recipe.Tags, recipe.CreatedBy, tx); err != nil {
+		return nil, err
+	}
+
+	recipe.Equipment = recipeEquipment(recipe)
+	if err := rh.EquipmentRepository.Insert(ctx, savedRecipe.ID, recipe.Equipment, recipe.CreatedBy, tx); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Successfully inserted recipe: %s with ID: %d", savedRecipe.RecipeName, savedRecipe.ID)
+	rh.RecommendationService.Invalidate()
+
+	return savedRecipe, nil
+}
+
 // decodeRecipe parses the HTTP request body into a Recipe struct.
 // It also sets default values for creation and update metadata.
 //
@@ -444,15 +831,21 @@ func (rh *RecipeHandler) handleIngredientSubmissionError(w http.ResponseWriter,
 // Parameters:
 //   - ctx: The context for database operations
 //   - procedure: The list of procedure steps to insert
+//   - timings: The optional timing of each step, in the same order
 //   - recipeID: The ID of the recipe to which the procedure steps belong
 //   - tx: The database transaction
 //
 // Returns:
 //   - error: An error if any procedure step insertion fails, nil otherwise
-func (rh *RecipeHandler) submitProcedure(ctx context.Context, procedure []string, recipeID int, tx pgx.Tx) error {
+func (rh *RecipeHandler) submitProcedure(ctx context.Context, procedure []string, timings []model.StepTiming, recipeID int, tx pgx.Tx) error {
+
+	for i, step := range procedure {
+		var timing *model.StepTiming
+		if i < len(timings) && !timings[i].IsZero() {
+			timing = &timings[i]
+		}
 
-	for _, step := range procedure {
-		err := rh.ProcedureRepository.Insert(ctx, step, recipeID, tx)
+		err := rh.ProcedureRepository.Insert(ctx, step, timing, recipeID, tx)
 		if err != nil {
 			log.Printf("Error inserting procedure: %v", err)
 			return err
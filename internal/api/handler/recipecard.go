@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/service"
+)
+
+// Photo returns an HTTP handler function that serves a recipe's primary photo, the first one added, as shown
+// on its recipe card.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes recipe photo requests
+func (rh *RecipeHandler) Photo() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, rh.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		photo, err := rh.FoodImageRepository.GetPrimary(r.Context(), recipeID)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving photo", err)
+			return
+		}
+
+		http.ServeFile(w, r, photo.Filepath)
+	}
+}
+
+// private functions
+
+// writeRecipeCard writes a recipe as a Markdown or printable HTML recipe card, scaled to ?servings= if it is
+// given, with its primary photo if it has one.
+func (rh *RecipeHandler) writeRecipeCard(w http.ResponseWriter, r *http.Request, recipe *model.Recipe, format string) {
+	servings, err := queryInt(r, "servings", 0)
+	if err != nil || servings < 0 {
+		writeModelError(w, rh.Config, "Invalid servings", model.ErrInvalidField("servings"))
+		return
+	}
+
+	var scaled *model.ScaledRecipe
+	if servings > 0 && servings != recipe.Servings {
+		result, err := rh.ScalingService.Scale(recipe, model.ScaleRequest{Servings: servings})
+		if err != nil {
+			writeModelError(w, rh.Config, "Error scaling recipe", err)
+			return
+		}
+		scaled = &result
+	}
+
+	photoUrl := ""
+	var notFound model.ErrNotFound
+	if _, err := rh.FoodImageRepository.GetPrimary(r.Context(), recipe.ID); err == nil {
+		photoUrl = fmt.Sprintf("/recipe/%d/photo", recipe.ID)
+	} else if !errors.As(err, &notFound) {
+		writeModelError(w, rh.Config, "Error retrieving photo", err)
+		return
+	}
+
+	card := service.NewRecipeCard(recipe, scaled, photoUrl)
+
+	if format == recipeFormatMarkdown {
+		text, err := rh.RecipeCardService.Markdown(card)
+		if err != nil {
+			writeInternalError(w, rh.Config, "Error rendering recipe", err)
+			return
+		}
+		writeText(w, "text/markdown; charset=utf-8", text)
+		return
+	}
+
+	page, err := rh.RecipeCardService.Html(card)
+	if err != nil {
+		writeInternalError(w, rh.Config, "Error rendering recipe", err)
+		return
+	}
+	writeText(w, "text/html; charset=utf-8", page)
+}
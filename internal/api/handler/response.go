@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/model"
+)
+
+// writeJSON encodes body as JSON and writes it to the response with the given status code.
+//
+// Parameters:
+//   - w: The HTTP response writer
+//   - status: The HTTP status code to send
+//   - body: The value to encode as the response body
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error encoding response body: %v", err)
+	}
+}
+
+// writeError writes a JSON error response in the same shape the recipe handlers use.
+//
+// Parameters:
+//   - w: The HTTP response writer
+//   - status: The HTTP status code to send
+//   - message: The error message returned to the client
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"error": message,
+	})
+}
+
+// writeInternalError writes a 500 response. In development mode, it includes detailed error information.
+//
+// Parameters:
+//   - w: The HTTP response writer
+//   - cfg: Application configuration, used to check the environment
+//   - message: A short description of what failed
+//   - err: The error that occurred
+func writeInternalError(w http.ResponseWriter, cfg *config.Config, message string, err error) {
+	var response map[string]string
+
+	if strings.ToLower(cfg.Environment) == "development" {
+		response = map[string]string{
+			"error":   message,
+			"details": err.Error(),
+		}
+	} else {
+		response = map[string]string{
+			"error": "Internal server error",
+		}
+	}
+
+	writeJSON(w, http.StatusInternalServerError, response)
+}
+
+// writeModelError maps the model error types to their HTTP status codes and writes the response.
+// Errors that are not model errors are treated as internal server errors.
+//
+// Parameters:
+//   - w: The HTTP response writer
+//   - cfg: Application configuration, used to check the environment
+//   - message: A short description of what failed, used for internal errors
+//   - err: The error that occurred
+func writeModelError(w http.ResponseWriter, cfg *config.Config, message string, err error) {
+	var notFound model.ErrNotFound
+	var missingField model.ErrMissingRequiredField
+	var invalidField model.ErrInvalidField
+	var permissionDenied model.ErrPermissionDenied
+	var alreadyExists model.ErrAlreadyExists
+	var conflict model.ErrConflict
+
+	switch {
+	case errors.As(err, &notFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.As(err, &missingField), errors.As(err, &invalidField):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.As(err, &permissionDenied):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.As(err, &alreadyExists), errors.As(err, &conflict):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("%s: %v", message, err)
+		writeInternalError(w, cfg, message, err)
+	}
+}
+
+// pathID parses a positive integer path wildcard such as {id} from the request.
+//
+// Parameters:
+//   - r: The HTTP request
+//   - name: The name of the path wildcard
+//
+// Returns:
+//   - int: The parsed ID
+//   - error: An ErrInvalidField error if the wildcard is not a positive integer
+func pathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || id <= 0 {
+		return 0, model.ErrInvalidField(name)
+	}
+	return id, nil
+}
+
+// queryInt parses an optional integer query parameter, returning fallback when it is absent.
+//
+// Parameters:
+//   - r: The HTTP request
+//   - name: The name of the query parameter
+//   - fallback: The value returned when the parameter is absent
+//
+// Returns:
+//   - int: The parsed value or the fallback
+//   - error: An ErrInvalidField error if the parameter is present but not an integer
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, model.ErrInvalidField(name)
+	}
+	return parsed, nil
+}
+
+// queryFloat parses an optional decimal query parameter, returning fallback when it is absent.
+//
+// Parameters:
+//   - r: The HTTP request
+//   - name: The name of the query parameter
+//   - fallback: The value returned when the parameter is absent
+//
+// Returns:
+//   - float64: The parsed value or the fallback
+//   - error: An ErrInvalidField error if the parameter is present but not a number
+func queryFloat(r *http.Request, name string, fallback float64) (float64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return 0, model.ErrInvalidField(name)
+	}
+	return parsed, nil
+}
+
+// decodeJSON decodes the request body into target.
+//
+// Parameters:
+//   - r: The HTTP request containing the JSON body
+//   - target: A pointer to the value to decode into
+//
+// Returns:
+//   - error: An error if decoding fails
+func decodeJSON(r *http.Request, target interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(target); err != nil {
+		return fmt.Errorf("error decoding request body: %v", err)
+	}
+	return nil
+}
+
+// defaultPageLimit and maxPageLimit bound the page size of paginated endpoints.
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageResponse is the response body of paginated endpoints.
+type pageResponse[T any] struct {
+	Items  []T `json:"items"`  // The items on this page
+	Total  int `json:"total"`  // The total number of items across all pages
+	Limit  int `json:"limit"`  // The maximum number of items per page
+	Offset int `json:"offset"` // The number of items skipped before this page
+}
+
+// pagination reads the limit and offset query parameters, applying the default and maximum page size.
+//
+// Parameters:
+//   - r: The HTTP request
+//
+// Returns:
+//   - int: The page size
+//   - int: The number of items to skip
+//   - error: An ErrInvalidField error if either parameter is not a valid integer
+func pagination(r *http.Request) (int, int, error) {
+	limit, err := queryInt(r, "limit", defaultPageLimit)
+	if err != nil {
+		return 0, 0, err
+	}
+	if limit <= 0 || limit > maxPageLimit {
+		return 0, 0, model.ErrInvalidField("limit")
+	}
+
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	if offset < 0 {
+		return 0, 0, model.ErrInvalidField("offset")
+	}
+
+	return limit, offset, nil
+}
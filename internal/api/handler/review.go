@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+)
+
+// ReviewHandler manages HTTP requests related to recipe ratings and reviews.
+type ReviewHandler struct {
+	// ReviewRepository handles database operations for reviews
+	ReviewRepository *repository.ReviewRepository
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewReviewHandler creates a new ReviewHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *ReviewHandler: A new review handler instance
+func NewReviewHandler(pool *pgxpool.Pool, config *config.Config) *ReviewHandler {
+	return &ReviewHandler{
+		ReviewRepository: repository.NewReviewRepository(pool),
+		Config:           config,
+	}
+}
+
+// reviewRequest is the request body for saving a review.
+type reviewRequest struct {
+	Rating     int    `json:"rating"`
+	ReviewText string `json:"reviewText"`
+}
+
+// List returns an HTTP handler function that lists a recipe's reviews, newest first, with limit/offset pagination.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes review list requests
+func (rvh *ReviewHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, rvh.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		limit, offset, err := pagination(r)
+		if err != nil {
+			writeModelError(w, rvh.Config, "Invalid pagination", err)
+			return
+		}
+
+		reviews, total, err := rvh.ReviewRepository.GetByRecipeId(r.Context(), recipeID, limit, offset)
+		if err != nil {
+			writeModelError(w, rvh.Config, "Error retrieving reviews", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, pageResponse[model.Review]{
+			Items:  reviews,
+			Total:  total,
+			Limit:  limit,
+			Offset: offset,
+		})
+	}
+}
+
+// GetMine returns an HTTP handler function that returns the current user's review of a recipe.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes review retrieval requests
+func (rvh *ReviewHandler) GetMine() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, rvh.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		review, err := rvh.ReviewRepository.GetByUser(r.Context(), recipeID, middleware.UserID(r.Context()))
+		if err != nil {
+			writeModelError(w, rvh.Config, "Error retrieving review", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, review)
+	}
+}
+
+// Put returns an HTTP handler function that creates or edits the current user's review of a recipe.
+// Each user has one review per recipe, so saving again replaces the earlier rating and text.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes review save requests
+func (rvh *ReviewHandler) Put() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, rvh.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		var request reviewRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		review := model.NewReview(recipeID, middleware.UserID(r.Context()), request.Rating, request.ReviewText)
+
+		if err := review.Validate(); err != nil {
+			writeModelError(w, rvh.Config, "Review validation failed", err)
+			return
+		}
+
+		saved, err := rvh.ReviewRepository.Upsert(r.Context(), review)
+		if err != nil {
+			writeModelError(w, rvh.Config, "Error saving review", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, saved)
+	}
+}
+
+// Delete returns an HTTP handler function that deletes the current user's review of a recipe.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes review deletion requests
+func (rvh *ReviewHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipeID, err := pathID(r, "id")
+		if err != nil {
+			writeModelError(w, rvh.Config, "Invalid recipe ID", err)
+			return
+		}
+
+		if err := rvh.ReviewRepository.Delete(r.Context(), recipeID, middleware.UserID(r.Context())); err != nil {
+			writeModelError(w, rvh.Config, "Error deleting review", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
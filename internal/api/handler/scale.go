@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"recipe-generator/internal/api/model"
+)
+
+// Scale returns an HTTP handler function that scales a recipe by exactly one of servings, a quantity of its
+// yield (such as 36 cookies for a recipe that makes 24), or different pans (such as an 8x8 pan or two 9-inch
+// rounds for a 9x13 recipe). Scaling by pan works out the factor from the pans' areas or volumes and suggests
+// a bake time range for the new pans. The scaled recipe is returned as a preview and not saved.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes recipe scaling requests
+func (rh *RecipeHandler) Scale() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipe, err := rh.pathRecipe(r)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving recipe", err)
+			return
+		}
+
+		var request model.ScaleRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := request.Validate(); err != nil {
+			writeModelError(w, rh.Config, "Scale request validation failed", err)
+			return
+		}
+
+		scaled, err := rh.ScalingService.Scale(recipe, request)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error scaling recipe", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, scaled)
+	}
+}
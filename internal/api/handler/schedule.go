@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// ScheduleHandler manages HTTP requests for planning cooking schedules.
+type ScheduleHandler struct {
+	// RecipeRepository handles database operations for recipes
+	RecipeRepository *repository.RecipeRepository
+	// ComponentService loads the recipes used as components of the scheduled recipes
+	ComponentService *service.ComponentService
+	// SchedulingService plans the timeline
+	SchedulingService *service.SchedulingService
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewScheduleHandler creates a new ScheduleHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *ScheduleHandler: A new schedule handler instance
+func NewScheduleHandler(pool *pgxpool.Pool, config *config.Config) *ScheduleHandler {
+	return &ScheduleHandler{
+		RecipeRepository:  repository.NewRecipeRepository(pool),
+		ComponentService:  service.NewComponentService(),
+		SchedulingService: service.NewSchedulingService(),
+		Config:            config,
+	}
+}
+
+// Plan returns an HTTP handler function that plans a combined timeline for cooking several recipes so they
+// are all ready at the serve time, such as a roast, a side and a dessert for one dinner. Every step gets a
+// start time, worked back from the serve time through the steps' dependencies, with no more ovens, burners or
+// cooks in use at once than the kitchen has; by default one oven, four burners and one cook. Recipes used as
+// components are scheduled to finish before the recipes that use them.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes schedule requests
+func (sh *ScheduleHandler) Plan() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request model.ScheduleRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := request.Validate(); err != nil {
+			writeModelError(w, sh.Config, "Schedule request validation failed", err)
+			return
+		}
+
+		loaded, err := sh.RecipeRepository.GetByIds(r.Context(), request.RecipeIds)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving recipes", err)
+			return
+		}
+
+		// requested recipes in the order given, each once.
+		recipes := make([]*model.Recipe, 0, len(request.RecipeIds))
+		seen := make(map[int]bool)
+		for _, recipeID := range request.RecipeIds {
+			recipe, ok := loaded[recipeID]
+			if !ok {
+				writeModelError(w, sh.Config, "Error retrieving recipes", model.ErrNotFound("recipe"))
+				return
+			}
+			if !seen[recipeID] {
+				seen[recipeID] = true
+				recipes = append(recipes, recipe)
+			}
+		}
+
+		components, err := sh.ComponentService.LoadComponents(r.Context(), recipes, sh.RecipeRepository.GetByIds)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving component recipes", err)
+			return
+		}
+
+		schedule, err := sh.SchedulingService.Plan(recipes, components, request)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error planning schedule", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, schedule)
+	}
+}
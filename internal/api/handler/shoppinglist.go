@@ -0,0 +1,647 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// Output formats a shopping list can be returned in, chosen with the format query parameter.
+const (
+	shoppingListFormatJSON = "json"
+	shoppingListFormatText = "text"
+	shoppingListFormatCSV  = "csv"
+)
+
+// ShoppingListHandler manages HTTP requests related to shopping lists.
+type ShoppingListHandler struct {
+	// IngredientsRepository handles database operations for ingredients
+	IngredientsRepository *repository.IngredientsRepository
+	// MealPlanRepository handles database operations for meal plan entries
+	MealPlanRepository *repository.MealPlanRepository
+	// HouseholdRepository handles database operations for households
+	HouseholdRepository *repository.HouseholdRepository
+	// ShoppingListRepository handles database operations for saved shopping lists
+	ShoppingListRepository *repository.ShoppingListRepository
+	// PantryRepository handles database operations for pantry items
+	PantryRepository *repository.PantryRepository
+	// ShoppingListService merges ingredients into a shopping list
+	ShoppingListService *service.ShoppingListService
+	// PantryService takes what is already in the pantry off a shopping list
+	PantryService *service.PantryService
+	// ComponentService replaces recipes used as ingredients with their own ingredients
+	ComponentService *service.ComponentService
+	// EventBroker streams changes to saved shopping lists to the clients watching them
+	EventBroker *service.EventBroker
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewShoppingListHandler creates a new ShoppingListHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//   - broker: Event broker shared by every handler that streams events
+//
+// Returns:
+//   - *ShoppingListHandler: A new shopping list handler instance
+func NewShoppingListHandler(pool *pgxpool.Pool, config *config.Config, broker *service.EventBroker) *ShoppingListHandler {
+	return &ShoppingListHandler{
+		IngredientsRepository:  repository.NewIngredientsRepository(pool),
+		MealPlanRepository:     repository.NewMealPlanRepository(pool),
+		HouseholdRepository:    repository.NewHouseholdRepository(pool),
+		ShoppingListRepository: repository.NewShoppingListRepository(pool),
+		PantryRepository:       repository.NewPantryRepository(pool),
+		ShoppingListService:    service.NewShoppingListService(),
+		PantryService:          service.NewPantryService(),
+		ComponentService:       service.NewComponentService(),
+		EventBroker:            broker,
+		Config:                 config,
+	}
+}
+
+// saveShoppingListRequest is the request body for saving a generated shopping list.
+type saveShoppingListRequest struct {
+	ListName string `json:"listName"`
+	model.ShoppingListRequest
+}
+
+// shoppingListItemRequest is the request body for adding an item to a saved shopping list by hand.
+type shoppingListItemRequest struct {
+	IngredientName string  `json:"ingredientName"`
+	Amount         float64 `json:"amount"`
+	Unit           string  `json:"unit"`
+}
+
+// checkedRequest is the request body for checking an item off a saved shopping list.
+// Version is optional; when given, the change fails with a conflict if the item changed after that version.
+type checkedRequest struct {
+	Checked bool `json:"checked"`
+	Version int  `json:"version"`
+}
+
+// Generate returns an HTTP handler function that builds a consolidated shopping list.
+// The request body lists recipes and servings, or gives a startDate and endDate to shop for
+// everything in the meal plan between them (pass ?householdId= for a household's plan).
+// With usePantry set, what is already in the pantry is left off and reported in the pantry field.
+// The format query parameter selects json (the default), text or csv output.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes shopping list requests
+func (sh *ShoppingListHandler) Generate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = shoppingListFormatJSON
+		}
+		if format != shoppingListFormatJSON && format != shoppingListFormatText && format != shoppingListFormatCSV {
+			writeModelError(w, sh.Config, "Invalid format", model.ErrInvalidField("format"))
+			return
+		}
+
+		var request model.ShoppingListRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := request.Validate(); err != nil {
+			writeModelError(w, sh.Config, "Shopping list validation failed", err)
+			return
+		}
+
+		list, err := sh.build(r, request)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error building shopping list", err)
+			return
+		}
+
+		switch format {
+		case shoppingListFormatText:
+			writeShoppingListText(w, list)
+		case shoppingListFormatCSV:
+			writeShoppingListCSV(w, list)
+		default:
+			writeJSON(w, http.StatusOK, list)
+		}
+	}
+}
+
+// Save returns an HTTP handler function that builds a shopping list like Generate and saves it,
+// so it can be checked off from several devices. Pass ?householdId= to save it to a household.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes shopping list save requests
+func (sh *ShoppingListHandler) Save() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, sh.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error resolving household", err)
+			return
+		}
+
+		var request saveShoppingListRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		saved := model.NewSavedShoppingList(request.ListName, scope, middleware.UserID(r.Context()))
+
+		if err := saved.Validate(); err != nil {
+			writeModelError(w, sh.Config, "Shopping list validation failed", err)
+			return
+		}
+		if err := request.ShoppingListRequest.Validate(); err != nil {
+			writeModelError(w, sh.Config, "Shopping list validation failed", err)
+			return
+		}
+
+		list, err := sh.build(r, request.ShoppingListRequest)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error building shopping list", err)
+			return
+		}
+
+		saved.Items = []model.ShoppingListItem{}
+		for _, aisle := range list.Aisles {
+			saved.Items = append(saved.Items, aisle.Items...)
+		}
+
+		created, err := sh.ShoppingListRepository.Insert(r.Context(), saved)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error saving shopping list", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, created)
+	}
+}
+
+// List returns an HTTP handler function that lists the current user's saved shopping lists,
+// or a household's with ?householdId=, newest first and without their items.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes shopping list list requests
+func (sh *ShoppingListHandler) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, sh.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error resolving household", err)
+			return
+		}
+
+		lists, err := sh.ShoppingListRepository.ListForScope(r.Context(), scope)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving shopping lists", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, lists)
+	}
+}
+
+// Get returns an HTTP handler function that returns a saved shopping list with its items.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes shopping list retrieval requests
+func (sh *ShoppingListHandler) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := sh.getAccessibleList(r)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving shopping list", err)
+			return
+		}
+
+		list.Items, err = sh.ShoppingListRepository.GetItems(r.Context(), list.ID)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving shopping list items", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, list)
+	}
+}
+
+// Delete returns an HTTP handler function that deletes a saved shopping list.
+// Clients watching the list receive a list_deleted event and their streams end.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes shopping list deletion requests
+func (sh *ShoppingListHandler) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := sh.getAccessibleList(r)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving shopping list", err)
+			return
+		}
+
+		if err := sh.ShoppingListRepository.Delete(r.Context(), list.ID); err != nil {
+			writeModelError(w, sh.Config, "Error deleting shopping list", err)
+			return
+		}
+
+		// the list's stored events are gone with it, so this one can't be replayed and has no ID.
+		sh.publish(&model.ShoppingListEvent{
+			ShoppingListId: list.ID,
+			EventType:      model.ShoppingListEventListDeleted,
+			CreatedBy:      middleware.UserID(r.Context()),
+			CreatedDate:    time.Now(),
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// AddItem returns an HTTP handler function that adds an item to a saved shopping list by hand,
+// such as something that isn't in any recipe.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes add item requests
+func (sh *ShoppingListHandler) AddItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := sh.getAccessibleList(r)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving shopping list", err)
+			return
+		}
+
+		var request shoppingListItemRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		name := strings.TrimSpace(request.IngredientName)
+		if name == "" {
+			writeModelError(w, sh.Config, "Shopping list item validation failed", model.ErrMissingRequiredField("ingredientName"))
+			return
+		}
+		if request.Amount < 0 {
+			writeModelError(w, sh.Config, "Shopping list item validation failed", model.ErrInvalidField("amount"))
+			return
+		}
+
+		item := &model.ShoppingListItem{
+			IngredientName: name,
+			Amount:         request.Amount,
+			Unit:           strings.TrimSpace(request.Unit),
+			Aisle:          service.AisleFor(service.CanonicalIngredientName(name)),
+			Recipes:        []model.ShoppingListSource{},
+			Manual:         true,
+		}
+
+		event, err := sh.ShoppingListRepository.AddItem(r.Context(), list.ID, item, middleware.UserID(r.Context()))
+		if err != nil {
+			writeModelError(w, sh.Config, "Error adding shopping list item", err)
+			return
+		}
+
+		sh.publish(event)
+
+		writeJSON(w, http.StatusCreated, event.Item)
+	}
+}
+
+// SetChecked returns an HTTP handler function that checks an item off a saved shopping list or puts it back.
+// When several people check off the same item at once the first change wins; the others get the item as it
+// now is. With a version in the body, a change to an item that has moved on since that version is refused
+// with 409 Conflict.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes check-off requests
+func (sh *ShoppingListHandler) SetChecked() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := sh.getAccessibleList(r)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving shopping list", err)
+			return
+		}
+
+		itemID, err := pathID(r, "itemId")
+		if err != nil {
+			writeModelError(w, sh.Config, "Invalid shopping list item ID", err)
+			return
+		}
+
+		var request checkedRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		item, event, err := sh.ShoppingListRepository.SetChecked(
+			r.Context(), list.ID, itemID, request.Checked, request.Version, middleware.UserID(r.Context()),
+		)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error updating shopping list item", err)
+			return
+		}
+
+		if event != nil {
+			sh.publish(event)
+		}
+
+		writeJSON(w, http.StatusOK, item)
+	}
+}
+
+// RemoveItem returns an HTTP handler function that removes an item from a saved shopping list.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes remove item requests
+func (sh *ShoppingListHandler) RemoveItem() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := sh.getAccessibleList(r)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving shopping list", err)
+			return
+		}
+
+		itemID, err := pathID(r, "itemId")
+		if err != nil {
+			writeModelError(w, sh.Config, "Invalid shopping list item ID", err)
+			return
+		}
+
+		event, err := sh.ShoppingListRepository.RemoveItem(r.Context(), list.ID, itemID, middleware.UserID(r.Context()))
+		if err != nil {
+			writeModelError(w, sh.Config, "Error removing shopping list item", err)
+			return
+		}
+
+		sh.publish(event)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Events returns an HTTP handler function that streams changes to a saved shopping list as server-sent events.
+// A client that reconnects with the Last-Event-ID header, or ?lastEventId=, first receives every change it missed.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes event stream requests
+func (sh *ShoppingListHandler) Events() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := sh.getAccessibleList(r)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving shopping list", err)
+			return
+		}
+
+		lastID, err := lastEventID(r)
+		if err != nil {
+			writeModelError(w, sh.Config, "Invalid last event ID", err)
+			return
+		}
+
+		// subscribe before loading the replay so nothing published in between is missed.
+		events, unsubscribe := sh.EventBroker.Subscribe(shoppingListTopic(list.ID))
+		defer unsubscribe()
+
+		replay := []service.Event{}
+		if lastID > 0 {
+			stored, err := sh.ShoppingListRepository.GetEventsSince(r.Context(), list.ID, lastID)
+			if err != nil {
+				writeModelError(w, sh.Config, "Error retrieving shopping list events", err)
+				return
+			}
+			for i := range stored {
+				replay = append(replay, shoppingListEvent(&stored[i]))
+			}
+		}
+
+		streamEvents(w, r, replay, events, model.ShoppingListEventListDeleted)
+	}
+}
+
+// private functions
+
+// build builds the shopping list for a request from its recipes or its meal plan date range.
+func (sh *ShoppingListHandler) build(r *http.Request, request model.ShoppingListRequest) (model.ShoppingList, error) {
+	recipes := request.Recipes
+	if request.UsesMealPlan() {
+		var err error
+		recipes, err = sh.mealPlanRecipes(r, request.StartDate, request.EndDate)
+		if err != nil {
+			return model.ShoppingList{}, err
+		}
+	}
+
+	portions, err := sh.portions(r.Context(), recipes)
+	if err != nil {
+		return model.ShoppingList{}, err
+	}
+
+	list := sh.ShoppingListService.Build(portions)
+
+	if request.UsePantry {
+		scope, err := mealPlanScope(r, sh.HouseholdRepository)
+		if err != nil {
+			return model.ShoppingList{}, err
+		}
+
+		pantry, err := sh.PantryRepository.ListForScope(r.Context(), scope)
+		if err != nil {
+			return model.ShoppingList{}, err
+		}
+
+		list = sh.PantryService.SubtractPantry(list, pantry)
+	}
+
+	return list, nil
+}
+
+// getAccessibleList retrieves the saved shopping list in the {id} path wildcard and checks that the
+// current user owns it or belongs to its household.
+func (sh *ShoppingListHandler) getAccessibleList(r *http.Request) (*model.SavedShoppingList, error) {
+	listID, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := sh.ShoppingListRepository.Get(r.Context(), listID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeScope(r.Context(), sh.HouseholdRepository, list.Scope()); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// publish sends a shopping list event to the clients watching the list.
+func (sh *ShoppingListHandler) publish(event *model.ShoppingListEvent) {
+	sh.EventBroker.Publish(shoppingListTopic(event.ShoppingListId), shoppingListEvent(event))
+}
+
+// shoppingListTopic returns the event broker topic of a saved shopping list.
+func shoppingListTopic(listID int) string {
+	return fmt.Sprintf("shopping-list:%d", listID)
+}
+
+// shoppingListEvent converts a stored shopping list event into a broker event.
+func shoppingListEvent(event *model.ShoppingListEvent) service.Event {
+	return service.Event{ID: event.ID, Type: event.EventType, Data: event}
+}
+
+// mealPlanRecipes returns the recipes planned in the request's meal plan between two dates,
+// one per entry so a recipe planned twice is shopped for twice.
+func (sh *ShoppingListHandler) mealPlanRecipes(r *http.Request, start model.Date, end model.Date) ([]model.ShoppingListRecipe, error) {
+	if daysBetweenDates(start, end) >= maxMealPlanRangeDays {
+		return nil, model.ErrInvalidField("endDate")
+	}
+
+	scope, err := mealPlanScope(r, sh.HouseholdRepository)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := sh.MealPlanRepository.GetRange(r.Context(), scope, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := make([]model.ShoppingListRecipe, 0, len(entries))
+	for _, entry := range entries {
+		recipes = append(recipes, model.ShoppingListRecipe{RecipeId: entry.RecipeId, Servings: entry.Servings})
+	}
+
+	return recipes, nil
+}
+
+// portions loads the ingredients of every recipe in a single query and pairs each recipe with its servings.
+// Recipes used as ingredients, such as a pizza's dough, are replaced with their own ingredients, scaled to the
+// amounts used, a level of components per query.
+// Returns model.ErrNotFound if one of the recipes does not exist.
+func (sh *ShoppingListHandler) portions(ctx context.Context, recipes []model.ShoppingListRecipe) ([]service.RecipePortion, error) {
+	if len(recipes) == 0 {
+		return []service.RecipePortion{}, nil
+	}
+
+	recipeIDs := make([]int, 0, len(recipes))
+	for _, recipe := range recipes {
+		recipeIDs = append(recipeIDs, recipe.RecipeId)
+	}
+
+	loaded, err := sh.IngredientsRepository.GetRecipesWithIngredients(ctx, recipeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*model.Recipe, 0, len(loaded))
+	for _, recipe := range loaded {
+		list = append(list, recipe)
+	}
+
+	components, err := sh.ComponentService.LoadComponents(ctx, list, sh.IngredientsRepository.GetRecipesWithIngredients)
+	if err != nil {
+		return nil, err
+	}
+
+	flattened := make(map[int]*model.Recipe, len(loaded))
+	for recipeID, recipe := range loaded {
+		plain := *recipe
+		plain.Ingredients = sh.ComponentService.Flatten(recipe, components)
+		flattened[recipeID] = &plain
+	}
+
+	portions := make([]service.RecipePortion, 0, len(recipes))
+	for _, recipe := range recipes {
+		loadedRecipe, ok := flattened[recipe.RecipeId]
+		if !ok {
+			return nil, model.ErrNotFound(fmt.Sprintf("recipe %d", recipe.RecipeId))
+		}
+		portions = append(portions, service.RecipePortion{Recipe: loadedRecipe, Servings: recipe.Servings})
+	}
+
+	return portions, nil
+}
+
+// formatShoppingListAmount formats an amount and unit for display, e.g. "1.25 cup" or "3".
+func formatShoppingListAmount(item model.ShoppingListItem) string {
+	amount := strconv.FormatFloat(item.Amount, 'f', -1, 64)
+	if item.Unit == "" {
+		return amount
+	}
+	return amount + " " + item.Unit
+}
+
+// shoppingListRecipeNames returns the names of the recipes that need an item, separated by sep.
+func shoppingListRecipeNames(item model.ShoppingListItem, sep string) string {
+	names := make([]string, 0, len(item.Recipes))
+	for _, recipe := range item.Recipes {
+		names = append(names, recipe.RecipeName)
+	}
+	return strings.Join(names, sep)
+}
+
+// writeShoppingListText writes a shopping list as plain text, one heading per aisle.
+func writeShoppingListText(w http.ResponseWriter, list model.ShoppingList) {
+	var text strings.Builder
+
+	for i, aisle := range list.Aisles {
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		text.WriteString(aisle.Aisle + "\n")
+
+		for _, item := range aisle.Items {
+			fmt.Fprintf(&text, "  - %s %s (%s)\n",
+				formatShoppingListAmount(item), item.IngredientName, shoppingListRecipeNames(item, ", "))
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write([]byte(text.String())); err != nil {
+		log.Printf("Error writing shopping list: %v", err)
+	}
+}
+
+// writeShoppingListCSV writes a shopping list as CSV with a header row.
+func writeShoppingListCSV(w http.ResponseWriter, list model.ShoppingList) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="shopping-list.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	records := [][]string{{"aisle", "ingredient", "amount", "unit", "recipes"}}
+
+	for _, aisle := range list.Aisles {
+		for _, item := range aisle.Items {
+			records = append(records, []string{
+				aisle.Aisle,
+				item.IngredientName,
+				strconv.FormatFloat(item.Amount, 'f', -1, 64),
+				item.Unit,
+				shoppingListRecipeNames(item, "; "),
+			})
+		}
+	}
+
+	if err := writer.WriteAll(records); err != nil {
+		log.Printf("Error writing shopping list: %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/service"
+)
+
+// sseHeartbeatInterval is how often an idle event stream sends a comment so proxies don't close it.
+const sseHeartbeatInterval = 25 * time.Second
+
+// sseRetryMilliseconds is how long clients wait before reconnecting to a dropped event stream.
+const sseRetryMilliseconds = 3000
+
+// lastEventID reads the ID of the last event a reconnecting client saw, from the Last-Event-ID header
+// browsers send automatically, or the lastEventId query parameter. It returns 0 for a new client.
+//
+// Parameters:
+//   - r: The HTTP request
+//
+// Returns:
+//   - int64: The last event ID, or 0
+//   - error: An ErrInvalidField error if the ID is not an integer
+func lastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, model.ErrInvalidField("lastEventId")
+	}
+	return id, nil
+}
+
+// streamEvents writes server-sent events until the client disconnects or the subscription ends.
+// The replayed events are sent first; live events the client has already seen through the replay are skipped,
+// so callers should subscribe before loading the replay to avoid missing events in between.
+//
+// Parameters:
+//   - w: The HTTP response writer
+//   - r: The HTTP request
+//   - replay: Stored events the client missed, oldest first
+//   - events: The live subscription
+//   - finalTypes: Event types after which the stream ends, such as a deleted list
+func streamEvents(w http.ResponseWriter, r *http.Request, replay []service.Event, events <-chan service.Event, finalTypes ...string) {
+	controller := http.NewResponseController(w)
+
+	// the server's write timeout is meant for ordinary requests, not streams that stay open.
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Error clearing write deadline for event stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetryMilliseconds); err != nil {
+		return
+	}
+
+	var lastID int64
+
+	send := func(event service.Event) bool {
+		if err := writeSSE(w, event); err != nil {
+			log.Printf("Error writing event stream: %v", err)
+			return false
+		}
+		if event.ID > lastID {
+			lastID = event.ID
+		}
+		for _, finalType := range finalTypes {
+			if event.Type == finalType {
+				controller.Flush()
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, event := range replay {
+		if !send(event) {
+			return
+		}
+	}
+	if err := controller.Flush(); err != nil {
+		log.Printf("Error flushing event stream: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// dropped for falling behind. the client reconnects and replays what it missed.
+				return
+			}
+			if event.ID != 0 && event.ID <= lastID {
+				continue
+			}
+			if !send(event) {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSSE writes one server-sent event with its data encoded as JSON.
+func writeSSE(w http.ResponseWriter, event service.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+
+	if event.ID != 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return err
+}
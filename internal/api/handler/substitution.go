@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/service"
+)
+
+// Substitutions returns an HTTP handler function that proposes ingredient substitutes for a recipe, with
+// amounts scaled to the recipe and notes on how each changes the dish. Repeat ?missing= to name missing
+// ingredients and ?avoid= to name allergens (such as dairy or gluten) or ingredients to avoid; pass
+// ?usePantry=true to treat everything the pantry doesn't cover as missing (?householdId= uses a household's
+// pantry). Without any of these, every ingredient with a known substitute is listed.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes substitution requests
+func (rh *RecipeHandler) Substitutions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipe, err := rh.pathRecipe(r)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving recipe", err)
+			return
+		}
+
+		filter := service.SubstitutionFilter{
+			Missing: r.URL.Query()["missing"],
+			Avoid:   r.URL.Query()["avoid"],
+		}
+
+		if r.URL.Query().Get("usePantry") == "true" {
+			scope, err := mealPlanScope(r, rh.HouseholdRepository)
+			if err != nil {
+				writeModelError(w, rh.Config, "Error resolving household", err)
+				return
+			}
+
+			pantry, err := rh.PantryRepository.ListForScope(r.Context(), scope)
+			if err != nil {
+				writeModelError(w, rh.Config, "Error retrieving pantry", err)
+				return
+			}
+
+			matches, _ := rh.PantryService.Match(service.RecipeRequirements(recipe, 0), pantry)
+			for _, match := range matches {
+				if !service.Covers(match) {
+					filter.Missing = append(filter.Missing, match.IngredientName)
+				}
+			}
+		}
+
+		writeJSON(w, http.StatusOK, rh.SubstitutionService.Propose(recipe, filter))
+	}
+}
+
+// Substitute returns an HTTP handler function that makes a copy of a recipe with the chosen substitutions,
+// using the option numbers from Substitutions. The copy is returned as a preview unless the request asks
+// to save it, in which case it is saved as a new recipe owned by the current user.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes substituted copy requests
+func (rh *RecipeHandler) Substitute() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recipe, err := rh.pathRecipe(r)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error retrieving recipe", err)
+			return
+		}
+
+		var request model.SubstitutionRequest
+		if err := decodeJSON(r, &request); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if err := request.Validate(); err != nil {
+			writeModelError(w, rh.Config, "Substitution request validation failed", err)
+			return
+		}
+
+		substituted, err := rh.SubstitutionService.Apply(recipe, request.Swaps)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error substituting ingredients", err)
+			return
+		}
+
+		substituted.RecipeName = strings.TrimSpace(request.RecipeName)
+		if substituted.RecipeName == "" {
+			substituted.RecipeName = fmt.Sprintf("%s (with substitutions)", recipe.RecipeName)
+		}
+
+		userID := middleware.UserID(r.Context())
+		now := time.Now()
+		substituted.CreatedBy = userID
+		substituted.CreatedDate = now
+		substituted.UpdatedBy = userID
+		substituted.UpdatedDate = now
+
+		if !request.Save {
+			writeJSON(w, http.StatusOK, substituted)
+			return
+		}
+
+		if err := substituted.Validate(); err != nil {
+			writeModelError(w, rh.Config, "Recipe validation failed", err)
+			return
+		}
+
+		saved, err := rh.saveRecipe(r.Context(), substituted)
+		if err != nil {
+			writeModelError(w, rh.Config, "Error saving substituted recipe", err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, saved)
+	}
+}
+
+// private functions
+
+// pathRecipe retrieves the recipe in the {id} path wildcard with its ingredients and procedure steps.
+func (rh *RecipeHandler) pathRecipe(r *http.Request) (*model.Recipe, error) {
+	recipeID, err := pathID(r, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	return rh.getRecipe(r.Context(), recipeID)
+}
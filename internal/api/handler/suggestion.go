@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/config"
+	"recipe-generator/internal/api/model"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
+)
+
+// defaultUseSoonDays is how many days ahead the use-soon suggestions look for expiring pantry items.
+const defaultUseSoonDays = 3
+
+// maxUseSoonDays is the furthest ahead the use-soon suggestions can look.
+const maxUseSoonDays = 30
+
+// defaultUseSoonLimit is how many recipes the use-soon suggestions return by default.
+const defaultUseSoonLimit = 10
+
+// SuggestionHandler manages HTTP requests for recipe suggestions.
+type SuggestionHandler struct {
+	// PantryRepository handles database operations for pantry items
+	PantryRepository *repository.PantryRepository
+	// HouseholdRepository handles database operations for households
+	HouseholdRepository *repository.HouseholdRepository
+	// RecipeRepository handles database operations for recipes
+	RecipeRepository *repository.RecipeRepository
+	// IngredientsRepository handles database operations for ingredients
+	IngredientsRepository *repository.IngredientsRepository
+	// SuggestionService ranks recipes against the pantry
+	SuggestionService *service.SuggestionService
+	// Config contains application configuration
+	Config *config.Config
+}
+
+// NewSuggestionHandler creates a new SuggestionHandler instance with the provided database connection pool and configuration.
+//
+// Parameters:
+//   - pool: PostgreSQL connection pool for database operations
+//   - config: Application configuration
+//
+// Returns:
+//   - *SuggestionHandler: A new suggestion handler instance
+func NewSuggestionHandler(pool *pgxpool.Pool, config *config.Config) *SuggestionHandler {
+	return &SuggestionHandler{
+		PantryRepository:      repository.NewPantryRepository(pool),
+		HouseholdRepository:   repository.NewHouseholdRepository(pool),
+		RecipeRepository:      repository.NewRecipeRepository(pool),
+		IngredientsRepository: repository.NewIngredientsRepository(pool),
+		SuggestionService:     service.NewSuggestionService(),
+		Config:                config,
+	}
+}
+
+// UseSoon returns an HTTP handler function that suggests recipes that use up pantry items expiring within
+// ?withinDays= days (3 by default). Recipes are ranked by how many expiring items they use, then how much of
+// them, then how much of the recipe the pantry covers. Repeating the tag query parameter, such as
+// ?tag=vegetarian, only suggests recipes with all of the given tags. The response also suggests two or three
+// recipes to cook together when they use more of the expiring stock than any one recipe.
+//
+// Returns:
+//   - http.HandlerFunc: A handler function that processes use-soon suggestion requests
+func (sh *SuggestionHandler) UseSoon() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, err := mealPlanScope(r, sh.HouseholdRepository)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error resolving household", err)
+			return
+		}
+
+		withinDays, err := queryInt(r, "withinDays", defaultUseSoonDays)
+		if err != nil || withinDays < 0 || withinDays > maxUseSoonDays {
+			writeModelError(w, sh.Config, "Invalid withinDays", model.ErrInvalidField("withinDays"))
+			return
+		}
+
+		limit, err := queryInt(r, "limit", defaultUseSoonLimit)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			writeModelError(w, sh.Config, "Invalid limit", model.ErrInvalidField("limit"))
+			return
+		}
+
+		pantry, err := sh.PantryRepository.ListForScope(r.Context(), scope)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving pantry", err)
+			return
+		}
+
+		expiring := service.ExpiringItems(pantry, model.Today(), withinDays)
+		if len(expiring) == 0 {
+			writeJSON(w, http.StatusOK, model.UseSoonSuggestions{Expiring: expiring, Recipes: []model.UseSoonSuggestion{}})
+			return
+		}
+
+		candidates, _, err := sh.RecipeRepository.List(r.Context(), repository.RecipeListOptions{
+			Tags:  r.URL.Query()["tag"],
+			Sort:  repository.RecipeSortRating,
+			Limit: maxCanCookCandidates,
+		})
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving recipes", err)
+			return
+		}
+
+		recipeIDs := make([]int, 0, len(candidates))
+		for _, candidate := range candidates {
+			recipeIDs = append(recipeIDs, candidate.ID)
+		}
+
+		recipes, err := sh.IngredientsRepository.GetRecipesWithIngredients(r.Context(), recipeIDs)
+		if err != nil {
+			writeModelError(w, sh.Config, "Error retrieving ingredients", err)
+			return
+		}
+
+		// keep the rating order so it breaks ties between equally good suggestions.
+		ordered := make([]*model.Recipe, 0, len(recipeIDs))
+		for _, recipeID := range recipeIDs {
+			if recipe, ok := recipes[recipeID]; ok && len(recipe.Ingredients) > 0 {
+				ordered = append(ordered, recipe)
+			}
+		}
+
+		writeJSON(w, http.StatusOK, sh.SuggestionService.UseSoon(ordered, pantry, expiring, limit))
+	}
+}
@@ -11,7 +11,7 @@ func CORS(mux http.Handler) http.Handler {
 		// configure CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-Id")
 		w.Header().Set("Access-Control-Max-Age", "86400") // super long age
 
 
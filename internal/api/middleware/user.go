@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// UserIDHeader is the request header used to identify the acting user.
+// There is no real authentication yet, so clients pass the user ID directly.
+const UserIDHeader = "X-User-Id"
+
+// DefaultUserID is the dummy user every request acts as when no user header is sent.
+// It matches the user created by internal/database/migration/insert_dummy_user.sql.
+const DefaultUserID = 1
+
+type userIDKey struct{}
+
+// User reads the acting user from the X-User-Id header and stores it in the request context.
+// Requests without the header, or with a header that is not a positive integer, act as the dummy user.
+func User(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := DefaultUserID
+
+		if header := r.Header.Get(UserIDHeader); header != "" {
+			if id, err := strconv.Atoi(header); err == nil && id > 0 {
+				userID = id
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), userIDKey{}, userID)
+		mux.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// UserID returns the acting user stored in the context by the User middleware.
+// It falls back to the dummy user when the middleware has not run.
+func UserID(ctx context.Context) int {
+	if userID, ok := ctx.Value(userIDKey{}).(int); ok {
+		return userID
+	}
+	return DefaultUserID
+}
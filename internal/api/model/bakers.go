@@ -0,0 +1,63 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+// BakersIngredient is an ingredient of a dough by weight and as a baker's percentage of the flour weight.
+type BakersIngredient struct {
+	IngredientName string  `json:"ingredientName"` // Name of the ingredient in the recipe
+	Amount         float64 `json:"amount"`         // Amount of the ingredient
+	Unit           string  `json:"unit"`           // Unit of the amount
+	Grams          float64 `json:"grams"`          // Weight of the amount in grams
+	Percent        float64 `json:"percent"`        // Weight as a percentage of the total flour weight
+	Flour          bool    `json:"flour"`          // Whether the ingredient counts toward the flour weight
+	Approximate    bool    `json:"approximate"`    // Whether the weight was worked out from a volume or a count
+}
+
+// UnweighedIngredient is an ingredient whose amount could not be converted to grams, such as a pinch of
+// something without a known density. It is left out of the percentages.
+type UnweighedIngredient struct {
+	IngredientName string  `json:"ingredientName"` // Name of the ingredient in the recipe
+	Amount         float64 `json:"amount"`         // Amount of the ingredient
+	Unit           string  `json:"unit"`           // Unit of the amount
+}
+
+// BakersPercentages is a dough formula with every ingredient relative to the total flour weight, which is 100%.
+type BakersPercentages struct {
+	RecipeId    int                   `json:"recipeId"`    // Foreign key to the recipe
+	RecipeName  string                `json:"recipeName"`  // Name of the recipe
+	FlourGrams  float64               `json:"flourGrams"`  // Total weight of the flour ingredients
+	DoughGrams  float64               `json:"doughGrams"`  // Total weight of the weighed ingredients
+	Hydration   float64               `json:"hydration"`   // Water in the dough as a percentage of all its flour
+	Ingredients []BakersIngredient    `json:"ingredients"` // The weighed ingredients
+	Unweighed   []UnweighedIngredient `json:"unweighed"`   // Ingredients that could not be weighed
+}
+
+// BakersFormulaRequest asks for the ingredient amounts of a dough of a given size from a recipe's baker's
+// percentages. Exactly one of FlourWeight and DoughWeight is set.
+type BakersFormulaRequest struct {
+	FlourWeight float64            `json:"flourWeight,omitempty"` // Total flour weight in grams
+	DoughWeight float64            `json:"doughWeight,omitempty"` // Total dough weight in grams
+	Percentages map[string]float64 `json:"percentages,omitempty"` // Changed percentages by ingredient name
+}
+
+// Validate checks if the BakersFormulaRequest instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (b *BakersFormulaRequest) Validate() error {
+	if b.FlourWeight == 0 && b.DoughWeight == 0 {
+		return ErrMissingRequiredField("flourWeight or doughWeight")
+	}
+	if b.FlourWeight != 0 && b.DoughWeight != 0 {
+		return ErrInvalidField("only one of flourWeight or doughWeight")
+	}
+	if b.FlourWeight < 0 {
+		return ErrInvalidField("flourWeight")
+	}
+	if b.DoughWeight < 0 {
+		return ErrInvalidField("doughWeight")
+	}
+	for _, percent := range b.Percentages {
+		if percent < 0 {
+			return ErrInvalidField("percentages")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"time"
+)
+
+// Collection permission levels. The owner can do everything, editors can change the
+// collection's details and recipes, and readers can only view it.
+const (
+	CollectionPermissionOwner = "owner"
+	CollectionPermissionEdit  = "edit"
+	CollectionPermissionRead  = "read"
+)
+
+// Collection represents a user's named, ordered group of recipes, such as "Weeknight dinners".
+type Collection struct {
+	ID             int                `json:"id"`                    // Unique identifier for the collection
+	OwnerID        int                `json:"ownerId"`               // User ID who owns the collection
+	CollectionName string             `json:"collectionName"`        // Name of the collection
+	Description    string             `json:"description,omitempty"` // Description of the collection
+	Permission     string             `json:"permission,omitempty"`  // The requesting user's access level
+	Recipes        []CollectionRecipe `json:"recipes,omitempty"`     // Recipes in the collection, in order
+	Shares         []CollectionShare  `json:"shares,omitempty"`      // Users the collection is shared with
+	CreatedBy      int                `json:"createdBy"`             // User ID who created this collection
+	CreatedDate    time.Time          `json:"createdDate"`           // Timestamp when the collection was created
+	UpdatedBy      int                `json:"updatedBy"`             // User ID who last updated this collection
+	UpdatedDate    time.Time          `json:"updatedDate"`           // Timestamp when the collection was last updated
+}
+
+// CollectionRecipe represents one recipe entry in a collection along with the owner's notes.
+type CollectionRecipe struct {
+	RecipeId int     `json:"recipeId"`         // Foreign key to the recipe
+	Position int     `json:"position"`         // Position of the recipe in the collection, starting at 1
+	Notes    string  `json:"notes,omitempty"`  // Personal notes about the recipe
+	Recipe   *Recipe `json:"recipe,omitempty"` // The hydrated recipe
+}
+
+// CollectionShare grants another user access to a collection.
+type CollectionShare struct {
+	UserId     int    `json:"userId"`     // User ID the collection is shared with
+	Permission string `json:"permission"` // Either CollectionPermissionRead or CollectionPermissionEdit
+}
+
+// NewCollection creates a new Collection instance with required fields.
+// It automatically sets the creation and update timestamps to the current time.
+func NewCollection(name string, ownerID int) *Collection {
+	now := time.Now()
+	return &Collection{
+		OwnerID:        ownerID,
+		CollectionName: name,
+		CreatedBy:      ownerID,
+		CreatedDate:    now,
+		UpdatedBy:      ownerID,
+		UpdatedDate:    now,
+	}
+}
+
+// Validate checks if the Collection instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (c *Collection) Validate() error {
+	if c.CollectionName == "" {
+		return ErrMissingRequiredField("collectionName")
+	}
+	if c.OwnerID == 0 {
+		return ErrMissingRequiredField("ownerId")
+	}
+	return nil
+}
+
+// Validate checks if the CollectionShare instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (s *CollectionShare) Validate() error {
+	if s.UserId == 0 {
+		return ErrMissingRequiredField("userId")
+	}
+	if s.Permission != CollectionPermissionRead && s.Permission != CollectionPermissionEdit {
+		return ErrInvalidField("permission")
+	}
+	return nil
+}
+
+// CanEditCollection reports whether the given permission level allows changing a collection's recipes.
+func CanEditCollection(permission string) bool {
+	return permission == CollectionPermissionOwner || permission == CollectionPermissionEdit
+}
@@ -0,0 +1,79 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"time"
+)
+
+// Layouts of the recipes in a cookbook.
+const (
+	CookbookLayoutSingle = "single" // One recipe to a page, starting on a new page
+	CookbookLayoutTwoUp  = "two-up" // Two recipes to a page, one above the other
+)
+
+// Statuses of a cookbook job.
+const (
+	CookbookStatusPending = "pending" // Waiting to be generated
+	CookbookStatusRunning = "running" // Being generated
+	CookbookStatusDone    = "done"    // Generated and ready to download
+	CookbookStatusFailed  = "failed"  // Couldn't be generated
+)
+
+// MaxCookbookRecipes is the most recipes a cookbook can have.
+const MaxCookbookRecipes = 500
+
+// CookbookRequest is the request body for generating a PDF cookbook from a collection or a tag filter.
+type CookbookRequest struct {
+	Title        string   `json:"title"`                  // Title on the title page; the collection's name when blank
+	CollectionId int      `json:"collectionId,omitempty"` // Collection whose recipes to include, in its order
+	Tags         []string `json:"tags,omitempty"`         // Include the recipes with every one of these tags, by name
+	Layout       string   `json:"layout,omitempty"`       // One of the CookbookLayout constants; single when blank
+}
+
+// Validate checks if the CookbookRequest instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (c *CookbookRequest) Validate() error {
+	if c.CollectionId == 0 && len(NormalizeTags(c.Tags)) == 0 {
+		return ErrMissingRequiredField("collectionId or tags")
+	}
+	if c.CollectionId != 0 && len(c.Tags) > 0 {
+		return ErrInvalidField("only one of collectionId or tags")
+	}
+	if c.CollectionId < 0 {
+		return ErrInvalidField("collectionId")
+	}
+	if c.Layout != "" && c.Layout != CookbookLayoutSingle && c.Layout != CookbookLayoutTwoUp {
+		return ErrInvalidField("layout")
+	}
+	if c.CollectionId == 0 && c.Title == "" {
+		return ErrMissingRequiredField("title")
+	}
+	return nil
+}
+
+// CookbookJob is a PDF cookbook being generated in the background, and the file once it is.
+type CookbookJob struct {
+	ID            int        `json:"id"`                      // Unique identifier for the job
+	UserId        int        `json:"userId"`                  // User who asked for the cookbook; only they can download it
+	Title         string     `json:"title"`                   // Title on the title page
+	CollectionId  int        `json:"collectionId,omitempty"`  // Collection the recipes come from, if any
+	Tags          []string   `json:"tags,omitempty"`          // Tags the recipes were filtered by, if any
+	Layout        string     `json:"layout"`                  // One of the CookbookLayout constants
+	Status        string     `json:"status"`                  // One of the CookbookStatus constants
+	RecipeCount   int        `json:"recipeCount,omitempty"`   // Number of recipes in the cookbook, once generated
+	PageCount     int        `json:"pageCount,omitempty"`     // Number of pages in the cookbook, once generated
+	Error         string     `json:"error,omitempty"`         // Why the cookbook couldn't be generated, if it failed
+	Filepath      string     `json:"-"`                       // Location of the PDF on disk, once generated
+	SizeBytes     int64      `json:"sizeBytes,omitempty"`     // Size of the PDF in bytes, once generated
+	DownloadUrl   string     `json:"downloadUrl,omitempty"`   // Where to download the PDF, once generated
+	CreatedDate   time.Time  `json:"createdDate"`             // Timestamp when the cookbook was asked for
+	CompletedDate *time.Time `json:"completedDate,omitempty"` // Timestamp when the job finished or failed
+}
+
+// Cookbook is what goes into a PDF cookbook, an EPUB e-book or a static site of recipes.
+type Cookbook struct {
+	Title   string         // Title on the title page
+	Layout  string         // One of the CookbookLayout constants; only PDFs are laid out
+	Recipes []*Recipe      // The recipes with their ingredients and procedures, in order
+	Photos  map[int][]byte // The primary photo of each recipe that has one, by recipe ID
+}
@@ -0,0 +1,212 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// States a cooking timer can be in.
+const (
+	TimerStatusRunning   = "running"
+	TimerStatusPaused    = "paused"
+	TimerStatusCancelled = "cancelled"
+	TimerStatusFinished  = "finished"
+)
+
+// Types of cooking session event streamed to the devices following a session.
+const (
+	CookingSessionEventSnapshot       = "session"         // The whole session, sent when a device connects
+	CookingSessionEventStepChanged    = "step_changed"    // The session moved to another step
+	CookingSessionEventTimerStarted   = "timer_started"   // A timer was started
+	CookingSessionEventTimerPaused    = "timer_paused"    // A timer was paused
+	CookingSessionEventTimerResumed   = "timer_resumed"   // A paused timer was started again
+	CookingSessionEventTimerCancelled = "timer_cancelled" // A timer was cancelled
+	CookingSessionEventTimerFinished  = "timer_finished"  // A timer went off
+	CookingSessionEventTick           = "tick"            // The running timers' remaining time, every second
+	CookingSessionEventEnded          = "session_ended"   // The session was ended
+	CookingSessionEventExpired        = "session_expired" // The session expired after being idle
+)
+
+// CookingTimer is a named countdown in a cooking session, such as "Simmer 20 minutes".
+type CookingTimer struct {
+	ID               int        `json:"id"`               // Unique identifier for the timer
+	CookingSessionId int        `json:"cookingSessionId"` // Foreign key to the session
+	TimerName        string     `json:"timerName"`        // Name of the timer
+	Step             int        `json:"step,omitempty"`   // Step of the procedure the timer is for, from 1
+	DurationSeconds  int        `json:"durationSeconds"`  // Length of the timer
+	Status           string     `json:"status"`           // One of the TimerStatus constants
+	RemainingSeconds int        `json:"remainingSeconds"` // Time left; for a running timer, as of when it was read
+	EndsAt           *time.Time `json:"endsAt,omitempty"` // When a running timer goes off
+	CreatedBy        int        `json:"createdBy"`        // User ID who started this timer
+	CreatedDate      time.Time  `json:"createdDate"`      // Timestamp when the timer was started
+	UpdatedBy        int        `json:"updatedBy"`        // User ID who last changed this timer
+	UpdatedDate      time.Time  `json:"updatedDate"`      // Timestamp when the timer was last changed
+}
+
+// NewCookingTimer creates a new running CookingTimer that goes off durationSeconds from now.
+// It automatically sets the creation and update timestamps to the current time.
+func NewCookingTimer(sessionID int, name string, step int, durationSeconds int, createdBy int) *CookingTimer {
+	now := time.Now()
+	endsAt := now.Add(time.Duration(durationSeconds) * time.Second)
+	return &CookingTimer{
+		CookingSessionId: sessionID,
+		TimerName:        name,
+		Step:             step,
+		DurationSeconds:  durationSeconds,
+		Status:           TimerStatusRunning,
+		RemainingSeconds: durationSeconds,
+		EndsAt:           &endsAt,
+		CreatedBy:        createdBy,
+		CreatedDate:      now,
+		UpdatedBy:        createdBy,
+		UpdatedDate:      now,
+	}
+}
+
+// Validate checks if the CookingTimer instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (c *CookingTimer) Validate() error {
+	if c.TimerName == "" {
+		return ErrMissingRequiredField("timerName")
+	}
+	if c.DurationSeconds <= 0 {
+		return ErrInvalidField("durationSeconds")
+	}
+	if c.Step < 0 {
+		return ErrInvalidField("step")
+	}
+	return nil
+}
+
+// Remaining returns the whole seconds left on the timer at a time, counting a part second as a second.
+func (c *CookingTimer) Remaining(now time.Time) int {
+	if c.Status != TimerStatusRunning || c.EndsAt == nil {
+		return c.RemainingSeconds
+	}
+	return int(math.Max(0, math.Ceil(c.EndsAt.Sub(now).Seconds())))
+}
+
+// Pause stops a running timer with the time it has left.
+// It returns ErrConflict if the timer isn't running.
+func (c *CookingTimer) Pause(now time.Time, userID int) error {
+	if c.Status != TimerStatusRunning {
+		return ErrConflict("only a running timer can be paused")
+	}
+	c.RemainingSeconds = c.Remaining(now)
+	c.Status = TimerStatusPaused
+	c.EndsAt = nil
+	c.UpdatedBy, c.UpdatedDate = userID, now
+	return nil
+}
+
+// Resume starts a paused timer again from the time it had left.
+// It returns ErrConflict if the timer isn't paused.
+func (c *CookingTimer) Resume(now time.Time, userID int) error {
+	if c.Status != TimerStatusPaused {
+		return ErrConflict("only a paused timer can be resumed")
+	}
+	endsAt := now.Add(time.Duration(c.RemainingSeconds) * time.Second)
+	c.Status = TimerStatusRunning
+	c.EndsAt = &endsAt
+	c.UpdatedBy, c.UpdatedDate = userID, now
+	return nil
+}
+
+// Cancel stops a running or paused timer for good.
+// It returns ErrConflict if the timer has already finished or been cancelled.
+func (c *CookingTimer) Cancel(now time.Time, userID int) error {
+	if c.Status != TimerStatusRunning && c.Status != TimerStatusPaused {
+		return ErrConflict("the timer has already " + c.Status)
+	}
+	c.RemainingSeconds = c.Remaining(now)
+	c.Status = TimerStatusCancelled
+	c.EndsAt = nil
+	c.UpdatedBy, c.UpdatedDate = userID, now
+	return nil
+}
+
+// CookingTimerTick is a running timer's remaining time, streamed every second.
+type CookingTimerTick struct {
+	TimerId          int    `json:"timerId"`          // Foreign key to the timer
+	TimerName        string `json:"timerName"`        // Name of the timer
+	RemainingSeconds int    `json:"remainingSeconds"` // Time left
+}
+
+// TimerSuggestion is a timer a procedure step calls for, such as 20 minutes for "Simmer for 20-25 minutes".
+type TimerSuggestion struct {
+	Step            int    `json:"step"`            // Step of the procedure, from 1
+	TimerName       string `json:"timerName"`       // Suggested name, from the step's text
+	DurationSeconds int    `json:"durationSeconds"` // Suggested length
+}
+
+// CookingSession is a recipe being cooked step by step, followed by one or more devices. Like a meal plan, it
+// belongs to either a user or a household.
+type CookingSession struct {
+	ID              int               `json:"id"`                    // Unique identifier for the session
+	RecipeId        int               `json:"recipeId"`              // Foreign key to the recipe being cooked
+	RecipeName      string            `json:"recipeName,omitempty"`  // Name of the recipe
+	UserId          int               `json:"userId,omitempty"`      // Owner of a personal session
+	HouseholdId     int               `json:"householdId,omitempty"` // Household of a shared session
+	CurrentStep     int               `json:"currentStep"`           // Step of the procedure being cooked, from 1
+	StepCount       int               `json:"stepCount"`             // Number of steps in the procedure
+	Instruction     string            `json:"instruction,omitempty"` // Text of the current step
+	SuggestedTimers []TimerSuggestion `json:"suggestedTimers"`       // Timers the current step calls for
+	Timers          []CookingTimer    `json:"timers"`                // Timers started in the session, oldest first
+	ExpiresAt       time.Time         `json:"expiresAt"`             // When the session expires if nothing more happens
+	CreatedBy       int               `json:"createdBy"`             // User ID who started this session
+	CreatedDate     time.Time         `json:"createdDate"`           // Timestamp when the session was started
+	UpdatedBy       int               `json:"updatedBy"`             // User ID who last changed this session
+	UpdatedDate     time.Time         `json:"updatedDate"`           // Timestamp of the last activity in the session
+}
+
+// NewCookingSession creates a new CookingSession at the first step of a recipe, in the given scope.
+// It automatically sets the creation and update timestamps to the current time.
+func NewCookingSession(recipeID int, scope MealPlanScope, createdBy int) *CookingSession {
+	now := time.Now()
+	return &CookingSession{
+		RecipeId:    recipeID,
+		UserId:      scope.UserId,
+		HouseholdId: scope.HouseholdId,
+		CurrentStep: 1,
+		CreatedBy:   createdBy,
+		CreatedDate: now,
+		UpdatedBy:   createdBy,
+		UpdatedDate: now,
+	}
+}
+
+// Scope returns the user or household the session belongs to.
+func (c *CookingSession) Scope() MealPlanScope {
+	return MealPlanScope{UserId: c.UserId, HouseholdId: c.HouseholdId}
+}
+
+// Validate checks if the CookingSession instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (c *CookingSession) Validate() error {
+	if c.RecipeId == 0 {
+		return ErrMissingRequiredField("recipeId")
+	}
+	if (c.UserId == 0) == (c.HouseholdId == 0) {
+		return ErrInvalidField("householdId")
+	}
+	if c.CurrentStep < 1 {
+		return ErrInvalidField("currentStep")
+	}
+	if c.CreatedBy == 0 {
+		return ErrMissingRequiredField("createdBy")
+	}
+	return nil
+}
+
+// LastActive returns when the session was last in use: its last change, or later if a timer is still running,
+// since a session is in use while a timer counts down.
+func (c *CookingSession) LastActive() time.Time {
+	active := c.UpdatedDate
+	for _, timer := range c.Timers {
+		if timer.Status == TimerStatusRunning && timer.EndsAt != nil && timer.EndsAt.After(active) {
+			active = *timer.EndsAt
+		}
+	}
+	return active
+}
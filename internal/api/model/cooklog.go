@@ -0,0 +1,86 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"time"
+)
+
+// CookLog represents one "I cooked this" event: when a user made a recipe and how it went.
+type CookLog struct {
+	ID           int            `json:"id"`                     // Unique identifier for the cook log entry
+	RecipeId     int            `json:"recipeId"`               // Foreign key to the recipe that was cooked
+	RecipeName   string         `json:"recipeName,omitempty"`   // Name of the recipe that was cooked
+	UserId       int            `json:"userId"`                 // User ID who cooked the recipe
+	CookedDate   Date           `json:"cookedDate"`             // Date the recipe was cooked
+	ServingsMade int            `json:"servingsMade,omitempty"` // Number of servings that were made
+	Tweaks       string         `json:"tweaks,omitempty"`       // Changes made to the recipe this time
+	Rating       int            `json:"rating,omitempty"`       // Optional star rating from 1 to 5 for this attempt
+	Photos       []CookLogPhoto `json:"photos,omitempty"`       // Photos taken of the dish
+	Pantry       []PantryMatch  `json:"pantry,omitempty"`       // What was taken from the pantry when the entry was created
+	CreatedBy    int            `json:"createdBy"`              // User ID who created this entry
+	CreatedDate  time.Time      `json:"createdDate"`            // Timestamp when the entry was created
+	UpdatedBy    int            `json:"updatedBy"`              // User ID who last updated this entry
+	UpdatedDate  time.Time      `json:"updatedDate"`            // Timestamp when the entry was last updated
+}
+
+// CookLogPhoto represents a photo attached to a cook log entry.
+type CookLogPhoto struct {
+	ID           int    `json:"id"`           // Unique identifier for the photo
+	CookLogId    int    `json:"cookLogId"`    // Foreign key to the cook log entry
+	Filepath     string `json:"-"`            // Location of the photo on disk
+	OriginalName string `json:"originalName"` // File name the photo was uploaded with
+	SizeBytes    int64  `json:"sizeBytes"`    // Size of the photo in bytes
+}
+
+// CookStat summarises how often and how recently a user has cooked a recipe.
+type CookStat struct {
+	RecipeId       int    `json:"recipeId"`       // Foreign key to the recipe
+	RecipeName     string `json:"recipeName"`     // Name of the recipe
+	TimesCooked    int    `json:"timesCooked"`    // Number of times the recipe was cooked in the period
+	LastCookedDate Date   `json:"lastCookedDate"` // Most recent date the recipe was cooked
+}
+
+// CookLogStats groups the cook log statistics returned for a user.
+type CookLogStats struct {
+	MostCookedThisYear []CookStat `json:"mostCookedThisYear"` // Recipes cooked most often since January 1st
+	NotCookedRecently  []CookStat `json:"notCookedRecently"`  // Recipes cooked before, but not within the recent window
+}
+
+// NewCookLog creates a new CookLog instance with required fields.
+// It automatically sets the creation and update timestamps to the current time.
+func NewCookLog(recipeId int, userId int, cookedDate Date) *CookLog {
+	now := time.Now()
+	return &CookLog{
+		RecipeId:    recipeId,
+		UserId:      userId,
+		CookedDate:  cookedDate,
+		CreatedBy:   userId,
+		CreatedDate: now,
+		UpdatedBy:   userId,
+		UpdatedDate: now,
+	}
+}
+
+// Validate checks if the CookLog instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (c *CookLog) Validate() error {
+	if c.RecipeId == 0 {
+		return ErrMissingRequiredField("recipeId")
+	}
+	if c.UserId == 0 {
+		return ErrMissingRequiredField("userId")
+	}
+	if c.CookedDate.IsZero() {
+		return ErrMissingRequiredField("cookedDate")
+	}
+	if c.CookedDate.After(Today().Time) {
+		return ErrInvalidField("cookedDate")
+	}
+	if c.ServingsMade < 0 {
+		return ErrInvalidField("servingsMade")
+	}
+	if c.Rating != 0 && (c.Rating < MinRating || c.Rating > MaxRating) {
+		return ErrInvalidField("rating")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DateLayout is the format calendar dates use in requests and responses, e.g. "2025-03-14".
+const DateLayout = "2006-01-02"
+
+// Date is a calendar date without a time of day. It is encoded in JSON as "YYYY-MM-DD"
+// rather than the full RFC 3339 timestamp time.Time uses.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the Date for the given year, month and day.
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses a date in DateLayout.
+func ParseDate(value string) (Date, error) {
+	parsed, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return Date{}, err
+	}
+	return Date{parsed}, nil
+}
+
+// Today returns the current date.
+func Today() Date {
+	now := time.Now()
+	return NewDate(now.Year(), now.Month(), now.Day())
+}
+
+// AddDays returns the date the given number of days after d.
+func (d Date) AddDays(days int) Date {
+	return Date{d.Time.AddDate(0, 0, days)}
+}
+
+// String returns the date in DateLayout.
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+// MarshalJSON implements json.Marshaler, encoding the date as "YYYY-MM-DD".
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding a "YYYY-MM-DD" string.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	if value == "" {
+		*d = Date{}
+		return nil
+	}
+
+	parsed, err := ParseDate(value)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,57 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+// DuplicateMatch is an existing recipe that is likely a duplicate of another.
+type DuplicateMatch struct {
+	RecipeId             int     `json:"recipeId"`             // Foreign key to the existing recipe
+	RecipeName           string  `json:"recipeName"`           // Name of the existing recipe
+	Score                float64 `json:"score"`                // Combined similarity, from 0 to 1
+	NameSimilarity       float64 `json:"nameSimilarity"`       // Trigram similarity of the names, from 0 to 1
+	IngredientSimilarity float64 `json:"ingredientSimilarity"` // Jaccard overlap of the ingredients, from 0 to 1
+}
+
+// DuplicatePair is two recipes in a duplicate cluster that are likely duplicates of each other.
+type DuplicatePair struct {
+	RecipeId             int     `json:"recipeId"`             // Foreign key to one recipe
+	OtherRecipeId        int     `json:"otherRecipeId"`        // Foreign key to the other recipe
+	Score                float64 `json:"score"`                // Combined similarity, from 0 to 1
+	NameSimilarity       float64 `json:"nameSimilarity"`       // Trigram similarity of the names, from 0 to 1
+	IngredientSimilarity float64 `json:"ingredientSimilarity"` // Jaccard overlap of the ingredients, from 0 to 1
+}
+
+// DuplicateRecipe is a recipe in a duplicate cluster.
+type DuplicateRecipe struct {
+	RecipeId   int    `json:"recipeId"`   // Foreign key to the recipe
+	RecipeName string `json:"recipeName"` // Name of the recipe
+}
+
+// DuplicateCluster is a group of recipes that are likely all the same recipe.
+type DuplicateCluster struct {
+	Recipes []DuplicateRecipe `json:"recipes"` // The recipes in the cluster, in ID order
+	Pairs   []DuplicatePair   `json:"pairs"`   // The pairs of recipes that put them in the cluster
+}
+
+// DuplicateMergeRequest is the request body for merging duplicate recipes.
+type DuplicateMergeRequest struct {
+	KeepId    int   `json:"keepId"`    // The recipe to keep
+	RecipeIds []int `json:"recipeIds"` // The duplicates to merge into it and delete
+}
+
+// Validate checks if the DuplicateMergeRequest names a recipe to keep and at least one other to merge into it.
+// It returns an error if any required field is missing or invalid.
+func (dr *DuplicateMergeRequest) Validate() error {
+	if dr.KeepId <= 0 {
+		return ErrMissingRequiredField("keepId")
+	}
+	if len(dr.RecipeIds) == 0 {
+		return ErrMissingRequiredField("recipeIds")
+	}
+	seen := map[int]bool{dr.KeepId: true}
+	for _, recipeID := range dr.RecipeIds {
+		if recipeID <= 0 || seen[recipeID] {
+			return ErrInvalidField("recipeIds")
+		}
+		seen[recipeID] = true
+	}
+	return nil
+}
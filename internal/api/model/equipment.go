@@ -0,0 +1,42 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"fmt"
+)
+
+// maxEquipmentLength is the longest equipment name that can be stored.
+const maxEquipmentLength = 64
+
+// EquipmentList is a set of kitchen equipment, such as what a recipe needs or what a user's kitchen has.
+type EquipmentList struct {
+	Equipment []string `json:"equipment"` // Equipment names, such as "stand mixer" or "9x13 pan"
+}
+
+// Validate checks if the EquipmentList instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (e *EquipmentList) Validate() error {
+	if e.Equipment == nil {
+		return ErrMissingRequiredField("equipment")
+	}
+	return validateEquipment(e.Equipment)
+}
+
+// RecipeEquipment is the equipment a recipe needs, along with what its procedure mentions, to help edit it.
+type RecipeEquipment struct {
+	RecipeId  int      `json:"recipeId"`  // Foreign key to the recipe
+	Equipment []string `json:"equipment"` // Equipment the recipe needs
+	Detected  []string `json:"detected"`  // Equipment the procedure's text mentions
+}
+
+// private functions
+
+// validateEquipment checks that every equipment name fits in the database.
+func validateEquipment(equipment []string) error {
+	for i, name := range equipment {
+		if len(name) > maxEquipmentLength {
+			return ErrInvalidField(fmt.Sprintf("equipment[%d]", i))
+		}
+	}
+	return nil
+}
@@ -12,3 +12,52 @@ type ErrMissingRequiredField string
 func (e ErrMissingRequiredField) Error() string {
 	return fmt.Sprintf("missing required field: %s", string(e))
 }
+
+// ErrNotFound is an error type that represents a record that could not be found.
+// The value names the kind of record that was looked up, e.g. "recipe".
+type ErrNotFound string
+
+// Error implements the error interface for ErrNotFound.
+// It returns a formatted error message indicating which record was not found.
+func (e ErrNotFound) Error() string {
+	return fmt.Sprintf("%s not found", string(e))
+}
+
+// ErrInvalidField is an error type that represents a field that is present but holds an invalid value.
+type ErrInvalidField string
+
+// Error implements the error interface for ErrInvalidField.
+// It returns a formatted error message indicating which field is invalid.
+func (e ErrInvalidField) Error() string {
+	return fmt.Sprintf("invalid value for field: %s", string(e))
+}
+
+// ErrPermissionDenied is an error type that represents an action the current user is not allowed to perform.
+// The value describes the action that was denied.
+type ErrPermissionDenied string
+
+// Error implements the error interface for ErrPermissionDenied.
+// It returns a formatted error message indicating which action was denied.
+func (e ErrPermissionDenied) Error() string {
+	return fmt.Sprintf("permission denied: %s", string(e))
+}
+
+// ErrAlreadyExists is an error type that represents a record that conflicts with one already stored.
+// The value names the kind of record that already exists.
+type ErrAlreadyExists string
+
+// Error implements the error interface for ErrAlreadyExists.
+// It returns a formatted error message indicating which record already exists.
+func (e ErrAlreadyExists) Error() string {
+	return fmt.Sprintf("%s already exists", string(e))
+}
+
+// ErrConflict is an error type that represents a change based on an out of date copy of a record.
+// The value describes what changed.
+type ErrConflict string
+
+// Error implements the error interface for ErrConflict.
+// It returns a formatted error message describing the conflict.
+func (e ErrConflict) Error() string {
+	return fmt.Sprintf("conflict: %s", string(e))
+}
@@ -0,0 +1,11 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+// FoodImage represents a photo of a recipe's dish.
+type FoodImage struct {
+	ID           int    `json:"id"`           // Unique identifier for the image
+	RecipeId     int    `json:"recipeId"`     // Foreign key to the recipe
+	Filepath     string `json:"-"`            // Location of the image on disk
+	OriginalName string `json:"originalName"` // File name the image was uploaded or imported with
+	SizeBytes    int64  `json:"sizeBytes"`    // Size of the image in bytes
+}
@@ -0,0 +1,42 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"time"
+)
+
+// Household represents a group of users who share meal plans and shopping lists.
+type Household struct {
+	ID            int       `json:"id"`            // Unique identifier for the household
+	HouseholdName string    `json:"householdName"` // Name of the household
+	MemberIds     []int     `json:"memberIds"`     // User IDs of the household members
+	CreatedBy     int       `json:"createdBy"`     // User ID who created this household
+	CreatedDate   time.Time `json:"createdDate"`   // Timestamp when the household was created
+	UpdatedBy     int       `json:"updatedBy"`     // User ID who last updated this household
+	UpdatedDate   time.Time `json:"updatedDate"`   // Timestamp when the household was last updated
+}
+
+// NewHousehold creates a new Household instance with required fields.
+// It automatically sets the creation and update timestamps to the current time.
+func NewHousehold(name string, createdBy int) *Household {
+	now := time.Now()
+	return &Household{
+		HouseholdName: name,
+		CreatedBy:     createdBy,
+		CreatedDate:   now,
+		UpdatedBy:     createdBy,
+		UpdatedDate:   now,
+	}
+}
+
+// Validate checks if the Household instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (h *Household) Validate() error {
+	if h.HouseholdName == "" {
+		return ErrMissingRequiredField("householdName")
+	}
+	if h.CreatedBy == 0 {
+		return ErrMissingRequiredField("createdBy")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+// Formats recipes can be imported from in bulk.
+const (
+	ImportFormatCooklang   = "cooklang"   // Cooklang .cook files, one or a tar of them
+	ImportFormatMealMaster = "mealmaster" // MealMaster .mmf text files, which may hold several recipes
+	ImportFormatPaprika    = "paprika"    // Paprika .paprikarecipes archives, or single .paprikarecipe files
+	ImportFormatMealie     = "mealie"     // Mealie export archives or recipe JSON files
+	ImportFormatTandoor    = "tandoor"    // Tandoor export archives or recipe.json files
+)
+
+// Outcomes of importing a recipe.
+const (
+	ImportStatusImported = "imported" // The recipe was saved
+	ImportStatusValid    = "valid"    // The recipe would have been saved, but it was a dry run
+	ImportStatusFailed   = "failed"   // The recipe couldn't be read, wasn't valid or couldn't be saved
+)
+
+// ImportFile is a file recipes are imported from, such as an uploaded archive.
+type ImportFile struct {
+	Name     string // File name, used to detect the format and to name recipes without a title
+	Contents []byte // Contents of the file
+}
+
+// ImportImage is a photo of a recipe found in an import, to be kept as a food image.
+type ImportImage struct {
+	Name     string // File name of the image within the import
+	Contents []byte // Contents of the image
+}
+
+// ImportEntry is a recipe read from an import, before it is checked or saved.
+type ImportEntry struct {
+	Name   string        // File within an archive the recipe was found in, or blank for the imported file itself
+	Recipe *Recipe       // The recipe read, or nil if it couldn't be read
+	Images []ImportImage // Photos of the recipe
+	Error  string        // Why the recipe couldn't be read, if it couldn't
+}
+
+// ImportOptions controls how imported recipes are saved.
+type ImportOptions struct {
+	UserId int  // User the recipes are saved as
+	Strict bool // Reject recipes that look like duplicates of existing ones rather than only reporting them
+	DryRun bool // Check the recipes without saving anything
+}
+
+// ImportResult is the outcome of importing one recipe from a file of several, such as a tar of Cooklang files.
+type ImportResult struct {
+	File               string           `json:"file"`                         // Name of the file the recipe was read from
+	RecipeName         string           `json:"recipeName,omitempty"`         // Name of the recipe read
+	Status             string           `json:"status"`                       // One of the ImportStatus constants
+	RecipeId           int              `json:"recipeId,omitempty"`           // ID of the saved recipe, if it was saved
+	Images             int              `json:"images,omitempty"`             // Number of photos kept as food images
+	PossibleDuplicates []DuplicateMatch `json:"possibleDuplicates,omitempty"` // Existing recipes it looks like
+	Warnings           []string         `json:"warnings,omitempty"`           // Problems that didn't stop the recipe being imported
+	Error              string           `json:"error,omitempty"`              // Why it wasn't imported, if it wasn't
+}
+
+// ImportReport is the outcome of importing the recipes in a file of several.
+type ImportReport struct {
+	Format   string         `json:"format,omitempty"` // One of the ImportFormat constants
+	DryRun   bool           `json:"dryRun"`           // Whether nothing was saved
+	Imported int            `json:"imported"`         // Number of recipes saved, or that would have been in a dry run
+	Failed   int            `json:"failed"`           // Number of recipes that weren't
+	Results  []ImportResult `json:"results"`          // The outcome for each recipe, in the order they were read
+}
+
+// Add records the outcome of importing a recipe and counts it.
+func (r *ImportReport) Add(result ImportResult) {
+	if result.Status == ImportStatusFailed {
+		r.Failed++
+	} else {
+		r.Imported++
+	}
+	r.Results = append(r.Results, result)
+}
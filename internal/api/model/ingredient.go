@@ -13,6 +13,8 @@ type Ingredient struct {
 	Amount            float64   `json:"amount"`              // Quantity of the ingredient
 	UnitOfMeasurement string    `json:"unitOfMeasurement"`   // Unit of measurement (e.g., cup, tablespoon)
 	IngredientName    string    `json:"ingredientName"`      // Name of the ingredient
+	ComponentRecipeId *int      `json:"componentRecipeId,omitempty"` // Recipe used as this ingredient, such as a dough, if any
+	Component         *Recipe   `json:"component,omitempty"` // The component recipe scaled to Amount, when expanded
 	RecipeId          int       `json:"recipeId"`            // Foreign key to the recipe this ingredient belongs to
 	CreatedBy         int       `json:"createdBy"`           // User ID who created this ingredient
 	CreatedDate       time.Time `json:"createdDate"`         // Timestamp when the ingredient was created
@@ -39,6 +41,10 @@ func NewIngredient(amount float64, unitOfMeasurement string, ingredientName stri
 // Validate checks if the Ingredient instance has all required fields properly set.
 // It returns an error if any required field is missing or invalid.
 func (i *Ingredient) Validate() error {
+	if i.ComponentRecipeId != nil && *i.ComponentRecipeId <= 0 {
+		return ErrInvalidField("componentRecipeId")
+	}
+
 	if i.Amount <= 0 {
 		return ErrMissingRequiredField("amount")
 	}
@@ -53,3 +59,14 @@ func (i *Ingredient) Validate() error {
 
 	return nil
 }
+
+// Units a component recipe's amount can be given in, besides the component recipe's yield unit.
+const (
+	ComponentUnitBatch   = "batch"   // Batches of the component recipe as written
+	ComponentUnitServing = "serving" // Servings of the component recipe
+)
+
+// IsComponent reports whether the ingredient is another recipe rather than a plain ingredient.
+func (i *Ingredient) IsComponent() bool {
+	return i.ComponentRecipeId != nil
+}
@@ -0,0 +1,57 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+// JsonLdContext is the @context of the schema.org documents recipes are exported as.
+const JsonLdContext = "https://schema.org"
+
+// JsonLdRecipe is a recipe in the schema.org Recipe format, as published in JSON-LD.
+// See https://schema.org/Recipe.
+type JsonLdRecipe struct {
+	Context            string            `json:"@context"`
+	Type               string            `json:"@type"`
+	Name               string            `json:"name"`
+	Description        string            `json:"description,omitempty"`
+	Author             *JsonLdPerson     `json:"author,omitempty"`
+	IsBasedOn          string            `json:"isBasedOn,omitempty"`
+	DateCreated        string            `json:"dateCreated,omitempty"`
+	DateModified       string            `json:"dateModified,omitempty"`
+	PrepTime           string            `json:"prepTime,omitempty"`
+	CookTime           string            `json:"cookTime,omitempty"`
+	TotalTime          string            `json:"totalTime,omitempty"`
+	RecipeYield        []string          `json:"recipeYield,omitempty"`
+	RecipeCategory     string            `json:"recipeCategory,omitempty"`
+	RecipeCuisine      string            `json:"recipeCuisine,omitempty"`
+	Keywords           string            `json:"keywords,omitempty"`
+	Tool               []JsonLdThing     `json:"tool,omitempty"`
+	RecipeIngredient   []string          `json:"recipeIngredient"`
+	RecipeInstructions []JsonLdHowToStep `json:"recipeInstructions"`
+	AggregateRating    *JsonLdRating     `json:"aggregateRating,omitempty"`
+}
+
+// JsonLdPerson is a schema.org Person, such as a recipe's author.
+type JsonLdPerson struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// JsonLdThing is a schema.org item known by its name, such as the HowToTool a recipe needs.
+type JsonLdThing struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// JsonLdHowToStep is one step of a recipe's instructions.
+type JsonLdHowToStep struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+}
+
+// JsonLdRating is a recipe's average review rating.
+type JsonLdRating struct {
+	Type        string  `json:"@type"`
+	RatingValue float64 `json:"ratingValue"`
+	RatingCount int     `json:"ratingCount"`
+	BestRating  int     `json:"bestRating"`
+	WorstRating int     `json:"worstRating"`
+}
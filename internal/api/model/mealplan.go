@@ -0,0 +1,155 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"time"
+)
+
+// Meal slots a meal plan entry can be planned for.
+const (
+	MealSlotBreakfast = "breakfast"
+	MealSlotLunch     = "lunch"
+	MealSlotDinner    = "dinner"
+	MealSlotSnack     = "snack"
+)
+
+// MealSlots lists the meal slots in the order they happen during a day.
+var MealSlots = []string{MealSlotBreakfast, MealSlotLunch, MealSlotDinner, MealSlotSnack}
+
+// IsMealSlot reports whether slot is one of the known meal slots.
+func IsMealSlot(slot string) bool {
+	for _, mealSlot := range MealSlots {
+		if slot == mealSlot {
+			return true
+		}
+	}
+	return false
+}
+
+// MealPlanScope identifies whose meal plan is being used: a single user's personal plan,
+// or the shared plan of a household. Exactly one of the fields is set.
+// Saved shopping lists are owned the same way.
+type MealPlanScope struct {
+	UserId      int // User ID of a personal meal plan
+	HouseholdId int // Household ID of a shared meal plan
+}
+
+// MealPlanEntry represents one recipe planned for a meal slot on a date.
+type MealPlanEntry struct {
+	ID            int       `json:"id"`                      // Unique identifier for the entry
+	UserId        int       `json:"userId,omitempty"`        // Owner of a personal meal plan entry
+	HouseholdId   int       `json:"householdId,omitempty"`   // Household of a shared meal plan entry
+	PlanDate      Date      `json:"planDate"`                // Date the meal is planned for
+	MealSlot      string    `json:"mealSlot"`                // One of the MealSlot constants
+	RecipeId      int       `json:"recipeId"`                // Foreign key to the planned recipe
+	RecipeName    string    `json:"recipeName,omitempty"`    // Name of the planned recipe
+	Servings      int       `json:"servings,omitempty"`      // Servings to make, overriding the recipe's own servings
+	Notes         string    `json:"notes,omitempty"`         // Free-form notes about the meal
+	EstimatedCost *float64  `json:"estimatedCost,omitempty"` // Cost of the servings from ingredient prices, set by the calendar
+	CreatedBy     int       `json:"createdBy"`               // User ID who created this entry
+	CreatedDate   time.Time `json:"createdDate"`             // Timestamp when the entry was created
+	UpdatedBy     int       `json:"updatedBy"`               // User ID who last updated this entry
+	UpdatedDate   time.Time `json:"updatedDate"`             // Timestamp when the entry was last updated
+}
+
+// NewMealPlanEntry creates a new MealPlanEntry instance in the given scope.
+// It automatically sets the creation and update timestamps to the current time.
+func NewMealPlanEntry(scope MealPlanScope, planDate Date, mealSlot string, recipeId int, createdBy int) *MealPlanEntry {
+	now := time.Now()
+	return &MealPlanEntry{
+		UserId:      scope.UserId,
+		HouseholdId: scope.HouseholdId,
+		PlanDate:    planDate,
+		MealSlot:    mealSlot,
+		RecipeId:    recipeId,
+		CreatedBy:   createdBy,
+		CreatedDate: now,
+		UpdatedBy:   createdBy,
+		UpdatedDate: now,
+	}
+}
+
+// Scope returns the meal plan the entry belongs to.
+func (e *MealPlanEntry) Scope() MealPlanScope {
+	return MealPlanScope{UserId: e.UserId, HouseholdId: e.HouseholdId}
+}
+
+// Validate checks if the MealPlanEntry instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (e *MealPlanEntry) Validate() error {
+	if (e.UserId == 0) == (e.HouseholdId == 0) {
+		return ErrInvalidField("householdId")
+	}
+	if e.PlanDate.IsZero() {
+		return ErrMissingRequiredField("planDate")
+	}
+	if e.MealSlot == "" {
+		return ErrMissingRequiredField("mealSlot")
+	}
+	if !IsMealSlot(e.MealSlot) {
+		return ErrInvalidField("mealSlot")
+	}
+	if e.RecipeId == 0 {
+		return ErrMissingRequiredField("recipeId")
+	}
+	if e.Servings < 0 {
+		return ErrInvalidField("servings")
+	}
+	return nil
+}
+
+// AutoFillRequest describes which empty meal slots to fill automatically and the constraints the picked recipes must meet.
+type AutoFillRequest struct {
+	StartDate           Date     `json:"startDate"`           // First date to fill, inclusive
+	EndDate             Date     `json:"endDate"`             // Last date to fill, inclusive
+	MealSlots           []string `json:"mealSlots"`           // Slots to fill each day, defaults to dinner
+	MaxWeeknightMinutes int      `json:"maxWeeknightMinutes"` // Maximum prep plus cook time Monday to Friday, 0 for no limit
+	NoRepeatDays        int      `json:"noRepeatDays"`        // Don't plan a recipe within this many days of another plan of it
+	Labels              []string `json:"labels"`              // Dietary labels every picked recipe must have
+	Servings            int      `json:"servings"`            // Servings override for the filled entries, 0 to use the recipe's own
+	MaxCostPerServing   float64  `json:"maxCostPerServing"`   // Maximum estimated cost per serving, 0 for no limit
+}
+
+// Validate checks if the AutoFillRequest instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (a *AutoFillRequest) Validate() error {
+	if a.StartDate.IsZero() {
+		return ErrMissingRequiredField("startDate")
+	}
+	if a.EndDate.IsZero() {
+		return ErrMissingRequiredField("endDate")
+	}
+	if a.EndDate.Before(a.StartDate.Time) {
+		return ErrInvalidField("endDate")
+	}
+	for _, slot := range a.MealSlots {
+		if !IsMealSlot(slot) {
+			return ErrInvalidField("mealSlots")
+		}
+	}
+	if a.MaxWeeknightMinutes < 0 {
+		return ErrInvalidField("maxWeeknightMinutes")
+	}
+	if a.NoRepeatDays < 0 {
+		return ErrInvalidField("noRepeatDays")
+	}
+	if a.Servings < 0 {
+		return ErrInvalidField("servings")
+	}
+	if a.MaxCostPerServing < 0 {
+		return ErrInvalidField("maxCostPerServing")
+	}
+	return nil
+}
+
+// AutoFillResult reports the entries an auto-fill created and the slots it could not fill.
+type AutoFillResult struct {
+	Created  []MealPlanEntry `json:"created"`  // Entries added to the meal plan
+	Unfilled []MealPlanSlot  `json:"unfilled"` // Empty slots no recipe met the constraints for
+}
+
+// MealPlanSlot identifies a meal slot on a date.
+type MealPlanSlot struct {
+	PlanDate Date   `json:"planDate"` // Date of the slot
+	MealSlot string `json:"mealSlot"` // One of the MealSlot constants
+}
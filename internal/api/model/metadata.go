@@ -0,0 +1,116 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"net/url"
+)
+
+// How hard a recipe is to make.
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+// Courses a recipe can be served as.
+const (
+	CourseBreakfast = "breakfast"
+	CourseAppetizer = "appetizer"
+	CourseSoup      = "soup"
+	CourseSalad     = "salad"
+	CourseMain      = "main"
+	CourseSide      = "side"
+	CourseDessert   = "dessert"
+	CourseSnack     = "snack"
+	CourseBread     = "bread"
+	CourseSauce     = "sauce"
+	CourseDrink     = "drink"
+)
+
+// Longest values the metadata columns hold.
+const (
+	maxMetadataLength = 255
+	maxCuisineLength  = 64
+)
+
+// IsDifficulty reports whether difficulty is one of the Difficulty constants.
+func IsDifficulty(difficulty string) bool {
+	switch difficulty {
+	case DifficultyEasy, DifficultyMedium, DifficultyHard:
+		return true
+	}
+	return false
+}
+
+// IsCourse reports whether course is one of the Course constants.
+func IsCourse(course string) bool {
+	switch course {
+	case CourseBreakfast, CourseAppetizer, CourseSoup, CourseSalad, CourseMain, CourseSide,
+		CourseDessert, CourseSnack, CourseBread, CourseSauce, CourseDrink:
+		return true
+	}
+	return false
+}
+
+// Attribution is where a recipe comes from: the person who wrote it, the cookbook and page it was scanned
+// from, the website it was found on, or the family member it was handed down by. Every field is optional.
+type Attribution struct {
+	Author       string `json:"author,omitempty"`       // Original author of the recipe
+	Cookbook     string `json:"cookbook,omitempty"`     // Title of the cookbook the recipe is from
+	Page         int    `json:"page,omitempty"`         // Page of the cookbook the recipe is on
+	Url          string `json:"url,omitempty"`          // Web page the recipe is from
+	FamilyMember string `json:"familyMember,omitempty"` // Family member the recipe is credited to, such as "Grandma Rose"
+}
+
+// IsZero reports whether no part of the attribution is set.
+func (a *Attribution) IsZero() bool {
+	return a.Author == "" && a.Cookbook == "" && a.Page == 0 && a.Url == "" && a.FamilyMember == ""
+}
+
+// Validate checks if the Attribution instance has all fields properly set.
+// It returns an error if any field is invalid.
+func (a *Attribution) Validate() error {
+	if len(a.Author) > maxMetadataLength {
+		return ErrInvalidField("source.author")
+	}
+	if len(a.Cookbook) > maxMetadataLength {
+		return ErrInvalidField("source.cookbook")
+	}
+	if a.Page < 0 {
+		return ErrInvalidField("source.page")
+	}
+	if a.Page > 0 && a.Cookbook == "" {
+		return ErrMissingRequiredField("source.cookbook")
+	}
+	if a.Url != "" {
+		parsed, err := url.Parse(a.Url)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ErrInvalidField("source.url")
+		}
+	}
+	if len(a.FamilyMember) > maxMetadataLength {
+		return ErrInvalidField("source.familyMember")
+	}
+	return nil
+}
+
+// private functions
+
+// validateMetadata checks a recipe's attribution, difficulty, cuisine and course.
+func validateMetadata(r *Recipe) error {
+	if r.Source != nil {
+		if err := r.Source.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Difficulty != "" && !IsDifficulty(r.Difficulty) {
+		return ErrInvalidField("difficulty")
+	}
+	if len(r.Cuisine) > maxCuisineLength {
+		return ErrInvalidField("cuisine")
+	}
+	if r.Course != "" && !IsCourse(r.Course) {
+		return ErrInvalidField("course")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"time"
+)
+
+// PantryItem is an ingredient on hand. Like a meal plan, a pantry belongs to either a user or a household.
+type PantryItem struct {
+	ID             int       `json:"id"`                    // Unique identifier for the pantry item
+	UserId         int       `json:"userId,omitempty"`      // Owner of a personal pantry
+	HouseholdId    int       `json:"householdId,omitempty"` // Household of a shared pantry
+	IngredientName string    `json:"ingredientName"`        // Name of the ingredient
+	Quantity       float64   `json:"quantity"`              // Amount on hand
+	Unit           string    `json:"unit"`                  // Unit of the quantity, empty for a plain count
+	ExpiryDate     *Date     `json:"expiryDate,omitempty"`  // Optional date the ingredient should be used by
+	CreatedBy      int       `json:"createdBy"`             // User ID who created this item
+	CreatedDate    time.Time `json:"createdDate"`           // Timestamp when the item was created
+	UpdatedBy      int       `json:"updatedBy"`             // User ID who last updated this item
+	UpdatedDate    time.Time `json:"updatedDate"`           // Timestamp when the item was last updated
+}
+
+// NewPantryItem creates a new PantryItem instance in the given scope.
+// It automatically sets the creation and update timestamps to the current time.
+func NewPantryItem(scope MealPlanScope, ingredientName string, quantity float64, unit string, createdBy int) *PantryItem {
+	now := time.Now()
+	return &PantryItem{
+		UserId:         scope.UserId,
+		HouseholdId:    scope.HouseholdId,
+		IngredientName: ingredientName,
+		Quantity:       quantity,
+		Unit:           unit,
+		CreatedBy:      createdBy,
+		CreatedDate:    now,
+		UpdatedBy:      createdBy,
+		UpdatedDate:    now,
+	}
+}
+
+// Scope returns the user or household the item belongs to.
+func (p *PantryItem) Scope() MealPlanScope {
+	return MealPlanScope{UserId: p.UserId, HouseholdId: p.HouseholdId}
+}
+
+// Validate checks if the PantryItem instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (p *PantryItem) Validate() error {
+	if (p.UserId == 0) == (p.HouseholdId == 0) {
+		return ErrInvalidField("householdId")
+	}
+	if p.IngredientName == "" {
+		return ErrMissingRequiredField("ingredientName")
+	}
+	if p.Quantity < 0 {
+		return ErrInvalidField("quantity")
+	}
+	if p.CreatedBy == 0 {
+		return ErrMissingRequiredField("createdBy")
+	}
+	return nil
+}
+
+// Ways an ingredient a recipe needs can match the pantry.
+const (
+	// PantryMatchMatched means the ingredient was found and its amount converted to the pantry's unit.
+	PantryMatchMatched = "matched"
+	// PantryMatchUncertain means a pantry item might be the ingredient, but the names only partly match
+	// or the units can't be converted, so nothing was taken from it.
+	PantryMatchUncertain = "uncertain"
+	// PantryMatchMissing means nothing in the pantry looks like the ingredient.
+	PantryMatchMissing = "missing"
+)
+
+// PantryMatch reports how an ingredient a recipe needs was matched against the pantry
+// and how much of it the pantry covers.
+type PantryMatch struct {
+	IngredientName string  `json:"ingredientName"`           // Name of the ingredient the recipe needs
+	Amount         float64 `json:"amount"`                   // Amount the recipe needs
+	Unit           string  `json:"unit"`                     // Unit of the amount
+	Status         string  `json:"status"`                   // One of the PantryMatch constants
+	Covered        float64 `json:"covered"`                  // Part of the amount the pantry covers, in the recipe's unit
+	PantryItemId   int     `json:"pantryItemId,omitempty"`   // Pantry item that matched, or might match
+	PantryItemName string  `json:"pantryItemName,omitempty"` // Name of that pantry item
+	Taken          float64 `json:"taken,omitempty"`          // Amount taken from the pantry item, in the pantry's unit
+	PantryUnit     string  `json:"pantryUnit,omitempty"`     // Unit of the pantry item
+	Approximate    bool    `json:"approximate,omitempty"`    // Whether converting needed a typical density, e.g. cups of flour to grams
+	Reason         string  `json:"reason,omitempty"`         // Why a match is uncertain
+}
+
+// CanCookRecipe reports how much of a recipe can be made from the pantry.
+type CanCookRecipe struct {
+	RecipeId   int           `json:"recipeId"`   // Foreign key to the recipe
+	RecipeName string        `json:"recipeName"` // Name of the recipe
+	Missing    int           `json:"missing"`    // Number of ingredients the pantry doesn't fully cover
+	Uncertain  int           `json:"uncertain"`  // Number of those that might be in the pantry
+	Matches    []PantryMatch `json:"matches"`    // How each ingredient matched
+}
@@ -0,0 +1,112 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"time"
+)
+
+// IngredientPrice is a price observed for an ingredient: Price buys Quantity of Unit, e.g. 3.49 for 2 lb.
+// Every observation is kept, so an ingredient's prices over time are its price history.
+type IngredientPrice struct {
+	ID             int       `json:"id"`              // Unique identifier for the price
+	IngredientName string    `json:"ingredientName"`  // Canonical name of the ingredient
+	Price          float64   `json:"price"`           // Price paid for the quantity
+	Quantity       float64   `json:"quantity"`        // Quantity the price buys
+	Unit           string    `json:"unit"`            // Unit of the quantity, empty for a plain count
+	Store          string    `json:"store,omitempty"` // Store the price was seen at
+	ObservedDate   Date      `json:"observedDate"`    // Date the price was seen
+	CreatedBy      int       `json:"createdBy"`       // User ID who created this price
+	CreatedDate    time.Time `json:"createdDate"`     // Timestamp when the price was created
+	UpdatedBy      int       `json:"updatedBy"`       // User ID who last updated this price
+	UpdatedDate    time.Time `json:"updatedDate"`     // Timestamp when the price was last updated
+}
+
+// NewIngredientPrice creates a new IngredientPrice instance with required fields.
+// It automatically sets the creation and update timestamps to the current time.
+func NewIngredientPrice(ingredientName string, price float64, quantity float64, unit string, createdBy int) *IngredientPrice {
+	now := time.Now()
+	return &IngredientPrice{
+		IngredientName: ingredientName,
+		Price:          price,
+		Quantity:       quantity,
+		Unit:           unit,
+		CreatedBy:      createdBy,
+		CreatedDate:    now,
+		UpdatedBy:      createdBy,
+		UpdatedDate:    now,
+	}
+}
+
+// Validate checks if the IngredientPrice instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (p *IngredientPrice) Validate() error {
+	if p.IngredientName == "" {
+		return ErrMissingRequiredField("ingredientName")
+	}
+	if p.Price < 0 {
+		return ErrInvalidField("price")
+	}
+	if p.Quantity <= 0 {
+		return ErrInvalidField("quantity")
+	}
+	if p.ObservedDate.IsZero() {
+		return ErrMissingRequiredField("observedDate")
+	}
+	if p.ObservedDate.After(Today().Time) {
+		return ErrInvalidField("observedDate")
+	}
+	if p.CreatedBy == 0 {
+		return ErrMissingRequiredField("createdBy")
+	}
+	return nil
+}
+
+// Reasons an ingredient could not be priced.
+const (
+	// UnpricedNoPrice means there is no price for the ingredient.
+	UnpricedNoPrice = "no price"
+	// UnpricedUnitMismatch means the ingredient has a price, but the recipe's unit can't be converted to the
+	// price's unit, such as a recipe asking for cups of an ingredient priced per count.
+	UnpricedUnitMismatch = "unit mismatch"
+)
+
+// IngredientCost is what an ingredient of a recipe costs and the price it was worked out from.
+type IngredientCost struct {
+	IngredientName string  `json:"ingredientName"`  // Name of the ingredient in the recipe
+	Amount         float64 `json:"amount"`          // Amount the recipe needs
+	Unit           string  `json:"unit"`            // Unit of the amount
+	Cost           float64 `json:"cost"`            // Cost of the amount
+	PriceId        int     `json:"priceId"`         // Foreign key to the price used
+	Price          float64 `json:"price"`           // Price paid for the price's quantity
+	PriceQuantity  float64 `json:"priceQuantity"`   // Quantity the price buys
+	PriceUnit      string  `json:"priceUnit"`       // Unit of the price's quantity
+	Store          string  `json:"store,omitempty"` // Store the price was seen at
+	ObservedDate   Date    `json:"observedDate"`    // Date the price was seen
+	Approximate    bool    `json:"approximate"`     // Whether converting to the price's unit used the ingredient's density
+}
+
+// UnpricedIngredient is an ingredient of a recipe whose cost could not be worked out.
+type UnpricedIngredient struct {
+	IngredientName string  `json:"ingredientName"` // Name of the ingredient in the recipe
+	Amount         float64 `json:"amount"`         // Amount the recipe needs
+	Unit           string  `json:"unit"`           // Unit of the amount
+	Reason         string  `json:"reason"`         // One of the Unpriced constants
+}
+
+// RecipeCost is the estimated cost of a recipe with a breakdown by ingredient.
+// The estimate only covers the priced ingredients; Complete reports whether that is all of them.
+type RecipeCost struct {
+	RecipeId       int                  `json:"recipeId"`                 // Foreign key to the recipe
+	RecipeName     string               `json:"recipeName"`               // Name of the recipe
+	Servings       int                  `json:"servings,omitempty"`       // Servings the cost is for
+	TotalCost      float64              `json:"totalCost"`                // Sum of the ingredient costs
+	CostPerServing *float64             `json:"costPerServing,omitempty"` // Total cost divided by servings, if the servings are known
+	Complete       bool                 `json:"complete"`                 // Whether every ingredient was priced
+	Ingredients    []IngredientCost     `json:"ingredients"`              // Cost of each priced ingredient
+	Unpriced       []UnpricedIngredient `json:"unpriced"`                 // Ingredients that could not be priced
+}
+
+// Priced reports whether any ingredient of the recipe was priced, so the estimate means something.
+func (c RecipeCost) Priced() bool {
+	return len(c.Ingredients) > 0
+}
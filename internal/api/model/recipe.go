@@ -19,7 +19,23 @@ type Recipe struct {
 	CookTimeMinutes int          `json:"cookTimeMinutes,omitempty"` // Time required for cooking in minutes
 	Ingredients     []Ingredient `json:"ingredients"`               // List of ingredients required for the recipe
 	Procedure       []string     `json:"procedure"`                 // Step-by-step cooking instructions
+	StepTimings     []StepTiming `json:"stepTimings,omitempty"`     // Optional timing of each procedure step, in the same order
 	Servings        int          `json:"servings,omitempty"`        // Number of servings the recipe yields
+	Yield           *RecipeYield `json:"yield,omitempty"`           // What the recipe makes besides servings, such as 24 cookies or a 9x13 pan
+	Tags            []string     `json:"tags,omitempty"`            // Labels such as "vegetarian" or "gluten-free"
+	Equipment       []string     `json:"equipment,omitempty"`       // Equipment the recipe needs, such as "stand mixer"; detected from the procedure if not given
+	Source          *Attribution `json:"source,omitempty"`          // Where the recipe comes from, such as a cookbook page or a family member
+	Notes           string       `json:"notes,omitempty"`           // Free-form notes, such as tips or variations
+	MakeAhead       string       `json:"makeAhead,omitempty"`       // What can be prepared ahead of time and how far ahead
+	Storage         string       `json:"storage,omitempty"`         // How to store leftovers and how long they keep
+	Freezing        string       `json:"freezing,omitempty"`        // Whether and how the dish freezes and thaws
+	Difficulty      string       `json:"difficulty,omitempty"`      // One of the Difficulty constants
+	Cuisine         string       `json:"cuisine,omitempty"`         // Cuisine such as "italian" or "thai"
+	Course          string       `json:"course,omitempty"`          // One of the Course constants
+	AverageRating   float64      `json:"averageRating"`             // Average star rating across all reviews
+	RatingCount     int          `json:"ratingCount"`               // Number of reviews the recipe has
+	EstimatedCost   *float64     `json:"estimatedCost,omitempty"`   // Cost from ingredient prices, set when listing by cost
+	CostPerServing  *float64     `json:"costPerServing,omitempty"`  // Estimated cost divided by servings, set when listing by cost
 	CreatedBy       int          `json:"createdBy"`                 // User ID who created this recipe
 	CreatedDate     time.Time    `json:"createdDate"`               // Timestamp when the recipe was created
 	UpdatedBy       int          `json:"updatedBy"`                 // User ID who last updated this recipe
@@ -51,6 +67,25 @@ func (r *Recipe) Validate() error {
 	if r.UpdatedBy == 0 {
 		return ErrMissingRequiredField("updatedBy")
 	}
+	if r.Yield != nil {
+		if err := r.Yield.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(r.StepTimings) > len(r.Procedure) {
+		return ErrInvalidField("stepTimings")
+	}
+	for i := range r.StepTimings {
+		if err := r.StepTimings[i].Validate(i + 1); err != nil {
+			return err
+		}
+	}
+	if err := validateEquipment(r.Equipment); err != nil {
+		return err
+	}
+	if err := validateMetadata(r); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,18 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+// RecipeCard is what the Markdown and printable HTML recipe templates are given to render a recipe. Templates
+// in the configured template directory can use any of its fields, including everything on the recipe itself.
+type RecipeCard struct {
+	Recipe           *Recipe  // The recipe, with its ingredients scaled to Servings
+	Servings         int      // Servings the card is scaled to, or 0 if the recipe doesn't give servings
+	OriginalServings int      // Servings the recipe makes as written
+	Ingredients      []string // Ingredient lines, such as "1 1/2 cups flour", scaled to Servings
+	Steps            []string // Procedure steps, in order
+	PrepTime         string   // Preparation time, such as "15 minutes", or "" if not given
+	CookTime         string   // Cooking time, or "" if not given
+	TotalTime        string   // Preparation and cooking time together, or "" if neither is given
+	Yield            string   // What the recipe makes besides servings, such as "24 cookies", or ""
+	PhotoUrl         string   // Address of the recipe's primary photo, or "" if it has none
+	ScaleNotes       []string // Advice about the scaled amounts
+}
@@ -0,0 +1,61 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"time"
+)
+
+// MinRating and MaxRating are the bounds of a star rating.
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Review represents one user's star rating and written review of a recipe.
+// Each user has at most one review per recipe.
+type Review struct {
+	ID          int       `json:"id"`                   // Unique identifier for the review
+	RecipeId    int       `json:"recipeId"`             // Foreign key to the reviewed recipe
+	UserId      int       `json:"userId"`               // User ID who wrote the review
+	Username    string    `json:"username,omitempty"`   // Username of the reviewer
+	Rating      int       `json:"rating"`               // Star rating from 1 to 5
+	ReviewText  string    `json:"reviewText,omitempty"` // Written review
+	CreatedBy   int       `json:"createdBy"`            // User ID who created this review
+	CreatedDate time.Time `json:"createdDate"`          // Timestamp when the review was created
+	UpdatedBy   int       `json:"updatedBy"`            // User ID who last updated this review
+	UpdatedDate time.Time `json:"updatedDate"`          // Timestamp when the review was last updated
+}
+
+// NewReview creates a new Review instance with required fields.
+// It automatically sets the creation and update timestamps to the current time.
+func NewReview(recipeId int, userId int, rating int, reviewText string) *Review {
+	now := time.Now()
+	return &Review{
+		RecipeId:    recipeId,
+		UserId:      userId,
+		Rating:      rating,
+		ReviewText:  reviewText,
+		CreatedBy:   userId,
+		CreatedDate: now,
+		UpdatedBy:   userId,
+		UpdatedDate: now,
+	}
+}
+
+// Validate checks if the Review instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (r *Review) Validate() error {
+	if r.RecipeId == 0 {
+		return ErrMissingRequiredField("recipeId")
+	}
+	if r.UserId == 0 {
+		return ErrMissingRequiredField("userId")
+	}
+	if r.Rating == 0 {
+		return ErrMissingRequiredField("rating")
+	}
+	if r.Rating < MinRating || r.Rating > MaxRating {
+		return ErrInvalidField("rating")
+	}
+	return nil
+}
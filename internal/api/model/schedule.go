@@ -0,0 +1,110 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// Resources a procedure step can occupy while it runs.
+const (
+	StepResourceOven     = "oven"     // Baking, roasting or broiling; one temperature per oven at a time
+	StepResourceStovetop = "stovetop" // Simmering, boiling or frying on a burner
+	StepResourceHands    = "hands"    // Work that needs the cook's attention, such as chopping or mixing
+	StepResourcePassive  = "passive"  // Waiting that needs nothing, such as resting, chilling or rising
+)
+
+// IsStepResource reports whether resource is one of the StepResource constants.
+func IsStepResource(resource string) bool {
+	switch resource {
+	case StepResourceOven, StepResourceStovetop, StepResourceHands, StepResourcePassive:
+		return true
+	}
+	return false
+}
+
+// StepTiming is structured timing for a procedure step, used to plan cooking schedules. Every field is
+// optional; what is missing is worked out from the step's text or estimated.
+type StepTiming struct {
+	DurationMinutes int    `json:"durationMinutes,omitempty"` // How long the step takes
+	Resource        string `json:"resource,omitempty"`        // One of the StepResource constants
+	OvenTemperature int    `json:"ovenTemperature,omitempty"` // Oven temperature in °F for oven steps
+	DependsOn       []int  `json:"dependsOn,omitempty"`       // Step numbers, from 1, that must finish first; the previous step if not set
+}
+
+// IsZero reports whether no timing is set.
+func (s StepTiming) IsZero() bool {
+	return s.DurationMinutes == 0 && s.Resource == "" && s.OvenTemperature == 0 && len(s.DependsOn) == 0
+}
+
+// Validate checks if the StepTiming of the given step number, from 1, is valid.
+// Steps can only depend on earlier steps. It returns an error if any field is invalid.
+func (s *StepTiming) Validate(step int) error {
+	if s.DurationMinutes < 0 {
+		return ErrInvalidField(fmt.Sprintf("stepTimings[%d].durationMinutes", step-1))
+	}
+	if s.Resource != "" && !IsStepResource(s.Resource) {
+		return ErrInvalidField(fmt.Sprintf("stepTimings[%d].resource", step-1))
+	}
+	if s.OvenTemperature < 0 {
+		return ErrInvalidField(fmt.Sprintf("stepTimings[%d].ovenTemperature", step-1))
+	}
+	for _, dependency := range s.DependsOn {
+		if dependency < 1 || dependency >= step {
+			return ErrInvalidField(fmt.Sprintf("stepTimings[%d].dependsOn", step-1))
+		}
+	}
+	return nil
+}
+
+// ScheduleRequest asks for a timeline to cook several recipes so they are all ready at the same time.
+type ScheduleRequest struct {
+	RecipeIds []int     `json:"recipeIds"`         // Recipes to cook
+	ServeTime time.Time `json:"serveTime"`         // When the food should be ready
+	Ovens     int       `json:"ovens,omitempty"`   // Ovens in the kitchen, 1 if not set
+	Burners   int       `json:"burners,omitempty"` // Stovetop burners in the kitchen, 4 if not set
+	Cooks     int       `json:"cooks,omitempty"`   // People cooking, 1 if not set
+}
+
+// Validate checks if the ScheduleRequest instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (s *ScheduleRequest) Validate() error {
+	if len(s.RecipeIds) == 0 {
+		return ErrMissingRequiredField("recipeIds")
+	}
+	if s.ServeTime.IsZero() {
+		return ErrMissingRequiredField("serveTime")
+	}
+	if s.Ovens < 0 {
+		return ErrInvalidField("ovens")
+	}
+	if s.Burners < 0 {
+		return ErrInvalidField("burners")
+	}
+	if s.Cooks < 0 {
+		return ErrInvalidField("cooks")
+	}
+	return nil
+}
+
+// ScheduledStep is a procedure step placed on a cooking timeline.
+type ScheduledStep struct {
+	RecipeId        int       `json:"recipeId"`                  // Foreign key to the recipe the step belongs to
+	RecipeName      string    `json:"recipeName"`                // Name of the recipe
+	Step            int       `json:"step"`                      // Step number in the recipe's procedure, from 1; 0 for added steps such as preheating
+	Instruction     string    `json:"instruction"`               // What to do
+	Resource        string    `json:"resource"`                  // One of the StepResource constants
+	OvenTemperature int       `json:"ovenTemperature,omitempty"` // Oven temperature in °F for oven steps
+	DurationMinutes int       `json:"durationMinutes"`           // How long the step takes
+	Estimated       bool      `json:"estimated"`                 // Whether the duration was estimated rather than given or read from the step
+	Start           time.Time `json:"start"`                     // When to start the step
+	End             time.Time `json:"end"`                       // When the step is done
+}
+
+// Schedule is a combined cooking timeline for several recipes, back-scheduled from the serve time.
+type Schedule struct {
+	ServeTime time.Time       `json:"serveTime"` // When the food should be ready
+	StartTime time.Time       `json:"startTime"` // When to start cooking
+	Steps     []ScheduledStep `json:"steps"`     // Every step in start time order
+	Notes     []string        `json:"notes"`     // Advice about the schedule, such as dishes that finish early
+}
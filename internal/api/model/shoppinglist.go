@@ -0,0 +1,158 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"time"
+)
+
+// ShoppingListRecipe is a recipe to shop for and how many servings of it to make.
+type ShoppingListRecipe struct {
+	RecipeId int `json:"recipeId"` // Foreign key to the recipe
+	Servings int `json:"servings"` // Servings to make, 0 to use the recipe's own servings
+}
+
+// ShoppingListRequest describes what to build a shopping list for: either a set of recipes,
+// or every recipe planned in a meal plan between two dates.
+type ShoppingListRequest struct {
+	Recipes   []ShoppingListRecipe `json:"recipes"`   // Recipes to shop for
+	StartDate Date                 `json:"startDate"` // First meal plan date to shop for, inclusive
+	EndDate   Date                 `json:"endDate"`   // Last meal plan date to shop for, inclusive
+	UsePantry bool                 `json:"usePantry"` // Whether to leave off what is already in the pantry
+}
+
+// UsesMealPlan reports whether the request is for a meal plan date range rather than a set of recipes.
+func (s *ShoppingListRequest) UsesMealPlan() bool {
+	return len(s.Recipes) == 0
+}
+
+// Validate checks if the ShoppingListRequest instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (s *ShoppingListRequest) Validate() error {
+	if s.UsesMealPlan() {
+		if s.StartDate.IsZero() {
+			return ErrMissingRequiredField("startDate")
+		}
+		if s.EndDate.IsZero() {
+			return ErrMissingRequiredField("endDate")
+		}
+		if s.EndDate.Before(s.StartDate.Time) {
+			return ErrInvalidField("endDate")
+		}
+		return nil
+	}
+
+	for _, recipe := range s.Recipes {
+		if recipe.RecipeId <= 0 {
+			return ErrInvalidField("recipeId")
+		}
+		if recipe.Servings < 0 {
+			return ErrInvalidField("servings")
+		}
+	}
+	return nil
+}
+
+// ShoppingListSource is a recipe that contributed to a shopping list item.
+type ShoppingListSource struct {
+	RecipeId   int    `json:"recipeId"`   // Foreign key to the recipe
+	RecipeName string `json:"recipeName"` // Name of the recipe
+}
+
+// ShoppingListItem is one line of a shopping list: an ingredient and the total amount
+// needed across every recipe that uses it. Items of a saved list also have an ID and a checked state.
+type ShoppingListItem struct {
+	ID             int                  `json:"id,omitempty"`          // Unique identifier of a saved item
+	IngredientName string               `json:"ingredientName"`        // Name of the ingredient
+	Amount         float64              `json:"amount"`                // Total amount needed, 0 if not given
+	Unit           string               `json:"unit"`                  // Unit of the amount, empty for a plain count
+	Aisle          string               `json:"aisle"`                 // Store aisle the ingredient is found in
+	Recipes        []ShoppingListSource `json:"recipes"`               // Recipes that need the ingredient
+	Manual         bool                 `json:"manual,omitempty"`      // Whether the item was added by hand rather than from a recipe
+	Checked        bool                 `json:"checked"`               // Whether the item has been picked up
+	CheckedBy      int                  `json:"checkedBy,omitempty"`   // User ID who checked the item off
+	CheckedDate    *time.Time           `json:"checkedDate,omitempty"` // Timestamp when the item was checked off
+	Version        int                  `json:"version,omitempty"`     // Goes up by one on every change to a saved item
+}
+
+// ShoppingListAisle is the shopping list items found in one store aisle.
+type ShoppingListAisle struct {
+	Aisle string             `json:"aisle"` // Name of the aisle
+	Items []ShoppingListItem `json:"items"` // Items in the aisle, by ingredient name
+}
+
+// ShoppingList is a consolidated list of ingredients grouped by store aisle.
+type ShoppingList struct {
+	Aisles []ShoppingListAisle `json:"aisles"`           // Aisles in the order a shopper walks them
+	Pantry []PantryMatch       `json:"pantry,omitempty"` // Items found in the pantry, when it was used
+}
+
+// SavedShoppingList is a shopping list stored so household members can check items off together.
+// Like a meal plan, it belongs to either a user or a household.
+type SavedShoppingList struct {
+	ID          int                `json:"id"`                    // Unique identifier for the list
+	ListName    string             `json:"listName"`              // Name of the list
+	UserId      int                `json:"userId,omitempty"`      // Owner of a personal list
+	HouseholdId int                `json:"householdId,omitempty"` // Household of a shared list
+	Items       []ShoppingListItem `json:"items,omitempty"`       // Items in aisle order
+	CreatedBy   int                `json:"createdBy"`             // User ID who created this list
+	CreatedDate time.Time          `json:"createdDate"`           // Timestamp when the list was created
+	UpdatedBy   int                `json:"updatedBy"`             // User ID who last updated this list
+	UpdatedDate time.Time          `json:"updatedDate"`           // Timestamp when the list was last updated
+}
+
+// NewSavedShoppingList creates a new SavedShoppingList instance in the given scope.
+// It automatically sets the creation and update timestamps to the current time.
+func NewSavedShoppingList(name string, scope MealPlanScope, createdBy int) *SavedShoppingList {
+	now := time.Now()
+	return &SavedShoppingList{
+		ListName:    name,
+		UserId:      scope.UserId,
+		HouseholdId: scope.HouseholdId,
+		CreatedBy:   createdBy,
+		CreatedDate: now,
+		UpdatedBy:   createdBy,
+		UpdatedDate: now,
+	}
+}
+
+// Scope returns the user or household the list belongs to.
+func (s *SavedShoppingList) Scope() MealPlanScope {
+	return MealPlanScope{UserId: s.UserId, HouseholdId: s.HouseholdId}
+}
+
+// Validate checks if the SavedShoppingList instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (s *SavedShoppingList) Validate() error {
+	if s.ListName == "" {
+		return ErrMissingRequiredField("listName")
+	}
+	if (s.UserId == 0) == (s.HouseholdId == 0) {
+		return ErrInvalidField("householdId")
+	}
+	if s.CreatedBy == 0 {
+		return ErrMissingRequiredField("createdBy")
+	}
+	return nil
+}
+
+// Types of change a shopping list event describes.
+const (
+	ShoppingListEventItemAdded     = "item_added"
+	ShoppingListEventItemChecked   = "item_checked"
+	ShoppingListEventItemUnchecked = "item_unchecked"
+	ShoppingListEventItemRemoved   = "item_removed"
+	ShoppingListEventListDeleted   = "list_deleted"
+)
+
+// ShoppingListEvent is a change to a saved shopping list, streamed to every client watching it.
+// Event IDs increase in the order the changes were made, so a client that reconnects can ask for
+// the events after the last one it saw.
+type ShoppingListEvent struct {
+	ID             int64             `json:"id"`             // Unique, increasing identifier for the event
+	ShoppingListId int               `json:"shoppingListId"` // Foreign key to the list that changed
+	EventType      string            `json:"eventType"`      // One of the ShoppingListEvent constants
+	ItemId         int               `json:"itemId"`         // Item that changed, 0 for changes to the whole list
+	Item           *ShoppingListItem `json:"item,omitempty"` // Item after the change, missing when it was removed
+	CreatedBy      int               `json:"createdBy"`      // User ID who made the change
+	CreatedDate    time.Time         `json:"createdDate"`    // Timestamp when the change was made
+}
@@ -0,0 +1,23 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+// SimilarityComponents are the parts of a similar recipe's score, each from 0 to 1.
+// A part is left out, as nil, when either recipe has nothing to compare, such as no tags.
+type SimilarityComponents struct {
+	Ingredients float64  `json:"ingredients"`          // Overlap of canonical ingredients, weighted toward less common ones
+	Tags        *float64 `json:"tags,omitempty"`       // Overlap of tags
+	Techniques  *float64 `json:"techniques,omitempty"` // Overlap of cooking techniques found in the procedure
+	Time        *float64 `json:"time,omitempty"`       // How close the total prep and cook times are
+}
+
+// SimilarRecipe is a recipe recommended as similar to another, with why.
+type SimilarRecipe struct {
+	RecipeId          int                  `json:"recipeId"`          // Foreign key to the recommended recipe
+	RecipeName        string               `json:"recipeName"`        // Name of the recommended recipe
+	Score             float64              `json:"score"`             // Weighted combination of the components, from 0 to 1
+	Components        SimilarityComponents `json:"components"`        // The parts of the score
+	SharedIngredients []string             `json:"sharedIngredients"` // Ingredients both recipes use, least common first
+	SharedTags        []string             `json:"sharedTags"`        // Tags both recipes have
+	SharedTechniques  []string             `json:"sharedTechniques"`  // Techniques both recipes use
+	Because           string               `json:"because"`           // Explanation of the recommendation
+}
@@ -0,0 +1,75 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+// Reasons an ingredient is offered substitutes.
+const (
+	SubstitutionReasonMissing  = "missing"  // The ingredient was listed as missing, or the pantry doesn't cover it
+	SubstitutionReasonAllergen = "allergen" // The ingredient contains an allergen to avoid
+	SubstitutionReasonAvoided  = "avoided"  // The ingredient itself was listed to avoid
+)
+
+// SubstituteAmount is an amount of an ingredient used in place of another.
+type SubstituteAmount struct {
+	IngredientName    string  `json:"ingredientName"`    // Name of the substitute ingredient
+	Amount            float64 `json:"amount"`            // Quantity of the substitute, scaled to the recipe
+	UnitOfMeasurement string  `json:"unitOfMeasurement"` // Unit of measurement of the amount
+}
+
+// SubstitutionOption is one way to replace an ingredient.
+type SubstitutionOption struct {
+	Option       int                `json:"option"`            // Number of the option, passed back to apply it
+	Replacements []SubstituteAmount `json:"replacements"`      // What to use instead, together
+	Notes        string             `json:"notes,omitempty"`   // How to use the substitute and how it changes the dish
+	Warning      string             `json:"warning,omitempty"` // Why the substitute may not suit this recipe
+	Suitable     bool               `json:"suitable"`          // Whether the substitute suits this recipe
+	Approximate  bool               `json:"approximate"`       // Whether the amounts went through a density conversion
+	Allergens    []string           `json:"allergens"`         // Allergens in the replacements
+}
+
+// IngredientSubstitution lists the substitutes for one ingredient of a recipe.
+type IngredientSubstitution struct {
+	IngredientName    string               `json:"ingredientName"`    // Name of the ingredient as the recipe writes it
+	Amount            float64              `json:"amount"`            // Quantity the recipe calls for
+	UnitOfMeasurement string               `json:"unitOfMeasurement"` // Unit of measurement of the amount
+	Reasons           []string             `json:"reasons"`           // Why substitutes are offered, one of the SubstitutionReason constants
+	Allergens         []string             `json:"allergens"`         // Allergens in the ingredient
+	Options           []SubstitutionOption `json:"options"`           // Substitutes, suitable ones first
+}
+
+// RecipeSubstitutions is the response of the recipe substitutions endpoint.
+type RecipeSubstitutions struct {
+	RecipeId      int                      `json:"recipeId"`      // Foreign key to the recipe
+	RecipeName    string                   `json:"recipeName"`    // Name of the recipe
+	Contexts      []string                 `json:"contexts"`      // How the recipe is cooked, such as "baking", which rules out some substitutes
+	Substitutions []IngredientSubstitution `json:"substitutions"` // Ingredients with substitutes
+}
+
+// SubstitutionSwap picks a substitute for one ingredient of a recipe.
+type SubstitutionSwap struct {
+	IngredientName string `json:"ingredientName"` // Name of the ingredient to replace, as the recipe writes it
+	Option         int    `json:"option"`         // Number of the substitution option to use
+}
+
+// SubstitutionRequest is the request body for making a substituted copy of a recipe.
+type SubstitutionRequest struct {
+	Swaps      []SubstitutionSwap `json:"swaps"`      // The substitutions to make
+	RecipeName string             `json:"recipeName"` // Name of the copy; defaults to the recipe's name with "(with substitutions)"
+	Save       bool               `json:"save"`       // Save the copy as a new recipe instead of only previewing it
+}
+
+// Validate checks if the SubstitutionRequest has at least one swap.
+// It returns an error if any required field is missing or invalid.
+func (sr *SubstitutionRequest) Validate() error {
+	if len(sr.Swaps) == 0 {
+		return ErrMissingRequiredField("swaps")
+	}
+	for _, swap := range sr.Swaps {
+		if swap.IngredientName == "" {
+			return ErrMissingRequiredField("swaps.ingredientName")
+		}
+		if swap.Option < 0 {
+			return ErrInvalidField("swaps.option")
+		}
+	}
+	return nil
+}
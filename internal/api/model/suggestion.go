@@ -0,0 +1,38 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+// ExpiringUse is how much of a soon-to-expire pantry item a recipe, or a combination of recipes, would use.
+type ExpiringUse struct {
+	PantryItemId   int     `json:"pantryItemId"`   // Foreign key to the pantry item
+	IngredientName string  `json:"ingredientName"` // Name of the pantry item
+	ExpiryDate     Date    `json:"expiryDate"`     // Date the item should be used by
+	Used           float64 `json:"used"`           // Amount used, in the pantry item's unit
+	Unit           string  `json:"unit"`           // Unit of the pantry item
+	Share          float64 `json:"share"`          // Fraction of the item's quantity used, from 0 to 1
+}
+
+// UseSoonSuggestion is a recipe that uses up pantry items that expire soon.
+type UseSoonSuggestion struct {
+	RecipeId      int           `json:"recipeId"`      // Foreign key to the recipe
+	RecipeName    string        `json:"recipeName"`    // Name of the recipe
+	ExpiringUsed  int           `json:"expiringUsed"`  // Number of soon-to-expire items the recipe uses
+	ExpiringShare float64       `json:"expiringShare"` // Sum of the shares of the soon-to-expire items used
+	Coverage      float64       `json:"coverage"`      // Fraction of the recipe's ingredients the pantry covers, from 0 to 1
+	Missing       int           `json:"missing"`       // Number of ingredients the pantry doesn't fully cover
+	Expiring      []ExpiringUse `json:"expiring"`      // The soon-to-expire items used
+}
+
+// UseSoonCombination is two or three recipes that together use up the most soon-to-expire stock.
+type UseSoonCombination struct {
+	Recipes       []ShoppingListSource `json:"recipes"`       // The recipes in the combination
+	ExpiringUsed  int                  `json:"expiringUsed"`  // Number of soon-to-expire items the recipes use
+	ExpiringShare float64              `json:"expiringShare"` // Sum of the shares of the soon-to-expire items used
+	Expiring      []ExpiringUse        `json:"expiring"`      // The soon-to-expire items used
+}
+
+// UseSoonSuggestions is the response of the use-soon suggestions endpoint.
+type UseSoonSuggestions struct {
+	Expiring    []PantryItem        `json:"expiring"`              // Pantry items that expire within the window
+	Recipes     []UseSoonSuggestion `json:"recipes"`               // Recipes that use them, best first
+	Combination *UseSoonCombination `json:"combination,omitempty"` // Best combination of recipes, if one beats the best single recipe
+}
@@ -0,0 +1,24 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"strings"
+)
+
+// NormalizeTags lowercases and trims recipe tags and drops blanks and duplicates, keeping the first occurrence order.
+// Tags are compared case-insensitively, so "Vegetarian" and "vegetarian " are the same tag.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
@@ -0,0 +1,214 @@
+// Package model provides data structures and error types for the recipe generator application.
+package model
+
+import (
+	"math"
+)
+
+// Shapes of baking pans.
+const (
+	PanShapeRectangular = "rectangular" // Rectangular or square pans, such as a 9x13 or 8x8, and loaf pans
+	PanShapeRound       = "round"       // Round pans, such as a 9-inch cake pan
+)
+
+// Units pan dimensions are given in.
+const (
+	PanUnitInch       = "in"
+	PanUnitCentimetre = "cm"
+)
+
+// centimetresPerInch converts pan dimensions given in centimetres to inches.
+const centimetresPerInch = 2.54
+
+// Pan is the size and number of the pans a recipe is baked in, such as one 9x13 pan or two 9-inch rounds.
+type Pan struct {
+	Shape    string  `json:"shape"`              // One of the PanShape constants
+	Length   float64 `json:"length,omitempty"`   // Length of a rectangular pan
+	Width    float64 `json:"width,omitempty"`    // Width of a rectangular pan
+	Diameter float64 `json:"diameter,omitempty"` // Diameter of a round pan
+	Depth    float64 `json:"depth,omitempty"`    // Optional depth of the pan, used to scale by volume
+	Count    int     `json:"count,omitempty"`    // Number of pans, 1 if not set
+	Unit     string  `json:"unit,omitempty"`     // One of the PanUnit constants, inches if not set
+}
+
+// Validate checks if the Pan instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (p *Pan) Validate() error {
+	switch p.Shape {
+	case "":
+		return ErrMissingRequiredField("pan.shape")
+	case PanShapeRectangular:
+		if p.Length <= 0 {
+			return ErrInvalidField("pan.length")
+		}
+		if p.Width <= 0 {
+			return ErrInvalidField("pan.width")
+		}
+	case PanShapeRound:
+		if p.Diameter <= 0 {
+			return ErrInvalidField("pan.diameter")
+		}
+	default:
+		return ErrInvalidField("pan.shape")
+	}
+	if p.Depth < 0 {
+		return ErrInvalidField("pan.depth")
+	}
+	if p.Count < 0 {
+		return ErrInvalidField("pan.count")
+	}
+	if p.Unit != "" && p.Unit != PanUnitInch && p.Unit != PanUnitCentimetre {
+		return ErrInvalidField("pan.unit")
+	}
+	return nil
+}
+
+// Pans returns the number of pans, which is 1 when Count is not set.
+func (p Pan) Pans() int {
+	if p.Count <= 0 {
+		return 1
+	}
+	return p.Count
+}
+
+// AreaPerPan returns the base area of one pan in square inches.
+func (p Pan) AreaPerPan() float64 {
+	scale := p.inches()
+	if p.Shape == PanShapeRound {
+		radius := p.Diameter * scale / 2
+		return math.Pi * radius * radius
+	}
+	return p.Length * scale * p.Width * scale
+}
+
+// Area returns the base area of all the pans in square inches.
+func (p Pan) Area() float64 {
+	return p.AreaPerPan() * float64(p.Pans())
+}
+
+// DepthInches returns the depth of the pan in inches, or 0 if it is not known.
+func (p Pan) DepthInches() float64 {
+	return p.Depth * p.inches()
+}
+
+// Volume returns the volume of all the pans in cubic inches, or 0 if the depth is not known.
+func (p Pan) Volume() float64 {
+	return p.Area() * p.DepthInches()
+}
+
+// inches returns the factor that converts the pan's dimensions to inches.
+func (p Pan) inches() float64 {
+	if p.Unit == PanUnitCentimetre {
+		return 1 / centimetresPerInch
+	}
+	return 1
+}
+
+// RecipeYield is what a recipe makes when it isn't only measured in servings, such as 24 cookies, 2 loaves
+// or one 9x13 pan of brownies.
+type RecipeYield struct {
+	Quantity float64 `json:"quantity"`      // How many of the unit the recipe makes
+	Unit     string  `json:"unit"`          // What the recipe makes, such as "cookies", "loaves" or "pans"
+	Pan      *Pan    `json:"pan,omitempty"` // The pans the recipe is baked in, if any
+}
+
+// Validate checks if the RecipeYield instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (y *RecipeYield) Validate() error {
+	if y.Quantity <= 0 {
+		return ErrInvalidField("yield.quantity")
+	}
+	if y.Unit == "" {
+		return ErrMissingRequiredField("yield.unit")
+	}
+	if y.Pan != nil {
+		return y.Pan.Validate()
+	}
+	return nil
+}
+
+// Scaling modes of a ScaleRequest.
+const (
+	ScaleModeServings = "servings" // Scale to a number of servings
+	ScaleModeYield    = "yield"    // Scale to a quantity of the recipe's yield, such as 36 cookies
+	ScaleModePan      = "pan"      // Scale to fit different pans
+)
+
+// Bases a pan scaling factor can be worked out on.
+const (
+	ScaleBasisArea   = "area"   // Ratio of the pans' base areas, keeping the batter the same depth
+	ScaleBasisVolume = "volume" // Ratio of the pans' volumes, filling them to the same level
+)
+
+// ScaleRequest asks for a recipe scaled by exactly one of servings, yield quantity or pans.
+type ScaleRequest struct {
+	Servings int     `json:"servings,omitempty"` // Servings to make
+	Yield    float64 `json:"yield,omitempty"`    // Quantity of the recipe's yield unit to make
+	Pan      *Pan    `json:"pan,omitempty"`      // Pans to bake in
+	Basis    string  `json:"basis,omitempty"`    // Optional ScaleBasis for pans; volume when both pans have a depth, otherwise area
+}
+
+// Mode returns the ScaleMode the request asks for.
+func (s *ScaleRequest) Mode() string {
+	switch {
+	case s.Pan != nil:
+		return ScaleModePan
+	case s.Yield > 0:
+		return ScaleModeYield
+	default:
+		return ScaleModeServings
+	}
+}
+
+// Validate checks if the ScaleRequest instance has all required fields properly set.
+// It returns an error if any required field is missing or invalid.
+func (s *ScaleRequest) Validate() error {
+	set := 0
+	if s.Servings != 0 {
+		set++
+	}
+	if s.Yield != 0 {
+		set++
+	}
+	if s.Pan != nil {
+		set++
+	}
+
+	if set == 0 {
+		return ErrMissingRequiredField("servings, yield or pan")
+	}
+	if set > 1 {
+		return ErrInvalidField("only one of servings, yield or pan")
+	}
+	if s.Servings < 0 {
+		return ErrInvalidField("servings")
+	}
+	if s.Yield < 0 {
+		return ErrInvalidField("yield")
+	}
+	if s.Pan != nil {
+		if err := s.Pan.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.Basis != "" && s.Basis != ScaleBasisArea && s.Basis != ScaleBasisVolume {
+		return ErrInvalidField("basis")
+	}
+	return nil
+}
+
+// BakeTimeRange is a suggested range of baking times in minutes.
+type BakeTimeRange struct {
+	MinMinutes int `json:"minMinutes"` // Start checking for doneness at this time
+	MaxMinutes int `json:"maxMinutes"` // Likely done by this time
+}
+
+// ScaledRecipe is a recipe scaled by a ScaleRequest, with how it was scaled.
+type ScaledRecipe struct {
+	Recipe   *Recipe        `json:"recipe"`             // The recipe with its ingredients, servings and yield scaled
+	Mode     string         `json:"mode"`               // One of the ScaleMode constants
+	Factor   float64        `json:"factor"`             // What the ingredient amounts were multiplied by
+	Basis    string         `json:"basis,omitempty"`    // One of the ScaleBasis constants when scaling by pan
+	BakeTime *BakeTimeRange `json:"bakeTime,omitempty"` // Suggested baking time in the new pans, if the recipe has a cook time
+	Notes    []string       `json:"notes"`              // Advice about the scaled recipe
+}
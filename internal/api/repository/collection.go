@@ -0,0 +1,532 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// CollectionRepository handles database operations related to collections.
+// It manages the collections table along with the recipes in each collection and who it is shared with.
+type CollectionRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewCollectionRepository creates a new instance of CollectionRepository.
+// It requires a database connection pool to perform database operations.
+func NewCollectionRepository(pool *pgxpool.Pool) *CollectionRepository {
+	return &CollectionRepository{ConnectionPool: pool}
+}
+
+// Insert adds a new collection to the database.
+// Returns the inserted collection with its ID populated, or an error if the insertion fails.
+func (cr *CollectionRepository) Insert(ctx context.Context, collection *model.Collection) (*model.Collection, error) {
+	log.Printf("Starting database insertion for collection: %s", collection.CollectionName)
+
+	query := `
+		INSERT INTO collections (
+			owner_id, collection_name, description,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) RETURNING id`
+
+	err := cr.ConnectionPool.QueryRow(
+		ctx,
+		query,
+		collection.OwnerID,
+		collection.CollectionName,
+		collection.Description,
+		collection.CreatedBy,
+		collection.CreatedDate,
+		collection.UpdatedBy,
+		collection.UpdatedDate,
+	).Scan(&collection.ID)
+
+	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			return nil, model.ErrAlreadyExists("collection")
+		}
+		log.Printf("Error inserting collection into database: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Successfully inserted collection with ID: %d", collection.ID)
+	return collection, nil
+}
+
+// Get retrieves a collection by its ID.
+// NOTE: The returned collection does not have its recipes or shares set.
+// Returns model.ErrNotFound if the collection does not exist.
+func (cr *CollectionRepository) Get(ctx context.Context, collectionID int) (*model.Collection, error) {
+	log.Printf("Retrieving collection with ID: %d from database.", collectionID)
+
+	query := `
+		SELECT id, owner_id, collection_name, COALESCE(description, ''),
+			created_by, created_date, updated_by, updated_date
+		FROM collections
+		WHERE id = $1
+	`
+
+	var collection model.Collection
+
+	err := cr.ConnectionPool.QueryRow(ctx, query, collectionID).Scan(
+		&collection.ID,
+		&collection.OwnerID,
+		&collection.CollectionName,
+		&collection.Description,
+		&collection.CreatedBy,
+		&collection.CreatedDate,
+		&collection.UpdatedBy,
+		&collection.UpdatedDate,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("collection")
+	}
+	if err != nil {
+		log.Printf("Error retrieving collection: %v", err)
+		return nil, err
+	}
+
+	return &collection, nil
+}
+
+// GetPermission returns the access level a user has on a collection: owner, edit, read,
+// or an empty string if the collection is not shared with them.
+// Returns model.ErrNotFound if the collection does not exist.
+func (cr *CollectionRepository) GetPermission(ctx context.Context, collectionID int, userID int) (string, error) {
+	query := `
+		SELECT CASE WHEN c.owner_id = $2 THEN 'owner' ELSE COALESCE(s.permission, '') END
+		FROM collections c
+		LEFT JOIN collection_shares s ON s.collection_id = c.id AND s.user_id = $2
+		WHERE c.id = $1
+	`
+
+	var permission string
+
+	err := cr.ConnectionPool.QueryRow(ctx, query, collectionID, userID).Scan(&permission)
+	if err == pgx.ErrNoRows {
+		return "", model.ErrNotFound("collection")
+	}
+	if err != nil {
+		log.Printf("Error retrieving collection permission: %v", err)
+		return "", err
+	}
+
+	return permission, nil
+}
+
+// ListForUser retrieves every collection a user owns or has been shared, with the user's permission set.
+// Returns the collections ordered by name and an error if the retrieval fails.
+func (cr *CollectionRepository) ListForUser(ctx context.Context, userID int) ([]model.Collection, error) {
+	log.Printf("Retrieving collections for user with ID: %d", userID)
+
+	connection, err := cr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `
+		SELECT c.id, c.owner_id, c.collection_name, COALESCE(c.description, ''),
+			CASE WHEN c.owner_id = $1 THEN 'owner' ELSE s.permission END,
+			c.created_by, c.created_date, c.updated_by, c.updated_date
+		FROM collections c
+		LEFT JOIN collection_shares s ON s.collection_id = c.id AND s.user_id = $1
+		WHERE c.owner_id = $1 OR s.user_id = $1
+		ORDER BY c.collection_name
+	`
+
+	result, err := connection.Query(ctx, query, userID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	collections := []model.Collection{}
+
+	for result.Next() {
+		var collection model.Collection
+
+		err := result.Scan(
+			&collection.ID,
+			&collection.OwnerID,
+			&collection.CollectionName,
+			&collection.Description,
+			&collection.Permission,
+			&collection.CreatedBy,
+			&collection.CreatedDate,
+			&collection.UpdatedBy,
+			&collection.UpdatedDate,
+		)
+		if err != nil {
+			log.Printf("Error scanning collection: %v", err)
+			return nil, err
+		}
+
+		collections = append(collections, collection)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving collections: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return collections, nil
+}
+
+// Update saves a collection's name and description.
+// Returns model.ErrNotFound if the collection does not exist.
+func (cr *CollectionRepository) Update(ctx context.Context, collection *model.Collection) error {
+	log.Printf("Updating collection with ID: %d", collection.ID)
+
+	query := `
+		UPDATE collections
+		SET collection_name = $2, description = $3, updated_by = $4, updated_date = $5
+		WHERE id = $1
+	`
+
+	tag, err := cr.ConnectionPool.Exec(
+		ctx,
+		query,
+		collection.ID,
+		collection.CollectionName,
+		collection.Description,
+		collection.UpdatedBy,
+		collection.UpdatedDate,
+	)
+	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			return model.ErrAlreadyExists("collection")
+		}
+		log.Printf("Error updating collection: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("collection")
+	}
+
+	return nil
+}
+
+// Delete removes a collection. Its recipe entries and shares are removed by the cascading foreign keys.
+// Returns model.ErrNotFound if the collection does not exist.
+func (cr *CollectionRepository) Delete(ctx context.Context, collectionID int) error {
+	log.Printf("Deleting collection with ID: %d", collectionID)
+
+	tag, err := cr.ConnectionPool.Exec(ctx, `DELETE FROM collections WHERE id = $1`, collectionID)
+	if err != nil {
+		log.Printf("Error deleting collection: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("collection")
+	}
+
+	return nil
+}
+
+// GetRecipes retrieves the recipe entries of a collection in position order.
+// NOTE: The returned entries do not have their Recipe set; see RecipeRepository.GetByIds.
+func (cr *CollectionRepository) GetRecipes(ctx context.Context, collectionID int) ([]model.CollectionRecipe, error) {
+	log.Printf("Retrieving recipes for collection with ID: %d", collectionID)
+
+	connection, err := cr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `
+		SELECT recipe_id, position, COALESCE(notes, '')
+		FROM collection_recipes
+		WHERE collection_id = $1
+		ORDER BY position
+	`
+
+	result, err := connection.Query(ctx, query, collectionID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	entries := []model.CollectionRecipe{}
+
+	for result.Next() {
+		var entry model.CollectionRecipe
+
+		if err := result.Scan(&entry.RecipeId, &entry.Position, &entry.Notes); err != nil {
+			log.Printf("Error scanning collection recipe: %v", err)
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving collection recipes: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return entries, nil
+}
+
+// AddRecipe appends a recipe to the end of a collection.
+// Returns the entry with its position set, model.ErrAlreadyExists if the recipe is already in the
+// collection, or model.ErrNotFound if the recipe does not exist.
+func (cr *CollectionRepository) AddRecipe(ctx context.Context, collectionID int, entry *model.CollectionRecipe, userID int) (*model.CollectionRecipe, error) {
+	log.Printf("Adding recipe %d to collection %d", entry.RecipeId, collectionID)
+
+	query := `
+		INSERT INTO collection_recipes (
+			collection_id, recipe_id, position, notes,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			$1, $2,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM collection_recipes WHERE collection_id = $1),
+			$3, $4, $5, $4, $5
+		) RETURNING position`
+
+	err := cr.ConnectionPool.QueryRow(ctx, query, collectionID, entry.RecipeId, entry.Notes, userID, time.Now()).Scan(&entry.Position)
+	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			return nil, model.ErrAlreadyExists("recipe in collection")
+		}
+		if isPgError(err, foreignKeyViolation) {
+			return nil, model.ErrNotFound("recipe")
+		}
+		log.Printf("Error adding recipe to collection: %v", err)
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// UpdateRecipeNotes replaces the notes on a recipe entry in a collection.
+// Returns model.ErrNotFound if the recipe is not in the collection.
+func (cr *CollectionRepository) UpdateRecipeNotes(ctx context.Context, collectionID int, recipeID int, notes string, userID int) error {
+	log.Printf("Updating notes for recipe %d in collection %d", recipeID, collectionID)
+
+	query := `
+		UPDATE collection_recipes
+		SET notes = $3, updated_by = $4, updated_date = $5
+		WHERE collection_id = $1 AND recipe_id = $2
+	`
+
+	tag, err := cr.ConnectionPool.Exec(ctx, query, collectionID, recipeID, notes, userID, time.Now())
+	if err != nil {
+		log.Printf("Error updating collection recipe notes: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("recipe in collection")
+	}
+
+	return nil
+}
+
+// RemoveRecipe removes a recipe from a collection and closes the gap it leaves in the positions.
+// Returns model.ErrNotFound if the recipe is not in the collection.
+func (cr *CollectionRepository) RemoveRecipe(ctx context.Context, collectionID int, recipeID int) error {
+	log.Printf("Removing recipe %d from collection %d", recipeID, collectionID)
+
+	tx, err := cr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	var position int
+
+	err = tx.QueryRow(
+		ctx,
+		`DELETE FROM collection_recipes WHERE collection_id = $1 AND recipe_id = $2 RETURNING position`,
+		collectionID,
+		recipeID,
+	).Scan(&position)
+
+	if err == pgx.ErrNoRows {
+		return model.ErrNotFound("recipe in collection")
+	}
+	if err != nil {
+		log.Printf("Error removing recipe from collection: %v", err)
+		return err
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`UPDATE collection_recipes SET position = position - 1 WHERE collection_id = $1 AND position > $2`,
+		collectionID,
+		position,
+	)
+	if err != nil {
+		log.Printf("Error renumbering collection recipes: %v", err)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Reorder sets the positions of a collection's recipes to the order of recipeIDs.
+// recipeIDs must contain every recipe in the collection exactly once; otherwise
+// model.ErrInvalidField is returned and nothing is changed.
+func (cr *CollectionRepository) Reorder(ctx context.Context, collectionID int, recipeIDs []int, userID int) error {
+	log.Printf("Reordering %d recipes in collection %d", len(recipeIDs), collectionID)
+
+	tx, err := cr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	// lock the entries so a concurrent add or remove can't slip in between the check and the update.
+	rows, err := tx.Query(ctx, `SELECT recipe_id FROM collection_recipes WHERE collection_id = $1 FOR UPDATE`, collectionID)
+	if err != nil {
+		log.Printf("Error locking collection recipes: %v", err)
+		return err
+	}
+
+	current := make(map[int]bool)
+	for rows.Next() {
+		var recipeID int
+		if err := rows.Scan(&recipeID); err != nil {
+			rows.Close()
+			return err
+		}
+		current[recipeID] = true
+	}
+	rows.Close()
+
+	if rows.Err() != nil {
+		log.Printf("Error reading collection recipes: %v", rows.Err())
+		return rows.Err()
+	}
+
+	if len(recipeIDs) != len(current) {
+		return model.ErrInvalidField("recipeIds")
+	}
+
+	seen := make(map[int]bool, len(recipeIDs))
+	for _, recipeID := range recipeIDs {
+		if !current[recipeID] || seen[recipeID] {
+			return model.ErrInvalidField("recipeIds")
+		}
+		seen[recipeID] = true
+	}
+
+	query := `
+		UPDATE collection_recipes cr
+		SET position = o.position, updated_by = $3, updated_date = $4
+		FROM unnest($2::int[]) WITH ORDINALITY AS o(recipe_id, position)
+		WHERE cr.collection_id = $1 AND cr.recipe_id = o.recipe_id
+	`
+
+	if _, err := tx.Exec(ctx, query, collectionID, recipeIDs, userID, time.Now()); err != nil {
+		log.Printf("Error reordering collection recipes: %v", err)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetShares retrieves the users a collection is shared with.
+func (cr *CollectionRepository) GetShares(ctx context.Context, collectionID int) ([]model.CollectionShare, error) {
+	log.Printf("Retrieving shares for collection with ID: %d", collectionID)
+
+	connection, err := cr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `SELECT user_id, permission FROM collection_shares WHERE collection_id = $1 ORDER BY user_id`
+
+	result, err := connection.Query(ctx, query, collectionID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	shares := []model.CollectionShare{}
+
+	for result.Next() {
+		var share model.CollectionShare
+
+		if err := result.Scan(&share.UserId, &share.Permission); err != nil {
+			log.Printf("Error scanning collection share: %v", err)
+			return nil, err
+		}
+
+		shares = append(shares, share)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving collection shares: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return shares, nil
+}
+
+// Share grants a user access to a collection, replacing any permission they already had.
+// Returns model.ErrNotFound if the user does not exist.
+func (cr *CollectionRepository) Share(ctx context.Context, collectionID int, share *model.CollectionShare, sharedBy int) error {
+	log.Printf("Sharing collection %d with user %d as %s", collectionID, share.UserId, share.Permission)
+
+	query := `
+		INSERT INTO collection_shares (
+			collection_id, user_id, permission, created_by, created_date, updated_by, updated_date
+		) VALUES (
+			$1, $2, $3, $4, $5, $4, $5
+		)
+		ON CONFLICT (collection_id, user_id)
+		DO UPDATE SET permission = EXCLUDED.permission, updated_by = EXCLUDED.updated_by, updated_date = EXCLUDED.updated_date
+	`
+
+	_, err := cr.ConnectionPool.Exec(ctx, query, collectionID, share.UserId, share.Permission, sharedBy, time.Now())
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return model.ErrNotFound("user")
+		}
+		log.Printf("Error sharing collection: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Unshare revokes a user's access to a collection.
+// Returns model.ErrNotFound if the collection was not shared with the user.
+func (cr *CollectionRepository) Unshare(ctx context.Context, collectionID int, userID int) error {
+	log.Printf("Unsharing collection %d with user %d", collectionID, userID)
+
+	tag, err := cr.ConnectionPool.Exec(ctx, `DELETE FROM collection_shares WHERE collection_id = $1 AND user_id = $2`, collectionID, userID)
+	if err != nil {
+		log.Printf("Error unsharing collection: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("collection share")
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// CookbookRepository handles database operations related to PDF cookbook jobs.
+type CookbookRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewCookbookRepository creates a new instance of CookbookRepository.
+// It requires a database connection pool to perform database operations.
+func NewCookbookRepository(pool *pgxpool.Pool) *CookbookRepository {
+	return &CookbookRepository{ConnectionPool: pool}
+}
+
+// Insert adds a new cookbook job to the database.
+// Returns the job with its ID and created date populated.
+func (cr *CookbookRepository) Insert(ctx context.Context, job *model.CookbookJob) (*model.CookbookJob, error) {
+	log.Printf("Starting database insertion for cookbook: %s", job.Title)
+
+	query := `
+		INSERT INTO cookbook_jobs (
+			user_id, title, collection_id, tags, layout, status, created_by, created_date, updated_by, updated_date
+		) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $1, $7, $1, $7)
+		RETURNING id, created_date`
+
+	tags := job.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	err := cr.ConnectionPool.QueryRow(
+		ctx,
+		query,
+		job.UserId,
+		job.Title,
+		job.CollectionId,
+		tags,
+		job.Layout,
+		job.Status,
+		time.Now(),
+	).Scan(&job.ID, &job.CreatedDate)
+
+	if err != nil {
+		log.Printf("Error inserting cookbook job into database: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Successfully inserted cookbook job with ID: %d", job.ID)
+	return job, nil
+}
+
+// Get retrieves a cookbook job by ID.
+// Returns model.ErrNotFound if the job does not exist.
+func (cr *CookbookRepository) Get(ctx context.Context, jobID int) (*model.CookbookJob, error) {
+	query := `
+		SELECT id, user_id, title, COALESCE(collection_id, 0), tags, layout, status, recipe_count, page_count,
+			COALESCE(error, ''), COALESCE(filepath, ''), size_bytes, created_date, completed_date
+		FROM cookbook_jobs
+		WHERE id = $1`
+
+	var job model.CookbookJob
+
+	err := cr.ConnectionPool.QueryRow(ctx, query, jobID).Scan(
+		&job.ID,
+		&job.UserId,
+		&job.Title,
+		&job.CollectionId,
+		&job.Tags,
+		&job.Layout,
+		&job.Status,
+		&job.RecipeCount,
+		&job.PageCount,
+		&job.Error,
+		&job.Filepath,
+		&job.SizeBytes,
+		&job.CreatedDate,
+		&job.CompletedDate,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("cookbook")
+	}
+	if err != nil {
+		log.Printf("Error retrieving cookbook job %d: %v", jobID, err)
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Update records a cookbook job's progress: its status, and once it finishes, its counts, file and error.
+// A job that is done or failed gets its completed date set.
+func (cr *CookbookRepository) Update(ctx context.Context, job *model.CookbookJob) error {
+	query := `
+		UPDATE cookbook_jobs
+		SET status = $2, recipe_count = $3, page_count = $4, error = NULLIF($5, ''), filepath = NULLIF($6, ''),
+			size_bytes = $7, updated_by = $8, updated_date = $9, completed_date = $10
+		WHERE id = $1`
+
+	now := time.Now()
+	if job.Status == model.CookbookStatusDone || job.Status == model.CookbookStatusFailed {
+		job.CompletedDate = &now
+	}
+
+	_, err := cr.ConnectionPool.Exec(
+		ctx,
+		query,
+		job.ID,
+		job.Status,
+		job.RecipeCount,
+		job.PageCount,
+		job.Error,
+		job.Filepath,
+		job.SizeBytes,
+		job.UserId,
+		now,
+		job.CompletedDate,
+	)
+
+	if err != nil {
+		log.Printf("Error updating cookbook job %d: %v", job.ID, err)
+		return err
+	}
+
+	return nil
+}
+
+// FailUnfinished marks every cookbook job that is pending or running as failed with a reason. It is used when
+// the server starts, as the jobs the last run left unfinished will never be picked up.
+// Returns the number of jobs marked as failed.
+func (cr *CookbookRepository) FailUnfinished(ctx context.Context, reason string) (int64, error) {
+	query := `
+		UPDATE cookbook_jobs
+		SET status = $1, error = $2, updated_date = $3, completed_date = $3
+		WHERE status IN ($4, $5)`
+
+	tag, err := cr.ConnectionPool.Exec(
+		ctx,
+		query,
+		model.CookbookStatusFailed,
+		reason,
+		time.Now(),
+		model.CookbookStatusPending,
+		model.CookbookStatusRunning,
+	)
+	if err != nil {
+		log.Printf("Error failing unfinished cookbook jobs: %v", err)
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,459 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// CookingSessionRepository handles database operations related to cooking sessions and their timers.
+//
+// Every change to a session locks the session's row for the rest of its transaction and counts as activity,
+// so changes made from several devices at once are applied one at a time and keep the session from expiring.
+type CookingSessionRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewCookingSessionRepository creates a new instance of CookingSessionRepository.
+// It requires a database connection pool to perform database operations.
+func NewCookingSessionRepository(pool *pgxpool.Pool) *CookingSessionRepository {
+	return &CookingSessionRepository{ConnectionPool: pool}
+}
+
+// cookingSessionColumns is the select list scanned by scanCookingSession.
+const cookingSessionColumns = `
+	id, recipe_id, COALESCE(user_id, 0), COALESCE(household_id, 0), current_step,
+	created_by, created_date, updated_by, updated_date
+`
+
+// cookingTimerColumns is the select list scanned by scanCookingTimer.
+const cookingTimerColumns = `
+	id, cooking_session_id, timer_name, COALESCE(step, 0), duration_seconds, status, remaining_seconds, ends_at,
+	created_by, created_date, updated_by, updated_date
+`
+
+// Insert adds a new cooking session to the database.
+// Returns the session with its ID populated.
+func (cr *CookingSessionRepository) Insert(ctx context.Context, session *model.CookingSession) (*model.CookingSession, error) {
+	log.Printf("Starting database insertion for cooking session of recipe: %d", session.RecipeId)
+
+	query := `
+		INSERT INTO cooking_sessions (
+			recipe_id, user_id, household_id, current_step, created_by, created_date, updated_by, updated_date
+		) VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7, $8)
+		RETURNING id`
+
+	err := cr.ConnectionPool.QueryRow(
+		ctx,
+		query,
+		session.RecipeId,
+		session.UserId,
+		session.HouseholdId,
+		session.CurrentStep,
+		session.CreatedBy,
+		session.CreatedDate,
+		session.UpdatedBy,
+		session.UpdatedDate,
+	).Scan(&session.ID)
+
+	if err != nil {
+		log.Printf("Error inserting cooking session into database: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Successfully inserted cooking session with ID: %d", session.ID)
+	return session, nil
+}
+
+// Get retrieves a cooking session by ID with its timers, oldest first.
+// Returns model.ErrNotFound if the session does not exist.
+func (cr *CookingSessionRepository) Get(ctx context.Context, sessionID int) (*model.CookingSession, error) {
+	query := `SELECT ` + cookingSessionColumns + ` FROM cooking_sessions WHERE id = $1`
+
+	session, err := scanCookingSession(cr.ConnectionPool.QueryRow(ctx, query, sessionID))
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("cooking session")
+	}
+	if err != nil {
+		log.Printf("Error retrieving cooking session: %v", err)
+		return nil, err
+	}
+
+	session.Timers, err = cr.getTimers(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// ListForScope retrieves the cooking sessions of a user or household with their timers, newest first,
+// so another device can join one.
+func (cr *CookingSessionRepository) ListForScope(ctx context.Context, scope model.MealPlanScope) ([]model.CookingSession, error) {
+	connection, err := cr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	column, scopeID := "user_id", scope.UserId
+	if scope.HouseholdId != 0 {
+		column, scopeID = "household_id", scope.HouseholdId
+	}
+
+	query := `
+		SELECT ` + cookingSessionColumns + `
+		FROM cooking_sessions
+		WHERE ` + column + ` = $1
+		ORDER BY created_date DESC, id DESC
+	`
+
+	result, err := connection.Query(ctx, query, scopeID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	sessions := []model.CookingSession{}
+
+	for result.Next() {
+		session, err := scanCookingSession(result)
+		if err != nil {
+			log.Printf("Error scanning cooking session: %v", err)
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving cooking sessions: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	result.Close()
+
+	for i := range sessions {
+		sessions[i].Timers, err = cr.getTimers(ctx, sessions[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sessions, nil
+}
+
+// SetStep moves a cooking session to another step of its recipe.
+// Returns model.ErrNotFound if the session does not exist.
+func (cr *CookingSessionRepository) SetStep(ctx context.Context, sessionID int, step int, userID int) error {
+	log.Printf("Moving cooking session %d to step %d", sessionID, step)
+
+	query := `
+		UPDATE cooking_sessions
+		SET current_step = $2, updated_by = $3, updated_date = $4
+		WHERE id = $1`
+
+	tag, err := cr.ConnectionPool.Exec(ctx, query, sessionID, step, userID, time.Now())
+	if err != nil {
+		log.Printf("Error updating cooking session step: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("cooking session")
+	}
+
+	return nil
+}
+
+// InsertTimer adds a timer to a cooking session.
+// Returns the timer with its ID populated, or model.ErrNotFound if the session does not exist.
+func (cr *CookingSessionRepository) InsertTimer(ctx context.Context, timer *model.CookingTimer) (*model.CookingTimer, error) {
+	log.Printf("Starting timer %s in cooking session %d", timer.TimerName, timer.CookingSessionId)
+
+	tx, err := cr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	if err := touchCookingSession(ctx, tx, timer.CookingSessionId, timer.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO cooking_session_timers (
+			cooking_session_id, timer_name, step, duration_seconds, status, remaining_seconds, ends_at,
+			created_by, created_date, updated_by, updated_date
+		) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`
+
+	err = tx.QueryRow(
+		ctx,
+		query,
+		timer.CookingSessionId,
+		timer.TimerName,
+		timer.Step,
+		timer.DurationSeconds,
+		timer.Status,
+		timer.RemainingSeconds,
+		timer.EndsAt,
+		timer.CreatedBy,
+		timer.CreatedDate,
+		timer.UpdatedBy,
+		timer.UpdatedDate,
+	).Scan(&timer.ID)
+
+	if err != nil {
+		log.Printf("Error inserting cooking timer into database: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, err
+	}
+
+	return timer, nil
+}
+
+// UpdateTimer applies a change, such as pausing, to a timer of a cooking session and saves it.
+// Changes to a session are applied one at a time, so change sees the timer as the previous change left it
+// and can refuse with model.ErrConflict, such as when two devices pause the same timer at once.
+//
+// Returns the changed timer, model.ErrNotFound if the timer is not in the session, or the error change
+// returned.
+func (cr *CookingSessionRepository) UpdateTimer(ctx context.Context, sessionID int, timerID int, userID int, change func(timer *model.CookingTimer, now time.Time) error) (*model.CookingTimer, error) {
+	log.Printf("Updating timer %d of cooking session %d", timerID, sessionID)
+
+	tx, err := cr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	if err := touchCookingSession(ctx, tx, sessionID, userID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + cookingTimerColumns + ` FROM cooking_session_timers WHERE id = $1 AND cooking_session_id = $2`
+
+	timer, err := scanCookingTimer(tx.QueryRow(ctx, query, timerID, sessionID))
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("cooking timer")
+	}
+	if err != nil {
+		log.Printf("Error retrieving cooking timer: %v", err)
+		return nil, err
+	}
+
+	if err := change(timer, time.Now()); err != nil {
+		return nil, err
+	}
+
+	update := `
+		UPDATE cooking_session_timers
+		SET status = $2, remaining_seconds = $3, ends_at = $4, updated_by = $5, updated_date = $6
+		WHERE id = $1`
+
+	_, err = tx.Exec(ctx, update, timer.ID, timer.Status, timer.RemainingSeconds, timer.EndsAt, timer.UpdatedBy, timer.UpdatedDate)
+	if err != nil {
+		log.Printf("Error updating cooking timer: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, err
+	}
+
+	return timer, nil
+}
+
+// FinishTimer marks a running timer as finished if it has gone off by now.
+// Only one caller can finish a timer, so a timer that is finished from several places at once, or that was
+// paused or cancelled first, is reported once. Finishing a timer doesn't count as activity in the session.
+//
+// Returns the finished timer, or nil if it wasn't running or hasn't gone off yet.
+func (cr *CookingSessionRepository) FinishTimer(ctx context.Context, timerID int, now time.Time) (*model.CookingTimer, error) {
+	query := `
+		UPDATE cooking_session_timers
+		SET status = $3, remaining_seconds = 0, ends_at = NULL, updated_date = $2
+		WHERE id = $1 AND status = $4 AND ends_at <= $2
+		RETURNING ` + cookingTimerColumns
+
+	timer, err := scanCookingTimer(cr.ConnectionPool.QueryRow(ctx, query, timerID, now, model.TimerStatusFinished, model.TimerStatusRunning))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Printf("Error finishing cooking timer: %v", err)
+		return nil, err
+	}
+
+	return timer, nil
+}
+
+// Delete removes a cooking session with its timers.
+// Returns model.ErrNotFound if the session does not exist.
+func (cr *CookingSessionRepository) Delete(ctx context.Context, sessionID int) error {
+	log.Printf("Deleting cooking session with ID: %d", sessionID)
+
+	tag, err := cr.ConnectionPool.Exec(ctx, `DELETE FROM cooking_sessions WHERE id = $1`, sessionID)
+	if err != nil {
+		log.Printf("Error deleting cooking session: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("cooking session")
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the cooking sessions, with their timers, that were last in use before a time: last
+// changed before it, with no timer running until after it.
+// Returns the IDs of the removed sessions.
+func (cr *CookingSessionRepository) DeleteExpired(ctx context.Context, lastActiveBefore time.Time) ([]int, error) {
+	query := `
+		DELETE FROM cooking_sessions s
+		WHERE s.updated_date < $1
+			AND NOT EXISTS (
+				SELECT 1 FROM cooking_session_timers t
+				WHERE t.cooking_session_id = s.id AND t.status = $2 AND t.ends_at >= $1
+			)
+		RETURNING s.id`
+
+	result, err := cr.ConnectionPool.Query(ctx, query, lastActiveBefore, model.TimerStatusRunning)
+	if err != nil {
+		log.Printf("Error deleting expired cooking sessions: %v", err)
+		return nil, err
+	}
+	defer result.Close()
+
+	sessionIDs := []int{}
+
+	for result.Next() {
+		var sessionID int
+		if err := result.Scan(&sessionID); err != nil {
+			log.Printf("Error scanning expired cooking session: %v", err)
+			return nil, err
+		}
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error deleting expired cooking sessions: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return sessionIDs, nil
+}
+
+// private functions
+
+// getTimers retrieves the timers of a cooking session, oldest first.
+func (cr *CookingSessionRepository) getTimers(ctx context.Context, sessionID int) ([]model.CookingTimer, error) {
+	query := `SELECT ` + cookingTimerColumns + ` FROM cooking_session_timers WHERE cooking_session_id = $1 ORDER BY id`
+
+	result, err := cr.ConnectionPool.Query(ctx, query, sessionID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	timers := []model.CookingTimer{}
+
+	for result.Next() {
+		timer, err := scanCookingTimer(result)
+		if err != nil {
+			log.Printf("Error scanning cooking timer: %v", err)
+			return nil, err
+		}
+		timers = append(timers, *timer)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving cooking timers: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return timers, nil
+}
+
+// touchCookingSession locks a cooking session's row until the end of the transaction and records activity
+// in it. Returns model.ErrNotFound if the session does not exist.
+func touchCookingSession(ctx context.Context, tx pgx.Tx, sessionID int, userID int) error {
+	var id int
+
+	query := `UPDATE cooking_sessions SET updated_by = $2, updated_date = $3 WHERE id = $1 RETURNING id`
+
+	err := tx.QueryRow(ctx, query, sessionID, userID, time.Now()).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return model.ErrNotFound("cooking session")
+	}
+	if err != nil {
+		log.Printf("Error locking cooking session: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// scanCookingSession scans a row selected with cookingSessionColumns.
+func scanCookingSession(row pgx.Row) (*model.CookingSession, error) {
+	var session model.CookingSession
+
+	err := row.Scan(
+		&session.ID,
+		&session.RecipeId,
+		&session.UserId,
+		&session.HouseholdId,
+		&session.CurrentStep,
+		&session.CreatedBy,
+		&session.CreatedDate,
+		&session.UpdatedBy,
+		&session.UpdatedDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// scanCookingTimer scans a row selected with cookingTimerColumns.
+func scanCookingTimer(row pgx.Row) (*model.CookingTimer, error) {
+	var timer model.CookingTimer
+
+	err := row.Scan(
+		&timer.ID,
+		&timer.CookingSessionId,
+		&timer.TimerName,
+		&timer.Step,
+		&timer.DurationSeconds,
+		&timer.Status,
+		&timer.RemainingSeconds,
+		&timer.EndsAt,
+		&timer.CreatedBy,
+		&timer.CreatedDate,
+		&timer.UpdatedBy,
+		&timer.UpdatedDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &timer, nil
+}
@@ -0,0 +1,429 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// CookLogRepository handles database operations related to the cook log,
+// the record of when users cooked a recipe and the photos they took.
+type CookLogRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewCookLogRepository creates a new instance of CookLogRepository.
+// It requires a database connection pool to perform database operations.
+func NewCookLogRepository(pool *pgxpool.Pool) *CookLogRepository {
+	return &CookLogRepository{ConnectionPool: pool}
+}
+
+// cookLogColumns is the select list scanned by scanCookLogs.
+const cookLogColumns = `
+	cl.id, cl.recipe_id, r.recipe_name, cl.user_id, cl.cooked_date,
+	COALESCE(cl.servings_made, 0), COALESCE(cl.tweaks, ''), COALESCE(cl.rating, 0),
+	cl.created_by, cl.created_date, cl.updated_by, cl.updated_date
+`
+
+// Insert adds a new cook log entry to the database within a transaction.
+// Returns the inserted entry with its ID populated, or model.ErrNotFound if the recipe does not exist.
+func (cr *CookLogRepository) Insert(ctx context.Context, cookLog *model.CookLog, tx pgx.Tx) (*model.CookLog, error) {
+	log.Printf("Logging recipe %d as cooked by user %d on %s", cookLog.RecipeId, cookLog.UserId, cookLog.CookedDate)
+
+	query := `
+		INSERT INTO cook_logs (
+			recipe_id, user_id, cooked_date, servings_made, tweaks, rating,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			$1, $2, $3, NULLIF($4, 0), NULLIF($5, ''), NULLIF($6, 0), $7, $8, $9, $10
+		) RETURNING id`
+
+	err := tx.QueryRow(
+		ctx,
+		query,
+		cookLog.RecipeId,
+		cookLog.UserId,
+		cookLog.CookedDate.Time,
+		cookLog.ServingsMade,
+		cookLog.Tweaks,
+		cookLog.Rating,
+		cookLog.CreatedBy,
+		cookLog.CreatedDate,
+		cookLog.UpdatedBy,
+		cookLog.UpdatedDate,
+	).Scan(&cookLog.ID)
+
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return nil, model.ErrNotFound("recipe")
+		}
+		log.Printf("Error inserting cook log: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Successfully inserted cook log with ID: %d", cookLog.ID)
+	return cookLog, nil
+}
+
+// Get retrieves a cook log entry, with its photos, by ID.
+// Returns model.ErrNotFound if the entry does not exist.
+func (cr *CookLogRepository) Get(ctx context.Context, cookLogID int) (*model.CookLog, error) {
+	cookLogs, err := cr.queryCookLogs(ctx, `WHERE cl.id = $1`, cookLogID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cookLogs) == 0 {
+		return nil, model.ErrNotFound("cook log")
+	}
+
+	return &cookLogs[0], nil
+}
+
+// Delete removes a cook log entry. Its photo rows are removed by the cascading foreign key.
+// Returns the photos that belonged to the entry so the caller can remove the files,
+// or model.ErrNotFound if the entry does not exist.
+func (cr *CookLogRepository) Delete(ctx context.Context, cookLogID int) ([]model.CookLogPhoto, error) {
+	log.Printf("Deleting cook log with ID: %d", cookLogID)
+
+	photos, err := cr.getPhotosByCookLogIds(ctx, []int{cookLogID})
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := cr.ConnectionPool.Exec(ctx, `DELETE FROM cook_logs WHERE id = $1`, cookLogID)
+	if err != nil {
+		log.Printf("Error deleting cook log: %v", err)
+		return nil, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return nil, model.ErrNotFound("cook log")
+	}
+
+	return photos[cookLogID], nil
+}
+
+// GetByRecipeId retrieves a page of the cook history of a recipe across all users, most recent first.
+// Returns the entries, the total number of entries for the recipe, and an error if the retrieval fails.
+func (cr *CookLogRepository) GetByRecipeId(ctx context.Context, recipeID int, limit int, offset int) ([]model.CookLog, int, error) {
+	log.Printf("Retrieving cook log for recipe %d", recipeID)
+
+	total, err := cr.count(ctx, `SELECT COUNT(*) FROM cook_logs WHERE recipe_id = $1`, recipeID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cookLogs, err := cr.queryCookLogs(
+		ctx,
+		`WHERE cl.recipe_id = $1 ORDER BY cl.cooked_date DESC, cl.id DESC LIMIT $2 OFFSET $3`,
+		recipeID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return cookLogs, total, nil
+}
+
+// GetByUser retrieves a page of a user's cook timeline, most recent first.
+// Returns the entries, the total number of entries for the user, and an error if the retrieval fails.
+func (cr *CookLogRepository) GetByUser(ctx context.Context, userID int, limit int, offset int) ([]model.CookLog, int, error) {
+	log.Printf("Retrieving cook log for user %d", userID)
+
+	total, err := cr.count(ctx, `SELECT COUNT(*) FROM cook_logs WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cookLogs, err := cr.queryCookLogs(
+		ctx,
+		`WHERE cl.user_id = $1 ORDER BY cl.cooked_date DESC, cl.id DESC LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return cookLogs, total, nil
+}
+
+// InsertPhoto records a photo that has been written to disk for a cook log entry.
+// Returns the photo with its ID populated, or an error if the insertion fails.
+func (cr *CookLogRepository) InsertPhoto(ctx context.Context, photo *model.CookLogPhoto, userID int) (*model.CookLogPhoto, error) {
+	log.Printf("Inserting photo %s for cook log %d", photo.OriginalName, photo.CookLogId)
+
+	query := `
+		INSERT INTO cook_log_photos (
+			cook_log_id, filepath, original_name, size_bytes,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $5, $6
+		) RETURNING id`
+
+	err := cr.ConnectionPool.QueryRow(
+		ctx,
+		query,
+		photo.CookLogId,
+		photo.Filepath,
+		photo.OriginalName,
+		photo.SizeBytes,
+		userID,
+		time.Now(),
+	).Scan(&photo.ID)
+
+	if err != nil {
+		log.Printf("Error inserting cook log photo: %v", err)
+		return nil, err
+	}
+
+	return photo, nil
+}
+
+// GetPhoto retrieves a cook log photo by ID.
+// Returns model.ErrNotFound if the photo does not exist.
+func (cr *CookLogRepository) GetPhoto(ctx context.Context, photoID int) (*model.CookLogPhoto, error) {
+	query := `SELECT id, cook_log_id, filepath, original_name, size_bytes FROM cook_log_photos WHERE id = $1`
+
+	var photo model.CookLogPhoto
+
+	err := cr.ConnectionPool.QueryRow(ctx, query, photoID).Scan(
+		&photo.ID,
+		&photo.CookLogId,
+		&photo.Filepath,
+		&photo.OriginalName,
+		&photo.SizeBytes,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("photo")
+	}
+	if err != nil {
+		log.Printf("Error retrieving cook log photo: %v", err)
+		return nil, err
+	}
+
+	return &photo, nil
+}
+
+// MostCookedSince retrieves the recipes a user has cooked most often on or after a date.
+// Returns at most limit recipes, most cooked first.
+func (cr *CookLogRepository) MostCookedSince(ctx context.Context, userID int, since model.Date, limit int) ([]model.CookStat, error) {
+	log.Printf("Retrieving most cooked recipes for user %d since %s", userID, since)
+
+	query := `
+		SELECT cl.recipe_id, r.recipe_name, COUNT(*), MAX(cl.cooked_date)
+		FROM cook_logs cl
+		JOIN recipes r ON r.id = cl.recipe_id
+		WHERE cl.user_id = $1 AND cl.cooked_date >= $2
+		GROUP BY cl.recipe_id, r.recipe_name
+		ORDER BY COUNT(*) DESC, MAX(cl.cooked_date) DESC
+		LIMIT $3
+	`
+
+	return cr.queryCookStats(ctx, query, userID, since.Time, limit)
+}
+
+// NotCookedSince retrieves the recipes a user has cooked before, but not on or after a date.
+// Returns the recipes ordered by how long ago they were last cooked, longest first.
+func (cr *CookLogRepository) NotCookedSince(ctx context.Context, userID int, since model.Date) ([]model.CookStat, error) {
+	log.Printf("Retrieving recipes user %d has not cooked since %s", userID, since)
+
+	query := `
+		SELECT cl.recipe_id, r.recipe_name, COUNT(*), MAX(cl.cooked_date)
+		FROM cook_logs cl
+		JOIN recipes r ON r.id = cl.recipe_id
+		WHERE cl.user_id = $1
+		GROUP BY cl.recipe_id, r.recipe_name
+		HAVING MAX(cl.cooked_date) < $2
+		ORDER BY MAX(cl.cooked_date), cl.recipe_id
+	`
+
+	return cr.queryCookStats(ctx, query, userID, since.Time)
+}
+
+// private functions
+
+// count runs a COUNT(*) query and returns the result.
+func (cr *CookLogRepository) count(ctx context.Context, query string, args ...interface{}) (int, error) {
+	var total int
+
+	if err := cr.ConnectionPool.QueryRow(ctx, query, args...).Scan(&total); err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// queryCookLogs retrieves the cook log entries matching the given WHERE/ORDER BY/LIMIT clause,
+// then attaches their photos with a single additional query.
+func (cr *CookLogRepository) queryCookLogs(ctx context.Context, clause string, args ...interface{}) ([]model.CookLog, error) {
+	connection, err := cr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+
+	query := `SELECT ` + cookLogColumns + ` FROM cook_logs cl JOIN recipes r ON r.id = cl.recipe_id ` + clause
+
+	cookLogs, err := scanCookLogs(ctx, connection, query, args...)
+	// the photo query acquires its own connection.
+	connection.Release()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cookLogs) == 0 {
+		return cookLogs, nil
+	}
+
+	cookLogIDs := make([]int, 0, len(cookLogs))
+	for _, cookLog := range cookLogs {
+		cookLogIDs = append(cookLogIDs, cookLog.ID)
+	}
+
+	photos, err := cr.getPhotosByCookLogIds(ctx, cookLogIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cookLogs {
+		cookLogs[i].Photos = photos[cookLogs[i].ID]
+	}
+
+	return cookLogs, nil
+}
+
+// scanCookLogs runs a query selecting cookLogColumns and scans the rows.
+func scanCookLogs(ctx context.Context, connection *pgxpool.Conn, query string, args ...interface{}) ([]model.CookLog, error) {
+	result, err := connection.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	cookLogs := []model.CookLog{}
+
+	for result.Next() {
+		var cookLog model.CookLog
+
+		err := result.Scan(
+			&cookLog.ID,
+			&cookLog.RecipeId,
+			&cookLog.RecipeName,
+			&cookLog.UserId,
+			&cookLog.CookedDate.Time,
+			&cookLog.ServingsMade,
+			&cookLog.Tweaks,
+			&cookLog.Rating,
+			&cookLog.CreatedBy,
+			&cookLog.CreatedDate,
+			&cookLog.UpdatedBy,
+			&cookLog.UpdatedDate,
+		)
+		if err != nil {
+			log.Printf("Error scanning cook log: %v", err)
+			return nil, err
+		}
+
+		cookLogs = append(cookLogs, cookLog)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving cook logs: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return cookLogs, nil
+}
+
+// getPhotosByCookLogIds retrieves the photos of several cook log entries with a single query.
+func (cr *CookLogRepository) getPhotosByCookLogIds(ctx context.Context, cookLogIDs []int) (map[int][]model.CookLogPhoto, error) {
+	connection, err := cr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `
+		SELECT id, cook_log_id, filepath, original_name, size_bytes
+		FROM cook_log_photos
+		WHERE cook_log_id = ANY($1)
+		ORDER BY id
+	`
+
+	result, err := connection.Query(ctx, query, cookLogIDs)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	photos := make(map[int][]model.CookLogPhoto)
+
+	for result.Next() {
+		var photo model.CookLogPhoto
+
+		err := result.Scan(&photo.ID, &photo.CookLogId, &photo.Filepath, &photo.OriginalName, &photo.SizeBytes)
+		if err != nil {
+			log.Printf("Error scanning cook log photo: %v", err)
+			return nil, err
+		}
+
+		photos[photo.CookLogId] = append(photos[photo.CookLogId], photo)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving cook log photos: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return photos, nil
+}
+
+// queryCookStats runs a query returning recipe_id, recipe_name, count and last cooked date, and scans the rows.
+func (cr *CookLogRepository) queryCookStats(ctx context.Context, query string, args ...interface{}) ([]model.CookStat, error) {
+	connection, err := cr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	result, err := connection.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	stats := []model.CookStat{}
+
+	for result.Next() {
+		var stat model.CookStat
+
+		err := result.Scan(&stat.RecipeId, &stat.RecipeName, &stat.TimesCooked, &stat.LastCookedDate.Time)
+		if err != nil {
+			log.Printf("Error scanning cook stat: %v", err)
+			return nil, err
+		}
+
+		stats = append(stats, stat)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving cook stats: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return stats, nil
+}
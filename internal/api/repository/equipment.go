@@ -0,0 +1,188 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EquipmentRepository handles database operations related to the equipment recipes need and the equipment
+// in users' kitchens.
+type EquipmentRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewEquipmentRepository creates a new instance of EquipmentRepository.
+// It requires a database connection pool to perform database operations.
+func NewEquipmentRepository(pool *pgxpool.Pool) *EquipmentRepository {
+	return &EquipmentRepository{ConnectionPool: pool}
+}
+
+// Insert adds equipment to a recipe within a transaction. Equipment the recipe already needs is ignored.
+// It requires a context, the recipe ID, the normalized equipment, the user adding it, and an active transaction.
+// Returns an error if the insertion fails.
+func (er *EquipmentRepository) Insert(ctx context.Context, recipeID int, equipment []string, createdBy int, tx pgx.Tx) error {
+	log.Printf("Inside of EquipmentRepository.Insert")
+	log.Printf("Inserting %d pieces of equipment for recipe %d", len(equipment), recipeID)
+
+	query := `
+		INSERT INTO recipe_equipment (recipe_id, equipment, created_by, created_date)
+		SELECT $1, equipment, $3, $4 FROM unnest($2::varchar[]) AS equipment
+		ON CONFLICT (recipe_id, equipment) DO NOTHING
+	`
+
+	if _, err := tx.Exec(ctx, query, recipeID, equipment, createdBy, time.Now()); err != nil {
+		log.Printf("Error inserting equipment: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Replace sets the equipment a recipe needs, replacing what it needed before.
+// Returns an error if the update fails.
+func (er *EquipmentRepository) Replace(ctx context.Context, recipeID int, equipment []string, userID int) error {
+	log.Printf("Replacing the equipment of recipe %d with %d pieces", recipeID, len(equipment))
+
+	tx, err := er.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	if _, err := tx.Exec(ctx, `DELETE FROM recipe_equipment WHERE recipe_id = $1`, recipeID); err != nil {
+		log.Printf("Error removing equipment: %v", err)
+		return err
+	}
+
+	if err := er.Insert(ctx, recipeID, equipment, userID, tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetEquipmentByRecipeIds retrieves the equipment of several recipes with a single query.
+// It requires a context and the IDs of the recipes.
+// Returns the equipment grouped by recipe ID in alphabetical order, and an error if the retrieval fails.
+func (er *EquipmentRepository) GetEquipmentByRecipeIds(ctx context.Context, recipeIDs []int) (map[int][]string, error) {
+	log.Printf("Retrieving equipment for %d recipes from database.", len(recipeIDs))
+
+	query := `SELECT recipe_id, equipment FROM recipe_equipment WHERE recipe_id = ANY($1) ORDER BY recipe_id, equipment`
+
+	result, err := er.ConnectionPool.Query(ctx, query, recipeIDs)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	equipment := make(map[int][]string)
+
+	for result.Next() {
+		var recipeID int
+		var name string
+
+		if err := result.Scan(&recipeID, &name); err != nil {
+			log.Printf("Error scanning equipment: %v", err)
+			return nil, err
+		}
+
+		equipment[recipeID] = append(equipment[recipeID], name)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving equipment: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return equipment, nil
+}
+
+// GetKitchen retrieves the equipment in a user's kitchen in alphabetical order.
+// Returns an empty list if the user hasn't set any.
+func (er *EquipmentRepository) GetKitchen(ctx context.Context, userID int) ([]string, error) {
+	query := `SELECT equipment FROM user_equipment WHERE user_id = $1 ORDER BY equipment`
+
+	result, err := er.ConnectionPool.Query(ctx, query, userID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	equipment := []string{}
+
+	for result.Next() {
+		var name string
+		if err := result.Scan(&name); err != nil {
+			log.Printf("Error scanning kitchen equipment: %v", err)
+			return nil, err
+		}
+		equipment = append(equipment, name)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving kitchen equipment: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return equipment, nil
+}
+
+// ReplaceKitchen sets the equipment in a user's kitchen, replacing what it had before.
+// Returns an error if the update fails.
+func (er *EquipmentRepository) ReplaceKitchen(ctx context.Context, userID int, equipment []string) error {
+	log.Printf("Replacing the kitchen equipment of user %d with %d pieces", userID, len(equipment))
+
+	tx, err := er.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_equipment WHERE user_id = $1`, userID); err != nil {
+		log.Printf("Error removing kitchen equipment: %v", err)
+		return err
+	}
+
+	query := `
+		INSERT INTO user_equipment (user_id, equipment, created_date)
+		SELECT $1, equipment, $3 FROM unnest($2::varchar[]) AS equipment
+		ON CONFLICT (user_id, equipment) DO NOTHING
+	`
+
+	if _, err := tx.Exec(ctx, query, userID, equipment, time.Now()); err != nil {
+		log.Printf("Error inserting kitchen equipment: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// private functions
+
+// ownedEquipmentCondition returns a condition that only lets through recipes, aliased r, that need nothing
+// outside the equipment array in the given placeholder. A NULL array lets every recipe through.
+func ownedEquipmentCondition(placeholder string) string {
+	return fmt.Sprintf(`(%[1]s::varchar[] IS NULL OR NOT EXISTS (
+		SELECT 1 FROM recipe_equipment e WHERE e.recipe_id = r.id AND NOT e.equipment = ANY(%[1]s::varchar[])
+	))`, placeholder)
+}
@@ -0,0 +1,126 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// FoodImageRepository handles database operations related to photos of recipes' dishes.
+type FoodImageRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewFoodImageRepository creates a new instance of FoodImageRepository.
+// It requires a database connection pool to perform database operations.
+func NewFoodImageRepository(pool *pgxpool.Pool) *FoodImageRepository {
+	return &FoodImageRepository{ConnectionPool: pool}
+}
+
+// Insert records an image that has been written to disk for a recipe.
+// Returns the image with its ID populated, or an error if the insertion fails.
+func (fr *FoodImageRepository) Insert(ctx context.Context, image *model.FoodImage, userID int) (*model.FoodImage, error) {
+	log.Printf("Inserting food image %s for recipe %d", image.OriginalName, image.RecipeId)
+
+	query := `
+		INSERT INTO food_images (
+			recipe_id, filepath, original_name, size_bytes,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $5, $6
+		) RETURNING id`
+
+	err := fr.ConnectionPool.QueryRow(
+		ctx,
+		query,
+		image.RecipeId,
+		image.Filepath,
+		image.OriginalName,
+		image.SizeBytes,
+		userID,
+		time.Now(),
+	).Scan(&image.ID)
+
+	if err != nil {
+		log.Printf("Error inserting food image: %v", err)
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// GetPrimary retrieves a recipe's primary photo, the first one added.
+// Returns model.ErrNotFound if the recipe has no photos.
+func (fr *FoodImageRepository) GetPrimary(ctx context.Context, recipeID int) (*model.FoodImage, error) {
+	query := `
+		SELECT id, recipe_id, filepath, original_name, size_bytes
+		FROM food_images
+		WHERE recipe_id = $1
+		ORDER BY id
+		LIMIT 1`
+
+	var image model.FoodImage
+
+	err := fr.ConnectionPool.QueryRow(ctx, query, recipeID).Scan(
+		&image.ID,
+		&image.RecipeId,
+		&image.Filepath,
+		&image.OriginalName,
+		&image.SizeBytes,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("photo")
+	}
+	if err != nil {
+		log.Printf("Error retrieving primary photo of recipe %d: %v", recipeID, err)
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+// GetPrimaryByRecipeIds retrieves the primary photo, the first one added, of each of the recipes that has one.
+// Returns the photos by recipe ID; recipes without photos are left out.
+func (fr *FoodImageRepository) GetPrimaryByRecipeIds(ctx context.Context, recipeIDs []int) (map[int]*model.FoodImage, error) {
+	photos := map[int]*model.FoodImage{}
+	if len(recipeIDs) == 0 {
+		return photos, nil
+	}
+
+	query := `
+		SELECT DISTINCT ON (recipe_id) id, recipe_id, filepath, original_name, size_bytes
+		FROM food_images
+		WHERE recipe_id = ANY($1)
+		ORDER BY recipe_id, id`
+
+	result, err := fr.ConnectionPool.Query(ctx, query, recipeIDs)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var image model.FoodImage
+		err := result.Scan(&image.ID, &image.RecipeId, &image.Filepath, &image.OriginalName, &image.SizeBytes)
+		if err != nil {
+			log.Printf("Error scanning food image: %v", err)
+			return nil, err
+		}
+		photos[image.RecipeId] = &image
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving primary photos: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return photos, nil
+}
@@ -0,0 +1,211 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// HouseholdRepository handles database operations related to households and their members.
+type HouseholdRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewHouseholdRepository creates a new instance of HouseholdRepository.
+// It requires a database connection pool to perform database operations.
+func NewHouseholdRepository(pool *pgxpool.Pool) *HouseholdRepository {
+	return &HouseholdRepository{ConnectionPool: pool}
+}
+
+// Insert adds a new household to the database and makes its creator the first member.
+// Returns the inserted household with its ID and members populated, or an error if the insertion fails.
+func (hr *HouseholdRepository) Insert(ctx context.Context, household *model.Household) (*model.Household, error) {
+	log.Printf("Starting database insertion for household: %s", household.HouseholdName)
+
+	tx, err := hr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	query := `
+		INSERT INTO households (household_name, created_by, created_date, updated_by, updated_date)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`
+
+	err = tx.QueryRow(
+		ctx,
+		query,
+		household.HouseholdName,
+		household.CreatedBy,
+		household.CreatedDate,
+		household.UpdatedBy,
+		household.UpdatedDate,
+	).Scan(&household.ID)
+
+	if err != nil {
+		log.Printf("Error inserting household into database: %v", err)
+		return nil, err
+	}
+
+	if err := hr.addMember(ctx, tx, household.ID, household.CreatedBy, household.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, err
+	}
+
+	household.MemberIds = []int{household.CreatedBy}
+
+	log.Printf("Successfully inserted household with ID: %d", household.ID)
+	return household, nil
+}
+
+// ListForUser retrieves the households a user belongs to, with their members.
+func (hr *HouseholdRepository) ListForUser(ctx context.Context, userID int) ([]model.Household, error) {
+	log.Printf("Retrieving households for user with ID: %d", userID)
+
+	connection, err := hr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `
+		SELECT h.id, h.household_name, h.created_by, h.created_date, h.updated_by, h.updated_date,
+			ARRAY(SELECT m.user_id FROM household_members m WHERE m.household_id = h.id ORDER BY m.user_id)
+		FROM households h
+		WHERE EXISTS (SELECT 1 FROM household_members m WHERE m.household_id = h.id AND m.user_id = $1)
+		ORDER BY h.household_name
+	`
+
+	result, err := connection.Query(ctx, query, userID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	households := []model.Household{}
+
+	for result.Next() {
+		var household model.Household
+
+		err := result.Scan(
+			&household.ID,
+			&household.HouseholdName,
+			&household.CreatedBy,
+			&household.CreatedDate,
+			&household.UpdatedBy,
+			&household.UpdatedDate,
+			&household.MemberIds,
+		)
+		if err != nil {
+			log.Printf("Error scanning household: %v", err)
+			return nil, err
+		}
+
+		households = append(households, household)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving households: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return households, nil
+}
+
+// IsMember reports whether a user belongs to a household.
+// Returns model.ErrNotFound if the household does not exist.
+func (hr *HouseholdRepository) IsMember(ctx context.Context, householdID int, userID int) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM household_members WHERE household_id = h.id AND user_id = $2)
+		FROM households h
+		WHERE h.id = $1
+	`
+
+	var isMember bool
+
+	err := hr.ConnectionPool.QueryRow(ctx, query, householdID, userID).Scan(&isMember)
+	if err == pgx.ErrNoRows {
+		return false, model.ErrNotFound("household")
+	}
+	if err != nil {
+		log.Printf("Error checking household membership: %v", err)
+		return false, err
+	}
+
+	return isMember, nil
+}
+
+// GetMemberIds retrieves the user IDs of a household's members.
+func (hr *HouseholdRepository) GetMemberIds(ctx context.Context, householdID int) ([]int, error) {
+	query := `SELECT ARRAY(SELECT user_id FROM household_members WHERE household_id = $1 ORDER BY user_id)`
+
+	var memberIDs []int
+
+	if err := hr.ConnectionPool.QueryRow(ctx, query, householdID).Scan(&memberIDs); err != nil {
+		log.Printf("Error retrieving household members: %v", err)
+		return nil, err
+	}
+
+	return memberIDs, nil
+}
+
+// AddMember adds a user to a household. Adding an existing member does nothing.
+// Returns model.ErrNotFound if the user does not exist.
+func (hr *HouseholdRepository) AddMember(ctx context.Context, householdID int, userID int, addedBy int) error {
+	log.Printf("Adding user %d to household %d", userID, householdID)
+
+	return hr.addMember(ctx, hr.ConnectionPool, householdID, userID, addedBy)
+}
+
+// RemoveMember removes a user from a household.
+// Returns model.ErrNotFound if the user is not a member.
+func (hr *HouseholdRepository) RemoveMember(ctx context.Context, householdID int, userID int) error {
+	log.Printf("Removing user %d from household %d", userID, householdID)
+
+	tag, err := hr.ConnectionPool.Exec(ctx, `DELETE FROM household_members WHERE household_id = $1 AND user_id = $2`, householdID, userID)
+	if err != nil {
+		log.Printf("Error removing household member: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("household member")
+	}
+
+	return nil
+}
+
+// private functions
+
+// addMember inserts a household membership using the given transaction or the pool.
+func (hr *HouseholdRepository) addMember(ctx context.Context, db executor, householdID int, userID int, addedBy int) error {
+	query := `
+		INSERT INTO household_members (household_id, user_id, created_by, created_date)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (household_id, user_id) DO NOTHING
+	`
+
+	if _, err := db.Exec(ctx, query, householdID, userID, addedBy, time.Now()); err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return model.ErrNotFound("user")
+		}
+		log.Printf("Error adding household member: %v", err)
+		return err
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"log"
 	"recipe-generator/internal/api/model"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -39,13 +40,14 @@ func (ir *IngredientsRepository) Insert(ctx context.Context, ingredient *model.I
 			created_by,
 			created_date,
 			updated_by,
-			updated_date
+			updated_date,
+			component_recipe_id
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
 		)
 		`
 
-	_, err := tx.Exec(ctx, query, ingredient.UnitOfMeasurement, ingredient.IngredientName, ingredient.Amount, recipeId, 1, time.Now(), 1, time.Now())
+	_, err := tx.Exec(ctx, query, ingredient.UnitOfMeasurement, ingredient.IngredientName, ingredient.Amount, recipeId, 1, time.Now(), 1, time.Now(), ingredient.ComponentRecipeId)
 	if err != nil {
 		log.Printf("Error inserting ingredient: %v", err)
 		return err
@@ -75,7 +77,7 @@ func (ir *IngredientsRepository) GetIngredientsByRecipeId(ctx context.Context, r
 	// debug
 	log.Printf("This is the recipeID: %v\n", recipeID)
 
-	query := `SELECT ingredient_name, unit_of_measurement, unit_amount FROM ingredients WHERE recipe_id = $1`
+	query := `SELECT ingredient_name, unit_of_measurement, unit_amount, component_recipe_id FROM ingredients WHERE recipe_id = $1`
 	
 
 	// execute the query
@@ -93,7 +95,7 @@ func (ir *IngredientsRepository) GetIngredientsByRecipeId(ctx context.Context, r
 
 		var ingredient model.Ingredient
 
-		err := result.Scan(&ingredient.IngredientName, &ingredient.UnitOfMeasurement, &ingredient.Amount)
+		err := result.Scan(&ingredient.IngredientName, &ingredient.UnitOfMeasurement, &ingredient.Amount, &ingredient.ComponentRecipeId)
 
 		if err != nil {
 			log.Printf("Error scanning ingredients: %v", err)
@@ -117,3 +119,201 @@ func (ir *IngredientsRepository) GetIngredientsByRecipeId(ctx context.Context, r
 func Get(ctx context.Context, id int) (*model.Ingredient, error) {
 	return nil, nil
 }
+
+// GetIngredientsByRecipeIds retrieves the ingredients for several recipes with a single query.
+// It requires a context and the IDs of the recipes to retrieve.
+// Returns the ingredients grouped by recipe ID, in insertion order, and an error if the retrieval fails.
+func (ir *IngredientsRepository) GetIngredientsByRecipeIds(ctx context.Context, recipeIDs []int) (map[int][]model.Ingredient, error) {
+	log.Printf("Inside of IngredientsRepository.GetIngredientsByRecipeIds")
+	log.Printf("Retrieving ingredients for %d recipes from database.", len(recipeIDs))
+
+	connection, err := ir.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `
+		SELECT id, recipe_id, ingredient_name, unit_of_measurement, unit_amount, component_recipe_id
+		FROM ingredients
+		WHERE recipe_id = ANY($1)
+		ORDER BY recipe_id, id
+	`
+
+	result, err := connection.Query(ctx, query, recipeIDs)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	ingredients := make(map[int][]model.Ingredient)
+
+	for result.Next() {
+		var ingredient model.Ingredient
+
+		err := result.Scan(
+			&ingredient.ID,
+			&ingredient.RecipeId,
+			&ingredient.IngredientName,
+			&ingredient.UnitOfMeasurement,
+			&ingredient.Amount,
+			&ingredient.ComponentRecipeId,
+		)
+		if err != nil {
+			log.Printf("Error scanning ingredients: %v", err)
+			return nil, err
+		}
+
+		ingredients[ingredient.RecipeId] = append(ingredients[ingredient.RecipeId], ingredient)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error getting ingredients: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return ingredients, nil
+}
+
+// GetRecipesWithIngredients retrieves several recipes' names, servings, yields and ingredients with a single query.
+// It requires a context and the IDs of the recipes to retrieve.
+// Returns the recipes by ID, with only those fields populated; recipes that don't exist are missing from the map.
+func (ir *IngredientsRepository) GetRecipesWithIngredients(ctx context.Context, recipeIDs []int) (map[int]*model.Recipe, error) {
+	log.Printf("Inside of IngredientsRepository.GetRecipesWithIngredients")
+	log.Printf("Retrieving ingredients for %d recipes from database.", len(recipeIDs))
+
+	list, err := ir.queryRecipesWithIngredients(ctx, `WHERE r.id = ANY($1)`, recipeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	recipes := make(map[int]*model.Recipe, len(list))
+	for _, recipe := range list {
+		recipes[recipe.ID] = recipe
+	}
+
+	return recipes, nil
+}
+
+// GetAllRecipesWithIngredients retrieves every recipe's name, servings, yield and ingredients with a single query.
+// Returns the recipes in ID order, with only those fields populated.
+func (ir *IngredientsRepository) GetAllRecipesWithIngredients(ctx context.Context) ([]*model.Recipe, error) {
+	log.Printf("Retrieving ingredients for all recipes from database.")
+
+	return ir.queryRecipesWithIngredients(ctx, ``)
+}
+
+// GetRecipesWithSimilarNames retrieves the names, servings, yields and ingredients of the recipes whose names
+// have at least a minimum trigram similarity to a name, with a single query that uses the trigram index on
+// recipe names. It needs the pg_trgm extension.
+// Returns the recipes most similar first, with only those fields populated.
+func (ir *IngredientsRepository) GetRecipesWithSimilarNames(ctx context.Context, name string, minSimilarity float64) ([]*model.Recipe, error) {
+	log.Printf("Retrieving ingredients for recipes with names like %s from database.", name)
+
+	// nothing is written; the transaction only scopes the similarity threshold % matches with.
+	tx, err := ir.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	threshold := strconv.FormatFloat(minSimilarity, 'f', 4, 64)
+	if _, err := tx.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, threshold); err != nil {
+		log.Printf("Error setting the similarity threshold: %v", err)
+		return nil, err
+	}
+
+	return selectRecipesWithIngredients(ctx, tx, `WHERE r.recipe_name % $1`, `similarity(r.recipe_name, $1) DESC, r.id, i.id`, name)
+}
+
+// private functions
+
+// queryRecipesWithIngredients retrieves the names, servings, yields and ingredients of the recipes matching a
+// WHERE clause on the recipes table, aliased r, in ID order. Yields are read without their pans.
+func (ir *IngredientsRepository) queryRecipesWithIngredients(ctx context.Context, where string, args ...any) ([]*model.Recipe, error) {
+	connection, err := ir.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	return selectRecipesWithIngredients(ctx, connection, where, `r.id, i.id`, args...)
+}
+
+// selectRecipesWithIngredients runs the query of queryRecipesWithIngredients on db, ordered by an ORDER BY
+// list that keeps each recipe's rows together, such as "r.id, i.id".
+func selectRecipesWithIngredients(ctx context.Context, db querier, where string, orderBy string, args ...any) ([]*model.Recipe, error) {
+	// left join so recipes without ingredients are still returned.
+	query := `
+		SELECT r.id, r.recipe_name, COALESCE(r.servings, 0), r.yield_quantity, COALESCE(r.yield_unit, ''),
+			COALESCE(i.id, 0), COALESCE(i.ingredient_name, ''), COALESCE(i.unit_of_measurement, ''), COALESCE(i.unit_amount, 0),
+			i.component_recipe_id
+		FROM recipes r
+		LEFT JOIN ingredients i ON i.recipe_id = r.id
+		` + where + `
+		ORDER BY ` + orderBy + `
+	`
+
+	result, err := db.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	recipes := []*model.Recipe{}
+
+	for result.Next() {
+		var recipeID, servings int
+		var recipeName, yieldUnit string
+		var yieldQuantity *float64
+		var ingredient model.Ingredient
+
+		err := result.Scan(
+			&recipeID,
+			&recipeName,
+			&servings,
+			&yieldQuantity,
+			&yieldUnit,
+			&ingredient.ID,
+			&ingredient.IngredientName,
+			&ingredient.UnitOfMeasurement,
+			&ingredient.Amount,
+			&ingredient.ComponentRecipeId,
+		)
+		if err != nil {
+			log.Printf("Error scanning ingredients: %v", err)
+			return nil, err
+		}
+
+		// rows come in recipe order, so a new recipe starts whenever the ID changes.
+		if len(recipes) == 0 || recipes[len(recipes)-1].ID != recipeID {
+			recipes = append(recipes, &model.Recipe{
+				ID:          recipeID,
+				RecipeName:  recipeName,
+				Servings:    servings,
+				Ingredients: []model.Ingredient{},
+			})
+			if yieldQuantity != nil {
+				recipes[len(recipes)-1].Yield = &model.RecipeYield{Quantity: *yieldQuantity, Unit: yieldUnit}
+			}
+		}
+		recipe := recipes[len(recipes)-1]
+
+		if ingredient.ID != 0 {
+			ingredient.RecipeId = recipeID
+			recipe.Ingredients = append(recipe.Ingredients, ingredient)
+		}
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error getting ingredients: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return recipes, nil
+}
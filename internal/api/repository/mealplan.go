@@ -0,0 +1,303 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// MealPlanRepository handles database operations related to meal plan entries.
+// Every query is limited to a single model.MealPlanScope, either a user's personal plan or a household's plan.
+type MealPlanRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewMealPlanRepository creates a new instance of MealPlanRepository.
+// It requires a database connection pool to perform database operations.
+func NewMealPlanRepository(pool *pgxpool.Pool) *MealPlanRepository {
+	return &MealPlanRepository{ConnectionPool: pool}
+}
+
+// mealPlanColumns is the select list scanned by scanMealPlanEntries.
+const mealPlanColumns = `
+	mp.id, COALESCE(mp.user_id, 0), COALESCE(mp.household_id, 0), mp.plan_date, mp.meal_slot,
+	mp.recipe_id, r.recipe_name, COALESCE(mp.servings, 0), COALESCE(mp.notes, ''),
+	mp.created_by, mp.created_date, mp.updated_by, mp.updated_date
+`
+
+// mealPlanOrder orders entries by date, then by meal slot in the order they happen during the day.
+const mealPlanOrder = `
+	ORDER BY mp.plan_date,
+		array_position(ARRAY['breakfast', 'lunch', 'dinner', 'snack']::varchar[], mp.meal_slot),
+		mp.id
+`
+
+// Insert adds new meal plan entries in a single transaction.
+// Returns the entries with their IDs populated, or model.ErrNotFound if one of the recipes does not exist.
+func (mr *MealPlanRepository) Insert(ctx context.Context, entries []model.MealPlanEntry) ([]model.MealPlanEntry, error) {
+	log.Printf("Inserting %d meal plan entries", len(entries))
+
+	tx, err := mr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	query := `
+		INSERT INTO meal_plans (
+			user_id, household_id, plan_date, meal_slot, recipe_id, servings, notes,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			NULLIF($1, 0), NULLIF($2, 0), $3, $4, $5, NULLIF($6, 0), NULLIF($7, ''), $8, $9, $10, $11
+		) RETURNING id`
+
+	for i := range entries {
+		entry := &entries[i]
+
+		err := tx.QueryRow(
+			ctx,
+			query,
+			entry.UserId,
+			entry.HouseholdId,
+			entry.PlanDate.Time,
+			entry.MealSlot,
+			entry.RecipeId,
+			entry.Servings,
+			entry.Notes,
+			entry.CreatedBy,
+			entry.CreatedDate,
+			entry.UpdatedBy,
+			entry.UpdatedDate,
+		).Scan(&entry.ID)
+
+		if err != nil {
+			if isPgError(err, foreignKeyViolation) {
+				return nil, model.ErrNotFound("recipe")
+			}
+			log.Printf("Error inserting meal plan entry: %v", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Get retrieves a meal plan entry by ID.
+// Returns model.ErrNotFound if the entry does not exist.
+func (mr *MealPlanRepository) Get(ctx context.Context, entryID int) (*model.MealPlanEntry, error) {
+	entries, err := mr.query(ctx, `WHERE mp.id = $1`, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return nil, model.ErrNotFound("meal plan entry")
+	}
+
+	return &entries[0], nil
+}
+
+// Update saves a meal plan entry's date, slot, recipe, servings and notes.
+// Returns model.ErrNotFound if the entry or its recipe does not exist.
+func (mr *MealPlanRepository) Update(ctx context.Context, entry *model.MealPlanEntry) error {
+	log.Printf("Updating meal plan entry with ID: %d", entry.ID)
+
+	query := `
+		UPDATE meal_plans
+		SET plan_date = $2, meal_slot = $3, recipe_id = $4, servings = NULLIF($5, 0), notes = NULLIF($6, ''),
+			updated_by = $7, updated_date = $8
+		WHERE id = $1
+	`
+
+	tag, err := mr.ConnectionPool.Exec(
+		ctx,
+		query,
+		entry.ID,
+		entry.PlanDate.Time,
+		entry.MealSlot,
+		entry.RecipeId,
+		entry.Servings,
+		entry.Notes,
+		entry.UpdatedBy,
+		entry.UpdatedDate,
+	)
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return model.ErrNotFound("recipe")
+		}
+		log.Printf("Error updating meal plan entry: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("meal plan entry")
+	}
+
+	return nil
+}
+
+// Delete removes a meal plan entry.
+// Returns model.ErrNotFound if the entry does not exist.
+func (mr *MealPlanRepository) Delete(ctx context.Context, entryID int) error {
+	log.Printf("Deleting meal plan entry with ID: %d", entryID)
+
+	tag, err := mr.ConnectionPool.Exec(ctx, `DELETE FROM meal_plans WHERE id = $1`, entryID)
+	if err != nil {
+		log.Printf("Error deleting meal plan entry: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("meal plan entry")
+	}
+
+	return nil
+}
+
+// GetRange retrieves the entries of a meal plan between two dates, inclusive, in calendar order.
+func (mr *MealPlanRepository) GetRange(ctx context.Context, scope model.MealPlanScope, start model.Date, end model.Date) ([]model.MealPlanEntry, error) {
+	log.Printf("Retrieving meal plan from %s to %s", start, end)
+
+	condition, scopeID := scopeCondition(scope)
+
+	return mr.query(
+		ctx,
+		`WHERE `+condition+` AND mp.plan_date BETWEEN $2 AND $3 `+mealPlanOrder,
+		scopeID, start.Time, end.Time,
+	)
+}
+
+// CopyRange copies the entries of a meal plan in the days starting at from to the same days starting at to.
+// If replace is set, entries already planned in the destination days are removed first; otherwise the
+// copies are added alongside them.
+// Returns the destination days' entries after the copy.
+func (mr *MealPlanRepository) CopyRange(ctx context.Context, scope model.MealPlanScope, from model.Date, to model.Date, days int, replace bool, userID int) ([]model.MealPlanEntry, error) {
+	log.Printf("Copying %d days of meal plan from %s to %s", days, from, to)
+
+	condition, scopeID := scopeCondition(scope)
+	lastDay := days - 1
+
+	tx, err := mr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	if replace {
+		_, err := tx.Exec(
+			ctx,
+			`DELETE FROM meal_plans mp WHERE `+condition+` AND mp.plan_date BETWEEN $2 AND $2 + $3::int`,
+			scopeID, to.Time, lastDay,
+		)
+		if err != nil {
+			log.Printf("Error clearing destination meal plan: %v", err)
+			return nil, err
+		}
+	}
+
+	query := `
+		INSERT INTO meal_plans (
+			user_id, household_id, plan_date, meal_slot, recipe_id, servings, notes,
+			created_by, created_date, updated_by, updated_date
+		)
+		SELECT mp.user_id, mp.household_id, mp.plan_date + ($3::date - $2::date), mp.meal_slot, mp.recipe_id,
+			mp.servings, mp.notes, $5, $6, $5, $6
+		FROM meal_plans mp
+		WHERE ` + condition + ` AND mp.plan_date BETWEEN $2 AND $2 + $4::int
+	`
+
+	if _, err := tx.Exec(ctx, query, scopeID, from.Time, to.Time, lastDay, userID, time.Now()); err != nil {
+		log.Printf("Error copying meal plan: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, err
+	}
+
+	return mr.GetRange(ctx, scope, to, to.AddDays(lastDay))
+}
+
+// private functions
+
+// scopeCondition returns the WHERE condition that limits meal_plans (aliased mp) to a scope,
+// using $1 for the scope's ID, and the value to bind to $1.
+func scopeCondition(scope model.MealPlanScope) (string, int) {
+	if scope.HouseholdId != 0 {
+		return `mp.household_id = $1`, scope.HouseholdId
+	}
+	return `mp.user_id = $1`, scope.UserId
+}
+
+// query retrieves the meal plan entries matching the given WHERE/ORDER BY clause.
+func (mr *MealPlanRepository) query(ctx context.Context, clause string, args ...interface{}) ([]model.MealPlanEntry, error) {
+	connection, err := mr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `SELECT ` + mealPlanColumns + ` FROM meal_plans mp JOIN recipes r ON r.id = mp.recipe_id ` + clause
+
+	result, err := connection.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	return scanMealPlanEntries(result)
+}
+
+// scanMealPlanEntries scans rows selecting mealPlanColumns.
+func scanMealPlanEntries(result pgx.Rows) ([]model.MealPlanEntry, error) {
+	entries := []model.MealPlanEntry{}
+
+	for result.Next() {
+		var entry model.MealPlanEntry
+
+		err := result.Scan(
+			&entry.ID,
+			&entry.UserId,
+			&entry.HouseholdId,
+			&entry.PlanDate.Time,
+			&entry.MealSlot,
+			&entry.RecipeId,
+			&entry.RecipeName,
+			&entry.Servings,
+			&entry.Notes,
+			&entry.CreatedBy,
+			&entry.CreatedDate,
+			&entry.UpdatedBy,
+			&entry.UpdatedDate,
+		)
+		if err != nil {
+			log.Printf("Error scanning meal plan entry: %v", err)
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving meal plan entries: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return entries, nil
+}
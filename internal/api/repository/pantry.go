@@ -0,0 +1,267 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// PantryRepository handles database operations related to pantry items.
+type PantryRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewPantryRepository creates a new instance of PantryRepository.
+// It requires a database connection pool to perform database operations.
+func NewPantryRepository(pool *pgxpool.Pool) *PantryRepository {
+	return &PantryRepository{ConnectionPool: pool}
+}
+
+// pantryColumns is the select list scanned by scanPantryItem.
+const pantryColumns = `
+	id, COALESCE(user_id, 0), COALESCE(household_id, 0), ingredient_name, quantity, COALESCE(unit, ''),
+	expiry_date, created_by, created_date, updated_by, updated_date
+`
+
+// Insert adds a new pantry item to the database.
+// Returns the item with its ID populated.
+func (pr *PantryRepository) Insert(ctx context.Context, item *model.PantryItem) (*model.PantryItem, error) {
+	log.Printf("Adding %s to pantry", item.IngredientName)
+
+	query := `
+		INSERT INTO pantry_items (
+			user_id, household_id, ingredient_name, quantity, unit, expiry_date,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			NULLIF($1, 0), NULLIF($2, 0), $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10
+		) RETURNING id`
+
+	err := pr.ConnectionPool.QueryRow(
+		ctx,
+		query,
+		item.UserId,
+		item.HouseholdId,
+		item.IngredientName,
+		item.Quantity,
+		item.Unit,
+		expiryDate(item),
+		item.CreatedBy,
+		item.CreatedDate,
+		item.UpdatedBy,
+		item.UpdatedDate,
+	).Scan(&item.ID)
+
+	if err != nil {
+		log.Printf("Error inserting pantry item: %v", err)
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// Get retrieves a pantry item by ID.
+// Returns model.ErrNotFound if the item does not exist.
+func (pr *PantryRepository) Get(ctx context.Context, itemID int) (*model.PantryItem, error) {
+	item, err := scanPantryItem(pr.ConnectionPool.QueryRow(ctx, `SELECT `+pantryColumns+` FROM pantry_items WHERE id = $1`, itemID))
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("pantry item")
+	}
+	if err != nil {
+		log.Printf("Error retrieving pantry item: %v", err)
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// ListForScope retrieves the pantry of a user or household, soonest to expire first.
+func (pr *PantryRepository) ListForScope(ctx context.Context, scope model.MealPlanScope) ([]model.PantryItem, error) {
+	connection, err := pr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	column, scopeID := "user_id", scope.UserId
+	if scope.HouseholdId != 0 {
+		column, scopeID = "household_id", scope.HouseholdId
+	}
+
+	query := `
+		SELECT ` + pantryColumns + `
+		FROM pantry_items
+		WHERE ` + column + ` = $1
+		ORDER BY expiry_date NULLS LAST, lower(ingredient_name), id
+	`
+
+	result, err := connection.Query(ctx, query, scopeID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	items := []model.PantryItem{}
+
+	for result.Next() {
+		item, err := scanPantryItem(result)
+		if err != nil {
+			log.Printf("Error scanning pantry item: %v", err)
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving pantry: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return items, nil
+}
+
+// Update saves a pantry item's name, quantity, unit and expiry date.
+// Returns model.ErrNotFound if the item does not exist.
+func (pr *PantryRepository) Update(ctx context.Context, item *model.PantryItem) error {
+	log.Printf("Updating pantry item with ID: %d", item.ID)
+
+	query := `
+		UPDATE pantry_items
+		SET ingredient_name = $2, quantity = $3, unit = NULLIF($4, ''), expiry_date = $5,
+			updated_by = $6, updated_date = $7
+		WHERE id = $1
+	`
+
+	tag, err := pr.ConnectionPool.Exec(
+		ctx,
+		query,
+		item.ID,
+		item.IngredientName,
+		item.Quantity,
+		item.Unit,
+		expiryDate(item),
+		item.UpdatedBy,
+		item.UpdatedDate,
+	)
+	if err != nil {
+		log.Printf("Error updating pantry item: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("pantry item")
+	}
+
+	return nil
+}
+
+// Delete removes a pantry item.
+// Returns model.ErrNotFound if the item does not exist.
+func (pr *PantryRepository) Delete(ctx context.Context, itemID int) error {
+	log.Printf("Deleting pantry item with ID: %d", itemID)
+
+	tag, err := pr.ConnectionPool.Exec(ctx, `DELETE FROM pantry_items WHERE id = $1`, itemID)
+	if err != nil {
+		log.Printf("Error deleting pantry item: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("pantry item")
+	}
+
+	return nil
+}
+
+// Deduct takes amounts off pantry items within a transaction, removing items that run out.
+// Amounts are subtracted from the stored quantity rather than overwriting it, so deductions made at
+// the same time from different devices both count.
+//
+// Parameters:
+//   - ctx: The request context
+//   - amounts: The amount to take off each pantry item by ID, in the item's unit
+//   - userID: The user making the change
+//   - tx: The database transaction
+func (pr *PantryRepository) Deduct(ctx context.Context, amounts map[int]float64, userID int, tx pgx.Tx) error {
+	if len(amounts) == 0 {
+		return nil
+	}
+
+	log.Printf("Deducting from %d pantry items", len(amounts))
+
+	now := time.Now()
+
+	// update in ID order so deductions running at the same time lock the rows in the same order.
+	itemIDs := make([]int, 0, len(amounts))
+	for itemID := range amounts {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Ints(itemIDs)
+
+	for _, itemID := range itemIDs {
+		_, err := tx.Exec(
+			ctx,
+			`UPDATE pantry_items SET quantity = GREATEST(quantity - $2, 0), updated_by = $3, updated_date = $4 WHERE id = $1`,
+			itemID, amounts[itemID], userID, now,
+		)
+		if err != nil {
+			log.Printf("Error deducting from pantry item: %v", err)
+			return err
+		}
+	}
+
+	// a little is left over from rounding in unit conversions, so nearly empty counts as empty.
+	if _, err := tx.Exec(ctx, `DELETE FROM pantry_items WHERE id = ANY($1) AND quantity < 0.005`, itemIDs); err != nil {
+		log.Printf("Error removing empty pantry items: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// private functions
+
+// expiryDate returns a pantry item's expiry date as a value for a nullable DATE column.
+func expiryDate(item *model.PantryItem) *time.Time {
+	if item.ExpiryDate == nil || item.ExpiryDate.IsZero() {
+		return nil
+	}
+	return &item.ExpiryDate.Time
+}
+
+// scanPantryItem scans a row selecting pantryColumns.
+func scanPantryItem(row pgx.Row) (*model.PantryItem, error) {
+	var item model.PantryItem
+	var expiry *time.Time
+
+	err := row.Scan(
+		&item.ID,
+		&item.UserId,
+		&item.HouseholdId,
+		&item.IngredientName,
+		&item.Quantity,
+		&item.Unit,
+		&expiry,
+		&item.CreatedBy,
+		&item.CreatedDate,
+		&item.UpdatedBy,
+		&item.UpdatedDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiry != nil {
+		item.ExpiryDate = &model.Date{Time: *expiry}
+	}
+
+	return &item, nil
+}
@@ -0,0 +1,190 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// PriceRepository handles database operations related to ingredient prices.
+type PriceRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewPriceRepository creates a new instance of PriceRepository.
+// It requires a database connection pool to perform database operations.
+func NewPriceRepository(pool *pgxpool.Pool) *PriceRepository {
+	return &PriceRepository{ConnectionPool: pool}
+}
+
+// priceColumns is the select list scanned by scanPrices.
+const priceColumns = `
+	id, ingredient_name, price, quantity, COALESCE(unit, ''), COALESCE(store, ''), observed_date,
+	created_by, created_date, updated_by, updated_date
+`
+
+// Insert adds a new ingredient price to the database.
+// Returns the price with its ID populated.
+func (pr *PriceRepository) Insert(ctx context.Context, price *model.IngredientPrice) (*model.IngredientPrice, error) {
+	log.Printf("Adding price of %s observed on %s", price.IngredientName, price.ObservedDate)
+
+	query := `
+		INSERT INTO ingredient_prices (
+			ingredient_name, price, quantity, unit, store, observed_date,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			$1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, $10
+		) RETURNING id`
+
+	err := pr.ConnectionPool.QueryRow(
+		ctx,
+		query,
+		price.IngredientName,
+		price.Price,
+		price.Quantity,
+		price.Unit,
+		price.Store,
+		price.ObservedDate.Time,
+		price.CreatedBy,
+		price.CreatedDate,
+		price.UpdatedBy,
+		price.UpdatedDate,
+	).Scan(&price.ID)
+
+	if err != nil {
+		log.Printf("Error inserting ingredient price: %v", err)
+		return nil, err
+	}
+
+	return price, nil
+}
+
+// GetCurrent retrieves the current price of every ingredient, keyed by canonical ingredient name.
+// The current price is the most recently observed one. When a store is given, the most recent price
+// at that store is used for the ingredients it has prices for.
+func (pr *PriceRepository) GetCurrent(ctx context.Context, store string) (map[string]model.IngredientPrice, error) {
+	query := `
+		SELECT DISTINCT ON (ingredient_name) ` + priceColumns + `
+		FROM ingredient_prices
+		ORDER BY ingredient_name, ($1 <> '' AND lower(COALESCE(store, '')) = lower($1)) DESC, observed_date DESC, id DESC
+	`
+
+	prices, err := pr.query(ctx, query, store)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]model.IngredientPrice, len(prices))
+	for _, price := range prices {
+		current[price.IngredientName] = price
+	}
+
+	return current, nil
+}
+
+// GetHistory retrieves every price observed for an ingredient, most recent first.
+func (pr *PriceRepository) GetHistory(ctx context.Context, ingredientName string) ([]model.IngredientPrice, error) {
+	query := `
+		SELECT ` + priceColumns + `
+		FROM ingredient_prices
+		WHERE ingredient_name = $1
+		ORDER BY observed_date DESC, id DESC
+	`
+
+	return pr.query(ctx, query, ingredientName)
+}
+
+// Get retrieves an ingredient price by ID.
+// Returns model.ErrNotFound if the price does not exist.
+func (pr *PriceRepository) Get(ctx context.Context, priceID int) (*model.IngredientPrice, error) {
+	prices, err := pr.query(ctx, `SELECT `+priceColumns+` FROM ingredient_prices WHERE id = $1`, priceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prices) == 0 {
+		return nil, model.ErrNotFound("ingredient price")
+	}
+
+	return &prices[0], nil
+}
+
+// Delete removes an ingredient price.
+// Returns model.ErrNotFound if the price does not exist.
+func (pr *PriceRepository) Delete(ctx context.Context, priceID int) error {
+	log.Printf("Deleting ingredient price with ID: %d", priceID)
+
+	tag, err := pr.ConnectionPool.Exec(ctx, `DELETE FROM ingredient_prices WHERE id = $1`, priceID)
+	if err != nil {
+		log.Printf("Error deleting ingredient price: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("ingredient price")
+	}
+
+	return nil
+}
+
+// private functions
+
+// query runs a query selecting priceColumns and scans the prices.
+func (pr *PriceRepository) query(ctx context.Context, query string, args ...interface{}) ([]model.IngredientPrice, error) {
+	connection, err := pr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	result, err := connection.Query(ctx, query, args...)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	return scanPrices(result)
+}
+
+// scanPrices scans rows selecting priceColumns.
+func scanPrices(result pgx.Rows) ([]model.IngredientPrice, error) {
+	prices := []model.IngredientPrice{}
+
+	for result.Next() {
+		var price model.IngredientPrice
+
+		err := result.Scan(
+			&price.ID,
+			&price.IngredientName,
+			&price.Price,
+			&price.Quantity,
+			&price.Unit,
+			&price.Store,
+			&price.ObservedDate.Time,
+			&price.CreatedBy,
+			&price.CreatedDate,
+			&price.UpdatedBy,
+			&price.UpdatedDate,
+		)
+		if err != nil {
+			log.Printf("Error scanning ingredient price: %v", err)
+			return nil, err
+		}
+
+		prices = append(prices, price)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving ingredient prices: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return prices, nil
+}
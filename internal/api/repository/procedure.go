@@ -4,6 +4,7 @@ package repository
 import (
 	"context"
 	"log"
+	"recipe-generator/internal/api/model"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -23,20 +24,21 @@ func NewProcedureRepository(pool *pgxpool.Pool) *ProcedureRepository {
 }
 
 // Insert adds a new procedure step to the database within a transaction.
-// It requires a context, the procedure step text, the associated recipe ID, and an active transaction.
+// It requires a context, the procedure step text, its timing (nil if it has none), the associated recipe ID,
+// and an active transaction.
 // Returns an error if the insertion fails.
-func (pr *ProcedureRepository) Insert(ctx context.Context, procedureStep string, recipeID int, tx pgx.Tx) error {
+func (pr *ProcedureRepository) Insert(ctx context.Context, procedureStep string, timing *model.StepTiming, recipeID int, tx pgx.Tx) error {
 	log.Printf("Inside of ProcedureRepository.Insert")
 	log.Printf("Inserting procedure step: %v", procedureStep)
 
 	query := `
 		INSERT INTO procedure_steps (
-			step, recipe_id, created_by, created_date, updated_by, updated_date
-		) VALUES ($1, $2, $3, $4, $5, $6)
+			step, recipe_id, created_by, created_date, updated_by, updated_date, timing
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
 		`
 
 	log.Printf("recipeID: %v", recipeID)
-	_, err := tx.Exec(ctx, query, procedureStep, recipeID, 1, time.Now(), 1, time.Now())
+	_, err := tx.Exec(ctx, query, procedureStep, recipeID, 1, time.Now(), 1, time.Now(), timing)
 	if err != nil {
 		log.Printf("Error inserting procedure step: %v", err)
 		return err
@@ -103,3 +105,80 @@ func(pr *ProcedureRepository) Get(ctx context.Context, recipeID int) ([]string,
 }
 
 
+// GetProceduresByRecipeIds retrieves the procedure steps for several recipes with a single query.
+// It requires a context and the IDs of the recipes to retrieve.
+// Returns the steps grouped by recipe ID, in insertion order, the steps' timings grouped the same way for the
+// recipes that have any, and an error if the retrieval fails.
+func (pr *ProcedureRepository) GetProceduresByRecipeIds(ctx context.Context, recipeIDs []int) (map[int][]string, map[int][]model.StepTiming, error) {
+	log.Println("Inside of GetProceduresByRecipeIds")
+	log.Printf("Retrieving procedure steps for %d recipes", len(recipeIDs))
+
+	connection, err := pr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v\n", err)
+		return nil, nil, err
+	}
+	defer connection.Release()
+
+	query := `
+		SELECT recipe_id, step, timing
+		FROM procedure_steps
+		WHERE recipe_id = ANY($1)
+		ORDER BY recipe_id, id
+	`
+
+	result, err := connection.Query(ctx, query, recipeIDs)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, nil, err
+	}
+	defer result.Close()
+
+	procedures := make(map[int][]string)
+	timings := make(map[int][]model.StepTiming)
+	timed := make(map[int]bool)
+
+	for result.Next() {
+		var recipeID int
+		var procedureStep string
+		var timing *model.StepTiming
+
+		if err := result.Scan(&recipeID, &procedureStep, &timing); err != nil {
+			log.Printf("Error scanning procedure step: %v\n", err)
+			return nil, nil, err
+		}
+
+		procedures[recipeID] = append(procedures[recipeID], procedureStep)
+
+		// steps without a timing keep their place with an empty one.
+		if timing == nil {
+			timing = &model.StepTiming{}
+		} else {
+			timed[recipeID] = true
+		}
+		timings[recipeID] = append(timings[recipeID], *timing)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving procedure steps: %v\n", result.Err())
+		return nil, nil, result.Err()
+	}
+
+	for recipeID := range timings {
+		if !timed[recipeID] {
+			delete(timings, recipeID)
+		}
+	}
+
+	return procedures, timings, nil
+}
+
+// private functions
+
+// stepTiming returns the timing of the step at index i, or nil if it has none.
+func stepTiming(timings []model.StepTiming, i int) *model.StepTiming {
+	if i >= len(timings) || timings[i].IsZero() {
+		return nil
+	}
+	return &timings[i]
+}
@@ -3,7 +3,10 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -25,40 +28,63 @@ func NewRecipeRepository(pool *pgxpool.Pool) *RecipeRepository {
 
 // Insert adds a new recipe to the database within a transaction.
 // It requires a context, the recipe model, and an active transaction.
-// Returns the inserted recipe with its ID populated, or an error if the insertion fails.
-func (r *RecipeRepository) Insert(ctx context.Context, model *model.Recipe,  transactionHandler pgx.Tx) (*model.Recipe, error) {
-	log.Printf("Starting database insertion for recipe: %s", model.RecipeName)
+// Returns the inserted recipe with its ID populated, model.ErrAlreadyExists if a recipe already has its name,
+// or an error if the insertion fails.
+func (r *RecipeRepository) Insert(ctx context.Context, recipe *model.Recipe,  transactionHandler pgx.Tx) (*model.Recipe, error) {
+	log.Printf("Starting database insertion for recipe: %s", recipe.RecipeName)
 
 	query := `
 		INSERT INTO recipes (
 			recipe_name, description, prep_time_minutes, 
 			cook_time_minutes, servings, created_by,
-			created_date, updated_by, updated_date
+			created_date, updated_by, updated_date,
+			` + yieldColumns + `,
+			` + metadataColumns + `
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9
+			$1, $2, $3, $4, $5, $6, $7, $8, $9,
+			$10, $11, $12, $13, $14, $15, $16, $17, $18,
+			$19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
 		) RETURNING id`
 
-	err := transactionHandler.QueryRow(
-		ctx,
-		query,
-		model.RecipeName,
-		model.Description,
-		model.PrepTimeMinutes,
-		model.CookTimeMinutes,
-		model.Servings,
-		model.CreatedBy,
-		model.CreatedDate,
-		model.UpdatedBy,
-		model.UpdatedDate,
-	).Scan(&model.ID)
+	args := append([]any{
+		recipe.RecipeName,
+		recipe.Description,
+		recipe.PrepTimeMinutes,
+		recipe.CookTimeMinutes,
+		recipe.Servings,
+		recipe.CreatedBy,
+		recipe.CreatedDate,
+		recipe.UpdatedBy,
+		recipe.UpdatedDate,
+	}, yieldValues(recipe.Yield)...)
+	args = append(args, metadataValues(recipe)...)
+
+	err := transactionHandler.QueryRow(ctx, query, args...).Scan(&recipe.ID)
 
 	if err != nil {
+		if isPgError(err, uniqueViolation) {
+			return nil, model.ErrAlreadyExists("recipe")
+		}
 		log.Printf("Error inserting recipe into database: %v", err)
 		return nil, err
 	}
 
-	log.Printf("Successfully inserted recipe with ID: %d", model.ID)
-	return model, nil
+	log.Printf("Successfully inserted recipe with ID: %d", recipe.ID)
+	return recipe, nil
+}
+
+// NameExists reports whether a recipe already has a name. Recipe names are unique, so Insert fails with
+// model.ErrAlreadyExists for such a name.
+func (r *RecipeRepository) NameExists(ctx context.Context, recipeName string) (bool, error) {
+	var exists bool
+
+	err := r.ConnectionPool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM recipes WHERE recipe_name = $1)`, recipeName).Scan(&exists)
+	if err != nil {
+		log.Printf("Error checking for recipe name %s: %v", recipeName, err)
+		return false, err
+	}
+
+	return exists, nil
 }
 
 // Get retrieves a recipe from the database by its ID.
@@ -82,9 +108,10 @@ func (r *RecipeRepository) Get(ctx context.Context, recipeID int) (*model.Recipe
 
 	// take ID and retreive data from database.
 	recipeQuery :=  `
-		SELECT recipe_name, description, prep_time_minutes, cook_time_minutes, servings
-		FROM recipes
-		WHERE id = $1
+		SELECT ` + recipeColumns + `
+		FROM recipes r
+		LEFT JOIN recipe_rating_summaries rs ON rs.recipe_id = r.id
+		WHERE r.id = $1
 	`
 	result, err := connection.Query(ctx, recipeQuery, recipeID)
 
@@ -93,18 +120,12 @@ func (r *RecipeRepository) Get(ctx context.Context, recipeID int) (*model.Recipe
 		return nil, err
 	}
 
-	var recipe model.Recipe
+	recipe := &model.Recipe{}
 
 	// scan the data into the recipe model
 	if result.Next() {
 
-		err = result.Scan(
-			&recipe.RecipeName,
-			&recipe.Description,
-			&recipe.PrepTimeMinutes,
-			&recipe.CookTimeMinutes,
-			&recipe.Servings,
-		)
+		recipe, err = scanRecipe(result)
 
 		if err != nil {
 			log.Printf("Error happened when retrieving the random ID to generate from database: ")
@@ -112,14 +133,14 @@ func (r *RecipeRepository) Get(ctx context.Context, recipeID int) (*model.Recipe
 		}
 	}
 
-	return &recipe, nil
+	return recipe, nil
 }
 
 
 // GetRandomRecipeId retrieves a random recipe ID from the database.
-// It requires a context.
+// It requires a context, and the equipment the cook owns to leave out recipes that need anything else, or nil.
 // Returns the random recipe ID and an error if the retrieval fails.
-func (r *RecipeRepository) GetRandomRecipeId(ctx context.Context) (int, error) {
+func (r *RecipeRepository) GetRandomRecipeId(ctx context.Context, ownedEquipment []string) (int, error) {
 
 	// grab connection from connection pool
 	connection, err := r.ConnectionPool.Acquire(ctx)
@@ -137,8 +158,8 @@ func (r *RecipeRepository) GetRandomRecipeId(ctx context.Context) (int, error) {
 	// then grab that ID from the database. 
 	selectRecipeQuery := `
 		WITH random_row AS (
-		SELECT id FROM recipes 
-		OFFSET floor(random() * (SELECT COUNT(*) FROM recipes))
+		SELECT id FROM recipes r WHERE ` + ownedEquipmentCondition("$1") + `
+		OFFSET floor(random() * (SELECT COUNT(*) FROM recipes r WHERE ` + ownedEquipmentCondition("$1") + `))
 		LIMIT 1)
 		SELECT id FROM random_row
 	`
@@ -146,6 +167,7 @@ func (r *RecipeRepository) GetRandomRecipeId(ctx context.Context) (int, error) {
 	result, err := connection.Query(
 		ctx,
 		selectRecipeQuery,
+		ownedEquipment,
 	)
 
 	// check if something went wrong with the query 
@@ -172,3 +194,685 @@ func (r *RecipeRepository) GetRandomRecipeId(ctx context.Context) (int, error) {
 
 	return randomID, nil
 }
+
+// GetByIds retrieves several recipes by ID along with their ingredients, procedure steps, tags and equipment.
+// It runs one query each against recipes, ingredients, procedure_steps, recipe_tags and recipe_equipment no
+// matter how many recipes are requested, so callers never need a round trip per recipe.
+// Returns the hydrated recipes keyed by ID; IDs that do not exist are left out of the map.
+func (r *RecipeRepository) GetByIds(ctx context.Context, recipeIDs []int) (map[int]*model.Recipe, error) {
+	log.Printf("inside GetByIds function of RecipeRepository")
+	log.Printf("Retrieving %d recipes from database.", len(recipeIDs))
+
+	if len(recipeIDs) == 0 {
+		return map[int]*model.Recipe{}, nil
+	}
+
+	recipes, err := r.getRecipeRowsByIds(ctx, recipeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ingredients, err := NewIngredientsRepository(r.ConnectionPool).GetIngredientsByRecipeIds(ctx, recipeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	procedures, timings, err := NewProcedureRepository(r.ConnectionPool).GetProceduresByRecipeIds(ctx, recipeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := NewTagRepository(r.ConnectionPool).GetTagsByRecipeIds(ctx, recipeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	equipment, err := NewEquipmentRepository(r.ConnectionPool).GetEquipmentByRecipeIds(ctx, recipeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, recipe := range recipes {
+		recipe.Ingredients = ingredients[id]
+		recipe.Procedure = procedures[id]
+		recipe.StepTimings = timings[id]
+		recipe.Tags = tags[id]
+		recipe.Equipment = equipment[id]
+	}
+
+	return recipes, nil
+}
+
+// GetAll retrieves every recipe along with its ingredients, procedure steps and tags, in ID order.
+// It runs the same four queries as GetByIds, so it is meant for building in-memory indexes rather than
+// serving requests directly.
+func (r *RecipeRepository) GetAll(ctx context.Context) ([]*model.Recipe, error) {
+	log.Printf("Retrieving all recipes from database.")
+
+	connection, err := r.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+
+	recipeIDs := []int{}
+
+	result, err := connection.Query(ctx, `SELECT id FROM recipes ORDER BY id`)
+	if err != nil {
+		connection.Release()
+		log.Printf("Error retrieving recipe IDs: %v", err)
+		return nil, err
+	}
+
+	for result.Next() {
+		var recipeID int
+		if err := result.Scan(&recipeID); err != nil {
+			result.Close()
+			connection.Release()
+			log.Printf("Error scanning recipe ID: %v", err)
+			return nil, err
+		}
+		recipeIDs = append(recipeIDs, recipeID)
+	}
+	result.Close()
+	connection.Release()
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving recipe IDs: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	recipes, err := r.GetByIds(ctx, recipeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// recipes deleted since the IDs were read are left out.
+	ordered := make([]*model.Recipe, 0, len(recipes))
+	for _, recipeID := range recipeIDs {
+		if recipe, ok := recipes[recipeID]; ok {
+			ordered = append(ordered, recipe)
+		}
+	}
+
+	return ordered, nil
+}
+
+// getRecipeRowsByIds retrieves the recipe rows for several IDs without ingredients or procedure steps.
+func (r *RecipeRepository) getRecipeRowsByIds(ctx context.Context, recipeIDs []int) (map[int]*model.Recipe, error) {
+	connection, err := r.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	recipeQuery := `
+		SELECT ` + recipeColumns + `
+		FROM recipes r
+		LEFT JOIN recipe_rating_summaries rs ON rs.recipe_id = r.id
+		WHERE r.id = ANY($1)
+	`
+
+	result, err := connection.Query(ctx, recipeQuery, recipeIDs)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", recipeQuery)
+		return nil, err
+	}
+	defer result.Close()
+
+	recipes := make(map[int]*model.Recipe, len(recipeIDs))
+
+	for result.Next() {
+		recipe, err := scanRecipe(result)
+		if err != nil {
+			log.Printf("Error scanning recipe: %v", err)
+			return nil, err
+		}
+
+		recipes[recipe.ID] = recipe
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving recipes: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return recipes, nil
+}
+
+// Sort orders accepted by RecipeRepository.List.
+const (
+	RecipeSortName   = "name"
+	RecipeSortNewest = "newest"
+	RecipeSortRating = "rating"
+	// RecipeSortCost and RecipeSortCostPerServing list recipes in the order of RecipeListOptions.CostOrder,
+	// which the caller works out from ingredient prices.
+	RecipeSortCost           = "cost"
+	RecipeSortCostPerServing = "cost-per-serving"
+)
+
+// ratingPriorWeight is how many reviews at the overall average rating every recipe is assumed to
+// start with when sorting by rating. This Bayesian average pulls recipes with only a few reviews
+// toward the overall mean, so a single 5-star review doesn't top the chart.
+const ratingPriorWeight = 5
+
+// RecipeListOptions controls the filtering, sorting and pagination of RecipeRepository.List.
+type RecipeListOptions struct {
+	Tags            []string // Only include recipes that have every one of these tags
+	MaxTotalMinutes int      // Only include recipes whose prep plus cook time is at most this, if set
+	RecipeIds       []int    // Only include these recipes, if not nil
+	OwnedEquipment  []string // Only include recipes that need no equipment outside this, if not nil
+	CostOrder       []int    // Recipe IDs cheapest first for the cost sorts; recipes not in it come last
+	Sort            string   // One of the RecipeSort constants, defaults to RecipeSortName
+	Limit           int      // Maximum number of recipes to return
+	Offset          int      // Number of recipes to skip
+}
+
+// recipeListQuery holds the pieces of a recipe list query that depend on the list options.
+type recipeListQuery struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg adds a query argument and returns its placeholder.
+func (q *recipeListQuery) arg(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// where returns the WHERE clause for the collected conditions, or an empty string if there are none.
+func (q *recipeListQuery) where() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// List retrieves a page of recipes with their rating summaries.
+// NOTE: The returned recipes do not have any ingredients or procedure steps set.
+// Returns the recipes, the total number of recipes across all pages, and an error if the retrieval fails.
+func (r *RecipeRepository) List(ctx context.Context, options RecipeListOptions) ([]model.Recipe, int, error) {
+	log.Printf("inside List function of RecipeRepository")
+	log.Printf("Listing recipes sorted by %q, limit %d, offset %d", options.Sort, options.Limit, options.Offset)
+
+	var orderBy string
+
+	switch options.Sort {
+	case "", RecipeSortName:
+		orderBy = "r.recipe_name, r.id"
+	case RecipeSortNewest:
+		orderBy = "r.created_date DESC, r.id DESC"
+	case RecipeSortRating:
+		orderBy = fmt.Sprintf(
+			"(%[1]d * o.mean + COALESCE(rs.average_rating * rs.rating_count, 0)) / (%[1]d + COALESCE(rs.rating_count, 0)) DESC, r.id",
+			ratingPriorWeight,
+		)
+	case RecipeSortCost, RecipeSortCostPerServing:
+		// the placeholder is filled in after the count query, which doesn't take the order as an argument.
+		orderBy = "array_position(%s::INT[], r.id) NULLS LAST, r.recipe_name, r.id"
+	default:
+		return nil, 0, model.ErrInvalidField("sort")
+	}
+
+	var listQuery recipeListQuery
+
+	if tags := model.NormalizeTags(options.Tags); len(tags) > 0 {
+		listQuery.conditions = append(listQuery.conditions, fmt.Sprintf(
+			"(SELECT COUNT(*) FROM recipe_tags t WHERE t.recipe_id = r.id AND t.tag = ANY(%s)) = %d",
+			listQuery.arg(tags),
+			len(tags),
+		))
+	}
+
+	if options.RecipeIds != nil {
+		listQuery.conditions = append(listQuery.conditions, fmt.Sprintf("r.id = ANY(%s)", listQuery.arg(options.RecipeIds)))
+	}
+
+	if options.OwnedEquipment != nil {
+		listQuery.conditions = append(listQuery.conditions, ownedEquipmentCondition(listQuery.arg(options.OwnedEquipment)))
+	}
+
+	if options.MaxTotalMinutes > 0 {
+		listQuery.conditions = append(listQuery.conditions, fmt.Sprintf(
+			"COALESCE(r.prep_time_minutes, 0) + COALESCE(r.cook_time_minutes, 0) <= %s",
+			listQuery.arg(options.MaxTotalMinutes),
+		))
+	}
+
+	connection, err := r.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, 0, err
+	}
+	defer connection.Release()
+
+	// the count and the page share the same FROM and WHERE so filters can use any joined table.
+	with := `
+		WITH overall AS (
+			SELECT COALESCE(AVG(rating), 0)::DOUBLE PRECISION AS mean FROM recipe_reviews
+		)`
+	from := `
+		FROM recipes r
+		CROSS JOIN overall o
+		LEFT JOIN recipe_rating_summaries rs ON rs.recipe_id = r.id
+		` + listQuery.where()
+
+	countQuery := with + ` SELECT COUNT(*) ` + from
+
+	var total int
+	if err := connection.QueryRow(ctx, countQuery, listQuery.args...).Scan(&total); err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", countQuery)
+		return nil, 0, err
+	}
+
+	if options.Sort == RecipeSortCost || options.Sort == RecipeSortCostPerServing {
+		orderBy = fmt.Sprintf(orderBy, listQuery.arg(options.CostOrder))
+	}
+
+	limit := listQuery.arg(options.Limit)
+	offset := listQuery.arg(options.Offset)
+
+	recipeQuery := with + `
+		SELECT ` + recipeColumns + `
+		` + from + `
+		ORDER BY ` + orderBy + `
+		LIMIT ` + limit + ` OFFSET ` + offset
+
+	result, err := connection.Query(ctx, recipeQuery, listQuery.args...)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", recipeQuery)
+		return nil, 0, err
+	}
+	defer result.Close()
+
+	recipes := []model.Recipe{}
+
+	for result.Next() {
+		recipe, err := scanRecipe(result)
+		if err != nil {
+			log.Printf("Error scanning recipe: %v", err)
+			return nil, 0, err
+		}
+
+		recipes = append(recipes, *recipe)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error listing recipes: %v", result.Err())
+		return nil, 0, result.Err()
+	}
+
+	return recipes, total, nil
+}
+
+// GetRandomRecipeIdFavoringNotRecentlyCooked retrieves a random recipe ID, weighting each recipe by how long
+// it has been since the user last cooked it. Recipes the user has never cooked, or last cooked a year or
+// more ago, are the most likely to be picked; one cooked yesterday is very unlikely.
+// It requires a context, the ID of the user whose cook log is used, and the equipment the user owns to leave
+// out recipes that need anything else, or nil.
+// Returns the random recipe ID and an error if the retrieval fails.
+func (r *RecipeRepository) GetRandomRecipeIdFavoringNotRecentlyCooked(ctx context.Context, userID int, ownedEquipment []string) (int, error) {
+	log.Printf("Retrieving random recipe ID favoring recipes user %d has not cooked recently", userID)
+
+	// weighted random sampling: each recipe draws an exponential key with rate equal to its weight
+	// and the smallest key wins, so a recipe's chance of being picked is proportional to its weight.
+	query := `
+		SELECT r.id
+		FROM recipes r
+		LEFT JOIN (
+			SELECT recipe_id, MAX(cooked_date) AS last_cooked_date
+			FROM cook_logs
+			WHERE user_id = $1
+			GROUP BY recipe_id
+		) lc ON lc.recipe_id = r.id
+		WHERE ` + ownedEquipmentCondition("$2") + `
+		ORDER BY -ln(1.0 - random()) / (LEAST(COALESCE(CURRENT_DATE - lc.last_cooked_date, 365), 365) + 1)
+		LIMIT 1
+	`
+
+	var randomID int
+
+	err := r.ConnectionPool.QueryRow(ctx, query, userID, ownedEquipment).Scan(&randomID)
+	if err == pgx.ErrNoRows {
+		return 0, model.ErrNotFound("recipe")
+	}
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return 0, err
+	}
+
+	return randomID, nil
+}
+
+// Merge folds duplicate recipes into the one being kept, in a single transaction. The kept recipe is updated
+// to the merged recipe's description, times, servings, ingredients, procedure, tags and equipment. Reviews, cook logs,
+// meal plan entries, collection entries, images and other recipes' uses of them as components move from the
+// duplicates to the kept recipe, and the duplicates are deleted. Where a user already reviewed the kept recipe, or a collection already holds it,
+// the duplicate's review or entry is dropped instead of moved.
+//
+// Parameters:
+//   - ctx: The request context
+//   - merged: The merged recipe, with the kept recipe's ID
+//   - duplicateIDs: The recipes to merge into it
+//   - userID: The user making the change
+//
+// Returns:
+//   - error: model.ErrNotFound if any of the recipes does not exist, or an error if the merge fails
+func (r *RecipeRepository) Merge(ctx context.Context, merged *model.Recipe, duplicateIDs []int, userID int) error {
+	log.Printf("Merging recipes %v into recipe with ID: %d", duplicateIDs, merged.ID)
+
+	tx, err := r.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	allIDs := append([]int{merged.ID}, duplicateIDs...)
+
+	// lock the recipes in ID order so merges running at the same time can't deadlock.
+	result, err := tx.Query(ctx, `SELECT id FROM recipes WHERE id = ANY($1) ORDER BY id FOR UPDATE`, allIDs)
+	if err != nil {
+		log.Printf("Error locking recipes: %v", err)
+		return err
+	}
+	locked := 0
+	for result.Next() {
+		locked++
+	}
+	result.Close()
+	if result.Err() != nil {
+		log.Printf("Error locking recipes: %v", result.Err())
+		return result.Err()
+	}
+	if locked != len(allIDs) {
+		return model.ErrNotFound("recipe")
+	}
+
+	now := time.Now()
+
+	names := make([]string, len(merged.Ingredients))
+	units := make([]string, len(merged.Ingredients))
+	amounts := make([]float64, len(merged.Ingredients))
+	components := make([]*int, len(merged.Ingredients))
+	for i, ingredient := range merged.Ingredients {
+		names[i] = ingredient.IngredientName
+		units[i] = ingredient.UnitOfMeasurement
+		amounts[i] = ingredient.Amount
+		components[i] = ingredient.ComponentRecipeId
+	}
+
+	timings := make([]*model.StepTiming, len(merged.Procedure))
+	for i := range merged.Procedure {
+		timings[i] = stepTiming(merged.StepTimings, i)
+	}
+
+	statements := []struct {
+		description string
+		query       string
+		args        []any
+	}{
+		{
+			"updating merged recipe",
+			`UPDATE recipes
+			SET description = $2, prep_time_minutes = $3, cook_time_minutes = $4, servings = $5,
+				updated_by = $6, updated_date = $7,
+				(` + yieldColumns + `) = ($8, $9, $10, $11, $12, $13, $14, $15, $16),
+				(` + metadataColumns + `) = ($17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28)
+			WHERE id = $1`,
+			append(
+				append(
+					[]any{merged.ID, merged.Description, merged.PrepTimeMinutes, merged.CookTimeMinutes, merged.Servings, userID, now},
+					yieldValues(merged.Yield)...,
+				),
+				metadataValues(merged)...,
+			),
+		},
+		{
+			"replacing ingredients",
+			`DELETE FROM ingredients WHERE recipe_id = $1`,
+			[]any{merged.ID},
+		},
+		{
+			"replacing ingredients",
+			`INSERT INTO ingredients (
+				unit_of_measurement, ingredient_name, unit_amount, recipe_id, created_by, created_date, updated_by, updated_date,
+				component_recipe_id
+			)
+			SELECT unit, name, amount, $4, $5, $6, $5, $6, component
+			FROM unnest($1::varchar[], $2::varchar[], $3::float8[], $7::int[])
+				WITH ORDINALITY AS i(unit, name, amount, component, position)
+			ORDER BY position`,
+			[]any{units, names, amounts, merged.ID, userID, now, components},
+		},
+		{
+			"replacing procedure",
+			`DELETE FROM procedure_steps WHERE recipe_id = $1`,
+			[]any{merged.ID},
+		},
+		{
+			"replacing procedure",
+			`INSERT INTO procedure_steps (step, recipe_id, created_by, created_date, updated_by, updated_date, timing)
+			SELECT step, $2, $3, $4, $3, $4, timing
+			FROM unnest($1::text[], $5::jsonb[]) WITH ORDINALITY AS p(step, timing, position)
+			ORDER BY position`,
+			[]any{merged.Procedure, merged.ID, userID, now, timings},
+		},
+		{
+			"merging tags",
+			`INSERT INTO recipe_tags (recipe_id, tag, created_by, created_date)
+			SELECT $1, tag, $3, $4 FROM unnest($2::varchar[]) AS tag
+			ON CONFLICT (recipe_id, tag) DO NOTHING`,
+			[]any{merged.ID, merged.Tags, userID, now},
+		},
+		{
+			"merging equipment",
+			`INSERT INTO recipe_equipment (recipe_id, equipment, created_by, created_date)
+			SELECT $1, equipment, $3, $4 FROM unnest($2::varchar[]) AS equipment
+			ON CONFLICT (recipe_id, equipment) DO NOTHING`,
+			[]any{merged.ID, merged.Equipment, userID, now},
+		},
+		{
+			// each user keeps one review: their review of the kept recipe, or else their latest of the duplicates.
+			"moving reviews",
+			`UPDATE recipe_reviews SET recipe_id = $1
+			WHERE id IN (
+				SELECT DISTINCT ON (user_id) id
+				FROM recipe_reviews
+				WHERE recipe_id = ANY($2)
+					AND user_id NOT IN (SELECT user_id FROM recipe_reviews WHERE recipe_id = $1)
+				ORDER BY user_id, updated_date DESC, id DESC
+			)`,
+			[]any{merged.ID, duplicateIDs},
+		},
+		{
+			"moving cook logs",
+			`UPDATE cook_logs SET recipe_id = $1 WHERE recipe_id = ANY($2)`,
+			[]any{merged.ID, duplicateIDs},
+		},
+		{
+			"moving meal plan entries",
+			`UPDATE meal_plans SET recipe_id = $1 WHERE recipe_id = ANY($2)`,
+			[]any{merged.ID, duplicateIDs},
+		},
+		{
+			"moving collection entries",
+			`UPDATE collection_recipes SET recipe_id = $1
+			WHERE id IN (
+				SELECT DISTINCT ON (collection_id) id
+				FROM collection_recipes
+				WHERE recipe_id = ANY($2)
+					AND collection_id NOT IN (SELECT collection_id FROM collection_recipes WHERE recipe_id = $1)
+				ORDER BY collection_id, position
+			)`,
+			[]any{merged.ID, duplicateIDs},
+		},
+		{
+			// recipes that use a duplicate as a component use the kept recipe instead.
+			"moving component references",
+			`UPDATE ingredients SET component_recipe_id = $1 WHERE component_recipe_id = ANY($2)`,
+			[]any{merged.ID, duplicateIDs},
+		},
+		{
+			"moving images",
+			`UPDATE food_images SET recipe_id = $1 WHERE recipe_id = ANY($2)`,
+			[]any{merged.ID, duplicateIDs},
+		},
+		{
+			// ingredients and procedure steps don't cascade, the rest of what is left does.
+			"deleting duplicates",
+			`DELETE FROM ingredients WHERE recipe_id = ANY($1)`,
+			[]any{duplicateIDs},
+		},
+		{
+			"deleting duplicates",
+			`DELETE FROM procedure_steps WHERE recipe_id = ANY($1)`,
+			[]any{duplicateIDs},
+		},
+		{
+			"deleting duplicates",
+			`DELETE FROM recipes WHERE id = ANY($1)`,
+			[]any{duplicateIDs},
+		},
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(ctx, statement.query, statement.args...); err != nil {
+			log.Printf("Error %s: %v", statement.description, err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// private functions
+
+// recipeColumns is the select list scanned by scanRecipe, from recipes aliased r and
+// recipe_rating_summaries aliased rs.
+const recipeColumns = `
+	r.id, r.recipe_name, COALESCE(r.description, ''), COALESCE(r.prep_time_minutes, 0),
+	COALESCE(r.cook_time_minutes, 0), COALESCE(r.servings, 0),
+	COALESCE(rs.average_rating, 0), COALESCE(rs.rating_count, 0),
+	r.yield_quantity, COALESCE(r.yield_unit, ''), COALESCE(r.pan_shape, ''), COALESCE(r.pan_length, 0),
+	COALESCE(r.pan_width, 0), COALESCE(r.pan_diameter, 0), COALESCE(r.pan_depth, 0), COALESCE(r.pan_count, 0),
+	COALESCE(r.pan_unit, ''),
+	COALESCE(r.source_author, ''), COALESCE(r.source_cookbook, ''), COALESCE(r.source_page, 0),
+	COALESCE(r.source_url, ''), COALESCE(r.source_family_member, ''), COALESCE(r.notes, ''),
+	COALESCE(r.make_ahead, ''), COALESCE(r.storage, ''), COALESCE(r.freezing, ''), COALESCE(r.difficulty, ''),
+	COALESCE(r.cuisine, ''), COALESCE(r.course, '')
+`
+
+// yieldColumns are the recipe columns holding the yield, in the order of yieldValues.
+const yieldColumns = `yield_quantity, yield_unit, pan_shape, pan_length, pan_width, pan_diameter, pan_depth, pan_count, pan_unit`
+
+// yieldValues returns the values of yieldColumns for a yield, with NULLs for a missing yield or pan.
+func yieldValues(yield *model.RecipeYield) []any {
+	values := make([]any, 9)
+	if yield == nil {
+		return values
+	}
+
+	values[0], values[1] = yield.Quantity, yield.Unit
+	if pan := yield.Pan; pan != nil {
+		values[2], values[3], values[4], values[5] = pan.Shape, pan.Length, pan.Width, pan.Diameter
+		values[6], values[7], values[8] = pan.Depth, pan.Pans(), pan.Unit
+	}
+
+	return values
+}
+
+// metadataColumns are the recipe columns holding the attribution, notes and guidance, in the order of
+// metadataValues.
+const metadataColumns = `source_author, source_cookbook, source_page, source_url, source_family_member,
+	notes, make_ahead, storage, freezing, difficulty, cuisine, course`
+
+// metadataValues returns the values of metadataColumns for a recipe, with NULLs for what isn't set.
+func metadataValues(recipe *model.Recipe) []any {
+	source := recipe.Source
+	if source == nil {
+		source = &model.Attribution{}
+	}
+
+	var page *int
+	if source.Page > 0 {
+		page = &source.Page
+	}
+
+	return []any{
+		nullString(source.Author), nullString(source.Cookbook), page, nullString(source.Url),
+		nullString(source.FamilyMember), nullString(recipe.Notes), nullString(recipe.MakeAhead),
+		nullString(recipe.Storage), nullString(recipe.Freezing), nullString(recipe.Difficulty),
+		nullString(recipe.Cuisine), nullString(recipe.Course),
+	}
+}
+
+// nullString returns nil for an empty string, so it's stored as NULL, or else the string.
+func nullString(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// scanRecipe scans a row selecting recipeColumns.
+func scanRecipe(row pgx.Row) (*model.Recipe, error) {
+	var recipe model.Recipe
+	var yieldQuantity *float64
+	var yieldUnit string
+	var pan model.Pan
+	var source model.Attribution
+
+	err := row.Scan(
+		&recipe.ID,
+		&recipe.RecipeName,
+		&recipe.Description,
+		&recipe.PrepTimeMinutes,
+		&recipe.CookTimeMinutes,
+		&recipe.Servings,
+		&recipe.AverageRating,
+		&recipe.RatingCount,
+		&yieldQuantity,
+		&yieldUnit,
+		&pan.Shape,
+		&pan.Length,
+		&pan.Width,
+		&pan.Diameter,
+		&pan.Depth,
+		&pan.Count,
+		&pan.Unit,
+		&source.Author,
+		&source.Cookbook,
+		&source.Page,
+		&source.Url,
+		&source.FamilyMember,
+		&recipe.Notes,
+		&recipe.MakeAhead,
+		&recipe.Storage,
+		&recipe.Freezing,
+		&recipe.Difficulty,
+		&recipe.Cuisine,
+		&recipe.Course,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if yieldQuantity != nil {
+		recipe.Yield = &model.RecipeYield{Quantity: *yieldQuantity, Unit: yieldUnit}
+		if pan.Shape != "" {
+			recipe.Yield.Pan = &pan
+		}
+	}
+	if !source.IsZero() {
+		recipe.Source = &source
+	}
+
+	return &recipe, nil
+}
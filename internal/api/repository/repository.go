@@ -5,6 +5,7 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // Repository defines a generic interface for database operations.
@@ -31,3 +32,14 @@ type Repository[T any] interface {
 	// Returns an error if the deletion fails.
 	Delete(item T) error
 }
+
+// executor is satisfied by pgx.Tx, *pgxpool.Conn and *pgxpool.Pool, so a statement can be
+// written once and run either inside a caller's transaction or on its own.
+type executor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// querier is the same as executor for statements that return rows.
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
@@ -0,0 +1,193 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// ReviewRepository handles database operations related to recipe ratings and reviews.
+type ReviewRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewReviewRepository creates a new instance of ReviewRepository.
+// It requires a database connection pool to perform database operations.
+func NewReviewRepository(pool *pgxpool.Pool) *ReviewRepository {
+	return &ReviewRepository{ConnectionPool: pool}
+}
+
+// Upsert saves a user's review of a recipe, replacing the rating and text of their existing review if they have one.
+// Returns the saved review with its ID and timestamps populated, or model.ErrNotFound if the recipe does not exist.
+func (rr *ReviewRepository) Upsert(ctx context.Context, review *model.Review) (*model.Review, error) {
+	log.Printf("Saving review of recipe %d by user %d", review.RecipeId, review.UserId)
+
+	query := `
+		INSERT INTO recipe_reviews (
+			recipe_id, user_id, rating, review_text,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+		ON CONFLICT (recipe_id, user_id)
+		DO UPDATE SET
+			rating = EXCLUDED.rating,
+			review_text = EXCLUDED.review_text,
+			updated_by = EXCLUDED.updated_by,
+			updated_date = EXCLUDED.updated_date
+		RETURNING id, created_by, created_date`
+
+	err := rr.ConnectionPool.QueryRow(
+		ctx,
+		query,
+		review.RecipeId,
+		review.UserId,
+		review.Rating,
+		review.ReviewText,
+		review.CreatedBy,
+		review.CreatedDate,
+		review.UpdatedBy,
+		review.UpdatedDate,
+	).Scan(&review.ID, &review.CreatedBy, &review.CreatedDate)
+
+	if err != nil {
+		if isPgError(err, foreignKeyViolation) {
+			return nil, model.ErrNotFound("recipe")
+		}
+		log.Printf("Error saving review: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Successfully saved review with ID: %d", review.ID)
+	return review, nil
+}
+
+// Delete removes a user's review of a recipe.
+// Returns model.ErrNotFound if the user has not reviewed the recipe.
+func (rr *ReviewRepository) Delete(ctx context.Context, recipeID int, userID int) error {
+	log.Printf("Deleting review of recipe %d by user %d", recipeID, userID)
+
+	tag, err := rr.ConnectionPool.Exec(ctx, `DELETE FROM recipe_reviews WHERE recipe_id = $1 AND user_id = $2`, recipeID, userID)
+	if err != nil {
+		log.Printf("Error deleting review: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("review")
+	}
+
+	return nil
+}
+
+// GetByRecipeId retrieves a page of a recipe's reviews, most recently updated first.
+// Returns the reviews, the total number of reviews for the recipe, and an error if the retrieval fails.
+func (rr *ReviewRepository) GetByRecipeId(ctx context.Context, recipeID int, limit int, offset int) ([]model.Review, int, error) {
+	log.Printf("Retrieving reviews for recipe %d, limit %d, offset %d", recipeID, limit, offset)
+
+	connection, err := rr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, 0, err
+	}
+	defer connection.Release()
+
+	var total int
+
+	err = connection.QueryRow(ctx, `SELECT COUNT(*) FROM recipe_reviews WHERE recipe_id = $1`, recipeID).Scan(&total)
+	if err != nil {
+		log.Printf("Error counting reviews: %v", err)
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT rv.id, rv.recipe_id, rv.user_id, u.username, rv.rating, COALESCE(rv.review_text, ''),
+			rv.created_by, rv.created_date, rv.updated_by, rv.updated_date
+		FROM recipe_reviews rv
+		JOIN users u ON u.id = rv.user_id
+		WHERE rv.recipe_id = $1
+		ORDER BY rv.updated_date DESC, rv.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	result, err := connection.Query(ctx, query, recipeID, limit, offset)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, 0, err
+	}
+	defer result.Close()
+
+	reviews := []model.Review{}
+
+	for result.Next() {
+		var review model.Review
+
+		err := result.Scan(
+			&review.ID,
+			&review.RecipeId,
+			&review.UserId,
+			&review.Username,
+			&review.Rating,
+			&review.ReviewText,
+			&review.CreatedBy,
+			&review.CreatedDate,
+			&review.UpdatedBy,
+			&review.UpdatedDate,
+		)
+		if err != nil {
+			log.Printf("Error scanning review: %v", err)
+			return nil, 0, err
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving reviews: %v", result.Err())
+		return nil, 0, result.Err()
+	}
+
+	return reviews, total, nil
+}
+
+// GetByUser retrieves a single user's review of a recipe.
+// Returns model.ErrNotFound if the user has not reviewed the recipe.
+func (rr *ReviewRepository) GetByUser(ctx context.Context, recipeID int, userID int) (*model.Review, error) {
+	query := `
+		SELECT rv.id, rv.recipe_id, rv.user_id, u.username, rv.rating, COALESCE(rv.review_text, ''),
+			rv.created_by, rv.created_date, rv.updated_by, rv.updated_date
+		FROM recipe_reviews rv
+		JOIN users u ON u.id = rv.user_id
+		WHERE rv.recipe_id = $1 AND rv.user_id = $2
+	`
+
+	var review model.Review
+
+	err := rr.ConnectionPool.QueryRow(ctx, query, recipeID, userID).Scan(
+		&review.ID,
+		&review.RecipeId,
+		&review.UserId,
+		&review.Username,
+		&review.Rating,
+		&review.ReviewText,
+		&review.CreatedBy,
+		&review.CreatedDate,
+		&review.UpdatedBy,
+		&review.UpdatedDate,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("review")
+	}
+	if err != nil {
+		log.Printf("Error retrieving review: %v", err)
+		return nil, err
+	}
+
+	return &review, nil
+}
@@ -0,0 +1,552 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"recipe-generator/internal/api/model"
+)
+
+// ShoppingListRepository handles database operations related to saved shopping lists, their items and events.
+//
+// Every change to a list's items locks the list's row for the rest of its transaction, so changes to one list
+// are applied one at a time and their events are numbered in the order the changes were made.
+type ShoppingListRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewShoppingListRepository creates a new instance of ShoppingListRepository.
+// It requires a database connection pool to perform database operations.
+func NewShoppingListRepository(pool *pgxpool.Pool) *ShoppingListRepository {
+	return &ShoppingListRepository{ConnectionPool: pool}
+}
+
+// shoppingListItemColumns is the select list scanned by scanShoppingListItem.
+const shoppingListItemColumns = `
+	i.id, i.ingredient_name, COALESCE(i.amount, 0), COALESCE(i.unit, ''), i.aisle, i.recipes, i.manual,
+	i.checked, COALESCE(i.checked_by, 0), i.checked_date, i.version
+`
+
+// Insert adds a new shopping list and its items to the database in a single transaction.
+// Returns the list with its ID and its items' IDs populated.
+func (sr *ShoppingListRepository) Insert(ctx context.Context, list *model.SavedShoppingList) (*model.SavedShoppingList, error) {
+	log.Printf("Starting database insertion for shopping list: %s", list.ListName)
+
+	tx, err := sr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	query := `
+		INSERT INTO shopping_lists (list_name, user_id, household_id, created_by, created_date, updated_by, updated_date)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7)
+		RETURNING id`
+
+	err = tx.QueryRow(
+		ctx,
+		query,
+		list.ListName,
+		list.UserId,
+		list.HouseholdId,
+		list.CreatedBy,
+		list.CreatedDate,
+		list.UpdatedBy,
+		list.UpdatedDate,
+	).Scan(&list.ID)
+
+	if err != nil {
+		log.Printf("Error inserting shopping list into database: %v", err)
+		return nil, err
+	}
+
+	for i := range list.Items {
+		item := &list.Items[i]
+		if err := sr.insertItem(ctx, tx, list.ID, item, i+1, list.CreatedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Successfully inserted shopping list with ID: %d", list.ID)
+	return list, nil
+}
+
+// Get retrieves a shopping list by ID, without its items.
+// Returns model.ErrNotFound if the list does not exist.
+func (sr *ShoppingListRepository) Get(ctx context.Context, listID int) (*model.SavedShoppingList, error) {
+	query := `
+		SELECT id, list_name, COALESCE(user_id, 0), COALESCE(household_id, 0),
+			created_by, created_date, updated_by, updated_date
+		FROM shopping_lists
+		WHERE id = $1
+	`
+
+	var list model.SavedShoppingList
+
+	err := sr.ConnectionPool.QueryRow(ctx, query, listID).Scan(
+		&list.ID,
+		&list.ListName,
+		&list.UserId,
+		&list.HouseholdId,
+		&list.CreatedBy,
+		&list.CreatedDate,
+		&list.UpdatedBy,
+		&list.UpdatedDate,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("shopping list")
+	}
+	if err != nil {
+		log.Printf("Error retrieving shopping list: %v", err)
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+// GetItems retrieves the items of a shopping list in aisle order.
+func (sr *ShoppingListRepository) GetItems(ctx context.Context, listID int) ([]model.ShoppingListItem, error) {
+	connection, err := sr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `SELECT ` + shoppingListItemColumns + ` FROM shopping_list_items i WHERE i.shopping_list_id = $1 ORDER BY i.position, i.id`
+
+	result, err := connection.Query(ctx, query, listID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	items := []model.ShoppingListItem{}
+
+	for result.Next() {
+		item, err := scanShoppingListItem(result)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving shopping list items: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return items, nil
+}
+
+// ListForScope retrieves the shopping lists of a user or household, newest first, without their items.
+func (sr *ShoppingListRepository) ListForScope(ctx context.Context, scope model.MealPlanScope) ([]model.SavedShoppingList, error) {
+	connection, err := sr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	column, scopeID := "user_id", scope.UserId
+	if scope.HouseholdId != 0 {
+		column, scopeID = "household_id", scope.HouseholdId
+	}
+
+	query := `
+		SELECT id, list_name, COALESCE(user_id, 0), COALESCE(household_id, 0),
+			created_by, created_date, updated_by, updated_date
+		FROM shopping_lists
+		WHERE ` + column + ` = $1
+		ORDER BY created_date DESC, id DESC
+	`
+
+	result, err := connection.Query(ctx, query, scopeID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	lists := []model.SavedShoppingList{}
+
+	for result.Next() {
+		var list model.SavedShoppingList
+
+		err := result.Scan(
+			&list.ID,
+			&list.ListName,
+			&list.UserId,
+			&list.HouseholdId,
+			&list.CreatedBy,
+			&list.CreatedDate,
+			&list.UpdatedBy,
+			&list.UpdatedDate,
+		)
+		if err != nil {
+			log.Printf("Error scanning shopping list: %v", err)
+			return nil, err
+		}
+
+		lists = append(lists, list)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving shopping lists: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return lists, nil
+}
+
+// Delete removes a shopping list with its items and events.
+// Returns model.ErrNotFound if the list does not exist.
+func (sr *ShoppingListRepository) Delete(ctx context.Context, listID int) error {
+	log.Printf("Deleting shopping list with ID: %d", listID)
+
+	tag, err := sr.ConnectionPool.Exec(ctx, `DELETE FROM shopping_lists WHERE id = $1`, listID)
+	if err != nil {
+		log.Printf("Error deleting shopping list: %v", err)
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return model.ErrNotFound("shopping list")
+	}
+
+	return nil
+}
+
+// AddItem adds an item to the end of a shopping list and records an item_added event.
+// Returns the event, which holds the saved item, or model.ErrNotFound if the list does not exist.
+func (sr *ShoppingListRepository) AddItem(ctx context.Context, listID int, item *model.ShoppingListItem, userID int) (*model.ShoppingListEvent, error) {
+	log.Printf("Adding item %s to shopping list %d", item.IngredientName, listID)
+
+	tx, err := sr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	if err := lockShoppingList(ctx, tx, listID); err != nil {
+		return nil, err
+	}
+
+	var position int
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(position), 0) + 1 FROM shopping_list_items WHERE shopping_list_id = $1`, listID).Scan(&position); err != nil {
+		log.Printf("Error finding shopping list position: %v", err)
+		return nil, err
+	}
+
+	if err := sr.insertItem(ctx, tx, listID, item, position, userID); err != nil {
+		return nil, err
+	}
+
+	event, err := insertShoppingListEvent(ctx, tx, listID, model.ShoppingListEventItemAdded, item.ID, item, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// SetChecked checks an item off or puts it back on the list.
+//
+// Changes to a list are applied one at a time, so when several people check off the same item at once
+// the first change to reach the database wins and is the one recorded as checkedBy. Later requests for the
+// state the item is already in change nothing and return a nil event with the current item. If
+// expectedVersion is not 0 and the item has been changed since the client saw that version, the request
+// fails with model.ErrConflict instead.
+//
+// Returns the item after the request, the event recorded if the item changed, and model.ErrNotFound if the
+// item is not on the list.
+func (sr *ShoppingListRepository) SetChecked(ctx context.Context, listID int, itemID int, checked bool, expectedVersion int, userID int) (*model.ShoppingListItem, *model.ShoppingListEvent, error) {
+	log.Printf("Setting checked to %t for item %d of shopping list %d", checked, itemID, listID)
+
+	tx, err := sr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	if err := lockShoppingList(ctx, tx, listID); err != nil {
+		return nil, nil, err
+	}
+
+	current, err := getShoppingListItem(ctx, tx, listID, itemID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if current.Checked == checked {
+		return current, nil, nil
+	}
+
+	if expectedVersion != 0 && current.Version != expectedVersion {
+		return nil, nil, model.ErrConflict("shopping list item has changed since you loaded it")
+	}
+
+	query := `
+		UPDATE shopping_list_items i
+		SET checked = $2,
+			checked_by = CASE WHEN $2 THEN $3::int END,
+			checked_date = CASE WHEN $2 THEN $4::timestamp END,
+			version = version + 1,
+			updated_by = $3,
+			updated_date = $4
+		WHERE i.id = $1
+		RETURNING ` + shoppingListItemColumns
+
+	item, err := scanShoppingListItem(tx.QueryRow(ctx, query, itemID, checked, userID, time.Now()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eventType := model.ShoppingListEventItemUnchecked
+	if checked {
+		eventType = model.ShoppingListEventItemChecked
+	}
+
+	event, err := insertShoppingListEvent(ctx, tx, listID, eventType, item.ID, item, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, nil, err
+	}
+
+	return item, event, nil
+}
+
+// RemoveItem removes an item from a shopping list and records an item_removed event.
+// Returns the event, or model.ErrNotFound if the item is not on the list.
+func (sr *ShoppingListRepository) RemoveItem(ctx context.Context, listID int, itemID int, userID int) (*model.ShoppingListEvent, error) {
+	log.Printf("Removing item %d from shopping list %d", itemID, listID)
+
+	tx, err := sr.ConnectionPool.Begin(ctx)
+	if err != nil {
+		log.Printf("Error starting transaction: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx) // Rollback if we don't commit
+
+	if err := lockShoppingList(ctx, tx, listID); err != nil {
+		return nil, err
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM shopping_list_items WHERE id = $1 AND shopping_list_id = $2`, itemID, listID)
+	if err != nil {
+		log.Printf("Error removing shopping list item: %v", err)
+		return nil, err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return nil, model.ErrNotFound("shopping list item")
+	}
+
+	event, err := insertShoppingListEvent(ctx, tx, listID, model.ShoppingListEventItemRemoved, itemID, nil, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("Error committing transaction: %v", err)
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// GetEventsSince retrieves a shopping list's events after the given event ID, oldest first.
+func (sr *ShoppingListRepository) GetEventsSince(ctx context.Context, listID int, lastEventID int64) ([]model.ShoppingListEvent, error) {
+	connection, err := sr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `
+		SELECT id, shopping_list_id, event_type, item_id, item, created_by, created_date
+		FROM shopping_list_events
+		WHERE shopping_list_id = $1 AND id > $2
+		ORDER BY id
+	`
+
+	result, err := connection.Query(ctx, query, listID, lastEventID)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	events := []model.ShoppingListEvent{}
+
+	for result.Next() {
+		var event model.ShoppingListEvent
+
+		err := result.Scan(
+			&event.ID,
+			&event.ShoppingListId,
+			&event.EventType,
+			&event.ItemId,
+			&event.Item,
+			&event.CreatedBy,
+			&event.CreatedDate,
+		)
+		if err != nil {
+			log.Printf("Error scanning shopping list event: %v", err)
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving shopping list events: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return events, nil
+}
+
+// private functions
+
+// insertItem inserts a shopping list item at the given position and sets its ID and version.
+func (sr *ShoppingListRepository) insertItem(ctx context.Context, tx pgx.Tx, listID int, item *model.ShoppingListItem, position int, userID int) error {
+	query := `
+		INSERT INTO shopping_list_items (
+			shopping_list_id, ingredient_name, amount, unit, aisle, recipes, manual, position,
+			created_by, created_date, updated_by, updated_date
+		) VALUES (
+			$1, $2, NULLIF($3, 0), NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $9, $10
+		) RETURNING id, version`
+
+	if item.Recipes == nil {
+		item.Recipes = []model.ShoppingListSource{}
+	}
+
+	err := tx.QueryRow(
+		ctx,
+		query,
+		listID,
+		item.IngredientName,
+		item.Amount,
+		item.Unit,
+		item.Aisle,
+		item.Recipes,
+		item.Manual,
+		position,
+		userID,
+		time.Now(),
+	).Scan(&item.ID, &item.Version)
+
+	if err != nil {
+		log.Printf("Error inserting shopping list item: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// lockShoppingList locks a shopping list's row until the end of the transaction.
+// Returns model.ErrNotFound if the list does not exist.
+func lockShoppingList(ctx context.Context, tx pgx.Tx, listID int) error {
+	var id int
+
+	err := tx.QueryRow(ctx, `SELECT id FROM shopping_lists WHERE id = $1 FOR UPDATE`, listID).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return model.ErrNotFound("shopping list")
+	}
+	if err != nil {
+		log.Printf("Error locking shopping list: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// getShoppingListItem retrieves an item of a shopping list within a transaction.
+// Returns model.ErrNotFound if the item is not on the list.
+func getShoppingListItem(ctx context.Context, tx pgx.Tx, listID int, itemID int) (*model.ShoppingListItem, error) {
+	query := `SELECT ` + shoppingListItemColumns + ` FROM shopping_list_items i WHERE i.id = $1 AND i.shopping_list_id = $2`
+
+	item, err := scanShoppingListItem(tx.QueryRow(ctx, query, itemID, listID))
+	if err == pgx.ErrNoRows {
+		return nil, model.ErrNotFound("shopping list item")
+	}
+	return item, err
+}
+
+// insertShoppingListEvent records a change to a shopping list.
+func insertShoppingListEvent(ctx context.Context, tx pgx.Tx, listID int, eventType string, itemID int, item *model.ShoppingListItem, userID int) (*model.ShoppingListEvent, error) {
+	event := &model.ShoppingListEvent{
+		ShoppingListId: listID,
+		EventType:      eventType,
+		ItemId:         itemID,
+		Item:           item,
+		CreatedBy:      userID,
+		CreatedDate:    time.Now(),
+	}
+
+	query := `
+		INSERT INTO shopping_list_events (shopping_list_id, event_type, item_id, item, created_by, created_date)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	err := tx.QueryRow(ctx, query, listID, eventType, itemID, item, userID, event.CreatedDate).Scan(&event.ID)
+	if err != nil {
+		log.Printf("Error inserting shopping list event: %v", err)
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// scanShoppingListItem scans a row selecting shoppingListItemColumns.
+func scanShoppingListItem(row pgx.Row) (*model.ShoppingListItem, error) {
+	var item model.ShoppingListItem
+
+	err := row.Scan(
+		&item.ID,
+		&item.IngredientName,
+		&item.Amount,
+		&item.Unit,
+		&item.Aisle,
+		&item.Recipes,
+		&item.Manual,
+		&item.Checked,
+		&item.CheckedBy,
+		&item.CheckedDate,
+		&item.Version,
+	)
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			log.Printf("Error scanning shopping list item: %v", err)
+		}
+		return nil, err
+	}
+
+	return &item, nil
+}
@@ -0,0 +1,87 @@
+// Package repository provides data access objects for interacting with the database.
+package repository
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TagRepository handles database operations related to recipe tags, such as dietary labels.
+type TagRepository struct {
+	ConnectionPool *pgxpool.Pool // Database connection pool
+}
+
+// NewTagRepository creates a new instance of TagRepository.
+// It requires a database connection pool to perform database operations.
+func NewTagRepository(pool *pgxpool.Pool) *TagRepository {
+	return &TagRepository{ConnectionPool: pool}
+}
+
+// Insert adds tags to a recipe within a transaction. Tags the recipe already has are ignored.
+// It requires a context, the recipe ID, the normalized tags, the user adding them, and an active transaction.
+// Returns an error if the insertion fails.
+func (tr *TagRepository) Insert(ctx context.Context, recipeID int, tags []string, createdBy int, tx pgx.Tx) error {
+	log.Printf("Inside of TagRepository.Insert")
+	log.Printf("Inserting %d tags for recipe %d", len(tags), recipeID)
+
+	query := `
+		INSERT INTO recipe_tags (recipe_id, tag, created_by, created_date)
+		SELECT $1, tag, $3, $4 FROM unnest($2::varchar[]) AS tag
+		ON CONFLICT (recipe_id, tag) DO NOTHING
+	`
+
+	if _, err := tx.Exec(ctx, query, recipeID, tags, createdBy, time.Now()); err != nil {
+		log.Printf("Error inserting tags: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetTagsByRecipeIds retrieves the tags of several recipes with a single query.
+// It requires a context and the IDs of the recipes.
+// Returns the tags grouped by recipe ID in alphabetical order, and an error if the retrieval fails.
+func (tr *TagRepository) GetTagsByRecipeIds(ctx context.Context, recipeIDs []int) (map[int][]string, error) {
+	log.Printf("Retrieving tags for %d recipes from database.", len(recipeIDs))
+
+	connection, err := tr.ConnectionPool.Acquire(ctx)
+	if err != nil {
+		log.Printf("Error getting a connection from the connection pool: %v", err)
+		return nil, err
+	}
+	defer connection.Release()
+
+	query := `SELECT recipe_id, tag FROM recipe_tags WHERE recipe_id = ANY($1) ORDER BY recipe_id, tag`
+
+	result, err := connection.Query(ctx, query, recipeIDs)
+	if err != nil {
+		log.Printf("Something went wrong with the following query: %v\n", query)
+		return nil, err
+	}
+	defer result.Close()
+
+	tags := make(map[int][]string)
+
+	for result.Next() {
+		var recipeID int
+		var tag string
+
+		if err := result.Scan(&recipeID, &tag); err != nil {
+			log.Printf("Error scanning tag: %v", err)
+			return nil, err
+		}
+
+		tags[recipeID] = append(tags[recipeID], tag)
+	}
+
+	if result.Err() != nil {
+		log.Printf("Error retrieving tags: %v", result.Err())
+		return nil, result.Err()
+	}
+
+	return tags, nil
+}
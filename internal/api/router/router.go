@@ -10,6 +10,8 @@ import (
 	"recipe-generator/internal/api/config"
 	"recipe-generator/internal/api/handler"
 	"recipe-generator/internal/api/middleware"
+	"recipe-generator/internal/api/repository"
+	"recipe-generator/internal/api/service"
 )
 
 // Router manages HTTP request routing for the application.
@@ -29,9 +31,29 @@ func NewRouter(cfg *config.Config, db *pgxpool.Pool) *Router {
 
 	router := &Router{}
 
+	// shared by the handlers that stream server-sent events
+	eventBroker := service.NewEventBroker()
+
+	// shared by the handlers that add or merge recipes, which refresh it
+	recommendations := service.NewRecommendationService(repository.NewRecipeRepository(db).GetAll)
+
 	// init handlers here
-	recipeHandler := handler.NewRecipeHandler(db, cfg)
+	recipeHandler := handler.NewRecipeHandler(db, cfg, recommendations)
 	healthHandler := handler.HealthHandler{}
+	collectionHandler := handler.NewCollectionHandler(db, cfg)
+	cookbookHandler := handler.NewCookbookHandler(db, cfg)
+	reviewHandler := handler.NewReviewHandler(db, cfg)
+	cookLogHandler := handler.NewCookLogHandler(db, cfg)
+	householdHandler := handler.NewHouseholdHandler(db, cfg)
+	mealPlanHandler := handler.NewMealPlanHandler(db, cfg)
+	shoppingListHandler := handler.NewShoppingListHandler(db, cfg, eventBroker)
+	pantryHandler := handler.NewPantryHandler(db, cfg)
+	suggestionHandler := handler.NewSuggestionHandler(db, cfg)
+	priceHandler := handler.NewPriceHandler(db, cfg)
+	scheduleHandler := handler.NewScheduleHandler(db, cfg)
+	cookingSessionHandler := handler.NewCookingSessionHandler(db, cfg, eventBroker)
+	equipmentHandler := handler.NewEquipmentHandler(db, cfg)
+	adminHandler := handler.NewAdminHandler(db, cfg, recommendations)
 
 	mux := http.NewServeMux()
 
@@ -43,12 +65,129 @@ func NewRouter(cfg *config.Config, db *pgxpool.Pool) *Router {
 	mux.Handle("/recipe/random", recipeHandler.GetRandom())
 	mux.Handle("/recipe/submit", recipeHandler.Post())
 	mux.Handle("/recipe", recipeHandler.Get())
+	mux.Handle("/recipe/{id}", recipeHandler.GetById())
+	mux.Handle("GET /recipes", recipeHandler.List())
+	mux.Handle("POST /recipes/import/jsonld", recipeHandler.ImportJsonLd())
+	mux.Handle("POST /recipes/import/cooklang", recipeHandler.ImportCooklang())
+	mux.Handle("POST /recipes/import", recipeHandler.ImportArchive())
+	mux.Handle("GET /recipe/{id}/photo", recipeHandler.Photo())
+	mux.Handle("GET /recipe/{id}/similar", recipeHandler.Similar())
+	mux.Handle("GET /recipe/{id}/cost", recipeHandler.Cost())
+	mux.Handle("POST /recipe/{id}/scale", recipeHandler.Scale())
+	mux.Handle("GET /recipe/{id}/bakers-percentages", recipeHandler.BakersPercentages())
+	mux.Handle("POST /recipe/{id}/bakers-percentages", recipeHandler.BakersFormula())
+
+	// substitution routes. GET proposes substitutes, POST previews or saves a substituted copy.
+	mux.Handle("GET /recipe/{id}/substitutions", recipeHandler.Substitutions())
+	mux.Handle("POST /recipe/{id}/substitutions", recipeHandler.Substitute())
+
+	// review routes. each user has one review per recipe, addressed through /review.
+	mux.Handle("GET /recipe/{id}/reviews", reviewHandler.List())
+	mux.Handle("GET /recipe/{id}/review", reviewHandler.GetMine())
+	mux.Handle("PUT /recipe/{id}/review", reviewHandler.Put())
+	mux.Handle("DELETE /recipe/{id}/review", reviewHandler.Delete())
+
+	// cook log routes
+	mux.Handle("POST /recipe/{id}/cooklog", cookLogHandler.Create())
+	mux.Handle("GET /recipe/{id}/cooklog", cookLogHandler.ListForRecipe())
+	mux.Handle("GET /me/cooklog", cookLogHandler.ListMine())
+	mux.Handle("GET /me/cooklog/stats", cookLogHandler.Stats())
+	mux.Handle("DELETE /cooklog/{id}", cookLogHandler.Delete())
+	mux.Handle("POST /cooklog/{id}/photos", cookLogHandler.AddPhotos())
+	mux.Handle("GET /cooklog/photos/{photoId}", cookLogHandler.GetPhoto())
+
+	// collection routes. the acting user comes from the X-User-Id header, see middleware.User.
+	mux.Handle("POST /collections", collectionHandler.Create())
+	mux.Handle("GET /collections", collectionHandler.List())
+	mux.Handle("GET /collections/{id}", collectionHandler.Get())
+	mux.Handle("PUT /collections/{id}", collectionHandler.Update())
+	mux.Handle("DELETE /collections/{id}", collectionHandler.Delete())
+	mux.Handle("POST /collections/{id}/recipes", collectionHandler.AddRecipe())
+	mux.Handle("PUT /collections/{id}/recipes/{recipeId}", collectionHandler.UpdateRecipe())
+	mux.Handle("DELETE /collections/{id}/recipes/{recipeId}", collectionHandler.RemoveRecipe())
+	mux.Handle("PUT /collections/{id}/order", collectionHandler.Reorder())
+	mux.Handle("PUT /collections/{id}/shares/{userId}", collectionHandler.Share())
+	mux.Handle("DELETE /collections/{id}/shares/{userId}", collectionHandler.Unshare())
+
+	// cookbook routes. a PDF cookbook is generated in the background; poll the job until it can be downloaded.
+	mux.Handle("POST /cookbooks", cookbookHandler.Create())
+	mux.Handle("GET /cookbooks/{id}", cookbookHandler.Get())
+	mux.Handle("GET /cookbooks/{id}/download", cookbookHandler.Download())
+
+	// household routes
+	mux.Handle("POST /households", householdHandler.Create())
+	mux.Handle("GET /me/households", householdHandler.ListMine())
+	mux.Handle("PUT /households/{id}/members/{userId}", householdHandler.AddMember())
+	mux.Handle("DELETE /households/{id}/members/{userId}", householdHandler.RemoveMember())
+
+	// meal plan routes. pass ?householdId= to use a household's shared plan instead of your own.
+	mux.Handle("GET /mealplan", mealPlanHandler.GetRange())
+	mux.Handle("POST /mealplan/entries", mealPlanHandler.CreateEntry())
+	mux.Handle("PUT /mealplan/entries/{id}", mealPlanHandler.UpdateEntry())
+	mux.Handle("DELETE /mealplan/entries/{id}", mealPlanHandler.DeleteEntry())
+	mux.Handle("POST /mealplan/copy-week", mealPlanHandler.CopyWeek())
+	mux.Handle("POST /mealplan/autofill", mealPlanHandler.AutoFill())
+
+	// shopping list routes. ?format=text or ?format=csv for plain text or CSV instead of JSON.
+	mux.Handle("POST /shopping-list", shoppingListHandler.Generate())
+
+	// saved shopping lists. ?householdId= saves or lists a household's lists.
+	mux.Handle("POST /shopping-lists", shoppingListHandler.Save())
+	mux.Handle("GET /shopping-lists", shoppingListHandler.List())
+	mux.Handle("GET /shopping-lists/{id}", shoppingListHandler.Get())
+	mux.Handle("DELETE /shopping-lists/{id}", shoppingListHandler.Delete())
+	mux.Handle("POST /shopping-lists/{id}/items", shoppingListHandler.AddItem())
+	mux.Handle("PUT /shopping-lists/{id}/items/{itemId}/checked", shoppingListHandler.SetChecked())
+	mux.Handle("DELETE /shopping-lists/{id}/items/{itemId}", shoppingListHandler.RemoveItem())
+	mux.Handle("GET /shopping-lists/{id}/events", shoppingListHandler.Events())
+
+	// pantry routes. ?householdId= uses a household's pantry.
+	mux.Handle("GET /pantry", pantryHandler.List())
+	mux.Handle("POST /pantry", pantryHandler.Create())
+	mux.Handle("PUT /pantry/{id}", pantryHandler.Update())
+	mux.Handle("DELETE /pantry/{id}", pantryHandler.Delete())
+	mux.Handle("GET /pantry/can-cook", pantryHandler.CanCook())
+
+	// suggestion routes. ?householdId= uses a household's pantry.
+	mux.Handle("GET /suggestions/use-soon", suggestionHandler.UseSoon())
+
+	// ingredient price routes. every observed price is kept as the ingredient's price history.
+	mux.Handle("POST /ingredient-prices", priceHandler.Create())
+	mux.Handle("GET /ingredient-prices", priceHandler.Current())
+	mux.Handle("GET /ingredient-prices/history", priceHandler.History())
+	mux.Handle("DELETE /ingredient-prices/{id}", priceHandler.Delete())
+
+	// schedule routes. plans a timeline to have several recipes ready at the same time.
+	mux.Handle("POST /schedule", scheduleHandler.Plan())
+
+	// cooking session routes. a session can be followed from several devices through its event stream.
+	mux.Handle("POST /recipe/{id}/sessions", cookingSessionHandler.Start())
+	mux.Handle("GET /sessions", cookingSessionHandler.List())
+	mux.Handle("GET /sessions/{id}", cookingSessionHandler.Get())
+	mux.Handle("DELETE /sessions/{id}", cookingSessionHandler.End())
+	mux.Handle("PUT /sessions/{id}/step", cookingSessionHandler.SetStep())
+	mux.Handle("POST /sessions/{id}/timers", cookingSessionHandler.StartTimer())
+	mux.Handle("POST /sessions/{id}/timers/{timerId}/pause", cookingSessionHandler.PauseTimer())
+	mux.Handle("POST /sessions/{id}/timers/{timerId}/resume", cookingSessionHandler.ResumeTimer())
+	mux.Handle("POST /sessions/{id}/timers/{timerId}/cancel", cookingSessionHandler.CancelTimer())
+	mux.Handle("GET /sessions/{id}/events", cookingSessionHandler.Events())
+
+	// equipment routes. recipe searches and random picks take ?myKitchen=true to skip recipes needing other tools.
+	mux.Handle("GET /equipment", equipmentHandler.Catalog())
+	mux.Handle("GET /recipe/{id}/equipment", equipmentHandler.GetRecipeEquipment())
+	mux.Handle("PUT /recipe/{id}/equipment", equipmentHandler.SetRecipeEquipment())
+	mux.Handle("GET /me/equipment", equipmentHandler.GetKitchen())
+	mux.Handle("PUT /me/equipment", equipmentHandler.SetKitchen())
+
+	// admin routes, limited to the users in ADMIN_USER_IDS.
+	mux.Handle("GET /admin/recipes/duplicates", adminHandler.DuplicateReport())
+	mux.Handle("POST /admin/recipes/duplicates/merge", adminHandler.MergeDuplicates())
 
 	// protected routes can go here.
 	// r.Handle("/api/v1/user/profile", r.auth.Authenticate(userHandler.ProfileHandler()))
 
 	
-	router.ServeMux = middleware.CORS(mux) 
+	router.ServeMux = middleware.CORS(middleware.User(mux))
 
 	return router
 }
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"strings"
+)
+
+// Store aisles shopping list items are grouped into, in the order a shopper usually walks them.
+const (
+	AisleProduce  = "Produce"
+	AisleBakery   = "Bakery"
+	AisleMeat     = "Meat & Seafood"
+	AisleDairy    = "Dairy & Eggs"
+	AisleBaking   = "Baking"
+	AisleSpices   = "Spices & Seasonings"
+	AislePantry   = "Pantry"
+	AisleFrozen   = "Frozen"
+	AisleBeverage = "Beverages"
+	AisleOther    = "Other"
+)
+
+// Aisles lists the store aisles in walking order.
+var Aisles = []string{
+	AisleProduce, AisleBakery, AisleMeat, AisleDairy, AisleBaking,
+	AisleSpices, AislePantry, AisleFrozen, AisleBeverage, AisleOther,
+}
+
+// aisleKeywords maps words and phrases in canonical ingredient names to the aisle they're found in.
+// A keyword that ends the name wins over one earlier in it, since that is usually the thing being bought,
+// so "chicken broth" is in the pantry and "ground beef" is with the meat. Longer keywords win ties.
+var aisleKeywords = map[string]string{
+	"apple": AisleProduce, "avocado": AisleProduce, "banana": AisleProduce, "basil": AisleProduce,
+	"bean sprout": AisleProduce, "bell pepper": AisleProduce, "berry": AisleProduce, "broccoli": AisleProduce,
+	"cabbage": AisleProduce, "carrot": AisleProduce, "cauliflower": AisleProduce, "celery": AisleProduce,
+	"cilantro": AisleProduce, "corn": AisleProduce, "cucumber": AisleProduce, "eggplant": AisleProduce,
+	"garlic": AisleProduce, "ginger": AisleProduce, "green onion": AisleProduce, "herb": AisleProduce,
+	"jalapeno": AisleProduce, "kale": AisleProduce, "leek": AisleProduce, "lemon": AisleProduce,
+	"lettuce": AisleProduce, "lime": AisleProduce, "mint": AisleProduce, "mushroom": AisleProduce,
+	"onion": AisleProduce, "orange": AisleProduce, "parsley": AisleProduce, "pea": AisleProduce,
+	"pepper": AisleProduce, "potato": AisleProduce, "rosemary": AisleProduce, "scallion": AisleProduce,
+	"shallot": AisleProduce, "spinach": AisleProduce, "squash": AisleProduce, "thyme": AisleProduce,
+	"tomato": AisleProduce, "zucchini": AisleProduce,
+
+	"bagel": AisleBakery, "baguette": AisleBakery, "bread": AisleBakery, "bun": AisleBakery,
+	"pita": AisleBakery, "roll": AisleBakery, "tortilla": AisleBakery,
+
+	"bacon": AisleMeat, "beef": AisleMeat, "chicken": AisleMeat, "cod": AisleMeat, "fish": AisleMeat,
+	"ham": AisleMeat, "lamb": AisleMeat, "pork": AisleMeat, "prawn": AisleMeat, "salmon": AisleMeat,
+	"sausage": AisleMeat, "shrimp": AisleMeat, "steak": AisleMeat, "tuna": AisleMeat, "turkey": AisleMeat,
+
+	"butter": AisleDairy, "buttermilk": AisleDairy, "cheddar": AisleDairy, "cheese": AisleDairy,
+	"cream": AisleDairy, "egg": AisleDairy, "feta": AisleDairy, "milk": AisleDairy,
+	"mozzarella": AisleDairy, "parmesan": AisleDairy, "sour cream": AisleDairy, "yogurt": AisleDairy,
+
+	"baking powder": AisleBaking, "baking soda": AisleBaking, "brown sugar": AisleBaking,
+	"chocolate": AisleBaking, "cocoa": AisleBaking, "cornstarch": AisleBaking, "flour": AisleBaking,
+	"honey": AisleBaking, "sugar": AisleBaking, "vanilla": AisleBaking, "yeast": AisleBaking,
+
+	"black pepper": AisleSpices, "chili powder": AisleSpices, "cinnamon": AisleSpices,
+	"cumin": AisleSpices, "nutmeg": AisleSpices, "oregano": AisleSpices, "paprika": AisleSpices,
+	"powder": AisleSpices, "salt": AisleSpices, "turmeric": AisleSpices,
+
+	"broth": AislePantry, "canned": AislePantry, "chickpea": AislePantry, "coconut milk": AislePantry,
+	"ketchup": AislePantry, "lentil": AislePantry, "mayonnaise": AislePantry, "mustard": AislePantry,
+	"noodle": AislePantry, "nut": AislePantry, "oat": AislePantry, "oil": AislePantry,
+	"pasta": AislePantry, "peanut butter": AislePantry, "rice": AislePantry, "sauce": AislePantry,
+	"soy sauce": AislePantry, "spaghetti": AislePantry, "stock": AislePantry, "tomato paste": AislePantry,
+	"tomato sauce": AislePantry, "vinegar": AislePantry,
+
+	"frozen": AisleFrozen, "ice cream": AisleFrozen,
+
+	"beer": AisleBeverage, "coffee": AisleBeverage, "juice": AisleBeverage, "soda": AisleBeverage,
+	"tea": AisleBeverage, "water": AisleBeverage, "wine": AisleBeverage,
+}
+
+// AisleFor returns the store aisle an ingredient is found in, or AisleOther if it isn't recognised.
+//
+// Parameters:
+//   - canonicalName: The ingredient name as returned by CanonicalIngredientName
+//
+// Returns:
+//   - string: One of the Aisle constants
+func AisleFor(canonicalName string) string {
+	padded := " " + canonicalName + " "
+
+	aisle := AisleOther
+	bestKeyword := ""
+	bestScore := 0
+
+	for keyword, keywordAisle := range aisleKeywords {
+		if !strings.Contains(padded, " "+keyword+" ") {
+			continue
+		}
+
+		score := len(keyword)
+		if strings.HasSuffix(padded, " "+keyword+" ") {
+			score += len(canonicalName)
+		}
+
+		// compare keywords on ties so the result doesn't depend on map order.
+		if score > bestScore || (score == bestScore && keyword < bestKeyword) {
+			aisle = keywordAisle
+			bestKeyword = keyword
+			bestScore = score
+		}
+	}
+
+	return aisle
+}
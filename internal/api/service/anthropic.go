@@ -62,7 +62,7 @@ func (client *AnthropicApiClient) Post(config *config.Config, payload string) (m
     res, err := http.DefaultClient.Do(req)
     
     if err != nil {
-	log.Printf("Error sending request to \n %n", url)
+	log.Printf("Error sending request to \n %s", url)
 	return nil, err
     }
     
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"math"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// flourPercentTolerance is how far from 100% the flour percentages can add up to, to absorb rounding.
+const flourPercentTolerance = 0.5
+
+// flours are ingredients that count toward the flour weight besides those whose name ends in "flour".
+var flours = map[string]bool{
+	"semolina":    true,
+	"durum":       true,
+	"masa harina": true,
+}
+
+// pieceWeights gives the typical weight in grams of one of an ingredient counted in pieces.
+var pieceWeights = map[string]float64{
+	"egg":       50,
+	"egg white": 33,
+	"egg yolk":  17,
+}
+
+// waterContents gives the share of an ingredient's weight that is water, for working out a dough's hydration.
+var waterContents = map[string]float64{
+	"water":       1,
+	"milk":        0.87,
+	"buttermilk":  0.9,
+	"cream":       0.6,
+	"heavy cream": 0.58,
+	"yogurt":      0.85,
+	"egg":         0.75,
+	"egg white":   0.88,
+	"egg yolk":    0.5,
+	"butter":      0.16,
+	"honey":       0.17,
+	"starter":     0.5,
+	"levain":      0.5,
+}
+
+// flourContents gives the share of an ingredient's weight that is flour, for ingredients other than the
+// flours that add flour to a dough. Starters are taken to be at 100% hydration.
+var flourContents = map[string]float64{
+	"starter": 0.5,
+	"levain":  0.5,
+}
+
+// BakersService works with dough recipes as baker's percentages.
+type BakersService struct{}
+
+// NewBakersService creates a new BakersService.
+func NewBakersService() *BakersService {
+	return &BakersService{}
+}
+
+// Percentages expresses a recipe as baker's percentages: every ingredient's weight relative to the total
+// weight of its flours, which is 100%. Volumes are weighed through the density table and eggs by their typical
+// weight. Hydration is the water in the dough, including the water in milk, eggs, butter and starters, as a
+// percentage of all its flour, including the flour in starters.
+//
+// Parameters:
+//   - recipe: The recipe with its ingredients loaded
+//
+// Returns:
+//   - model.BakersPercentages: The formula
+//   - error: model.ErrInvalidField if the recipe has no flour that can be weighed
+func (bs *BakersService) Percentages(recipe *model.Recipe) (model.BakersPercentages, error) {
+	formula := model.BakersPercentages{
+		RecipeId:    recipe.ID,
+		RecipeName:  recipe.RecipeName,
+		Ingredients: []model.BakersIngredient{},
+		Unweighed:   []model.UnweighedIngredient{},
+	}
+
+	for _, ingredient := range recipe.Ingredients {
+		name := CanonicalIngredientName(ingredient.IngredientName)
+
+		grams, approximate, ok := weigh(ingredient.Amount, ingredient.UnitOfMeasurement, name)
+		if !ok {
+			formula.Unweighed = append(formula.Unweighed, model.UnweighedIngredient{
+				IngredientName: ingredient.IngredientName,
+				Amount:         ingredient.Amount,
+				Unit:           ingredient.UnitOfMeasurement,
+			})
+			continue
+		}
+
+		formula.Ingredients = append(formula.Ingredients, model.BakersIngredient{
+			IngredientName: ingredient.IngredientName,
+			Amount:         ingredient.Amount,
+			Unit:           ingredient.UnitOfMeasurement,
+			Grams:          grams,
+			Flour:          isFlour(name),
+			Approximate:    approximate,
+		})
+	}
+
+	if err := setPercentages(&formula); err != nil {
+		return formula, err
+	}
+
+	return formula, nil
+}
+
+// Generate works out the ingredient amounts, in grams, of a dough with a recipe's baker's percentages and the
+// requested flour or dough weight. Percentages can be changed by ingredient name, such as raising the water to
+// 75%; the flours' percentages must still add up to 100%.
+//
+// Parameters:
+//   - formula: The recipe's baker's percentages, as returned by Percentages
+//   - request: The weight to make and any changed percentages; must be valid
+//
+// Returns:
+//   - model.BakersPercentages: The formula with the new weights
+//   - error: model.ErrInvalidField if a changed percentage names no ingredient or the flours don't add up to 100%
+func (bs *BakersService) Generate(formula model.BakersPercentages, request model.BakersFormulaRequest) (model.BakersPercentages, error) {
+	generated := formula
+	generated.Ingredients = make([]model.BakersIngredient, len(formula.Ingredients))
+	copy(generated.Ingredients, formula.Ingredients)
+
+	for name, percent := range request.Percentages {
+		found := false
+		for i := range generated.Ingredients {
+			if CanonicalIngredientName(generated.Ingredients[i].IngredientName) == CanonicalIngredientName(name) {
+				generated.Ingredients[i].Percent = percent
+				found = true
+			}
+		}
+		if !found {
+			return generated, model.ErrInvalidField("percentages: " + name)
+		}
+	}
+
+	flourPercent, totalPercent := 0.0, 0.0
+	for _, ingredient := range generated.Ingredients {
+		if ingredient.Flour {
+			flourPercent += ingredient.Percent
+		}
+		totalPercent += ingredient.Percent
+	}
+
+	if math.Abs(flourPercent-100) > flourPercentTolerance {
+		return generated, model.ErrInvalidField("percentages: the flours must add up to 100%")
+	}
+
+	flourGrams := request.FlourWeight
+	if request.DoughWeight > 0 {
+		flourGrams = request.DoughWeight * 100 / totalPercent
+	}
+
+	for i := range generated.Ingredients {
+		ingredient := &generated.Ingredients[i]
+		ingredient.Grams = ingredient.Percent * flourGrams / 100
+		ingredient.Amount = RoundAmount(ingredient.Grams)
+		ingredient.Unit = "g"
+		ingredient.Approximate = false
+	}
+
+	if err := setPercentages(&generated); err != nil {
+		return generated, err
+	}
+
+	return generated, nil
+}
+
+// private functions
+
+// setPercentages works out the flour weight, dough weight, hydration and each ingredient's percentage from
+// the ingredients' weights, and rounds the weights.
+func setPercentages(formula *model.BakersPercentages) error {
+	flourGrams, doughGrams, water, totalFlour := 0.0, 0.0, 0.0, 0.0
+
+	for _, ingredient := range formula.Ingredients {
+		name := CanonicalIngredientName(ingredient.IngredientName)
+
+		doughGrams += ingredient.Grams
+		water += ingredient.Grams * suffixLookup(waterContents, name)
+		if ingredient.Flour {
+			flourGrams += ingredient.Grams
+			totalFlour += ingredient.Grams
+		} else {
+			totalFlour += ingredient.Grams * suffixLookup(flourContents, name)
+		}
+	}
+
+	if flourGrams <= 0 {
+		return model.ErrInvalidField("ingredients: the recipe has no flour that can be weighed")
+	}
+
+	for i := range formula.Ingredients {
+		ingredient := &formula.Ingredients[i]
+		ingredient.Percent = RoundAmount(ingredient.Grams / flourGrams * 100)
+		ingredient.Grams = RoundAmount(ingredient.Grams)
+	}
+
+	formula.FlourGrams = RoundAmount(flourGrams)
+	formula.DoughGrams = RoundAmount(doughGrams)
+	formula.Hydration = RoundAmount(water / totalFlour * 100)
+
+	return nil
+}
+
+// weigh converts an amount of an ingredient to grams, through its density for volumes and its typical
+// weight for counts. It reports whether the weight is approximate and whether it could be worked out.
+func weigh(amount float64, unitOfMeasurement string, canonicalName string) (float64, bool, bool) {
+	unit := LookupUnit(unitOfMeasurement)
+
+	if unit.Kind == UnitKindCount {
+		if unit.Name != "" {
+			return 0, false, false
+		}
+		weight := suffixLookup(pieceWeights, canonicalName)
+		if weight == 0 {
+			return 0, false, false
+		}
+		return amount * weight, true, true
+	}
+
+	return ConvertIngredient(amount, unit, units["g"], canonicalName)
+}
+
+// isFlour reports whether an ingredient counts toward the flour weight.
+func isFlour(canonicalName string) bool {
+	words := strings.Fields(canonicalName)
+	if len(words) == 0 {
+		return false
+	}
+	return words[len(words)-1] == "flour" || flours[canonicalName] || flours[words[len(words)-1]]
+}
+
+// suffixLookup returns the value listed for an ingredient, or for the end of its name when the whole name
+// isn't listed, so "whole milk" uses the value for milk. It returns 0 if nothing is listed.
+func suffixLookup(table map[string]float64, canonicalName string) float64 {
+	words := strings.Fields(canonicalName)
+
+	for i := range words {
+		if value, ok := table[strings.Join(words[i:], " ")]; ok {
+			return value
+		}
+	}
+
+	return 0
+}
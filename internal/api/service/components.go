@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// maxComponentDepth limits how deeply recipes can be nested as components of one another, such as a pizza
+// that uses a dough that uses a starter.
+const maxComponentDepth = 8
+
+// ComponentLoader loads recipes with their ingredients by ID, leaving IDs that don't exist out of the map.
+type ComponentLoader func(ctx context.Context, recipeIDs []int) (map[int]*model.Recipe, error)
+
+// ComponentService works with recipes used as ingredients of other recipes, such as the dough and sauce of a
+// pizza. A component ingredient's amount is in batches of the component recipe, servings of it, or its yield
+// unit, such as 2 cups of a sauce that makes 3 cups.
+type ComponentService struct{}
+
+// NewComponentService creates a new ComponentService.
+func NewComponentService() *ComponentService {
+	return &ComponentService{}
+}
+
+// LoadComponents loads the component recipes the recipes use, and the ones those use in turn, a level at a time.
+//
+// Parameters:
+//   - ctx: The context for database operations
+//   - recipes: The recipes with their ingredients loaded
+//   - load: Loads recipes with their ingredients by ID
+//
+// Returns:
+//   - map[int]*model.Recipe: Every component recipe in the tree by ID
+//   - error: model.ErrNotFound if a component recipe does not exist, model.ErrInvalidField if components are
+//     nested too deeply, or an error if loading fails
+func (cs *ComponentService) LoadComponents(ctx context.Context, recipes []*model.Recipe, load ComponentLoader) (map[int]*model.Recipe, error) {
+	components := make(map[int]*model.Recipe)
+	pending := componentIds(recipes, components)
+
+	for depth := 0; len(pending) > 0; depth++ {
+		if depth == maxComponentDepth {
+			return nil, model.ErrInvalidField(fmt.Sprintf("ingredients: components are nested more than %d deep", maxComponentDepth))
+		}
+
+		loaded, err := load(ctx, pending)
+		if err != nil {
+			return nil, err
+		}
+
+		next := make([]*model.Recipe, 0, len(pending))
+		for _, recipeID := range pending {
+			component, ok := loaded[recipeID]
+			if !ok {
+				return nil, model.ErrNotFound(fmt.Sprintf("component recipe %d", recipeID))
+			}
+			components[recipeID] = component
+			next = append(next, component)
+		}
+
+		pending = componentIds(next, components)
+	}
+
+	return components, nil
+}
+
+// Resolve completes the component ingredients of a recipe being saved: a missing name becomes the component
+// recipe's name and a missing unit becomes batches. It checks that every amount can be worked out as a number
+// of batches.
+//
+// Parameters:
+//   - ingredients: The recipe's ingredients, which are updated in place
+//   - components: The component recipes, as returned by LoadComponents
+//
+// Returns:
+//   - error: model.ErrInvalidField if a component's unit isn't batches, servings or its yield unit
+func (cs *ComponentService) Resolve(ingredients []model.Ingredient, components map[int]*model.Recipe) error {
+	for i := range ingredients {
+		ingredient := &ingredients[i]
+		if !ingredient.IsComponent() {
+			continue
+		}
+
+		component := components[*ingredient.ComponentRecipeId]
+
+		if strings.TrimSpace(ingredient.IngredientName) == "" {
+			ingredient.IngredientName = component.RecipeName
+		}
+		if strings.TrimSpace(ingredient.UnitOfMeasurement) == "" {
+			ingredient.UnitOfMeasurement = model.ComponentUnitBatch
+		}
+
+		if _, ok := ComponentFactor(*ingredient, component); !ok {
+			return model.ErrInvalidField(fmt.Sprintf(
+				"unitOfMeasurement of %s: use batches, servings or what %s yields",
+				ingredient.IngredientName,
+				component.RecipeName,
+			))
+		}
+	}
+
+	return nil
+}
+
+// CheckCycles checks that a recipe doesn't use itself as a component, directly or through other components,
+// such as a pizza that uses a dough that uses the pizza.
+//
+// Parameters:
+//   - recipeID: The recipe's ID, or 0 for a recipe that isn't saved yet
+//   - ingredients: The recipe's ingredients
+//   - components: The component recipes, as returned by LoadComponents
+//   - aliases: Recipe IDs to treat as other recipes, such as duplicates being merged into recipeID; may be nil
+//
+// Returns:
+//   - error: model.ErrInvalidField naming the cycle if there is one
+func (cs *ComponentService) CheckCycles(recipeID int, ingredients []model.Ingredient, components map[int]*model.Recipe, aliases map[int]int) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[int]int)
+	path := []string{}
+
+	var visit func(ingredients []model.Ingredient) error
+	visit = func(ingredients []model.Ingredient) error {
+		for _, ingredient := range ingredients {
+			if !ingredient.IsComponent() {
+				continue
+			}
+
+			componentID := *ingredient.ComponentRecipeId
+			if alias, ok := aliases[componentID]; ok {
+				componentID = alias
+			}
+
+			if (recipeID != 0 && componentID == recipeID) || state[componentID] == visiting {
+				return model.ErrInvalidField(fmt.Sprintf(
+					"ingredients: the recipe uses itself as a component through %s",
+					strings.Join(append(path, ingredient.IngredientName), " > "),
+				))
+			}
+
+			component, ok := components[componentID]
+			if !ok || state[componentID] == visited {
+				continue
+			}
+
+			state[componentID] = visiting
+			path = append(path, ingredient.IngredientName)
+			if err := visit(component.Ingredients); err != nil {
+				return err
+			}
+			path = path[:len(path)-1]
+			state[componentID] = visited
+		}
+
+		return nil
+	}
+
+	return visit(ingredients)
+}
+
+// Expand returns a copy of a recipe with each component ingredient's recipe inline, scaled to the amount used,
+// so 2 batches of a dough show the dough recipe doubled. Nested components are expanded the same way, with
+// their scaling compounded.
+//
+// Parameters:
+//   - recipe: The recipe with its ingredients loaded
+//   - components: The component recipes, as returned by LoadComponents
+//
+// Returns:
+//   - *model.Recipe: The copy with Component set on its component ingredients
+func (cs *ComponentService) Expand(recipe *model.Recipe, components map[int]*model.Recipe) *model.Recipe {
+	return expandComponents(recipe, components, 0)
+}
+
+// Flatten returns a recipe's ingredients with each component ingredient replaced by the component recipe's
+// own ingredients, scaled to the amount used, all the way down. It is what shopping for the recipe takes.
+// Components that can't be worked out are left as they are.
+//
+// Parameters:
+//   - recipe: The recipe with its ingredients loaded
+//   - components: The component recipes, as returned by LoadComponents
+//
+// Returns:
+//   - []model.Ingredient: The plain ingredients
+func (cs *ComponentService) Flatten(recipe *model.Recipe, components map[int]*model.Recipe) []model.Ingredient {
+	return flattenComponents(recipe.Ingredients, 1, components, 0)
+}
+
+// ComponentFactor works out how many batches of a component recipe an ingredient uses: its amount in
+// batches, servings of the component, or the component's yield unit. It reports false when the unit is none
+// of those.
+func ComponentFactor(ingredient model.Ingredient, component *model.Recipe) (float64, bool) {
+	unit := Singular(strings.ToLower(strings.TrimSpace(ingredient.UnitOfMeasurement)))
+
+	switch unit {
+	case model.ComponentUnitBatch, "recipe":
+		return ingredient.Amount, true
+	case model.ComponentUnitServing, "portion":
+		if component.Servings > 0 {
+			return ingredient.Amount / float64(component.Servings), true
+		}
+		return 0, false
+	}
+
+	if component.Yield == nil {
+		return 0, false
+	}
+
+	if unit == Singular(strings.ToLower(strings.TrimSpace(component.Yield.Unit))) {
+		return ingredient.Amount / component.Yield.Quantity, true
+	}
+
+	// a sauce that makes 3 cups can be used by the tablespoon.
+	from, to := LookupUnit(ingredient.UnitOfMeasurement), LookupUnit(component.Yield.Unit)
+	if from.Kind != UnitKindCount && from.Convertible(to) {
+		return Convert(ingredient.Amount, from, to) / component.Yield.Quantity, true
+	}
+
+	return 0, false
+}
+
+// private functions
+
+// componentIds returns the IDs of the component recipes the recipes use that aren't in loaded yet.
+func componentIds(recipes []*model.Recipe, loaded map[int]*model.Recipe) []int {
+	seen := make(map[int]bool)
+	recipeIDs := []int{}
+
+	for _, recipe := range recipes {
+		for _, ingredient := range recipe.Ingredients {
+			if !ingredient.IsComponent() {
+				continue
+			}
+			componentID := *ingredient.ComponentRecipeId
+			if _, ok := loaded[componentID]; ok || seen[componentID] {
+				continue
+			}
+			seen[componentID] = true
+			recipeIDs = append(recipeIDs, componentID)
+		}
+	}
+
+	return recipeIDs
+}
+
+// expandComponents expands a recipe's components, stopping at maxComponentDepth in case the stored recipes
+// hold a cycle.
+func expandComponents(recipe *model.Recipe, components map[int]*model.Recipe, depth int) *model.Recipe {
+	expanded := *recipe
+	expanded.Ingredients = make([]model.Ingredient, len(recipe.Ingredients))
+	copy(expanded.Ingredients, recipe.Ingredients)
+
+	if depth == maxComponentDepth {
+		return &expanded
+	}
+
+	for i := range expanded.Ingredients {
+		ingredient := &expanded.Ingredients[i]
+		if !ingredient.IsComponent() {
+			continue
+		}
+
+		component, ok := components[*ingredient.ComponentRecipeId]
+		if !ok {
+			continue
+		}
+
+		factor, ok := ComponentFactor(*ingredient, component)
+		if !ok {
+			continue
+		}
+
+		ingredient.Component = expandComponents(scaleRecipe(component, factor, nil), components, depth+1)
+	}
+
+	return &expanded
+}
+
+// flattenComponents replaces component ingredients with the component recipes' ingredients, with amounts
+// multiplied by factor.
+func flattenComponents(ingredients []model.Ingredient, factor float64, components map[int]*model.Recipe, depth int) []model.Ingredient {
+	flattened := make([]model.Ingredient, 0, len(ingredients))
+
+	for _, ingredient := range ingredients {
+		if ingredient.IsComponent() && depth < maxComponentDepth {
+			if component, ok := components[*ingredient.ComponentRecipeId]; ok {
+				if batches, ok := ComponentFactor(ingredient, component); ok {
+					flattened = append(flattened, flattenComponents(component.Ingredients, factor*batches, components, depth+1)...)
+					continue
+				}
+			}
+		}
+
+		ingredient.Amount *= factor
+		flattened = append(flattened, ingredient)
+	}
+
+	return flattened
+}
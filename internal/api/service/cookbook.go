@@ -0,0 +1,416 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// Page geometry of a cookbook, in points.
+const (
+	cookbookMargin  = 54.0  // Space left of, right of and above the text
+	cookbookBottom  = 72.0  // Lowest a line of text goes, leaving room for the page number
+	cookbookFooterY = 36.0  // Baseline of the page number
+	cookbookGutter  = 18.0  // Space between the columns of the index
+	cookbookMiddle  = 396.0 // Height of the line between the two recipes of a two-up page
+)
+
+// cookbookTwoUpScale is how much smaller recipes are set in the two-up layout.
+const cookbookTwoUpScale = 0.85
+
+// cookbookTocLeading is the height of a line of the table of contents, and cookbookTocLines how many fit on a
+// page below its heading.
+const (
+	cookbookTocLeading = 18.0
+	cookbookTocLines   = 34 // (pdfPageHeight - cookbookMargin - cookbookBottom - 48) / cookbookTocLeading, rounded down
+)
+
+// remarkPattern matches a remark in parentheses such as "(optional)" in an ingredient's name.
+var remarkPattern = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// cookbookFrame is an area of a page text flows into: the whole page, half of it, or a column.
+type cookbookFrame struct {
+	left   float64
+	width  float64
+	top    float64
+	bottom float64
+}
+
+// cookbookLayout flows text and photos into the frames of a cookbook's pages, starting a new page when the
+// frames of the current one are full.
+type cookbookLayout struct {
+	document *pdfDocument
+	template []cookbookFrame // Frames of each new page
+	scale    float64         // Size of the text relative to the single layout
+	divider  bool            // Whether to draw a line between the top and bottom halves of each new page
+	page     *pdfPage        // Page being filled
+	frame    cookbookFrame   // Frame being filled
+	frames   []cookbookFrame // Frames of the page after the one being filled
+	y        float64         // Top of the next line in the frame being filled
+}
+
+// BuildCookbook lays out a printable PDF cookbook: a title page, a table of contents with page numbers, the
+// recipes one to a page or two to a page, and an index of their ingredients at the back. Photos that can't be
+// read as JPEG, PNG or GIF images are left out.
+//
+// Parameters:
+//   - cookbook: The title, layout, recipes with their ingredients and procedures, and photos
+//
+// Returns:
+//   - []byte: The PDF file
+//   - int: The number of pages
+//   - error: An error if the PDF couldn't be written
+func BuildCookbook(cookbook model.Cookbook) ([]byte, int, error) {
+	document := newPdfDocument(cookbook.Title)
+	layout := &cookbookLayout{document: document, scale: 1}
+
+	titlePage(document.addPage(), cookbook)
+
+	tocPages := (len(cookbook.Recipes) + cookbookTocLines) / cookbookTocLines
+	for i := 0; i < tocPages; i++ {
+		layout.startSection([]cookbookFrame{{cookbookMargin, pdfPageWidth - 2*cookbookMargin, pdfPageHeight - cookbookMargin, cookbookBottom}})
+	}
+
+	contentWidth := pdfPageWidth - 2*cookbookMargin
+	pages := []cookbookFrame{{cookbookMargin, contentWidth, pdfPageHeight - cookbookMargin, cookbookBottom}}
+	photoHeight := 216.0
+	if cookbook.Layout == model.CookbookLayoutTwoUp {
+		pages = []cookbookFrame{
+			{cookbookMargin, contentWidth, pdfPageHeight - cookbookMargin, cookbookMiddle + 12},
+			{cookbookMargin, contentWidth, cookbookMiddle - 12, cookbookBottom},
+		}
+		layout.scale = cookbookTwoUpScale
+		layout.divider = true
+		photoHeight = 110
+	}
+
+	contents := []string{}
+	contentPages := []int{}
+	index := map[string][]int{}
+
+	layout.template = pages
+	layout.frames = nil
+	for _, recipe := range cookbook.Recipes {
+		if cookbook.Layout == model.CookbookLayoutTwoUp {
+			layout.nextFrame()
+		} else {
+			layout.newPage()
+		}
+
+		contents = append(contents, recipe.RecipeName)
+		contentPages = append(contentPages, len(document.pages))
+		layout.recipe(recipe, cookbook.Photos[recipe.ID], photoHeight, index)
+	}
+
+	if len(index) > 0 {
+		layout.scale = 1
+		layout.divider = false
+		columnWidth := (contentWidth - cookbookGutter) / 2
+		layout.startSection([]cookbookFrame{
+			{cookbookMargin, columnWidth, pdfPageHeight - cookbookMargin, cookbookBottom},
+			{cookbookMargin + columnWidth + cookbookGutter, columnWidth, pdfPageHeight - cookbookMargin, cookbookBottom},
+		})
+		contents = append(contents, "Index")
+		contentPages = append(contentPages, len(document.pages))
+		layout.heading("Index")
+		layout.index(index)
+	}
+
+	tableOfContents(document.pages[1:1+tocPages], contents, contentPages)
+
+	var output bytes.Buffer
+	if err := document.write(&output); err != nil {
+		log.Printf("Error writing cookbook %s: %v", cookbook.Title, err)
+		return nil, 0, err
+	}
+
+	return output.Bytes(), len(document.pages), nil
+}
+
+// private functions
+
+// titlePage draws the cookbook's title and how many recipes it has in the middle of the page.
+func titlePage(page *pdfPage, cookbook model.Cookbook) {
+	titleStyle := pdfTextStyle{font: pdfFontBold, size: 32}
+	y := 480.0
+
+	for _, line := range pdfWrap(titleStyle, cookbook.Title, pdfPageWidth-2*cookbookMargin) {
+		page.text(titleStyle, (pdfPageWidth-pdfTextWidth(titleStyle, line))/2, y, line)
+		y -= 40
+	}
+
+	count := "1 recipe"
+	if len(cookbook.Recipes) != 1 {
+		count = fmt.Sprintf("%d recipes", len(cookbook.Recipes))
+	}
+	countStyle := pdfTextStyle{font: pdfFontRegular, size: 14, gray: 0.4}
+	page.line(pdfPageWidth/2-72, y+8, pdfPageWidth/2+72, y+8, 0.75)
+	page.text(countStyle, (pdfPageWidth-pdfTextWidth(countStyle, count))/2, y-20, count)
+}
+
+// tableOfContents fills the pages set aside for the table of contents with each entry's title, dot leaders
+// and page number.
+func tableOfContents(pages []*pdfPage, titles []string, pageNumbers []int) {
+	headingStyle := pdfTextStyle{font: pdfFontBold, size: 20}
+	entryStyle := pdfTextStyle{font: pdfFontRegular, size: 11}
+	leaderStyle := pdfTextStyle{font: pdfFontRegular, size: 11, gray: 0.6}
+	right := pdfPageWidth - cookbookMargin
+
+	pages[0].text(headingStyle, cookbookMargin, pdfPageHeight-cookbookMargin-20, "Contents")
+
+	for i, title := range titles {
+		page := pages[i/cookbookTocLines]
+		y := pdfPageHeight - cookbookMargin - 48 - float64(i%cookbookTocLines)*cookbookTocLeading - entryStyle.size
+
+		number := strconv.Itoa(pageNumbers[i])
+		numberX := right - pdfTextWidth(entryStyle, number)
+		title = pdfTruncate(entryStyle, title, numberX-cookbookMargin-24)
+		titleEnd := cookbookMargin + pdfTextWidth(entryStyle, title)
+
+		page.text(entryStyle, cookbookMargin, y, title)
+		dots := int((numberX - titleEnd - 12) / pdfTextWidth(leaderStyle, " ."))
+		if dots > 0 {
+			leader := strings.Repeat(" .", dots)
+			page.text(leaderStyle, numberX-6-pdfTextWidth(leaderStyle, leader), y, leader)
+		}
+		page.text(entryStyle, numberX, y, number)
+	}
+}
+
+// pdfTruncate shortens text with an ellipsis until it is no wider than width.
+func pdfTruncate(style pdfTextStyle, text string, width float64) string {
+	if pdfTextWidth(style, text) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && pdfTextWidth(style, string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimSpace(string(runes)) + "…"
+}
+
+// indexName is the name an ingredient is listed under in the index: its name in lower case, up to the first
+// comma and without remarks in parentheses, so "Butter, softened (unsalted)" is listed as "butter".
+func indexName(ingredient model.Ingredient) string {
+	name := remarkPattern.ReplaceAllString(ingredient.IngredientName, "")
+	name, _, _ = strings.Cut(name, ",")
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// style returns a text style scaled to the layout.
+func (l *cookbookLayout) style(font string, size float64, gray float64) pdfTextStyle {
+	return pdfTextStyle{font: font, size: size * l.scale, gray: gray}
+}
+
+// startSection starts a new page with different frames, such as the columns of the index.
+func (l *cookbookLayout) startSection(frames []cookbookFrame) {
+	l.template = frames
+	l.newPage()
+}
+
+// newPage adds a page with its number at the bottom and starts filling its first frame.
+func (l *cookbookLayout) newPage() {
+	l.page = l.document.addPage()
+
+	footerStyle := pdfTextStyle{font: pdfFontRegular, size: 9, gray: 0.4}
+	number := strconv.Itoa(len(l.document.pages))
+	l.page.text(footerStyle, (pdfPageWidth-pdfTextWidth(footerStyle, number))/2, cookbookFooterY, number)
+
+	if l.divider {
+		l.page.line(cookbookMargin, cookbookMiddle, pdfPageWidth-cookbookMargin, cookbookMiddle, 0.5)
+	}
+
+	l.frame, l.frames = l.template[0], l.template[1:]
+	l.y = l.frame.top
+}
+
+// nextFrame starts filling the next frame of the page, or a new page if it has no more.
+func (l *cookbookLayout) nextFrame() {
+	if len(l.frames) == 0 {
+		l.newPage()
+		return
+	}
+	l.frame, l.frames = l.frames[0], l.frames[1:]
+	l.y = l.frame.top
+}
+
+// room moves on to the next frame if there isn't height left in this one. Something taller than a whole
+// frame is drawn at the top of one anyway.
+func (l *cookbookLayout) room(height float64) {
+	if l.y-height < l.frame.bottom && l.y < l.frame.top {
+		l.nextFrame()
+	}
+}
+
+// space leaves a gap before the next line, unless it would start a frame.
+func (l *cookbookLayout) space(height float64) {
+	if l.y < l.frame.top {
+		l.y -= height
+	}
+}
+
+// paragraph draws text wrapped to the frame, with a prefix such as a bullet or step number before its first
+// line and its lines indented past the prefix.
+func (l *cookbookLayout) paragraph(style pdfTextStyle, text string, prefix string, indent float64) {
+	leading := style.size * 1.35
+
+	for i, line := range pdfWrap(style, text, l.frame.width-indent) {
+		l.room(leading)
+		if i == 0 && prefix != "" {
+			l.page.text(style, l.frame.left, l.y-style.size, prefix)
+		}
+		l.page.text(style, l.frame.left+indent, l.y-style.size, line)
+		l.y -= leading
+	}
+}
+
+// heading draws a section heading across the top of the page and lowers the page's frames below it.
+func (l *cookbookLayout) heading(text string) {
+	l.page.text(pdfTextStyle{font: pdfFontBold, size: 20}, cookbookMargin, l.frame.top-20, text)
+
+	l.frame.top -= 40
+	l.y = l.frame.top
+	frames := make([]cookbookFrame, len(l.frames))
+	for i, frame := range l.frames {
+		frame.top -= 40
+		frames[i] = frame
+	}
+	l.frames = frames
+}
+
+// recipe draws a recipe: its name, servings and times, photo, description, ingredients, steps and notes. Each
+// ingredient's index name is recorded with the page it is on.
+func (l *cookbookLayout) recipe(recipe *model.Recipe, photo []byte, photoHeight float64, index map[string][]int) {
+	bodyStyle := l.style(pdfFontRegular, 10.5, 0)
+	sectionStyle := l.style(pdfFontBold, 12, 0)
+
+	l.paragraph(l.style(pdfFontBold, 20, 0), recipe.RecipeName, "", 0)
+
+	facts := []string{}
+	if recipe.Servings > 0 {
+		facts = append(facts, fmt.Sprintf("Serves %d", recipe.Servings))
+	}
+	if recipe.Yield != nil {
+		facts = append(facts, strings.TrimSpace("Makes "+FormatAmount(recipe.Yield.Quantity)+" "+recipe.Yield.Unit))
+	}
+	if recipe.PrepTimeMinutes > 0 {
+		facts = append(facts, "Prep "+formatTimeText(recipe.PrepTimeMinutes))
+	}
+	if recipe.CookTimeMinutes > 0 {
+		facts = append(facts, "Cook "+formatTimeText(recipe.CookTimeMinutes))
+	}
+	if len(facts) > 0 {
+		l.paragraph(l.style(pdfFontItalic, 10, 0.35), strings.Join(facts, " · "), "", 0)
+	}
+	l.space(6 * l.scale)
+
+	if len(photo) > 0 {
+		l.photo(recipe, photo, photoHeight*l.scale)
+	}
+
+	if recipe.Description != "" {
+		l.paragraph(l.style(pdfFontItalic, 10.5, 0.2), recipe.Description, "", 0)
+		l.space(6 * l.scale)
+	}
+
+	if len(recipe.Ingredients) > 0 {
+		l.room(sectionStyle.size*1.35 + bodyStyle.size*1.35)
+		l.paragraph(sectionStyle, "Ingredients", "", 0)
+		for _, ingredient := range recipe.Ingredients {
+			l.paragraph(bodyStyle, FormatIngredient(ingredient), "•", 12*l.scale)
+
+			name := indexName(ingredient)
+			pages := index[name]
+			if name != "" && (len(pages) == 0 || pages[len(pages)-1] != len(l.document.pages)) {
+				index[name] = append(pages, len(l.document.pages))
+			}
+		}
+		l.space(6 * l.scale)
+	}
+
+	if len(recipe.Procedure) > 0 {
+		l.room(sectionStyle.size*1.35 + bodyStyle.size*1.35)
+		l.paragraph(sectionStyle, "Steps", "", 0)
+		for i, step := range recipe.Procedure {
+			l.paragraph(bodyStyle, step, fmt.Sprintf("%d.", i+1), 18*l.scale)
+			l.space(2 * l.scale)
+		}
+		l.space(4 * l.scale)
+	}
+
+	if recipe.Notes != "" {
+		l.room(sectionStyle.size*1.35 + bodyStyle.size*1.35)
+		l.paragraph(sectionStyle, "Notes", "", 0)
+		for _, note := range strings.Split(recipe.Notes, "\n") {
+			l.paragraph(bodyStyle, note, "", 0)
+		}
+	}
+}
+
+// photo draws a recipe's photo at the width of the frame, or narrower so it is no taller than maxHeight.
+func (l *cookbookLayout) photo(recipe *model.Recipe, photo []byte, maxHeight float64) {
+	image, size, err := l.document.addImage(photo)
+	if err != nil {
+		log.Printf("Leaving the photo of recipe %d out of the cookbook: %v", recipe.ID, err)
+		return
+	}
+	if size.X == 0 || size.Y == 0 {
+		return
+	}
+
+	width := l.frame.width
+	height := width * float64(size.Y) / float64(size.X)
+	if height > maxHeight {
+		height = maxHeight
+		width = height * float64(size.X) / float64(size.Y)
+	}
+
+	l.room(height)
+	l.page.image(image, l.frame.left, l.y-height, width, height)
+	l.y -= height + 10*l.scale
+}
+
+// index draws the ingredient index in alphabetical order, each name followed by the pages it is on.
+func (l *cookbookLayout) index(index map[string][]int) {
+	style := pdfTextStyle{font: pdfFontRegular, size: 9.5}
+	leading := math.Round(style.size * 1.35)
+
+	names := make([]string, 0, len(index))
+	for name := range index {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	initial := ""
+	for _, name := range names {
+		if first := strings.ToUpper(string([]rune(name)[0])); first != initial {
+			initial = first
+			l.space(leading / 2)
+			l.room(leading * 2)
+			l.paragraph(pdfTextStyle{font: pdfFontBold, size: 10.5}, initial, "", 0)
+		}
+
+		pages := make([]string, len(index[name]))
+		for i, page := range index[name] {
+			pages[i] = strconv.Itoa(page)
+		}
+
+		for i, line := range pdfWrap(style, name+", "+strings.Join(pages, ", "), l.frame.width-12) {
+			indent := 0.0
+			if i > 0 {
+				indent = 12
+			}
+			l.room(leading)
+			l.page.text(style, l.frame.left+indent, l.y-style.size, line)
+			l.y -= leading
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"recipe-generator/internal/api/model"
+)
+
+// maxTimerNameLength limits how much of a step's text a suggested timer's name takes.
+const maxTimerNameLength = 60
+
+// timerTickInterval is how often the running timers' remaining time is streamed.
+const timerTickInterval = time.Second
+
+// clausePattern splits a step's text into the clauses a timer can be named after.
+var clausePattern = regexp.MustCompile(`[.;!?]+\s*|,\s*(?:then|and then)\s+`)
+
+// SuggestTimers reads the timers a procedure step calls for from its text, one per duration, named after the
+// clause it is in, so "Simmer for 20-25 minutes, then rest 5 minutes." suggests "Simmer for 20-25 minutes" for
+// 20 minutes and "Rest 5 minutes" for 5. Ranges use the shorter end, when it's time to check.
+//
+// Parameters:
+//   - step: The step's number, from 1
+//   - instruction: The step's text
+//
+// Returns:
+//   - []model.TimerSuggestion: The suggested timers, in the order they appear
+func SuggestTimers(step int, instruction string) []model.TimerSuggestion {
+	suggestions := []model.TimerSuggestion{}
+
+	for _, clause := range clausePattern.Split(instruction, -1) {
+		clause = strings.TrimSpace(clause)
+
+		for _, match := range stepDurationPattern.FindAllStringSubmatch(clause, -1) {
+			amount, _ := strconv.ParseFloat(match[1], 64)
+			seconds := amount * 60
+			if strings.HasPrefix(strings.ToLower(match[3]), "h") {
+				seconds *= 60
+			}
+			if seconds < 1 {
+				continue
+			}
+
+			suggestions = append(suggestions, model.TimerSuggestion{
+				Step:            step,
+				TimerName:       timerName(clause),
+				DurationSeconds: int(math.Round(seconds)),
+			})
+		}
+	}
+
+	return suggestions
+}
+
+// TimerClock counts down the running timers of cooking sessions in memory. Every second it reports the
+// remaining time of each session's running timers, and it reports each timer when it goes off. It only holds
+// timers it has been given, so callers hand it a session's timers after every change and after a restart.
+type TimerClock struct {
+	tick     func(sessionID int, ticks []model.CookingTimerTick)
+	finish   func(sessionID int, timerID int)
+	mutex    sync.Mutex
+	sessions map[int]*clockSession
+}
+
+// clockSession is the running timers of one session and the channel that stops its countdown.
+type clockSession struct {
+	timers []model.CookingTimer
+	stop   chan struct{}
+}
+
+// NewTimerClock creates a new TimerClock with no timers.
+//
+// Parameters:
+//   - tick: Called every second with the remaining time of a session's running timers
+//   - finish: Called once for each timer that goes off, after its last tick
+//
+// Returns:
+//   - *TimerClock: A new timer clock
+func NewTimerClock(tick func(sessionID int, ticks []model.CookingTimerTick), finish func(sessionID int, timerID int)) *TimerClock {
+	return &TimerClock{tick: tick, finish: finish, sessions: make(map[int]*clockSession)}
+}
+
+// Set replaces the timers counted down for a session with its running ones, starting its countdown if it
+// wasn't counting already and stopping it if no timer is running.
+func (tc *TimerClock) Set(sessionID int, timers []model.CookingTimer) {
+	running := []model.CookingTimer{}
+	for _, timer := range timers {
+		if timer.Status == model.TimerStatusRunning && timer.EndsAt != nil {
+			running = append(running, timer)
+		}
+	}
+
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
+
+	session, ok := tc.sessions[sessionID]
+	if len(running) == 0 {
+		if ok {
+			close(session.stop)
+			delete(tc.sessions, sessionID)
+		}
+		return
+	}
+
+	if ok {
+		session.timers = running
+		return
+	}
+
+	session = &clockSession{timers: running, stop: make(chan struct{})}
+	tc.sessions[sessionID] = session
+	go tc.run(sessionID, session)
+}
+
+// Stop stops counting down a session's timers, such as when the session ends.
+func (tc *TimerClock) Stop(sessionID int) {
+	tc.Set(sessionID, nil)
+}
+
+// private functions
+
+// run counts down a session's timers until none is left running or the session is stopped.
+func (tc *TimerClock) run(sessionID int, session *clockSession) {
+	ticker := time.NewTicker(timerTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-session.stop:
+			return
+		case now := <-ticker.C:
+			tc.mutex.Lock()
+			ticks := make([]model.CookingTimerTick, 0, len(session.timers))
+			finished := []int{}
+			running := session.timers[:0]
+			for _, timer := range session.timers {
+				remaining := timer.Remaining(now)
+				ticks = append(ticks, model.CookingTimerTick{TimerId: timer.ID, TimerName: timer.TimerName, RemainingSeconds: remaining})
+				if remaining == 0 {
+					finished = append(finished, timer.ID)
+				} else {
+					running = append(running, timer)
+				}
+			}
+			session.timers = running
+			done := len(running) == 0
+			if done {
+				delete(tc.sessions, sessionID)
+			}
+			tc.mutex.Unlock()
+
+			tc.tick(sessionID, ticks)
+			for _, timerID := range finished {
+				tc.finish(sessionID, timerID)
+			}
+
+			if done {
+				return
+			}
+		}
+	}
+}
+
+// timerName shortens a clause of a step's text to a timer name, cutting at a word and capitalizing it.
+func timerName(clause string) string {
+	if len(clause) > maxTimerNameLength {
+		clause = clause[:maxTimerNameLength]
+		if cut := strings.LastIndex(clause, " "); cut > 0 {
+			clause = clause[:cut]
+		}
+	}
+
+	clause = strings.TrimRight(clause, " ,:")
+	if clause == "" {
+		return clause
+	}
+	return strings.ToUpper(clause[:1]) + clause[1:]
+}
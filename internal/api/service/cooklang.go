@@ -0,0 +1,601 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"recipe-generator/internal/api/model"
+)
+
+// blockCommentPattern matches Cooklang block comments, [- like this -].
+var blockCommentPattern = regexp.MustCompile(`(?s)\[-.*?-\]`)
+
+// timeTextPattern matches the parts of a time written out in Cooklang metadata or other apps' exports, such
+// as the "1 hour" and "30 min" of "1 hour 30 min" or the "1h" and "30m" of "1h30m".
+var timeTextPattern = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(hours?|hrs?|h|minutes?|mins?|m)?\b`)
+
+// cooklangSegment is part of a procedure step being written as Cooklang: plain text, or markup such as an
+// ingredient that mentions can't be found in again.
+type cooklangSegment struct {
+	text   string
+	markup bool
+}
+
+// cooklangPosition is a place in the plain text of procedure steps being written as Cooklang.
+type cooklangPosition struct {
+	step, segment, offset int
+}
+
+// ParseCooklang reads a recipe from a Cooklang file. Each paragraph is a procedure step, with its
+// @ingredients{quantity%unit}, #cookware{} and ~timers{quantity%unit} written out as text. The ingredients are
+// listed in the order they are mentioned, once per mention, with a (preparation) after one added to its name.
+// Metadata is read from ">> key: value" lines or a front matter block between "---" lines, and "> " lines
+// are notes. Comments, "-- " to the end of a line and [- blocks -], are dropped.
+//
+// Parameters:
+//   - text: The Cooklang file
+//
+// Returns:
+//   - *model.Recipe: The recipe, not validated; its name is blank if the file doesn't have a title
+func ParseCooklang(text string) *model.Recipe {
+	recipe := &model.Recipe{Ingredients: []model.Ingredient{}, Procedure: []string{}}
+	metadata := [][2]string{}
+	notes := []string{}
+	cookware := []string{}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = blockCommentPattern.ReplaceAllString(text, "")
+	lines := strings.Split(text, "\n")
+
+	// front matter is YAML-like: "key: value" lines, with lists as "- item" lines under a key.
+	start := 0
+	for start < len(lines) && strings.TrimSpace(lines[start]) == "" {
+		start++
+	}
+	if start < len(lines) && strings.TrimSpace(lines[start]) == "---" {
+		for end := start + 1; end < len(lines); end++ {
+			line := strings.TrimSpace(lines[end])
+			if line == "---" {
+				start = end + 1
+				break
+			}
+			if item, ok := strings.CutPrefix(line, "- "); ok && len(metadata) > 0 {
+				last := &metadata[len(metadata)-1]
+				last[1] = strings.TrimPrefix(last[1]+", "+strings.Trim(item, `"'`), ", ")
+			} else if key, value, ok := strings.Cut(line, ":"); ok {
+				metadata = append(metadata, [2]string{key, strings.Trim(strings.TrimSpace(value), `"'`)})
+			}
+		}
+	}
+
+	paragraph := []string{}
+	endParagraph := func() {
+		if len(paragraph) > 0 {
+			step := parseCooklangStep(strings.Join(paragraph, " "), recipe, &cookware)
+			if step != "" {
+				recipe.Procedure = append(recipe.Procedure, step)
+			}
+			paragraph = paragraph[:0]
+		}
+	}
+
+	for _, line := range lines[start:] {
+		line = strings.TrimSpace(stripCooklangComment(line))
+
+		switch {
+		case line == "", strings.HasPrefix(line, "="):
+			// blank lines end a step, and so do section headings, which aren't kept.
+			endParagraph()
+		case strings.HasPrefix(line, ">>"):
+			endParagraph()
+			if key, value, ok := strings.Cut(strings.TrimPrefix(line, ">>"), ":"); ok {
+				metadata = append(metadata, [2]string{key, strings.TrimSpace(value)})
+			}
+		case strings.HasPrefix(line, ">"):
+			endParagraph()
+			notes = append(notes, strings.TrimSpace(strings.TrimPrefix(line, ">")))
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	endParagraph()
+
+	for _, entry := range metadata {
+		applyCooklangMetadata(recipe, strings.ToLower(strings.TrimSpace(entry[0])), strings.TrimSpace(entry[1]))
+	}
+	if len(notes) > 0 {
+		recipe.Notes = strings.TrimSpace(strings.Join(append(notes, recipe.Notes), "\n"))
+	}
+	if len(cookware) > 0 {
+		recipe.Equipment = NormalizeEquipment(cookware)
+	}
+
+	return recipe
+}
+
+// WriteCooklang writes a recipe as a Cooklang file, the reverse of ParseCooklang. Each ingredient is marked up
+// where its name is mentioned in the procedure, in the order they are listed, so reading the file back gives
+// the same ingredients in the same order. If the procedure doesn't mention them all in that order, they are
+// marked up in an opening step that gathers them instead. Equipment and durations the procedure mentions are
+// marked up as cookware and timers.
+//
+// Parameters:
+//   - recipe: The recipe with its ingredients, procedure, tags and equipment
+//
+// Returns:
+//   - string: The Cooklang file
+func WriteCooklang(recipe *model.Recipe) string {
+	var builder strings.Builder
+
+	writeMetadata := func(key string, value string) {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			fmt.Fprintf(&builder, ">> %s: %s\n", key, value)
+		}
+	}
+
+	writeMetadata("title", recipe.RecipeName)
+	writeMetadata("description", recipe.Description)
+	if recipe.Servings > 0 {
+		writeMetadata("servings", strconv.Itoa(recipe.Servings))
+	}
+	if recipe.Yield != nil {
+		writeMetadata("yield", fmt.Sprintf("%s %s", FormatAmount(recipe.Yield.Quantity), recipe.Yield.Unit))
+	}
+	writeMetadata("prep time", formatTimeText(recipe.PrepTimeMinutes))
+	writeMetadata("cook time", formatTimeText(recipe.CookTimeMinutes))
+	writeMetadata("tags", strings.Join(recipe.Tags, ", "))
+	writeMetadata("cuisine", recipe.Cuisine)
+	writeMetadata("course", recipe.Course)
+	writeMetadata("difficulty", recipe.Difficulty)
+	if source := recipe.Source; source != nil {
+		writeMetadata("author", source.Author)
+		writeMetadata("cookbook", source.Cookbook)
+		if source.Page > 0 {
+			writeMetadata("page", strconv.Itoa(source.Page))
+		}
+		writeMetadata("source", source.Url)
+		writeMetadata("family member", source.FamilyMember)
+	}
+	writeMetadata("make ahead", recipe.MakeAhead)
+	writeMetadata("storage", recipe.Storage)
+	writeMetadata("freezing", recipe.Freezing)
+
+	if strings.TrimSpace(recipe.Notes) != "" {
+		builder.WriteString("\n")
+		for _, note := range strings.Split(recipe.Notes, "\n") {
+			if note = strings.TrimSpace(note); note != "" {
+				fmt.Fprintf(&builder, "> %s\n", note)
+			}
+		}
+	}
+
+	for _, step := range cooklangSteps(recipe) {
+		builder.WriteString("\n")
+		for i, segment := range step {
+			switch {
+			case segment.markup:
+				builder.WriteString(segment.text)
+			case i > 0 && strings.HasPrefix(step[i-1].text, "@") && strings.HasPrefix(segment.text, "("):
+				// a parenthesis right after an ingredient would read as its preparation.
+				builder.WriteString(`\` + escapeCooklang(segment.text))
+			default:
+				builder.WriteString(escapeCooklang(segment.text))
+			}
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
+
+// private functions
+
+// stripCooklangComment drops a "--" comment from the end of a line, unless its dashes are escaped.
+func stripCooklangComment(line string) string {
+	for i := 0; i+1 < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if line[i] == '-' && line[i+1] == '-' {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseCooklangStep writes out the markup of a step as text, adding its ingredients to the recipe and its
+// cookware to cookware.
+func parseCooklangStep(text string, recipe *model.Recipe, cookware *[]string) string {
+	var step strings.Builder
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if r == '\\' && i+1 < len(runes) {
+			i++
+			step.WriteRune(runes[i])
+			continue
+		}
+
+		if (r != '@' && r != '#' && r != '~') || i+1 >= len(runes) {
+			step.WriteRune(r)
+			continue
+		}
+
+		name, quantity, braced, next := readCooklangItem(runes, i+1)
+		if name == "" && !braced {
+			step.WriteRune(r)
+			continue
+		}
+
+		i = next - 1
+
+		switch r {
+		case '@':
+			// a reference to another recipe file, such as @./sauces/Marinara{}, is named after the file.
+			name = strings.TrimSuffix(name[strings.LastIndex(name, "/")+1:], ".cook")
+			ingredient := cooklangIngredient(name, quantity)
+
+			// an ingredient's preparation can follow it in parentheses, as in @onion{1}(diced).
+			if next < len(runes) && runes[next] == '(' {
+				for end := next + 1; end < len(runes); end++ {
+					if runes[end] == ')' {
+						ingredient.IngredientName += ", " + strings.TrimSpace(string(runes[next+1:end]))
+						i = end
+						break
+					}
+				}
+			}
+
+			recipe.Ingredients = append(recipe.Ingredients, ingredient)
+			step.WriteString(name)
+		case '#':
+			*cookware = append(*cookware, name)
+			step.WriteString(name)
+		case '~':
+			// a timer reads as its duration, or as its name if it doesn't have one.
+			amount, unit, _ := strings.Cut(quantity, "%")
+			step.WriteString(firstNonBlank(strings.TrimSpace(amount)+" "+strings.TrimSpace(unit), name))
+		}
+	}
+
+	return strings.Join(strings.Fields(step.String()), " ")
+}
+
+// readCooklangItem reads the name and {quantity} of an ingredient, cookware or timer starting at runes[start],
+// just after its @, # or ~. A name is one word unless braces follow it before any other markup. It returns the
+// index after the item.
+func readCooklangItem(runes []rune, start int) (string, string, bool, int) {
+	rest := string(runes[start:])
+
+	if open := strings.IndexRune(rest, '{'); open >= 0 && !strings.ContainsAny(rest[:open], "@#~{}\n") {
+		if end := strings.IndexRune(rest[open:], '}'); end > 0 {
+			name := strings.TrimSpace(rest[:open])
+			quantity := strings.TrimSpace(rest[open+1 : open+end])
+			return name, quantity, true, start + len([]rune(rest[:open+end+1]))
+		}
+	}
+
+	end := start
+	for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_' || runes[end] == '-') {
+		end++
+	}
+
+	return string(runes[start:end]), "", false, end
+}
+
+// cooklangIngredient converts an ingredient's name and {quantity%unit} to an ingredient. Without a quantity it
+// is used as needed, and a quantity in words such as {to taste} is its unit.
+func cooklangIngredient(name string, quantity string) model.Ingredient {
+	amountText, unit, _ := strings.Cut(quantity, "%")
+	amountText = strings.TrimSpace(amountText)
+	unit = strings.TrimSpace(unit)
+
+	ingredient := model.Ingredient{Amount: 1, IngredientName: strings.TrimSpace(name)}
+
+	match := amountPattern.FindStringSubmatch(amountText)
+	switch {
+	case amountText == "":
+		ingredient.UnitOfMeasurement = firstNonBlank(unit, UnitAsNeeded)
+	case match != nil && strings.TrimSpace(match[0]) == amountText:
+		ingredient.Amount = parseAmount(match[1])
+		ingredient.UnitOfMeasurement = firstNonBlank(unit, UnitWhole)
+	default:
+		ingredient.UnitOfMeasurement = strings.ToLower(strings.TrimSpace(amountText + " " + unit))
+	}
+
+	return ingredient
+}
+
+// applyCooklangMetadata sets the part of a recipe a metadata entry is for. Keys the recipe has no place for
+// are ignored.
+func applyCooklangMetadata(recipe *model.Recipe, key string, value string) {
+	if value == "" {
+		return
+	}
+
+	source := recipe.Source
+	if source == nil {
+		source = &model.Attribution{}
+	}
+
+	switch strings.NewReplacer("_", " ", "-", " ").Replace(key) {
+	case "title", "name":
+		recipe.RecipeName = value
+	case "description", "introduction":
+		recipe.Description = value
+	case "servings", "serves", "yield", "yields":
+		applyJsonLdYield(recipe, value)
+	case "prep time", "time.prep", "prep":
+		recipe.PrepTimeMinutes = parseTimeText(value)
+	case "cook time", "time.cook", "cook":
+		recipe.CookTimeMinutes = parseTimeText(value)
+	case "time", "total time", "duration":
+		if recipe.PrepTimeMinutes == 0 && recipe.CookTimeMinutes == 0 {
+			recipe.CookTimeMinutes = parseTimeText(value)
+		}
+	case "tags", "tag", "keywords":
+		for _, tag := range strings.Split(strings.Trim(value, "[]"), ",") {
+			if tag = strings.Trim(strings.TrimSpace(tag), `"'`); tag != "" {
+				recipe.Tags = append(recipe.Tags, tag)
+			}
+		}
+	case "cuisine":
+		recipe.Cuisine = strings.ToLower(value)
+	case "course", "category":
+		recipe.Course = jsonLdCourse(value)
+	case "difficulty":
+		if model.IsDifficulty(strings.ToLower(value)) {
+			recipe.Difficulty = strings.ToLower(value)
+		}
+	case "author", "source.author":
+		source.Author = value
+	case "cookbook", "source.name", "book":
+		source.Cookbook = value
+	case "page":
+		source.Page, _ = strconv.Atoi(value)
+	case "source", "source.url", "url":
+		if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+			source.Url = value
+		} else if source.Cookbook == "" {
+			source.Cookbook = value
+		}
+	case "family member", "credit":
+		source.FamilyMember = value
+	case "notes", "note":
+		recipe.Notes = strings.TrimSpace(recipe.Notes + "\n" + value)
+	case "make ahead":
+		recipe.MakeAhead = value
+	case "storage":
+		recipe.Storage = value
+	case "freezing":
+		recipe.Freezing = value
+	}
+
+	if !source.IsZero() {
+		recipe.Source = source
+	}
+}
+
+// parseTimeText reads a time such as "1 hour 30 minutes", "1 hr 30 mins", "1h30m", "PT1H30M" or "90" as minutes.
+func parseTimeText(value string) int {
+	if minutes := ParseDuration(value); minutes > 0 {
+		return minutes
+	}
+
+	minutes := 0.0
+	for _, match := range timeTextPattern.FindAllStringSubmatch(value, -1) {
+		amount, _ := strconv.ParseFloat(match[1], 64)
+		if strings.HasPrefix(strings.ToLower(match[2]), "h") {
+			amount *= 60
+		}
+		minutes += amount
+	}
+
+	return int(math.Round(minutes))
+}
+
+// formatTimeText writes minutes as a time such as "1 hour 30 minutes", or "" for none.
+func formatTimeText(minutes int) string {
+	parts := []string{}
+
+	if hours := minutes / 60; hours == 1 {
+		parts = append(parts, "1 hour")
+	} else if hours > 1 {
+		parts = append(parts, fmt.Sprintf("%d hours", hours))
+	}
+
+	if minutes%60 == 1 {
+		parts = append(parts, "1 minute")
+	} else if minutes%60 > 1 {
+		parts = append(parts, fmt.Sprintf("%d minutes", minutes%60))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// cooklangSteps splits a recipe's procedure into plain text and markup, with its ingredients, equipment and
+// durations marked up.
+func cooklangSteps(recipe *model.Recipe) [][]cooklangSegment {
+	steps := make([][]cooklangSegment, 0, len(recipe.Procedure)+1)
+	for _, step := range recipe.Procedure {
+		steps = append(steps, []cooklangSegment{{text: strings.Join(strings.Fields(step), " ")}})
+	}
+
+	if !markCooklangIngredients(steps, recipe.Ingredients) {
+		// start over, gathering the ingredients in a step of their own.
+		steps = steps[:0]
+		for _, step := range recipe.Procedure {
+			steps = append(steps, []cooklangSegment{{text: strings.Join(strings.Fields(step), " ")}})
+		}
+
+		if len(recipe.Ingredients) > 0 {
+			gather := []cooklangSegment{{text: "Gather "}}
+			for i, ingredient := range recipe.Ingredients {
+				if i > 0 && i == len(recipe.Ingredients)-1 {
+					gather = append(gather, cooklangSegment{text: " and "})
+				} else if i > 0 {
+					gather = append(gather, cooklangSegment{text: ", "})
+				}
+				gather = append(gather, cooklangSegment{text: cooklangIngredientMarkup(ingredient.IngredientName, "", ingredient), markup: true})
+			}
+			gather = append(gather, cooklangSegment{text: "."})
+			steps = append([][]cooklangSegment{gather}, steps...)
+		}
+	}
+
+	for _, equipment := range recipe.Equipment {
+		if position, length, ok := findCooklangMention(steps, equipment, cooklangPosition{}); ok {
+			mention := steps[position.step][position.segment].text[position.offset : position.offset+length]
+			markCooklang(steps, position, length, "#"+mention+"{}")
+		}
+	}
+
+	for i := range steps {
+		for j := 0; j < len(steps[i]); j++ {
+			segment := steps[i][j]
+			if segment.markup {
+				continue
+			}
+
+			match := stepDurationPattern.FindStringSubmatchIndex(segment.text)
+			if match == nil {
+				continue
+			}
+
+			text := segment.text[match[0]:match[1]]
+			unit := segment.text[match[6]:match[7]]
+			quantity := strings.TrimSuffix(strings.TrimSuffix(text, unit), " ")
+			if quantity+" "+unit != text {
+				continue
+			}
+
+			markCooklang(steps, cooklangPosition{step: i, segment: j, offset: match[0]}, len(text), fmt.Sprintf("~{%s%%%s}", quantity, unit))
+		}
+	}
+
+	return steps
+}
+
+// markCooklangIngredients marks up each ingredient where the procedure mentions it, in the order they are
+// listed, by its full name or by the part of it before a comma with the rest as its preparation. It reports
+// false, leaving steps partly marked up, if an ingredient isn't mentioned after the one before it.
+func markCooklangIngredients(steps [][]cooklangSegment, ingredients []model.Ingredient) bool {
+	cursor := cooklangPosition{}
+
+	for _, ingredient := range ingredients {
+		name := strings.TrimSpace(ingredient.IngredientName)
+		core, prepared, _ := strings.Cut(name, ", ")
+
+		position, length, ok := findCooklangMention(steps, name, cursor)
+		if ok {
+			core, prepared = name, ""
+		} else if prepared != "" {
+			position, length, ok = findCooklangMention(steps, core, cursor)
+		}
+		if !ok {
+			return false
+		}
+
+		markCooklang(steps, position, length, cooklangIngredientMarkup(core, prepared, ingredient))
+		cursor = cooklangPosition{step: position.step, segment: position.segment + 2}
+	}
+
+	return true
+}
+
+// cooklangIngredientMarkup writes an ingredient as Cooklang markup, such as @flour{2%cup}(sifted).
+func cooklangIngredientMarkup(name string, prepared string, ingredient model.Ingredient) string {
+	var quantity string
+
+	switch unit := strings.TrimSpace(ingredient.UnitOfMeasurement); strings.ToLower(unit) {
+	case UnitAsNeeded:
+		quantity = ""
+	case UnitWhole, "":
+		quantity = cooklangAmount(ingredient.Amount)
+	default:
+		if ingredient.Amount == 1 && !unicode.IsDigit([]rune(unit)[0]) && LookupUnit(unit).Kind == UnitKindCount && !countUnits[Singular(strings.ToLower(unit))] {
+			// a unit in words, such as "to taste", is written as the quantity.
+			quantity = unit
+		} else {
+			quantity = cooklangAmount(ingredient.Amount) + "%" + unit
+		}
+	}
+
+	markup := "@" + name + "{" + quantity + "}"
+	if prepared != "" {
+		markup += "(" + prepared + ")"
+	}
+	return markup
+}
+
+// cooklangAmount writes an amount as a fraction if it reads back as exactly the same amount, or as a decimal.
+func cooklangAmount(amount float64) string {
+	if fraction := FormatAmount(amount); !strings.Contains(fraction, " ") && parseAmount(fraction) == amount {
+		return fraction
+	}
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// findCooklangMention finds the first mention of a name as whole words in the plain text of steps, at or after
+// from. Case matters, since the mention is what reads back as the name. It returns where it is and its length
+// in bytes.
+func findCooklangMention(steps [][]cooklangSegment, name string, from cooklangPosition) (cooklangPosition, int, bool) {
+	if name == "" {
+		return cooklangPosition{}, 0, false
+	}
+	pattern, err := regexp.Compile(`(?:^|\b)` + regexp.QuoteMeta(name) + `(?:\b|$)`)
+	if err != nil {
+		return cooklangPosition{}, 0, false
+	}
+
+	for i := from.step; i < len(steps); i++ {
+		for j := 0; j < len(steps[i]); j++ {
+			if (i == from.step && j < from.segment) || steps[i][j].markup {
+				continue
+			}
+
+			offset := 0
+			if i == from.step && j == from.segment {
+				offset = from.offset
+			}
+
+			if match := pattern.FindStringIndex(steps[i][j].text[offset:]); match != nil {
+				return cooklangPosition{step: i, segment: j, offset: offset + match[0]}, match[1] - match[0], true
+			}
+		}
+	}
+
+	return cooklangPosition{}, 0, false
+}
+
+// markCooklang replaces length bytes of plain text at a position with markup, splitting its segment in three.
+func markCooklang(steps [][]cooklangSegment, position cooklangPosition, length int, markup string) {
+	segments := steps[position.step]
+	text := segments[position.segment].text
+
+	split := []cooklangSegment{
+		{text: text[:position.offset]},
+		{text: markup, markup: true},
+		{text: text[position.offset+length:]},
+	}
+
+	replaced := append([]cooklangSegment{}, segments[:position.segment]...)
+	replaced = append(replaced, split...)
+	steps[position.step] = append(replaced, segments[position.segment+1:]...)
+}
+
+// escapeCooklang escapes the characters of plain text that Cooklang would read as markup or comments.
+func escapeCooklang(text string) string {
+	text = strings.NewReplacer(`\`, `\\`, "@", `\@`, "#", `\#`, "~", `\~`, "--", `-\-`, "[-", `[\-`).Replace(text)
+	if strings.HasPrefix(text, ">") || strings.HasPrefix(text, "=") {
+		text = `\` + text
+	}
+	return text
+}
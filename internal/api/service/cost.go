@@ -0,0 +1,179 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// CostService estimates what recipes cost from observed ingredient prices.
+type CostService struct{}
+
+// NewCostService creates a new CostService.
+func NewCostService() *CostService {
+	return &CostService{}
+}
+
+// Estimate works out what a recipe costs to make. Each ingredient's amount is converted to the unit of its
+// price, through the ingredient's density when going between volume and mass, and charged at the price per
+// unit. An ingredient without a price of its own uses the price of the most specific ingredient its name
+// ends with, so "chicken thigh" is priced as "chicken" when only chicken has a price. Ingredients that can't
+// be priced are listed with the reason and left out of the total.
+//
+// Parameters:
+//   - recipe: The recipe with its ingredients loaded
+//   - servings: Servings to cost, 0 to use the recipe's own servings
+//   - prices: The current price of each ingredient by canonical name
+//
+// Returns:
+//   - model.RecipeCost: The estimate with a breakdown by ingredient
+func (cs *CostService) Estimate(recipe *model.Recipe, servings int, prices map[string]model.IngredientPrice) model.RecipeCost {
+	if servings <= 0 || recipe.Servings <= 0 {
+		servings = recipe.Servings
+	}
+
+	cost := model.RecipeCost{
+		RecipeId:    recipe.ID,
+		RecipeName:  recipe.RecipeName,
+		Servings:    servings,
+		Ingredients: []model.IngredientCost{},
+		Unpriced:    []model.UnpricedIngredient{},
+	}
+
+	for _, requirement := range RecipeRequirements(recipe, servings) {
+		name := CanonicalIngredientName(requirement.IngredientName)
+
+		price, ok := priceFor(name, prices)
+		if !ok {
+			cost.Unpriced = append(cost.Unpriced, unpriced(requirement, model.UnpricedNoPrice))
+			continue
+		}
+
+		converted, approximate, ok := ConvertIngredient(
+			requirement.Amount,
+			LookupUnit(requirement.Unit),
+			LookupUnit(price.Unit),
+			name,
+		)
+		if !ok {
+			cost.Unpriced = append(cost.Unpriced, unpriced(requirement, model.UnpricedUnitMismatch))
+			continue
+		}
+
+		ingredientCost := converted / price.Quantity * price.Price
+		cost.TotalCost += ingredientCost
+
+		cost.Ingredients = append(cost.Ingredients, model.IngredientCost{
+			IngredientName: requirement.IngredientName,
+			Amount:         RoundAmount(requirement.Amount),
+			Unit:           requirement.Unit,
+			Cost:           RoundAmount(ingredientCost),
+			PriceId:        price.ID,
+			Price:          price.Price,
+			PriceQuantity:  price.Quantity,
+			PriceUnit:      price.Unit,
+			Store:          price.Store,
+			ObservedDate:   price.ObservedDate,
+			Approximate:    approximate,
+		})
+	}
+
+	if servings > 0 {
+		perServing := RoundAmount(cost.TotalCost / float64(servings))
+		cost.CostPerServing = &perServing
+	}
+
+	cost.TotalCost = RoundAmount(cost.TotalCost)
+	cost.Complete = len(cost.Unpriced) == 0
+
+	return cost
+}
+
+// EstimateAll estimates every recipe as written, keyed by recipe ID.
+func (cs *CostService) EstimateAll(recipes []*model.Recipe, prices map[string]model.IngredientPrice) map[int]model.RecipeCost {
+	costs := make(map[int]model.RecipeCost, len(recipes))
+	for _, recipe := range recipes {
+		costs[recipe.ID] = cs.Estimate(recipe, 0, prices)
+	}
+	return costs
+}
+
+// Within returns the IDs of the recipes whose estimated cost is at most maxCost, or whose cost per serving
+// is at most maxCostPerServing. A limit of 0 is not applied. Recipes with nothing priced, or without
+// servings when limiting the cost per serving, are left out because their cost is unknown.
+func (cs *CostService) Within(costs map[int]model.RecipeCost, maxCost float64, maxCostPerServing float64) []int {
+	recipeIDs := []int{}
+
+	for recipeID, cost := range costs {
+		if !cost.Priced() {
+			continue
+		}
+		if maxCost > 0 && cost.TotalCost > maxCost {
+			continue
+		}
+		if maxCostPerServing > 0 && (cost.CostPerServing == nil || *cost.CostPerServing > maxCostPerServing) {
+			continue
+		}
+		recipeIDs = append(recipeIDs, recipeID)
+	}
+
+	sort.Ints(recipeIDs)
+	return recipeIDs
+}
+
+// Cheapest returns the IDs of the recipes with a known cost, cheapest first, by total cost or by cost per
+// serving. Recipes with the same cost are in ID order.
+func (cs *CostService) Cheapest(costs map[int]model.RecipeCost, perServing bool) []int {
+	estimates := make(map[int]float64, len(costs))
+
+	for recipeID, cost := range costs {
+		if !cost.Priced() {
+			continue
+		}
+		if !perServing {
+			estimates[recipeID] = cost.TotalCost
+		} else if cost.CostPerServing != nil {
+			estimates[recipeID] = *cost.CostPerServing
+		}
+	}
+
+	recipeIDs := make([]int, 0, len(estimates))
+	for recipeID := range estimates {
+		recipeIDs = append(recipeIDs, recipeID)
+	}
+
+	sort.Slice(recipeIDs, func(i, j int) bool {
+		if estimates[recipeIDs[i]] != estimates[recipeIDs[j]] {
+			return estimates[recipeIDs[i]] < estimates[recipeIDs[j]]
+		}
+		return recipeIDs[i] < recipeIDs[j]
+	})
+
+	return recipeIDs
+}
+
+// private functions
+
+// priceFor returns the price of an ingredient, or of the most specific ingredient its name ends with.
+func priceFor(canonicalName string, prices map[string]model.IngredientPrice) (model.IngredientPrice, bool) {
+	words := strings.Fields(canonicalName)
+
+	for i := range words {
+		if price, ok := prices[strings.Join(words[i:], " ")]; ok {
+			return price, true
+		}
+	}
+
+	return model.IngredientPrice{}, false
+}
+
+// unpriced reports a requirement that could not be priced.
+func unpriced(requirement PantryRequirement, reason string) model.UnpricedIngredient {
+	return model.UnpricedIngredient{
+		IngredientName: requirement.IngredientName,
+		Amount:         RoundAmount(requirement.Amount),
+		Unit:           requirement.Unit,
+		Reason:         reason,
+	}
+}
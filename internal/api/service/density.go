@@ -0,0 +1,114 @@
+package service
+
+import (
+	"strings"
+)
+
+// densities gives the typical density of common ingredients in grams per millilitre, for converting
+// between volumes and masses, e.g. a cup of flour to grams. Measured by spooning into the cup and levelling,
+// so they are approximate.
+var densities = map[string]float64{
+	"water":             1.0,
+	"milk":              1.03,
+	"buttermilk":        1.03,
+	"cream":             1.0,
+	"heavy cream":       1.0,
+	"sour cream":        1.0,
+	"yogurt":            1.03,
+	"butter":            0.96,
+	"oil":               0.92,
+	"olive oil":         0.91,
+	"vegetable oil":     0.92,
+	"honey":             1.42,
+	"maple syrup":       1.32,
+	"molasses":          1.4,
+	"flour":             0.53,
+	"bread flour":       0.55,
+	"whole wheat flour": 0.51,
+	"cake flour":        0.48,
+	"rye flour":         0.45,
+	"spelt flour":       0.46,
+	"semolina":          0.69,
+	"sugar":             0.85,
+	"granulated sugar":  0.85,
+	"brown sugar":       0.93,
+	"powdered sugar":    0.51,
+	"cocoa":             0.36,
+	"cocoa powder":      0.36,
+	"cornstarch":        0.54,
+	"baking powder":     0.81,
+	"baking soda":       0.93,
+	"salt":              1.22,
+	"kosher salt":       0.61,
+	"yeast":             0.64,
+	"starter":           1.0,
+	"levain":            1.0,
+	"rice":              0.85,
+	"oat":               0.36,
+	"rolled oat":        0.36,
+	"chocolate chip":    0.72,
+	"peanut butter":     1.08,
+	"parmesan":          0.42,
+	"cheese":            0.45,
+}
+
+// DensityFor returns the typical density of an ingredient in grams per millilitre.
+// A density listed for the end of the name is used when the whole name isn't listed,
+// so "unbleached all-purpose flour" uses the density of flour.
+//
+// Parameters:
+//   - canonicalName: The ingredient name as returned by CanonicalIngredientName
+//
+// Returns:
+//   - float64: The density in grams per millilitre
+//   - bool: Whether a density is known
+func DensityFor(canonicalName string) (float64, bool) {
+	words := strings.Fields(canonicalName)
+
+	for i := range words {
+		if density, ok := densities[strings.Join(words[i:], " ")]; ok {
+			return density, true
+		}
+	}
+
+	return 0, false
+}
+
+// ConvertIngredient converts an amount of an ingredient from one unit to another.
+// Volumes and masses are converted through the ingredient's density, which makes the result approximate.
+//
+// Parameters:
+//   - amount: The amount to convert
+//   - from: The unit of the amount
+//   - to: The unit to convert to
+//   - canonicalName: The ingredient name as returned by CanonicalIngredientName
+//
+// Returns:
+//   - float64: The converted amount
+//   - bool: Whether the result is approximate because a density was used
+//   - bool: Whether the units could be converted at all
+func ConvertIngredient(amount float64, from Unit, to Unit, canonicalName string) (float64, bool, bool) {
+	if from.Convertible(to) {
+		return Convert(amount, from, to), false, true
+	}
+
+	if from.Kind == UnitKindCount || to.Kind == UnitKindCount {
+		return 0, false, false
+	}
+
+	density, ok := DensityFor(canonicalName)
+	if !ok {
+		return 0, false, false
+	}
+
+	base := amount * from.Size
+	if from.Kind == UnitKindVolume {
+		// millilitres to grams
+		base *= density
+	} else {
+		// grams to millilitres
+		base /= density
+	}
+
+	return base / to.Size, true, true
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"embed"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io"
+	"log"
+	"path"
+	texttemplate "text/template"
+	"time"
+
+	"recipe-generator/internal/api/model"
+)
+
+// epubTemplates are the templates and style sheet of the EPUB e-book.
+//
+//go:embed templates/epub
+var epubTemplates embed.FS
+
+// epubItem is a recipe page or photo in the EPUB's manifest.
+type epubItem struct {
+	ID        int    // ID of the recipe
+	Name      string // Name of the recipe
+	Href      string // Path from the package document
+	MediaType string
+}
+
+// epubPackage is what the package document and navigation document are executed with.
+type epubPackage struct {
+	Identifier string
+	Title      string
+	Modified   string
+	Recipes    []epubItem
+	Images     []epubItem
+}
+
+// WriteEpub writes a cookbook as an EPUB 3 e-book: a title page, a table of contents, and a chapter for each
+// recipe with its photo. The book's identifier comes from its title and recipes, so exporting the same
+// recipes again gives an e-book readers treat as the same book.
+//
+// Parameters:
+//   - w: Where to write the EPUB file
+//   - cookbook: The book's title, and the recipes with their ingredients and procedures and photos
+//
+// Returns:
+//   - error: An error if a template fails to render or the file can't be written
+func WriteEpub(w io.Writer, cookbook model.Cookbook) error {
+	recipeTemplate, err := htmltemplate.New("recipe.xhtml").Funcs(recipeCardFuncs).ParseFS(epubTemplates, "templates/epub/recipe.xhtml")
+	if err != nil {
+		log.Printf("Error parsing EPUB template recipe.xhtml: %v", err)
+		return err
+	}
+	navTemplate, err := htmltemplate.ParseFS(epubTemplates, "templates/epub/nav.xhtml", "templates/epub/title.xhtml")
+	if err != nil {
+		log.Printf("Error parsing EPUB navigation templates: %v", err)
+		return err
+	}
+	packageTemplate, err := texttemplate.New("content.opf").Funcs(map[string]any{"xml": html.EscapeString}).ParseFS(epubTemplates, "templates/epub/content.opf")
+	if err != nil {
+		log.Printf("Error parsing EPUB template content.opf: %v", err)
+		return err
+	}
+
+	photos := exportPhotos(cookbook)
+	book := epubPackage{Title: cookbook.Title, Modified: epubModified(cookbook).Format("2006-01-02T15:04:05Z")}
+	identifier := sha1.New()
+	io.WriteString(identifier, cookbook.Title)
+
+	archive := zip.NewWriter(w)
+
+	// the mimetype file has to come first, uncompressed, so the file can be recognized as an EPUB.
+	mimetype, err := archive.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	write := func(name string, contents []byte) error {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(contents)
+		return err
+	}
+
+	for _, recipe := range cookbook.Recipes {
+		fmt.Fprintf(identifier, "\n%d", recipe.ID)
+		item := epubItem{ID: recipe.ID, Name: recipe.RecipeName, Href: fmt.Sprintf("recipe-%d.xhtml", recipe.ID)}
+
+		photoUrl := ""
+		if photo, ok := photos[recipe.ID]; ok {
+			photoUrl = "images/" + photo.name
+			book.Images = append(book.Images, epubItem{ID: recipe.ID, Href: photoUrl, MediaType: photo.mediaType})
+			if err := write("OEBPS/"+photoUrl, photo.contents); err != nil {
+				return err
+			}
+		}
+
+		var page bytes.Buffer
+		if err := recipeTemplate.Execute(&page, NewRecipeCard(recipe, nil, photoUrl)); err != nil {
+			log.Printf("Error rendering EPUB page of recipe %d: %v", recipe.ID, err)
+			return err
+		}
+		if err := write("OEBPS/"+item.Href, page.Bytes()); err != nil {
+			return err
+		}
+
+		book.Recipes = append(book.Recipes, item)
+	}
+
+	// a name-based UUID, as a version 5 UUID is.
+	sum := identifier.Sum(nil)
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	book.Identifier = fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+
+	documents := []struct {
+		name     string
+		template interface{ Execute(io.Writer, any) error }
+	}{
+		{"OEBPS/nav.xhtml", navTemplate.Lookup("nav.xhtml")},
+		{"OEBPS/title.xhtml", navTemplate.Lookup("title.xhtml")},
+		{"OEBPS/content.opf", packageTemplate},
+	}
+
+	for _, document := range documents {
+		var output bytes.Buffer
+		if err := document.template.Execute(&output, book); err != nil {
+			log.Printf("Error rendering EPUB document %s: %v", document.name, err)
+			return err
+		}
+		if err := write(document.name, output.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range []string{"META-INF/container.xml", "OEBPS/style.css"} {
+		contents, err := epubTemplates.ReadFile("templates/epub/" + path.Base(name))
+		if err != nil {
+			return err
+		}
+		if err := write(name, contents); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// private functions
+
+// epubModified is when a cookbook's recipes were last changed, or now if that isn't known.
+func epubModified(cookbook model.Cookbook) time.Time {
+	modified := time.Time{}
+	for _, recipe := range cookbook.Recipes {
+		if recipe.UpdatedDate.After(modified) {
+			modified = recipe.UpdatedDate
+		}
+	}
+
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+	return modified.UTC()
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// equipmentKeywords maps words and phrases in a procedure's text to the equipment they call for. Keywords are
+// matched longest first and each stretch of text is only matched once, so "cast iron skillet" is a cast iron
+// skillet rather than a skillet as well. Plurals are matched too.
+var equipmentKeywords = map[string]string{
+	"stand mixer": "stand mixer", "kitchenaid": "stand mixer", "dough hook": "stand mixer",
+	"paddle attachment": "stand mixer", "whisk attachment": "stand mixer",
+	"hand mixer": "hand mixer", "electric mixer": "hand mixer", "electric beater": "hand mixer",
+	"food processor": "food processor", "blender": "blender", "immersion blender": "immersion blender",
+	"stick blender": "immersion blender", "spice grinder": "spice grinder", "coffee grinder": "spice grinder",
+	"mortar and pestle": "mortar and pestle", "food mill": "food mill", "spiralizer": "spiralizer",
+	"mandoline": "mandoline", "microplane": "microplane", "zester": "microplane", "box grater": "box grater",
+	"rolling pin": "rolling pin", "pastry cutter": "pastry cutter", "pastry blender": "pastry cutter",
+	"piping bag": "piping bag", "pastry bag": "piping bag", "kitchen scale": "kitchen scale",
+	"thermometer": "thermometer", "candy thermometer": "thermometer", "meat thermometer": "thermometer",
+	"kitchen torch": "kitchen torch", "blowtorch": "kitchen torch", "pasta machine": "pasta machine",
+	"pasta roller": "pasta machine", "ice cream maker": "ice cream maker", "waffle iron": "waffle iron",
+	"waffle maker": "waffle iron", "cheesecloth": "cheesecloth", "fine mesh sieve": "sieve", "sieve": "sieve",
+	"strainer": "sieve", "colander": "colander", "salad spinner": "salad spinner", "steamer basket": "steamer basket",
+	"bamboo steamer": "steamer basket", "baking stone": "pizza stone", "pizza stone": "pizza stone",
+	"pizza steel": "pizza stone", "banneton": "proofing basket", "proofing basket": "proofing basket",
+
+	"dutch oven": "dutch oven", "stockpot": "stockpot", "stock pot": "stockpot", "saucepan": "saucepan",
+	"sauce pan": "saucepan", "skillet": "skillet", "frying pan": "skillet", "fry pan": "skillet",
+	"cast iron skillet": "cast iron skillet", "cast iron pan": "cast iron skillet", "wok": "wok",
+	"griddle": "griddle", "grill pan": "grill pan", "double boiler": "double boiler",
+	"deep fryer": "deep fryer", "pressure cooker": "pressure cooker", "instant pot": "pressure cooker",
+	"slow cooker": "slow cooker", "crock pot": "slow cooker", "crockpot": "slow cooker", "air fryer": "air fryer",
+	"rice cooker": "rice cooker", "sous vide": "sous vide", "grill": "grill", "smoker": "smoker",
+	"microwave": "microwave", "broiler": "broiler", "broil": "broiler",
+
+	"sheet pan": "sheet pan", "baking sheet": "sheet pan", "cookie sheet": "sheet pan",
+	"rimmed baking sheet": "sheet pan", "9x13 pan": "9x13 pan", "9x13 baking dish": "9x13 pan",
+	"9x13": "9x13 pan", "8x8 pan": "8x8 pan", "8x8 baking dish": "8x8 pan", "8x8": "8x8 pan", "9x9": "9x9 pan",
+	"9x9 pan": "9x9 pan", "loaf pan": "loaf pan", "9x5": "loaf pan", "muffin tin": "muffin tin",
+	"muffin pan": "muffin tin", "cupcake pan": "muffin tin", "bundt pan": "bundt pan",
+	"springform pan": "springform pan", "springform": "springform pan", "tart pan": "tart pan",
+	"pie dish": "pie dish", "pie plate": "pie dish", "pie pan": "pie dish", "cake pan": "cake pan",
+	"round cake pan": "cake pan", "ramekin": "ramekins", "baking dish": "baking dish", "casserole dish": "baking dish",
+	"roasting pan": "roasting pan", "wire rack": "wire rack", "cooling rack": "wire rack",
+}
+
+// panSizePattern matches pan sizes written in different ways, such as "9 x 13", "9×13" or "9-by-13-inch",
+// so they can be matched as "9x13".
+var panSizePattern = regexp.MustCompile(`(\d+)\s*(?:-|\s)?(?:x|×|by)(?:-|\s)?\s*(\d+)(?:\s*-?\s*(?:inch|in\b|"))?`)
+
+// nonWordPattern matches the punctuation between words.
+var nonWordPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// equipmentByLength is the keywords of equipmentKeywords, longest first.
+var equipmentByLength = sortedEquipmentKeywords()
+
+// EquipmentCatalog returns every piece of equipment that can be detected, in alphabetical order.
+func EquipmentCatalog() []string {
+	seen := make(map[string]bool)
+	catalog := []string{}
+
+	for _, equipment := range equipmentKeywords {
+		if !seen[equipment] {
+			seen[equipment] = true
+			catalog = append(catalog, equipment)
+		}
+	}
+
+	sort.Strings(catalog)
+	return catalog
+}
+
+// DetectEquipment reads the equipment a recipe needs from its procedure's text, using the keyword catalog.
+//
+// Parameters:
+//   - procedure: The recipe's procedure steps
+//
+// Returns:
+//   - []string: The equipment mentioned, in alphabetical order
+func DetectEquipment(procedure []string) []string {
+	found := []string{}
+
+	for _, step := range procedure {
+		text := " " + equipmentText(step) + " "
+
+		for _, keyword := range equipmentByLength {
+			for _, form := range []string{keyword, keyword + "s", keyword + "es"} {
+				if strings.Contains(text, " "+form+" ") {
+					found = append(found, equipmentKeywords[keyword])
+					// blank the match out so shorter keywords inside it aren't matched again.
+					text = strings.ReplaceAll(text, " "+form+" ", " | ")
+				}
+			}
+		}
+	}
+
+	return NormalizeEquipment(found)
+}
+
+// NormalizeEquipment lowercases and trims equipment names, names what the catalog knows by its catalog name,
+// and drops blanks and duplicates, so "Dutch Ovens" and "dutch oven" are the same equipment.
+//
+// Parameters:
+//   - equipment: Equipment names as given
+//
+// Returns:
+//   - []string: The normalized names, in alphabetical order
+func NormalizeEquipment(equipment []string) []string {
+	seen := make(map[string]bool)
+	normalized := []string{}
+
+	for _, name := range equipment {
+		name = canonicalEquipment(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, name)
+	}
+
+	sort.Strings(normalized)
+	return normalized
+}
+
+// private functions
+
+// equipmentText lowercases text, writes pan sizes as "9x13" and reduces punctuation to single spaces.
+func equipmentText(text string) string {
+	text = strings.ToLower(text)
+	text = panSizePattern.ReplaceAllString(text, "${1}x${2}")
+	return strings.TrimSpace(nonWordPattern.ReplaceAllString(text, " "))
+}
+
+// canonicalEquipment returns the catalog name of a piece of equipment if the catalog knows it, singular or
+// plural, or else the name lowercased and trimmed.
+func canonicalEquipment(name string) string {
+	text := equipmentText(name)
+
+	for _, form := range []string{text, strings.TrimSuffix(text, "s"), strings.TrimSuffix(text, "es")} {
+		if equipment, ok := equipmentKeywords[form]; ok {
+			return equipment
+		}
+	}
+
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// sortedEquipmentKeywords returns the catalog's keywords longest first, alphabetically among equals, so the
+// matches don't depend on map order.
+func sortedEquipmentKeywords() []string {
+	keywords := make([]string, 0, len(equipmentKeywords))
+	for keyword := range equipmentKeywords {
+		keywords = append(keywords, keyword)
+	}
+
+	sort.Slice(keywords, func(i, j int) bool {
+		if len(keywords[i]) != len(keywords[j]) {
+			return len(keywords[i]) > len(keywords[j])
+		}
+		return keywords[i] < keywords[j]
+	})
+
+	return keywords
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"sync"
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind by before it is dropped.
+const subscriberBuffer = 64
+
+// Event is a message published to the subscribers of a topic.
+type Event struct {
+	ID   int64       // Increasing identifier clients resume from, 0 if the event can't be replayed
+	Type string      // Name of the event
+	Data interface{} // Value sent to clients as JSON
+}
+
+// EventBroker fans events out to every subscriber of a topic, such as the clients watching one shopping list.
+// It only reaches subscribers in this process; events that need to survive a reconnect are stored by the
+// caller and replayed from the last event ID the client saw.
+type EventBroker struct {
+	mutex       sync.Mutex
+	subscribers map[string]map[chan Event]bool
+}
+
+// NewEventBroker creates a new EventBroker with no subscribers.
+func NewEventBroker() *EventBroker {
+	return &EventBroker{subscribers: make(map[string]map[chan Event]bool)}
+}
+
+// Subscribe starts receiving the events published to a topic.
+// The channel is closed when unsubscribe is called, or if the subscriber falls too far behind,
+// in which case the client should reconnect and replay what it missed.
+//
+// Parameters:
+//   - topic: The topic to receive events for
+//
+// Returns:
+//   - <-chan Event: The channel events are delivered on
+//   - func(): Stops the subscription; safe to call more than once
+func (eb *EventBroker) Subscribe(topic string) (<-chan Event, func()) {
+	channel := make(chan Event, subscriberBuffer)
+
+	eb.mutex.Lock()
+	if eb.subscribers[topic] == nil {
+		eb.subscribers[topic] = make(map[chan Event]bool)
+	}
+	eb.subscribers[topic][channel] = true
+	eb.mutex.Unlock()
+
+	unsubscribe := func() {
+		eb.mutex.Lock()
+		defer eb.mutex.Unlock()
+		eb.remove(topic, channel)
+	}
+
+	return channel, unsubscribe
+}
+
+// Publish sends an event to every subscriber of a topic without waiting for them.
+// Subscribers whose buffers are full are dropped rather than allowed to slow down the publisher.
+func (eb *EventBroker) Publish(topic string, event Event) {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+
+	for channel := range eb.subscribers[topic] {
+		select {
+		case channel <- event:
+		default:
+			eb.remove(topic, channel)
+		}
+	}
+}
+
+// remove closes a subscriber's channel and forgets it. The caller must hold the mutex.
+func (eb *EventBroker) remove(topic string, channel chan Event) {
+	if !eb.subscribers[topic][channel] {
+		return
+	}
+
+	delete(eb.subscribers[topic], channel)
+	close(channel)
+
+	if len(eb.subscribers[topic]) == 0 {
+		delete(eb.subscribers, topic)
+	}
+}
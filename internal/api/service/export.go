@@ -0,0 +1,127 @@
+package service
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"recipe-generator/internal/api/model"
+)
+
+// exportPhotoExtensions are the extensions photos are exported with, by media type. Photos of other types are
+// left out.
+var exportPhotoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ExportWriter receives the files of an export, such as a static site, by their slash-separated path within it.
+type ExportWriter interface {
+	WriteFile(name string, contents []byte) error
+}
+
+// DirectoryExportWriter writes the files of an export under a directory, creating the directories they are in.
+type DirectoryExportWriter struct {
+	directory string
+}
+
+// NewDirectoryExportWriter creates a new DirectoryExportWriter that writes under directory.
+func NewDirectoryExportWriter(directory string) *DirectoryExportWriter {
+	return &DirectoryExportWriter{directory: directory}
+}
+
+// WriteFile writes a file of the export, replacing it if it exists.
+func (dw *DirectoryExportWriter) WriteFile(name string, contents []byte) error {
+	path := filepath.Join(dw.directory, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, contents, 0o644)
+}
+
+// ZipExportWriter writes the files of an export into a zip archive. Close must be called to finish the archive.
+type ZipExportWriter struct {
+	writer *zip.Writer
+}
+
+// NewZipExportWriter creates a new ZipExportWriter that writes a zip archive to w.
+func NewZipExportWriter(w io.Writer) *ZipExportWriter {
+	return &ZipExportWriter{writer: zip.NewWriter(w)}
+}
+
+// WriteFile adds a file to the archive.
+func (zw *ZipExportWriter) WriteFile(name string, contents []byte) error {
+	file, err := zw.writer.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(contents)
+	return err
+}
+
+// Close finishes the archive. It does not close the underlying writer.
+func (zw *ZipExportWriter) Close() error {
+	return zw.writer.Close()
+}
+
+// private functions
+
+// exportPhoto is a recipe's photo as it is exported.
+type exportPhoto struct {
+	name      string // File name, such as "12.jpg"
+	mediaType string
+	contents  []byte
+}
+
+// exportPhotos works out the file names and media types of a cookbook's photos, by recipe ID, leaving out
+// those that aren't JPEG, PNG, GIF or WebP images.
+func exportPhotos(cookbook model.Cookbook) map[int]exportPhoto {
+	photos := map[int]exportPhoto{}
+
+	for recipeID, contents := range cookbook.Photos {
+		mediaType := http.DetectContentType(contents)
+		extension, ok := exportPhotoExtensions[mediaType]
+		if !ok {
+			continue
+		}
+		photos[recipeID] = exportPhoto{
+			name:      fmt.Sprintf("%d%s", recipeID, extension),
+			mediaType: mediaType,
+			contents:  contents,
+		}
+	}
+
+	return photos
+}
+
+// slugify turns a name into lower-case letters, digits and hyphens for a file name, such as "Pad Thai (easy)"
+// into "pad-thai-easy". Returns fallback if the name has no letters or digits.
+func slugify(name string, fallback string) string {
+	var builder strings.Builder
+	hyphen := false
+
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && builder.Len() > 0 {
+				builder.WriteByte('-')
+			}
+			builder.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+
+	if builder.Len() == 0 {
+		return fallback
+	}
+	return builder.String()
+}
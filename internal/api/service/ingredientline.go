@@ -0,0 +1,245 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// Units given to ingredients written without an amount, such as "salt, to taste" or "parsley, for garnish",
+// which are stored as an amount of 1.
+const (
+	UnitToTaste  = "to taste"
+	UnitAsNeeded = "as needed"
+	UnitWhole    = "whole"
+)
+
+// countUnits are the units besides volumes and masses that ingredient lines are written in.
+var countUnits = map[string]bool{
+	"clove": true, "can": true, "jar": true, "bottle": true, "package": true, "packet": true, "pkg": true,
+	"box": true, "bag": true, "stick": true, "slice": true, "sprig": true, "bunch": true, "head": true,
+	"stalk": true, "rib": true, "pinch": true, "dash": true, "handful": true, "sheet": true, "fillet": true,
+	"envelope": true, "container": true, "carton": true, "piece": true, "drop": true, "knob": true,
+}
+
+// unicodeFractions maps the vulgar fraction characters recipes use to their values.
+var unicodeFractions = map[rune]float64{
+	'½': 0.5, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '¼': 0.25, '¾': 0.75, '⅕': 0.2, '⅖': 0.4, '⅗': 0.6, '⅘': 0.8,
+	'⅙': 1.0 / 6, '⅚': 5.0 / 6, '⅛': 0.125, '⅜': 0.375, '⅝': 0.625, '⅞': 0.875,
+}
+
+// amountPattern matches the amount at the start of an ingredient line: a whole number, decimal, fraction or
+// mixed number, optionally followed by a range such as "2-3" or "2 to 3", whose lower end is used.
+var amountPattern = regexp.MustCompile(`^(\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+)?|\.\d+)(?:\s*(?:-|–|to)\s*(?:\d+\s+\d+/\d+|\d+/\d+|\d+(?:\.\d+)?))?\s*`)
+
+// parentheticalPattern matches a parenthetical after the amount, such as the "(15 ounce)" of "1 (15 ounce) can".
+var parentheticalPattern = regexp.MustCompile(`^\([^)]*\)\s*`)
+
+// ParseIngredientLine reads an ingredient written as a line of text, such as "2 1/2 cups flour, sifted" or
+// "1 (15 ounce) can black beans", into an amount, unit and name. Lines without an amount are stored as 1 of
+// UnitToTaste or UnitAsNeeded, and counted ingredients without a unit, such as "2 eggs", as UnitWhole.
+//
+// Parameters:
+//   - line: The ingredient line
+//
+// Returns:
+//   - model.Ingredient: The ingredient with its amount, unit and name set
+func ParseIngredientLine(line string) model.Ingredient {
+	text := strings.Join(strings.Fields(expandUnicodeFractions(line)), " ")
+	text = strings.TrimLeft(text, "-*•· ")
+
+	match := amountPattern.FindStringSubmatch(text)
+	if match == nil {
+		unit := UnitAsNeeded
+		if strings.Contains(strings.ToLower(text), UnitToTaste) {
+			unit = UnitToTaste
+		}
+		return model.Ingredient{Amount: 1, UnitOfMeasurement: unit, IngredientName: trimIngredientName(text)}
+	}
+
+	amount := parseAmount(match[1])
+	rest := strings.TrimPrefix(text, match[0])
+	// a package size such as the "(15 ounce)" of "1 (15 ounce) can" is kept after the name.
+	size := strings.TrimSpace(parentheticalPattern.FindString(rest))
+	rest = strings.TrimPrefix(rest, parentheticalPattern.FindString(rest))
+
+	unit, name := splitUnit(rest)
+	if size != "" {
+		name = trimIngredientName(name) + " " + size
+	}
+	if unit == "" {
+		unit = UnitWhole
+	}
+	if amount <= 0 {
+		amount = 1
+	}
+
+	return model.Ingredient{Amount: amount, UnitOfMeasurement: unit, IngredientName: trimIngredientName(name)}
+}
+
+// FormatIngredient writes an ingredient as a line of text, the reverse of ParseIngredientLine, with amounts
+// written as fractions where they are close to one, such as "1 1/2 cups flour" or "salt, to taste".
+// Ingredients used as needed are written by name alone.
+//
+// Parameters:
+//   - ingredient: The ingredient
+//
+// Returns:
+//   - string: The ingredient line
+func FormatIngredient(ingredient model.Ingredient) string {
+	unit := strings.TrimSpace(ingredient.UnitOfMeasurement)
+
+	switch strings.ToLower(unit) {
+	case UnitToTaste:
+		return fmt.Sprintf("%s, %s", ingredient.IngredientName, UnitToTaste)
+	case UnitAsNeeded:
+		return ingredient.IngredientName
+	case UnitWhole, "":
+		return fmt.Sprintf("%s %s", FormatAmount(ingredient.Amount), ingredient.IngredientName)
+	}
+
+	if ingredient.Amount > 1 && LookupUnit(unit).Kind == UnitKindCount && !strings.HasSuffix(unit, "s") {
+		unit = pluralUnit(unit)
+	} else if ingredient.Amount > 1 && (unit == "cup" || unit == "pint" || unit == "quart" || unit == "gallon") {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("%s %s %s", FormatAmount(ingredient.Amount), unit, ingredient.IngredientName)
+}
+
+// FormatAmount writes an amount as a whole or mixed number, such as "1 1/2", when it is within a hundredth of
+// a half, third, quarter or eighth, and as a decimal otherwise.
+func FormatAmount(amount float64) string {
+	whole := math.Floor(amount)
+	fraction := amount - whole
+
+	for _, denominator := range []int{2, 3, 4, 8} {
+		numerator := math.Round(fraction * float64(denominator))
+		if math.Abs(fraction-numerator/float64(denominator)) > 0.01 {
+			continue
+		}
+
+		switch {
+		case numerator == 0:
+			return strconv.Itoa(int(whole))
+		case int(numerator) == denominator:
+			return strconv.Itoa(int(whole) + 1)
+		case whole == 0:
+			return fmt.Sprintf("%d/%d", int(numerator), denominator)
+		}
+		return fmt.Sprintf("%d %d/%d", int(whole), int(numerator), denominator)
+	}
+
+	return strconv.FormatFloat(RoundAmount(amount), 'f', -1, 64)
+}
+
+// private functions
+
+// expandUnicodeFractions writes vulgar fraction characters as decimals, so "1½" reads as "1.5" and "½" as "0.5".
+func expandUnicodeFractions(text string) string {
+	var builder strings.Builder
+	runes := []rune(text)
+
+	for i, r := range runes {
+		value, ok := unicodeFractions[r]
+		if !ok {
+			builder.WriteRune(r)
+			continue
+		}
+
+		// a fraction after a whole number adds to it, such as the ½ of "1½" or "1 ½".
+		written := builder.String()
+		trimmed := strings.TrimRight(written, " ")
+		digits := len(trimmed) - len(strings.TrimRight(trimmed, "0123456789"))
+		if digits > 0 && !strings.Contains(trimmed[len(trimmed)-digits:], ".") {
+			whole, _ := strconv.Atoi(trimmed[len(trimmed)-digits:])
+			builder.Reset()
+			builder.WriteString(trimmed[:len(trimmed)-digits])
+			value += float64(whole)
+		}
+
+		builder.WriteString(strconv.FormatFloat(RoundAmount(value), 'f', -1, 64))
+		if i+1 < len(runes) && runes[i+1] != ' ' {
+			builder.WriteRune(' ')
+		}
+	}
+
+	return builder.String()
+}
+
+// parseAmount parses a whole number, decimal, fraction or mixed number such as "1 1/2".
+func parseAmount(text string) float64 {
+	total := 0.0
+
+	for _, part := range strings.Fields(text) {
+		if numerator, denominator, ok := strings.Cut(part, "/"); ok {
+			n, _ := strconv.ParseFloat(numerator, 64)
+			d, _ := strconv.ParseFloat(denominator, 64)
+			if d != 0 {
+				total += n / d
+			}
+			continue
+		}
+
+		value, _ := strconv.ParseFloat(part, 64)
+		total += value
+	}
+
+	return total
+}
+
+// splitUnit splits the unit of measurement off the front of the rest of an ingredient line, trying two-word
+// units such as "fl oz" first. It returns a blank unit if the line doesn't start with one.
+func splitUnit(rest string) (string, string) {
+	words := strings.Fields(rest)
+
+	for length := 2; length >= 1; length-- {
+		if len(words) <= length {
+			continue
+		}
+
+		// a capital T is a tablespoon, as in LookupUnit.
+		if length == 1 && words[0] == "T" {
+			return "tbsp", strings.Join(words[1:], " ")
+		}
+
+		candidate := strings.ToLower(strings.TrimSuffix(strings.Join(words[:length], " "), "."))
+		if canonical, ok := unitAliases[candidate]; ok && canonical != "" {
+			return canonical, strings.Join(words[length:], " ")
+		}
+		if length == 1 && countUnits[Singular(candidate)] {
+			return Singular(candidate), strings.Join(words[1:], " ")
+		}
+	}
+
+	return "", rest
+}
+
+// trimIngredientName drops a leading "of", as in "2 cups of flour", and a trailing "to taste" or "as needed".
+func trimIngredientName(name string) string {
+	name = strings.TrimSpace(name)
+	name = strings.TrimPrefix(name, "of ")
+
+	lower := strings.ToLower(name)
+	for _, suffix := range []string{UnitToTaste, UnitAsNeeded} {
+		if strings.HasSuffix(lower, suffix) {
+			name = name[:len(name)-len(suffix)]
+			lower = lower[:len(lower)-len(suffix)]
+		}
+	}
+
+	return strings.TrimRight(strings.TrimSpace(name), ",;")
+}
+
+// pluralUnit returns the plural of a count unit such as "clove" or "pinch".
+func pluralUnit(unit string) string {
+	switch {
+	case strings.HasSuffix(unit, "ch"), strings.HasSuffix(unit, "sh"), strings.HasSuffix(unit, "x"):
+		return unit + "es"
+	}
+	return unit + "s"
+}
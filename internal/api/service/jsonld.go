@@ -0,0 +1,431 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"recipe-generator/internal/api/model"
+)
+
+// jsonLdScriptPattern matches the JSON-LD script elements of an HTML page.
+var jsonLdScriptPattern = regexp.MustCompile(`(?is)<script[^>]*type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+
+// htmlTagPattern matches the HTML tags some sites leave in the text of their JSON-LD.
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// durationPattern matches an ISO-8601 duration such as "PT1H30M" or "P0DT0H45M".
+var durationPattern = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// yieldPattern matches a yield such as "4", "4 servings", "Makes 24 cookies" or "6-8 people".
+var yieldPattern = regexp.MustCompile(`(?i)^(?:makes|serves|yield:?|yields)?\s*(\d+(?:\.\d+)?)(?:\s*(?:-|–|to)\s*\d+(?:\.\d+)?)?\s*(.*)$`)
+
+// courseAliases maps the recipeCategory values sites use to Course constants.
+var courseAliases = map[string]string{
+	"main course": model.CourseMain, "main dish": model.CourseMain, "entree": model.CourseMain,
+	"dinner": model.CourseMain, "lunch": model.CourseMain, "starter": model.CourseAppetizer,
+	"appetizers": model.CourseAppetizer, "side dish": model.CourseSide, "desserts": model.CourseDessert,
+	"beverage": model.CourseDrink, "drinks": model.CourseDrink, "cocktail": model.CourseDrink,
+	"breads": model.CourseBread, "soups": model.CourseSoup, "salads": model.CourseSalad,
+	"snacks": model.CourseSnack, "sauces": model.CourseSauce, "brunch": model.CourseBreakfast,
+}
+
+// RecipeToJsonLd converts a recipe to the schema.org Recipe format. The procedure becomes HowToStep entries,
+// the times ISO-8601 durations, and the servings and yield the recipeYield.
+//
+// Parameters:
+//   - recipe: The recipe with its ingredients, procedure, tags and equipment
+//
+// Returns:
+//   - *model.JsonLdRecipe: The recipe as schema.org JSON-LD
+func RecipeToJsonLd(recipe *model.Recipe) *model.JsonLdRecipe {
+	document := &model.JsonLdRecipe{
+		Context:            model.JsonLdContext,
+		Type:               "Recipe",
+		Name:               recipe.RecipeName,
+		Description:        recipe.Description,
+		PrepTime:           FormatDuration(recipe.PrepTimeMinutes),
+		CookTime:           FormatDuration(recipe.CookTimeMinutes),
+		TotalTime:          FormatDuration(recipe.PrepTimeMinutes + recipe.CookTimeMinutes),
+		RecipeCategory:     recipe.Course,
+		RecipeCuisine:      recipe.Cuisine,
+		Keywords:           strings.Join(recipe.Tags, ", "),
+		RecipeIngredient:   []string{},
+		RecipeInstructions: []model.JsonLdHowToStep{},
+	}
+
+	if !recipe.CreatedDate.IsZero() {
+		document.DateCreated = recipe.CreatedDate.Format(time.RFC3339)
+	}
+	if !recipe.UpdatedDate.IsZero() {
+		document.DateModified = recipe.UpdatedDate.Format(time.RFC3339)
+	}
+
+	if source := recipe.Source; source != nil {
+		if author := firstNonBlank(source.Author, source.FamilyMember); author != "" {
+			document.Author = &model.JsonLdPerson{Type: "Person", Name: author}
+		}
+		document.IsBasedOn = source.Url
+	}
+
+	if recipe.Servings > 0 {
+		document.RecipeYield = append(document.RecipeYield, fmt.Sprintf("%d servings", recipe.Servings))
+	}
+	if recipe.Yield != nil {
+		document.RecipeYield = append(document.RecipeYield, fmt.Sprintf("%s %s", FormatAmount(recipe.Yield.Quantity), recipe.Yield.Unit))
+	}
+
+	for _, equipment := range recipe.Equipment {
+		document.Tool = append(document.Tool, model.JsonLdThing{Type: "HowToTool", Name: equipment})
+	}
+
+	for _, ingredient := range recipe.Ingredients {
+		document.RecipeIngredient = append(document.RecipeIngredient, FormatIngredient(ingredient))
+	}
+
+	for i, step := range recipe.Procedure {
+		document.RecipeInstructions = append(document.RecipeInstructions, model.JsonLdHowToStep{
+			Type:     "HowToStep",
+			Position: i + 1,
+			Text:     step,
+		})
+	}
+
+	if recipe.RatingCount > 0 {
+		document.AggregateRating = &model.JsonLdRating{
+			Type:        "AggregateRating",
+			RatingValue: RoundAmount(recipe.AverageRating),
+			RatingCount: recipe.RatingCount,
+			BestRating:  model.MaxRating,
+			WorstRating: model.MinRating,
+		}
+	}
+
+	return document
+}
+
+// ParseJsonLd reads a recipe from a schema.org JSON-LD document, or from an HTML page with one in a
+// <script type="application/ld+json"> element. The Recipe can be the document itself, one of an array of
+// items, or one of the nodes of an @graph; instructions can be text, HowToStep entries, or HowToSection
+// entries grouping steps. The recipe isn't validated.
+//
+// Parameters:
+//   - document: The JSON-LD document or HTML page
+//
+// Returns:
+//   - *model.Recipe: The recipe, with its ingredients, procedure, times, yield and metadata set
+//   - error: ErrInvalidField if the document has no Recipe or isn't valid JSON
+func ParseJsonLd(document []byte) (*model.Recipe, error) {
+	blocks := [][]byte{document}
+
+	if trimmed := bytes.TrimSpace(document); len(trimmed) > 0 && trimmed[0] == '<' {
+		blocks = nil
+		for _, match := range jsonLdScriptPattern.FindAllSubmatch(document, -1) {
+			blocks = append(blocks, match[1])
+		}
+	}
+
+	for _, block := range blocks {
+		var node any
+		if err := json.Unmarshal(bytes.TrimSpace(block), &node); err != nil {
+			continue
+		}
+
+		if found := findJsonLdRecipe(node); found != nil {
+			return jsonLdToRecipe(found), nil
+		}
+	}
+
+	return nil, model.ErrInvalidField("document: no schema.org Recipe found")
+}
+
+// FormatDuration writes a number of minutes as an ISO-8601 duration such as "PT1H30M", or "" for none.
+func FormatDuration(minutes int) string {
+	if minutes <= 0 {
+		return ""
+	}
+
+	duration := "PT"
+	if minutes >= 60 {
+		duration += fmt.Sprintf("%dH", minutes/60)
+	}
+	if minutes%60 > 0 {
+		duration += fmt.Sprintf("%dM", minutes%60)
+	}
+
+	return duration
+}
+
+// ParseDuration reads an ISO-8601 duration such as "PT1H30M" as a number of minutes, rounded to the nearest
+// minute. It returns 0 for anything else.
+func ParseDuration(duration string) int {
+	match := durationPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(duration)))
+	if match == nil {
+		return 0
+	}
+
+	minutes := 0.0
+	for i, perUnit := range []float64{24 * 60, 60, 1, 1.0 / 60} {
+		if match[i+1] != "" {
+			value, _ := strconv.ParseFloat(match[i+1], 64)
+			minutes += value * perUnit
+		}
+	}
+
+	return int(math.Round(minutes))
+}
+
+// private functions
+
+// findJsonLdRecipe returns the first node with a @type of Recipe in a JSON-LD document, looking through arrays,
+// @graph and mainEntity.
+func findJsonLdRecipe(node any) map[string]any {
+	switch value := node.(type) {
+	case []any:
+		for _, item := range value {
+			if found := findJsonLdRecipe(item); found != nil {
+				return found
+			}
+		}
+	case map[string]any:
+		if hasJsonLdType(value, "Recipe") {
+			return value
+		}
+		for _, key := range []string{"@graph", "mainEntity", "mainEntityOfPage"} {
+			if found := findJsonLdRecipe(value[key]); found != nil {
+				return found
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasJsonLdType reports whether a node's @type is, or includes, the given type.
+func hasJsonLdType(node map[string]any, name string) bool {
+	for _, value := range jsonLdStrings(node["@type"]) {
+		if value == name || strings.HasSuffix(value, "/"+name) || strings.HasSuffix(value, ":"+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonLdToRecipe converts a schema.org Recipe node to a recipe.
+func jsonLdToRecipe(node map[string]any) *model.Recipe {
+	recipe := &model.Recipe{
+		RecipeName:      cleanJsonLdText(jsonLdText(node["name"])),
+		Description:     cleanJsonLdText(jsonLdText(node["description"])),
+		PrepTimeMinutes: ParseDuration(jsonLdText(node["prepTime"])),
+		CookTimeMinutes: ParseDuration(jsonLdText(node["cookTime"])),
+		Ingredients:     []model.Ingredient{},
+		Procedure:       []string{},
+	}
+
+	// some sites only give the total time.
+	if recipe.PrepTimeMinutes == 0 && recipe.CookTimeMinutes == 0 {
+		recipe.CookTimeMinutes = ParseDuration(jsonLdText(node["totalTime"]))
+	}
+
+	ingredients := node["recipeIngredient"]
+	if ingredients == nil {
+		ingredients = node["ingredients"]
+	}
+	for _, line := range jsonLdStrings(ingredients) {
+		if line = cleanJsonLdText(line); line != "" {
+			recipe.Ingredients = append(recipe.Ingredients, ParseIngredientLine(line))
+		}
+	}
+
+	recipe.Procedure = jsonLdInstructions(node["recipeInstructions"])
+
+	for _, yield := range jsonLdStrings(node["recipeYield"]) {
+		applyJsonLdYield(recipe, yield)
+	}
+
+	source := &model.Attribution{Author: jsonLdName(node["author"])}
+	source.Url = firstNonBlank(jsonLdText(node["url"]), jsonLdText(node["isBasedOn"]), jsonLdText(node["@id"]))
+	if !strings.HasPrefix(source.Url, "http://") && !strings.HasPrefix(source.Url, "https://") {
+		source.Url = ""
+	}
+	if !source.IsZero() {
+		recipe.Source = source
+	}
+
+	for _, category := range jsonLdStrings(node["recipeCategory"]) {
+		if course := jsonLdCourse(category); course != "" {
+			recipe.Course = course
+			break
+		}
+	}
+	if cuisines := jsonLdStrings(node["recipeCuisine"]); len(cuisines) > 0 {
+		recipe.Cuisine = strings.ToLower(cleanJsonLdText(cuisines[0]))
+	}
+
+	for _, keywords := range jsonLdStrings(node["keywords"]) {
+		for _, keyword := range strings.Split(keywords, ",") {
+			if keyword = cleanJsonLdText(keyword); keyword != "" {
+				recipe.Tags = append(recipe.Tags, keyword)
+			}
+		}
+	}
+
+	if tools := node["tool"]; tools != nil {
+		recipe.Equipment = []string{}
+		for _, tool := range jsonLdList(tools) {
+			if name := jsonLdName(tool); name != "" {
+				recipe.Equipment = append(recipe.Equipment, name)
+			}
+		}
+		recipe.Equipment = NormalizeEquipment(recipe.Equipment)
+	}
+
+	return recipe
+}
+
+// jsonLdInstructions flattens recipeInstructions into procedure steps. They can be a block of text with a
+// step on each line, a list of strings, HowToStep entries, or HowToSection entries whose itemListElement
+// holds the steps.
+func jsonLdInstructions(instructions any) []string {
+	steps := []string{}
+
+	if text, ok := instructions.(string); ok {
+		for _, line := range strings.Split(html.UnescapeString(htmlTagPattern.ReplaceAllString(text, "\n")), "\n") {
+			if line = cleanJsonLdText(line); line != "" {
+				steps = append(steps, line)
+			}
+		}
+		return steps
+	}
+
+	for _, item := range jsonLdList(instructions) {
+		switch value := item.(type) {
+		case string:
+			if step := cleanJsonLdText(value); step != "" {
+				steps = append(steps, step)
+			}
+		case map[string]any:
+			if elements, ok := value["itemListElement"]; ok {
+				steps = append(steps, jsonLdInstructions(elements)...)
+				continue
+			}
+			if step := cleanJsonLdText(firstNonBlank(jsonLdText(value["text"]), jsonLdText(value["name"]))); step != "" {
+				steps = append(steps, step)
+			}
+		}
+	}
+
+	return steps
+}
+
+// applyJsonLdYield sets a recipe's servings or yield from a recipeYield value: a bare number or servings,
+// people or portions are the servings, and anything else, such as "24 cookies", the yield.
+func applyJsonLdYield(recipe *model.Recipe, yield string) {
+	match := yieldPattern.FindStringSubmatch(cleanJsonLdText(yield))
+	if match == nil {
+		return
+	}
+
+	quantity, _ := strconv.ParseFloat(match[1], 64)
+	unit := strings.ToLower(strings.TrimSpace(match[2]))
+	if quantity <= 0 {
+		return
+	}
+
+	switch {
+	case unit == "" || strings.HasPrefix(unit, "serving") || strings.HasPrefix(unit, "people") ||
+		strings.HasPrefix(unit, "person") || strings.HasPrefix(unit, "portion"):
+		if recipe.Servings == 0 {
+			recipe.Servings = int(math.Round(quantity))
+		}
+	case recipe.Yield == nil:
+		recipe.Yield = &model.RecipeYield{Quantity: quantity, Unit: unit}
+	}
+}
+
+// jsonLdCourse returns the Course constant a recipeCategory stands for, or "" if it isn't a course.
+func jsonLdCourse(category string) string {
+	category = strings.ToLower(cleanJsonLdText(category))
+	if model.IsCourse(category) {
+		return category
+	}
+	if course, ok := courseAliases[category]; ok {
+		return course
+	}
+	if singular := Singular(category); model.IsCourse(singular) {
+		return singular
+	}
+	return ""
+}
+
+// jsonLdList returns a JSON-LD value as a list: an array as it is, or anything else as a list of one.
+func jsonLdList(value any) []any {
+	switch list := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		return list
+	}
+	return []any{value}
+}
+
+// jsonLdStrings returns the strings and numbers of a JSON-LD value that can be one or a list of them.
+func jsonLdStrings(value any) []string {
+	values := []string{}
+	for _, item := range jsonLdList(value) {
+		if text := jsonLdText(item); text != "" {
+			values = append(values, text)
+		}
+	}
+	return values
+}
+
+// jsonLdText returns a JSON-LD string or number as text, or the first of a list of them.
+func jsonLdText(value any) string {
+	switch text := value.(type) {
+	case string:
+		return text
+	case float64:
+		return strconv.FormatFloat(text, 'f', -1, 64)
+	case []any:
+		if len(text) > 0 {
+			return jsonLdText(text[0])
+		}
+	}
+	return ""
+}
+
+// jsonLdName returns the name of a JSON-LD value that can be a name, a node with a name, or a list of either.
+func jsonLdName(value any) string {
+	switch node := value.(type) {
+	case map[string]any:
+		return cleanJsonLdText(jsonLdText(node["name"]))
+	case []any:
+		if len(node) > 0 {
+			return jsonLdName(node[0])
+		}
+	}
+	return cleanJsonLdText(jsonLdText(value))
+}
+
+// cleanJsonLdText strips HTML tags and entities from text and collapses its whitespace.
+func cleanJsonLdText(text string) string {
+	text = html.UnescapeString(htmlTagPattern.ReplaceAllString(text, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// firstNonBlank returns the first of its arguments that isn't blank.
+func firstNonBlank(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
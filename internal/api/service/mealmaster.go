@@ -0,0 +1,208 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// mealMasterStartPattern matches the line a MealMaster recipe starts with, such as
+// "MMMMM----- Recipe via Meal-Master (tm) v8.05" or "---------- Recipe via Meal-Master (tm) v8.02".
+var mealMasterStartPattern = regexp.MustCompile(`(?i)^(?:MMMMM|-----)-*.*meal-?master`)
+
+// mealMasterEndPattern matches the line a MealMaster recipe ends with: "MMMMM" or a row of dashes.
+var mealMasterEndPattern = regexp.MustCompile(`^(?:MMMMM|-----)\s*$`)
+
+// mealMasterHeaderPattern matches the title, categories and yield lines at the top of a MealMaster recipe.
+var mealMasterHeaderPattern = regexp.MustCompile(`(?i)^\s*(title|categories|yield|servings)\s*:\s*(.*)$`)
+
+// mealMasterIngredientPattern matches a MealMaster ingredient column: the amount in the first seven
+// characters, the unit code in the two after a space, and the name after another space.
+var mealMasterIngredientPattern = regexp.MustCompile(`^([ \d./]{7}) ([A-Za-z ]{2}) (.*)$`)
+
+// mealMasterSectionPattern matches a heading within a MealMaster recipe, such as "-----FILLING-----".
+var mealMasterSectionPattern = regexp.MustCompile(`^\s*(?:MMMMM|-----)-*[^-]+-*\s*$`)
+
+// mealMasterUnits maps MealMaster's two letter unit codes to units. Sizes become part of the name, as in
+// "2 large eggs", and "x" is per serving.
+var mealMasterUnits = map[string]string{
+	"t": "tsp", "ts": "tsp", "T": "tbsp", "tb": "tbsp", "fl": "fl oz", "c": "cup", "pt": "pint", "qt": "quart",
+	"ga": "gallon", "oz": "oz", "lb": "lb", "ml": "ml", "cl": "cl", "dl": "dl", "l": "l", "mg": "mg", "g": "g",
+	"kg": "kg", "cn": "can", "pk": "package", "pn": "pinch", "dr": "drop", "ds": "dash", "ct": "carton",
+	"bn": "bunch", "sl": "slice", "ea": "", "x": "", "cb": "cc", "cg": "cg", "dg": "dg",
+}
+
+// mealMasterSizes are the unit codes that are sizes rather than units.
+var mealMasterSizes = map[string]string{"sm": "small", "md": "medium", "lg": "large"}
+
+// ParseMealMaster reads the recipes in a MealMaster file. Each recipe's title, categories and yield become its
+// name, tags and course, and servings or yield; its ingredient columns are read in order, with continuation
+// lines added to the ingredient before them; and each paragraph of its directions is a procedure step.
+//
+// Parameters:
+//   - text: The MealMaster file, which may hold several recipes
+//
+// Returns:
+//   - []model.ImportEntry: The recipes, in the order they appear
+func ParseMealMaster(text string) []model.ImportEntry {
+	entries := []model.ImportEntry{}
+	var lines []string
+	inRecipe := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\x1a")
+
+		switch {
+		case mealMasterStartPattern.MatchString(line):
+			if inRecipe {
+				entries = append(entries, mealMasterEntry(lines))
+			}
+			lines = nil
+			inRecipe = true
+		case inRecipe && mealMasterEndPattern.MatchString(line):
+			entries = append(entries, mealMasterEntry(lines))
+			lines = nil
+			inRecipe = false
+		case inRecipe:
+			lines = append(lines, line)
+		}
+	}
+
+	if inRecipe && len(lines) > 0 {
+		entries = append(entries, mealMasterEntry(lines))
+	}
+
+	return entries
+}
+
+// private functions
+
+// mealMasterEntry reads one MealMaster recipe from the lines between its start and end.
+func mealMasterEntry(lines []string) model.ImportEntry {
+	recipe := &model.Recipe{Ingredients: []model.Ingredient{}, Procedure: []string{}}
+	i := 0
+
+	// the header: title, categories and yield, before the first blank line after them.
+	for ; i < len(lines); i++ {
+		match := mealMasterHeaderPattern.FindStringSubmatch(lines[i])
+		if match == nil {
+			if strings.TrimSpace(lines[i]) == "" && recipe.RecipeName != "" {
+				break
+			}
+			continue
+		}
+
+		value := strings.TrimSpace(match[2])
+		switch strings.ToLower(match[1]) {
+		case "title":
+			recipe.RecipeName = value
+		case "categories":
+			categories := []string{}
+			for _, category := range strings.Split(value, ",") {
+				if !strings.EqualFold(strings.TrimSpace(category), "none") {
+					categories = append(categories, category)
+				}
+			}
+			addImportCategories(recipe, categories)
+		case "yield", "servings":
+			applyJsonLdYield(recipe, value)
+		}
+	}
+
+	// the ingredients: columns up to the first line that isn't one, skipping blank lines between them.
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			if len(recipe.Ingredients) > 0 && (i+1 >= len(lines) || !isMealMasterIngredient(lines[i+1])) {
+				break
+			}
+			continue
+		}
+		if mealMasterSectionPattern.MatchString(line) {
+			continue
+		}
+		if !isMealMasterIngredient(line) {
+			break
+		}
+
+		// two column layouts put a second ingredient at column 41.
+		columns := []string{line}
+		if len(line) > 41 && strings.TrimSpace(line[39:41]) == "" && isMealMasterIngredient(line[41:]) {
+			columns = []string{strings.TrimRight(line[:41], " "), line[41:]}
+		}
+
+		for _, column := range columns {
+			addMealMasterIngredient(recipe, column)
+		}
+	}
+
+	// the directions: each paragraph is a step.
+	paragraph := []string{}
+	for ; i <= len(lines); i++ {
+		if i == len(lines) || strings.TrimSpace(lines[i]) == "" || mealMasterSectionPattern.MatchString(lines[i]) {
+			if step := strings.Join(strings.Fields(strings.Join(paragraph, " ")), " "); step != "" {
+				recipe.Procedure = append(recipe.Procedure, step)
+			}
+			paragraph = paragraph[:0]
+			continue
+		}
+		paragraph = append(paragraph, lines[i])
+	}
+
+	entry := model.ImportEntry{Recipe: recipe}
+	if recipe.RecipeName == "" {
+		entry.Error = "recipe has no title"
+	}
+	return entry
+}
+
+// isMealMasterIngredient reports whether a line is in the layout of a MealMaster ingredient.
+func isMealMasterIngredient(line string) bool {
+	match := mealMasterIngredientPattern.FindStringSubmatch(line)
+	if match == nil || strings.TrimSpace(match[3]) == "" {
+		return false
+	}
+
+	unit := strings.TrimSpace(match[2])
+	_, isUnit := mealMasterUnits[unit]
+	_, isSize := mealMasterSizes[unit]
+	return unit == "" || isUnit || isSize
+}
+
+// addMealMasterIngredient adds an ingredient column to a recipe. A column without an amount or unit whose name
+// starts with a dash continues the name of the ingredient before it.
+func addMealMasterIngredient(recipe *model.Recipe, column string) {
+	match := mealMasterIngredientPattern.FindStringSubmatch(column)
+	amount := strings.TrimSpace(match[1])
+	code := strings.TrimSpace(match[2])
+	name := strings.TrimSpace(match[3])
+
+	if amount == "" && code == "" && strings.HasPrefix(name, "-") && len(recipe.Ingredients) > 0 {
+		previous := &recipe.Ingredients[len(recipe.Ingredients)-1]
+		previous.IngredientName = strings.TrimSpace(previous.IngredientName + " " + strings.TrimLeft(name, "- "))
+		return
+	}
+
+	// the preparation is written after a semicolon, as in "Apples; peeled and sliced".
+	name = strings.Join(strings.Fields(strings.ReplaceAll(name, ";", ",")), " ")
+	if size, ok := mealMasterSizes[code]; ok {
+		name = size + " " + name
+		code = ""
+	}
+
+	if amount == "" {
+		recipe.Ingredients = append(recipe.Ingredients, ParseIngredientLine(name))
+		return
+	}
+
+	ingredient := model.Ingredient{Amount: parseAmount(amount), UnitOfMeasurement: mealMasterUnits[code], IngredientName: name}
+	if ingredient.Amount <= 0 {
+		ingredient.Amount = 1
+	}
+	if ingredient.UnitOfMeasurement == "" {
+		ingredient.UnitOfMeasurement = UnitWhole
+	}
+
+	recipe.Ingredients = append(recipe.Ingredients, ingredient)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"math/rand"
+	"time"
+
+	"recipe-generator/internal/api/model"
+)
+
+// MealPlanService holds the meal planning logic that doesn't need the database.
+type MealPlanService struct{}
+
+// NewMealPlanService creates a new MealPlanService.
+func NewMealPlanService() *MealPlanService {
+	return &MealPlanService{}
+}
+
+// AutoFill picks recipes for the empty slots described by request.
+// A slot is empty when existing has no entry for that date and meal slot.
+//
+// Parameters:
+//   - request: The dates, slots and constraints to fill with
+//   - scope: The meal plan the new entries belong to
+//   - existing: Entries already planned from NoRepeatDays before the start date to NoRepeatDays after the end date
+//   - candidates: Recipes that already meet the dietary labels, in preference order
+//   - userID: The user creating the entries
+//
+// Returns:
+//   - model.AutoFillResult: The new entries, which have not been saved, and the slots left empty
+func (ms *MealPlanService) AutoFill(request model.AutoFillRequest, scope model.MealPlanScope, existing []model.MealPlanEntry, candidates []model.Recipe, userID int) model.AutoFillResult {
+	slots := request.MealSlots
+	if len(slots) == 0 {
+		slots = []string{model.MealSlotDinner}
+	}
+
+	occupied := make(map[model.MealPlanSlot]bool, len(existing))
+	plannedDates := make(map[int][]model.Date)
+
+	for _, entry := range existing {
+		occupied[model.MealPlanSlot{PlanDate: entry.PlanDate, MealSlot: entry.MealSlot}] = true
+		plannedDates[entry.RecipeId] = append(plannedDates[entry.RecipeId], entry.PlanDate)
+	}
+
+	// shuffle so repeated auto-fills don't always produce the same plan, then take candidates
+	// round-robin so the plan uses as many different recipes as it can.
+	queue := make([]model.Recipe, len(candidates))
+	copy(queue, candidates)
+	rand.Shuffle(len(queue), func(i, j int) { queue[i], queue[j] = queue[j], queue[i] })
+
+	result := model.AutoFillResult{
+		Created:  []model.MealPlanEntry{},
+		Unfilled: []model.MealPlanSlot{},
+	}
+
+	for date := request.StartDate; !date.After(request.EndDate.Time); date = date.AddDays(1) {
+		for _, mealSlot := range slots {
+			slot := model.MealPlanSlot{PlanDate: date, MealSlot: mealSlot}
+			if occupied[slot] {
+				continue
+			}
+
+			index := -1
+			for i, recipe := range queue {
+				if ms.fits(recipe, date, request, plannedDates[recipe.ID]) {
+					index = i
+					break
+				}
+			}
+
+			if index < 0 {
+				result.Unfilled = append(result.Unfilled, slot)
+				continue
+			}
+
+			recipe := queue[index]
+			queue = append(append(queue[:index:index], queue[index+1:]...), recipe)
+
+			entry := model.NewMealPlanEntry(scope, date, mealSlot, recipe.ID, userID)
+			entry.RecipeName = recipe.RecipeName
+			entry.Servings = request.Servings
+
+			result.Created = append(result.Created, *entry)
+			occupied[slot] = true
+			plannedDates[recipe.ID] = append(plannedDates[recipe.ID], date)
+		}
+	}
+
+	return result
+}
+
+// fits reports whether a recipe can be planned on a date under the request's constraints.
+func (ms *MealPlanService) fits(recipe model.Recipe, date model.Date, request model.AutoFillRequest, planned []model.Date) bool {
+	if request.MaxWeeknightMinutes > 0 && IsWeeknight(date) {
+		totalMinutes := recipe.PrepTimeMinutes + recipe.CookTimeMinutes
+		// a recipe without times can't be shown to fit, so it's left for the weekend.
+		if totalMinutes == 0 || totalMinutes > request.MaxWeeknightMinutes {
+			return false
+		}
+	}
+
+	for _, plannedDate := range planned {
+		if daysBetween(plannedDate, date) < request.NoRepeatDays {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsWeeknight reports whether a date falls Monday through Friday.
+func IsWeeknight(date model.Date) bool {
+	weekday := date.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday
+}
+
+// daysBetween returns the absolute number of days between two dates.
+func daysBetween(a model.Date, b model.Date) int {
+	days := int(b.Sub(a.Time).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// pantryEpsilon is the smallest amount treated as more than nothing, to absorb rounding in unit conversions.
+const pantryEpsilon = 0.005
+
+// PantryRequirement is an amount of an ingredient to find in the pantry.
+type PantryRequirement struct {
+	IngredientName string  // Name of the ingredient as the recipe writes it
+	Amount         float64 // Amount needed
+	Unit           string  // Unit of the amount as the recipe writes it
+}
+
+// PantryService matches the ingredients recipes need against what is in the pantry.
+type PantryService struct{}
+
+// NewPantryService creates a new PantryService.
+func NewPantryService() *PantryService {
+	return &PantryService{}
+}
+
+// Match works out how much of each requirement the pantry covers.
+//
+// An ingredient matches pantry items with the same name, ignoring case and plurals. Items that expire soonest
+// are used first, and what one requirement uses isn't available to the next, so two lines of a recipe can't
+// both use the same flour. Amounts are converted to the pantry's unit, through the ingredient's density when
+// going between volume and mass. A pantry item whose name only partly matches, such as "cheddar cheese" for
+// "cheese", or whose unit can't be converted, is reported as an uncertain match and nothing is taken from it.
+//
+// Parameters:
+//   - requirements: The ingredients to find
+//   - pantry: The items in the pantry; not modified
+//
+// Returns:
+//   - []model.PantryMatch: How each requirement matched, in order
+//   - map[int]float64: The amount taken from each pantry item by ID, in the item's unit
+func (ps *PantryService) Match(requirements []PantryRequirement, pantry []model.PantryItem) ([]model.PantryMatch, map[int]float64) {
+	items := make([]model.PantryItem, len(pantry))
+	copy(items, pantry)
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].ExpiryDate, items[j].ExpiryDate
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.Before(b.Time)
+	})
+
+	names := make([]string, len(items))
+	remaining := make([]float64, len(items))
+	for i, item := range items {
+		names[i] = CanonicalIngredientName(item.IngredientName)
+		remaining[i] = item.Quantity
+	}
+
+	taken := make(map[int]float64)
+	matches := make([]model.PantryMatch, 0, len(requirements))
+
+	for _, requirement := range requirements {
+		name := CanonicalIngredientName(requirement.IngredientName)
+		unit := LookupUnit(requirement.Unit)
+
+		match := model.PantryMatch{
+			IngredientName: requirement.IngredientName,
+			Amount:         requirement.Amount,
+			Unit:           requirement.Unit,
+			Status:         model.PantryMatchMissing,
+		}
+
+		need := requirement.Amount
+
+		for i, item := range items {
+			if names[i] != name {
+				continue
+			}
+
+			pantryUnit := LookupUnit(item.Unit)
+
+			needInPantryUnit, approximate, ok := ConvertIngredient(need, unit, pantryUnit, name)
+			if !ok {
+				if match.Status == model.PantryMatchMissing {
+					match.Status = model.PantryMatchUncertain
+					setPantryItem(&match, item)
+					match.Reason = fmt.Sprintf("can't convert %s to %s", unitLabel(unit), unitLabel(pantryUnit))
+				}
+				continue
+			}
+
+			if match.Status != model.PantryMatchMatched {
+				match.Status = model.PantryMatchMatched
+				match.Reason = ""
+				match.Taken = 0
+				setPantryItem(&match, item)
+			}
+			match.Approximate = match.Approximate || approximate
+
+			if need <= pantryEpsilon || remaining[i] <= pantryEpsilon {
+				continue
+			}
+
+			take := math.Min(needInPantryUnit, remaining[i])
+			remaining[i] -= take
+			taken[item.ID] += take
+			if item.ID == match.PantryItemId {
+				match.Taken += take
+			}
+
+			covered := need * take / needInPantryUnit
+			match.Covered += covered
+			need -= covered
+		}
+
+		if match.Status == model.PantryMatchMissing {
+			for i, item := range items {
+				if remaining[i] > pantryEpsilon && partialNameMatch(name, names[i]) {
+					match.Status = model.PantryMatchUncertain
+					setPantryItem(&match, item)
+					match.Reason = fmt.Sprintf("%q only partly matches %q", requirement.IngredientName, item.IngredientName)
+					break
+				}
+			}
+		}
+
+		match.Covered = RoundAmount(match.Covered)
+		match.Taken = RoundAmount(match.Taken)
+		matches = append(matches, match)
+	}
+
+	return matches, taken
+}
+
+// Covers reports whether a match covers all of the amount needed.
+func Covers(match model.PantryMatch) bool {
+	return match.Status == model.PantryMatchMatched && match.Amount-match.Covered <= pantryEpsilon
+}
+
+// SubtractPantry takes what is already in the pantry off a shopping list.
+// Items the pantry fully covers are removed and the rest are reduced; uncertain matches leave the item as it is.
+//
+// Parameters:
+//   - list: The shopping list
+//   - pantry: The items in the pantry
+//
+// Returns:
+//   - model.ShoppingList: The list with pantry matches reported in its Pantry field
+func (ps *PantryService) SubtractPantry(list model.ShoppingList, pantry []model.PantryItem) model.ShoppingList {
+	requirements := []PantryRequirement{}
+	for _, aisle := range list.Aisles {
+		for _, item := range aisle.Items {
+			requirements = append(requirements, PantryRequirement{
+				IngredientName: item.IngredientName,
+				Amount:         item.Amount,
+				Unit:           item.Unit,
+			})
+		}
+	}
+
+	matches, _ := ps.Match(requirements, pantry)
+
+	result := model.ShoppingList{Aisles: []model.ShoppingListAisle{}, Pantry: []model.PantryMatch{}}
+	index := 0
+
+	for _, aisle := range list.Aisles {
+		items := []model.ShoppingListItem{}
+
+		for _, item := range aisle.Items {
+			match := matches[index]
+			index++
+
+			if match.Status != model.PantryMatchMissing {
+				result.Pantry = append(result.Pantry, match)
+			}
+			if Covers(match) {
+				continue
+			}
+			if match.Status == model.PantryMatchMatched {
+				item.Amount = RoundAmount(item.Amount - match.Covered)
+			}
+			items = append(items, item)
+		}
+
+		if len(items) > 0 {
+			result.Aisles = append(result.Aisles, model.ShoppingListAisle{Aisle: aisle.Aisle, Items: items})
+		}
+	}
+
+	return result
+}
+
+// RecipeRequirements returns the ingredients of a recipe scaled to the given number of servings
+// as pantry requirements. Servings of 0 makes the recipe as written.
+func RecipeRequirements(recipe *model.Recipe, servings int) []PantryRequirement {
+	scale := RecipePortion{Recipe: recipe, Servings: servings}.Scale()
+
+	requirements := make([]PantryRequirement, 0, len(recipe.Ingredients))
+	for _, ingredient := range recipe.Ingredients {
+		requirements = append(requirements, PantryRequirement{
+			IngredientName: ingredient.IngredientName,
+			Amount:         ingredient.Amount * scale,
+			Unit:           ingredient.UnitOfMeasurement,
+		})
+	}
+
+	return requirements
+}
+
+// setPantryItem records the pantry item a match refers to.
+func setPantryItem(match *model.PantryMatch, item model.PantryItem) {
+	match.PantryItemId = item.ID
+	match.PantryItemName = item.IngredientName
+	match.PantryUnit = item.Unit
+}
+
+// partialNameMatch reports whether every word of one name appears in the other, such as "cheese" and
+// "cheddar cheese", without the names being the same.
+func partialNameMatch(a string, b string) bool {
+	if a == b || a == "" || b == "" {
+		return false
+	}
+	return containsWords(a, b) || containsWords(b, a)
+}
+
+// containsWords reports whether every word of small appears in large.
+func containsWords(large string, small string) bool {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(large) {
+		words[word] = true
+	}
+	for _, word := range strings.Fields(small) {
+		if !words[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// unitLabel names a unit for messages.
+func unitLabel(unit Unit) string {
+	if unit.Name == "" {
+		return "a count"
+	}
+	return unit.Name
+}
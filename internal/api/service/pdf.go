@@ -0,0 +1,343 @@
+package service
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"  // register the GIF decoder for recipe photos
+	_ "image/jpeg" // register the JPEG decoder for recipe photos
+	_ "image/png"  // register the PNG decoder for recipe photos
+	"io"
+	"strings"
+)
+
+// pdfPageWidth and pdfPageHeight are the size of a US Letter page in points.
+const (
+	pdfPageWidth  = 612.0
+	pdfPageHeight = 792.0
+)
+
+// Fonts PDF pages are written in: the standard Helvetica faces every PDF reader has, so no font is embedded.
+const (
+	pdfFontRegular = "F1"
+	pdfFontBold    = "F2"
+	pdfFontItalic  = "F3"
+)
+
+// pdfFonts are the base fonts of the font resources, in the order their objects are written.
+var pdfFonts = []struct {
+	resource string
+	baseFont string
+}{
+	{pdfFontRegular, "Helvetica"},
+	{pdfFontBold, "Helvetica-Bold"},
+	{pdfFontItalic, "Helvetica-Oblique"},
+}
+
+// helveticaWidths and helveticaBoldWidths are the widths of the printable ASCII characters, from space to
+// tilde, in thousandths of the font size. Helvetica-Oblique has the same widths as Helvetica.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// winAnsiSpecials maps the characters of the Windows-1252 encoding the standard fonts use that aren't at
+// their Unicode code point. Latin-1 characters from U+00A0 up are.
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88, '‰': 0x89,
+	'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95,
+	'–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// pdfTextStyle is the font, size and shade text is drawn in.
+type pdfTextStyle struct {
+	font string
+	size float64
+	gray float64 // 0 for black, 1 for white
+}
+
+// pdfDocument is a PDF being built a page at a time. Pages can be drawn on in any order until it is written,
+// so a table of contents can be filled in once the pages it lists are laid out.
+type pdfDocument struct {
+	title  string
+	pages  []*pdfPage
+	images []*pdfImage
+}
+
+// pdfPage is the content stream of a page.
+type pdfPage struct {
+	content bytes.Buffer
+}
+
+// pdfImage is an image XObject: a JPEG as it is, or anything else as Flate-compressed RGB samples.
+type pdfImage struct {
+	width      int
+	height     int
+	colorSpace string
+	filter     string
+	data       []byte
+}
+
+// newPdfDocument creates an empty PDF with a title.
+func newPdfDocument(title string) *pdfDocument {
+	return &pdfDocument{title: title}
+}
+
+// addPage adds a blank page to the end of the document.
+func (d *pdfDocument) addPage() *pdfPage {
+	page := &pdfPage{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// addImage adds a JPEG, PNG or GIF image to the document, returning its index and its size in pixels. JPEGs
+// are embedded as they are; other images are flattened onto white and compressed.
+func (d *pdfDocument) addImage(contents []byte) (int, image.Point, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(contents))
+	if err != nil {
+		return 0, image.Point{}, err
+	}
+
+	pdfImg := &pdfImage{width: config.Width, height: config.Height}
+
+	switch {
+	case format == "jpeg" && config.ColorModel == color.YCbCrModel:
+		pdfImg.colorSpace, pdfImg.filter, pdfImg.data = "DeviceRGB", "DCTDecode", contents
+	case format == "jpeg" && config.ColorModel == color.GrayModel:
+		pdfImg.colorSpace, pdfImg.filter, pdfImg.data = "DeviceGray", "DCTDecode", contents
+	default:
+		decoded, _, err := image.Decode(bytes.NewReader(contents))
+		if err != nil {
+			return 0, image.Point{}, err
+		}
+		pdfImg.colorSpace, pdfImg.filter = "DeviceRGB", "FlateDecode"
+		if pdfImg.data, err = flateRGB(decoded); err != nil {
+			return 0, image.Point{}, err
+		}
+	}
+
+	d.images = append(d.images, pdfImg)
+	return len(d.images) - 1, image.Point{X: config.Width, Y: config.Height}, nil
+}
+
+// text draws text with its baseline starting at x, y, measured in points from the bottom left of the page.
+func (p *pdfPage) text(style pdfTextStyle, x float64, y float64, text string) {
+	fmt.Fprintf(&p.content, "BT %.3f g /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		style.gray, style.font, style.size, x, y, pdfEscape(pdfEncode(text)))
+}
+
+// line draws a gray line.
+func (p *pdfPage) line(x1 float64, y1 float64, x2 float64, y2 float64, width float64) {
+	fmt.Fprintf(&p.content, "q 0.6 G %.2f w %.2f %.2f m %.2f %.2f l S Q\n", width, x1, y1, x2, y2)
+}
+
+// image draws an image added to the document, stretched to the given size with its bottom left at x, y.
+func (p *pdfPage) image(index int, x float64, y float64, width float64, height float64) {
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", width, height, x, y, index)
+}
+
+// write writes the document as a PDF file.
+func (d *pdfDocument) write(w io.Writer) error {
+	var output bytes.Buffer
+	offsets := []int{}
+
+	object := func(number int, body []byte) {
+		for len(offsets) < number {
+			offsets = append(offsets, 0)
+		}
+		offsets[number-1] = output.Len()
+		fmt.Fprintf(&output, "%d 0 obj\n", number)
+		output.Write(body)
+		output.WriteString("\nendobj\n")
+	}
+	stream := func(dictionary string, data []byte) []byte {
+		var body bytes.Buffer
+		fmt.Fprintf(&body, "<< %s /Length %d >>\nstream\n", dictionary, len(data))
+		body.Write(data)
+		body.WriteString("\nendstream")
+		return body.Bytes()
+	}
+
+	// objects: the catalog, page tree and info, then the fonts, the images, and each page and its content.
+	const catalog, pageTree, info = 1, 2, 3
+	fontStart := 4
+	imageStart := fontStart + len(pdfFonts)
+	pageStart := imageStart + len(d.images)
+
+	output.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	object(catalog, []byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pageTree)))
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", pageStart+2*i)
+	}
+	object(pageTree, []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %g %g] >>",
+		strings.Join(kids, " "), len(d.pages), pdfPageWidth, pdfPageHeight)))
+
+	object(info, []byte(fmt.Sprintf("<< /Title (%s) /Producer (recipe-generator) >>", pdfEscape(pdfEncode(d.title)))))
+
+	fonts := []string{}
+	for i, font := range pdfFonts {
+		object(fontStart+i, []byte(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.baseFont)))
+		fonts = append(fonts, fmt.Sprintf("/%s %d 0 R", font.resource, fontStart+i))
+	}
+
+	images := []string{}
+	for i, img := range d.images {
+		object(imageStart+i, stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s",
+			img.width, img.height, img.colorSpace, img.filter), img.data))
+		images = append(images, fmt.Sprintf("/Im%d %d 0 R", i, imageStart+i))
+	}
+
+	resources := fmt.Sprintf("<< /Font << %s >> /XObject << %s >> >>", strings.Join(fonts, " "), strings.Join(images, " "))
+
+	for i, page := range d.pages {
+		content, err := flate(page.content.Bytes())
+		if err != nil {
+			return err
+		}
+		object(pageStart+2*i, []byte(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Resources %s /Contents %d 0 R >>", pageTree, resources, pageStart+2*i+1)))
+		object(pageStart+2*i+1, stream("/Filter /FlateDecode", content))
+	}
+
+	xref := output.Len()
+	fmt.Fprintf(&output, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&output, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&output, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, catalog, info, xref)
+
+	_, err := w.Write(output.Bytes())
+	return err
+}
+
+// pdfTextWidth returns the width of text in points when drawn in a style.
+func pdfTextWidth(style pdfTextStyle, text string) float64 {
+	widths := &helveticaWidths
+	if style.font == pdfFontBold {
+		widths = &helveticaBoldWidths
+	}
+
+	total := 0
+	for _, b := range pdfEncode(text) {
+		switch {
+		case b >= 32 && b <= 126:
+			total += widths[b-32]
+		case b == 0x95:
+			total += 350
+		case b == 0xb7:
+			total += 278
+		default:
+			total += 556
+		}
+	}
+
+	return float64(total) * style.size / 1000
+}
+
+// pdfWrap breaks text into lines no wider than width, between words.
+func pdfWrap(style pdfTextStyle, text string, width float64) []string {
+	lines := []string{}
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && pdfTextWidth(style, candidate) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfEncode encodes text in the Windows-1252 encoding of the standard fonts, writing fractions such as ⅓ out
+// and anything else the encoding doesn't have as a question mark.
+func pdfEncode(text string) []byte {
+	encoded := make([]byte, 0, len(text))
+
+	for _, r := range text {
+		switch {
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			encoded = append(encoded, byte(r))
+		case winAnsiSpecials[r] != 0:
+			encoded = append(encoded, winAnsiSpecials[r])
+		case unicodeFractions[r] != 0:
+			encoded = append(encoded, FormatAmount(unicodeFractions[r])...)
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+
+	return encoded
+}
+
+// pdfEscape escapes the characters of a PDF string literal that must be.
+func pdfEscape(text []byte) string {
+	var builder strings.Builder
+	for _, b := range text {
+		switch b {
+		case '\\', '(', ')':
+			builder.WriteByte('\\')
+			builder.WriteByte(b)
+		case '\r', '\n', '\t':
+			builder.WriteByte(' ')
+		default:
+			builder.WriteByte(b)
+		}
+	}
+	return builder.String()
+}
+
+// flate compresses data for a FlateDecode stream.
+func flate(data []byte) ([]byte, error) {
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// flateRGB returns an image's pixels as compressed 8-bit RGB samples, with transparency flattened onto white.
+func flateRGB(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	samples := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			// colors are premultiplied by alpha, so adding the uncovered part of white flattens them.
+			white := 0xffff - a
+			samples = append(samples, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+
+	return flate(samples)
+}
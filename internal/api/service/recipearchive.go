@@ -0,0 +1,559 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// maxImportEntryBytes is the largest file read from within an import archive once it is uncompressed.
+const maxImportEntryBytes = 64 << 20
+
+// stepNumberPattern matches the numbering at the start of a direction, such as "1." or "Step 2:".
+var stepNumberPattern = regexp.MustCompile(`(?i)^(?:step\s*)?\d+[.):]\s*`)
+
+// paprikaRecipe is a recipe as Paprika exports it: gzipped JSON, on its own in a .paprikarecipe file or in a
+// zip of them in a .paprikarecipes file.
+type paprikaRecipe struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Ingredients string   `json:"ingredients"`
+	Directions  string   `json:"directions"`
+	Notes       string   `json:"notes"`
+	Servings    string   `json:"servings"`
+	PrepTime    string   `json:"prep_time"`
+	CookTime    string   `json:"cook_time"`
+	TotalTime   string   `json:"total_time"`
+	Difficulty  string   `json:"difficulty"`
+	Categories  []string `json:"categories"`
+	Source      string   `json:"source"`
+	SourceUrl   string   `json:"source_url"`
+	PhotoData   string   `json:"photo_data"`
+	Photo       string   `json:"photo"`
+	Photos      []struct {
+		Filename string `json:"filename"`
+		Data     string `json:"data"`
+	} `json:"photos"`
+}
+
+// importName is a named object, such as a Mealie tag or a Tandoor food.
+type importName struct {
+	Name string `json:"name"`
+}
+
+// mealieRecipe is a recipe as Mealie exports it, in recipes/<slug>/<slug>.json with its photos in
+// recipes/<slug>/images.
+type mealieRecipe struct {
+	Name           string `json:"name"`
+	Slug           string `json:"slug"`
+	Description    string `json:"description"`
+	RecipeYield    any    `json:"recipeYield"`
+	RecipeServings any    `json:"recipeServings"`
+	PrepTime       string `json:"prepTime"`
+	PerformTime    string `json:"performTime"`
+	CookTime       string `json:"cookTime"`
+	TotalTime      string `json:"totalTime"`
+	OrgUrl         string `json:"orgURL"`
+	Ingredients    []struct {
+		Quantity      float64     `json:"quantity"`
+		Unit          *importName `json:"unit"`
+		Food          *importName `json:"food"`
+		Note          string      `json:"note"`
+		OriginalText  string      `json:"originalText"`
+		Display       string      `json:"display"`
+		DisableAmount bool        `json:"disableAmount"`
+	} `json:"recipeIngredient"`
+	Instructions []struct {
+		Text string `json:"text"`
+	} `json:"recipeInstructions"`
+	Tags       []importName `json:"tags"`
+	Categories []importName `json:"recipeCategory"`
+	Tools      []importName `json:"tools"`
+	Notes      []struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"notes"`
+}
+
+// tandoorRecipe is a recipe as Tandoor exports it: recipe.json in a zip with its photo, inside the zip of a
+// whole export.
+type tandoorRecipe struct {
+	Name         string       `json:"name"`
+	Description  string       `json:"description"`
+	Keywords     []importName `json:"keywords"`
+	WorkingTime  int          `json:"working_time"`
+	WaitingTime  int          `json:"waiting_time"`
+	Servings     int          `json:"servings"`
+	ServingsText string       `json:"servings_text"`
+	SourceUrl    string       `json:"source_url"`
+	Steps        []struct {
+		Instruction string `json:"instruction"`
+		Ingredients []struct {
+			Food     *importName `json:"food"`
+			Unit     *importName `json:"unit"`
+			Amount   float64     `json:"amount"`
+			Note     string      `json:"note"`
+			IsHeader bool        `json:"is_header"`
+			NoAmount bool        `json:"no_amount"`
+		} `json:"ingredients"`
+	} `json:"steps"`
+}
+
+// ParseImportFile detects the format of a file exported from another recipe manager and reads its recipes:
+// a MealMaster .mmf file, a Paprika .paprikarecipes archive or single .paprikarecipe, a Mealie export zip or
+// recipe JSON, a Tandoor export zip or recipe.json, or a Cooklang .cook file. Ingredient lines are read with
+// ParseIngredientLine and photos are returned with their recipes. A recipe that can't be read is returned
+// with an Error rather than failing the file.
+//
+// Parameters:
+//   - file: The file to import
+//
+// Returns:
+//   - string: The format detected, one of the model.ImportFormat constants
+//   - []model.ImportEntry: The recipes in the file, in the order they were found
+//   - error: model.ErrInvalidField if the format isn't recognized or the file can't be read, or
+//     model.ErrMissingRequiredField if it has no recipes
+func ParseImportFile(file model.ImportFile) (string, []model.ImportEntry, error) {
+	contents := file.Contents
+	extension := strings.ToLower(path.Ext(file.Name))
+
+	switch {
+	case bytes.HasPrefix(contents, []byte("PK\x03\x04")):
+		return parseImportZip(file)
+	case isGzip(contents):
+		return model.ImportFormatPaprika, []model.ImportEntry{paprikaEntry("", contents)}, nil
+	case bytes.HasPrefix(bytes.TrimSpace(contents), []byte("{")):
+		format, entry := jsonImportEntry("", contents)
+		if format == "" {
+			return "", nil, model.ErrInvalidField("file: unrecognized format")
+		}
+		return format, []model.ImportEntry{entry}, nil
+	case extension == ".cook":
+		recipe := ParseCooklang(string(contents))
+		if recipe.RecipeName == "" {
+			recipe.RecipeName = strings.TrimSpace(strings.TrimSuffix(path.Base(file.Name), path.Ext(file.Name)))
+		}
+		return model.ImportFormatCooklang, []model.ImportEntry{{Recipe: recipe}}, nil
+	case extension == ".mmf" || extension == ".mm" || bytes.Contains(contents, []byte("MMMMM")) ||
+		bytes.Contains(bytes.ToLower(contents), []byte("meal-master")):
+		entries := ParseMealMaster(string(contents))
+		if len(entries) == 0 {
+			return model.ImportFormatMealMaster, nil, model.ErrMissingRequiredField("file: no MealMaster recipes were found")
+		}
+		return model.ImportFormatMealMaster, entries, nil
+	}
+
+	return "", nil, model.ErrInvalidField("file: unrecognized format")
+}
+
+// private functions
+
+// parseImportZip reads the recipes in a zip: .paprikarecipe files for Paprika, a zip for each recipe for
+// Tandoor, or recipe JSON files with photos in an images directory beside them for Mealie.
+func parseImportZip(file model.ImportFile) (string, []model.ImportEntry, error) {
+	archive, err := zip.NewReader(bytes.NewReader(file.Contents), int64(len(file.Contents)))
+	if err != nil {
+		return "", nil, model.ErrInvalidField("file")
+	}
+
+	format := ""
+	entries := []model.ImportEntry{}
+	// Mealie keeps each recipe's photos in recipes/<slug>/images, keyed here by recipes/<slug>.
+	images := map[string][]model.ImportImage{}
+
+	for _, archived := range archive.File {
+		base := path.Base(archived.Name)
+		extension := strings.ToLower(path.Ext(base))
+		if archived.FileInfo().IsDir() || strings.HasPrefix(base, ".") || strings.HasPrefix(archived.Name, "__MACOSX/") {
+			continue
+		}
+
+		isImage := path.Base(path.Dir(archived.Name)) == "images" && strings.HasPrefix(base, "original")
+		if extension != ".paprikarecipe" && extension != ".zip" && extension != ".json" && !isImage {
+			continue
+		}
+
+		contents, err := readZipFile(archived)
+		if err != nil {
+			entries = append(entries, model.ImportEntry{Name: archived.Name, Error: "file couldn't be read"})
+			continue
+		}
+
+		switch {
+		case isImage:
+			directory := path.Dir(path.Dir(archived.Name))
+			images[directory] = append(images[directory], model.ImportImage{Name: archived.Name, Contents: contents})
+		case extension == ".paprikarecipe":
+			format = model.ImportFormatPaprika
+			entries = append(entries, paprikaEntry(archived.Name, contents))
+		case extension == ".zip":
+			entry, ok := tandoorZipEntry(archived.Name, contents)
+			if ok {
+				format = model.ImportFormatTandoor
+				entries = append(entries, entry)
+			}
+		default:
+			// files that aren't recipes, such as Mealie's database export, are skipped.
+			entryFormat, entry := jsonImportEntry(archived.Name, contents)
+			if entryFormat != "" {
+				format = entryFormat
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	if format == "" {
+		return "", nil, model.ErrInvalidField("file: unrecognized format")
+	}
+	if len(entries) == 0 {
+		return format, nil, model.ErrMissingRequiredField("file: the archive has no recipes")
+	}
+
+	if format == model.ImportFormatMealie {
+		for i := range entries {
+			entries[i].Images = append(entries[i].Images, images[path.Dir(entries[i].Name)]...)
+		}
+	}
+
+	return format, entries, nil
+}
+
+// readZipFile reads a file from a zip, up to maxImportEntryBytes.
+func readZipFile(archived *zip.File) ([]byte, error) {
+	reader, err := archived.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return readLimited(reader)
+}
+
+// readLimited reads up to maxImportEntryBytes, failing rather than truncating a larger file.
+func readLimited(reader io.Reader) ([]byte, error) {
+	contents, err := io.ReadAll(io.LimitReader(reader, maxImportEntryBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) > maxImportEntryBytes {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxImportEntryBytes)
+	}
+	return contents, nil
+}
+
+// isGzip reports whether contents start with the gzip magic number.
+func isGzip(contents []byte) bool {
+	return len(contents) > 2 && contents[0] == 0x1f && contents[1] == 0x8b
+}
+
+// jsonImportEntry reads a recipe JSON file, telling Mealie's recipeIngredient from Tandoor's steps. It returns a
+// blank format if the file is neither.
+func jsonImportEntry(name string, contents []byte) (string, model.ImportEntry) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &fields); err != nil {
+		return "", model.ImportEntry{}
+	}
+
+	_, hasIngredients := fields["recipeIngredient"]
+	_, hasSteps := fields["steps"]
+	_, hasWorkingTime := fields["working_time"]
+
+	switch {
+	case hasIngredients:
+		return model.ImportFormatMealie, mealieEntry(name, contents)
+	case hasSteps && hasWorkingTime:
+		return model.ImportFormatTandoor, tandoorEntry(name, contents, nil)
+	}
+	return "", model.ImportEntry{}
+}
+
+// paprikaEntry reads a gzipped Paprika recipe.
+func paprikaEntry(name string, contents []byte) model.ImportEntry {
+	entry := model.ImportEntry{Name: name}
+
+	reader, err := gzip.NewReader(bytes.NewReader(contents))
+	if err != nil {
+		entry.Error = "recipe isn't gzipped JSON"
+		return entry
+	}
+
+	document, err := readLimited(reader)
+	if err != nil {
+		entry.Error = "recipe couldn't be uncompressed"
+		return entry
+	}
+
+	var paprika paprikaRecipe
+	if err := json.Unmarshal(document, &paprika); err != nil {
+		entry.Error = fmt.Sprintf("recipe isn't valid JSON: %v", err)
+		return entry
+	}
+
+	recipe := newImportRecipe(paprika.Name, paprika.Description)
+	recipe.PrepTimeMinutes = parseTimeText(paprika.PrepTime)
+	recipe.CookTimeMinutes = parseTimeText(paprika.CookTime)
+	if recipe.PrepTimeMinutes == 0 && recipe.CookTimeMinutes == 0 {
+		recipe.CookTimeMinutes = parseTimeText(paprika.TotalTime)
+	}
+
+	for _, line := range importLines(paprika.Ingredients) {
+		recipe.Ingredients = append(recipe.Ingredients, ParseIngredientLine(line))
+	}
+	recipe.Procedure = importLines(paprika.Directions)
+
+	applyJsonLdYield(recipe, paprika.Servings)
+	recipe.Notes = strings.TrimSpace(paprika.Notes)
+	if difficulty := strings.ToLower(strings.TrimSpace(paprika.Difficulty)); model.IsDifficulty(difficulty) {
+		recipe.Difficulty = difficulty
+	}
+	addImportCategories(recipe, paprika.Categories)
+	setImportSource(recipe, paprika.Source, paprika.SourceUrl)
+
+	entry.Recipe = recipe
+
+	if image, err := base64.StdEncoding.DecodeString(paprika.PhotoData); err == nil && len(image) > 0 {
+		entry.Images = append(entry.Images, model.ImportImage{Name: firstNonBlank(paprika.Photo, "photo"), Contents: image})
+	}
+	for _, photo := range paprika.Photos {
+		if image, err := base64.StdEncoding.DecodeString(photo.Data); err == nil && len(image) > 0 {
+			entry.Images = append(entry.Images, model.ImportImage{Name: photo.Filename, Contents: image})
+		}
+	}
+
+	return entry
+}
+
+// mealieEntry reads a Mealie recipe JSON file. Ingredients Mealie has parsed keep its food, unit and quantity;
+// others are read from their original text.
+func mealieEntry(name string, contents []byte) model.ImportEntry {
+	entry := model.ImportEntry{Name: name}
+
+	var mealie mealieRecipe
+	if err := json.Unmarshal(contents, &mealie); err != nil {
+		entry.Error = fmt.Sprintf("recipe isn't valid Mealie JSON: %v", err)
+		return entry
+	}
+
+	recipe := newImportRecipe(mealie.Name, mealie.Description)
+	recipe.PrepTimeMinutes = parseTimeText(mealie.PrepTime)
+	recipe.CookTimeMinutes = parseTimeText(firstNonBlank(mealie.PerformTime, mealie.CookTime))
+	if recipe.PrepTimeMinutes == 0 && recipe.CookTimeMinutes == 0 {
+		recipe.CookTimeMinutes = parseTimeText(mealie.TotalTime)
+	}
+
+	for _, line := range mealie.Ingredients {
+		if line.Food == nil || strings.TrimSpace(line.Food.Name) == "" || line.DisableAmount {
+			if text := cleanJsonLdText(firstNonBlank(line.OriginalText, line.Display, line.Note)); text != "" {
+				recipe.Ingredients = append(recipe.Ingredients, ParseIngredientLine(text))
+			}
+			continue
+		}
+
+		unit := ""
+		if line.Unit != nil {
+			unit = strings.TrimSpace(line.Unit.Name)
+		}
+		recipe.Ingredients = append(recipe.Ingredients, importIngredient(line.Quantity, unit, line.Food.Name, line.Note))
+	}
+
+	for _, instruction := range mealie.Instructions {
+		recipe.Procedure = append(recipe.Procedure, importLines(instruction.Text)...)
+	}
+
+	applyJsonLdYield(recipe, jsonLdText(mealie.RecipeServings))
+	applyJsonLdYield(recipe, jsonLdText(mealie.RecipeYield))
+
+	for _, tag := range mealie.Tags {
+		if tag.Name = strings.TrimSpace(tag.Name); tag.Name != "" {
+			recipe.Tags = append(recipe.Tags, tag.Name)
+		}
+	}
+	categories := []string{}
+	for _, category := range mealie.Categories {
+		categories = append(categories, category.Name)
+	}
+	addImportCategories(recipe, categories)
+
+	if len(mealie.Tools) > 0 {
+		for _, tool := range mealie.Tools {
+			recipe.Equipment = append(recipe.Equipment, tool.Name)
+		}
+		recipe.Equipment = NormalizeEquipment(recipe.Equipment)
+	}
+
+	notes := []string{}
+	for _, note := range mealie.Notes {
+		text := strings.TrimSpace(note.Text)
+		if title := strings.TrimSpace(note.Title); title != "" && text != "" {
+			text = title + ": " + text
+		}
+		if text != "" {
+			notes = append(notes, text)
+		}
+	}
+	recipe.Notes = strings.Join(notes, "\n\n")
+	setImportSource(recipe, "", mealie.OrgUrl)
+
+	entry.Recipe = recipe
+	return entry
+}
+
+// tandoorZipEntry reads the zip Tandoor exports each recipe in, with its recipe.json and photo. It reports
+// whether the zip holds a recipe.
+func tandoorZipEntry(name string, contents []byte) (model.ImportEntry, bool) {
+	archive, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	if err != nil {
+		return model.ImportEntry{}, false
+	}
+
+	var document []byte
+	images := []model.ImportImage{}
+
+	for _, archived := range archive.File {
+		base := path.Base(archived.Name)
+		if base != "recipe.json" && !strings.HasPrefix(base, "image") {
+			continue
+		}
+
+		contents, err := readZipFile(archived)
+		if err != nil {
+			return model.ImportEntry{Name: name, Error: fmt.Sprintf("%s couldn't be read", base)}, true
+		}
+
+		if base == "recipe.json" {
+			document = contents
+		} else {
+			images = append(images, model.ImportImage{Name: name + "/" + base, Contents: contents})
+		}
+	}
+
+	if document == nil {
+		return model.ImportEntry{}, false
+	}
+	return tandoorEntry(name, document, images), true
+}
+
+// tandoorEntry reads a Tandoor recipe.json. Each step's instruction is a procedure step and its ingredients
+// are added in order, skipping section headers.
+func tandoorEntry(name string, contents []byte, images []model.ImportImage) model.ImportEntry {
+	entry := model.ImportEntry{Name: name, Images: images}
+
+	var tandoor tandoorRecipe
+	if err := json.Unmarshal(contents, &tandoor); err != nil {
+		entry.Error = fmt.Sprintf("recipe isn't valid Tandoor JSON: %v", err)
+		return entry
+	}
+
+	recipe := newImportRecipe(tandoor.Name, tandoor.Description)
+	recipe.PrepTimeMinutes = tandoor.WorkingTime
+	recipe.CookTimeMinutes = tandoor.WaitingTime
+
+	for _, step := range tandoor.Steps {
+		for _, line := range step.Ingredients {
+			if line.IsHeader || line.Food == nil || strings.TrimSpace(line.Food.Name) == "" {
+				continue
+			}
+
+			unit := ""
+			if line.Unit != nil {
+				unit = strings.TrimSpace(line.Unit.Name)
+			}
+			if line.NoAmount {
+				line.Amount, unit = 0, ""
+			}
+			recipe.Ingredients = append(recipe.Ingredients, importIngredient(line.Amount, unit, line.Food.Name, line.Note))
+		}
+
+		recipe.Procedure = append(recipe.Procedure, importLines(step.Instruction)...)
+	}
+
+	if tandoor.Servings > 0 {
+		applyJsonLdYield(recipe, strings.TrimSpace(fmt.Sprintf("%d %s", tandoor.Servings, tandoor.ServingsText)))
+	}
+	for _, keyword := range tandoor.Keywords {
+		if keyword.Name = strings.TrimSpace(keyword.Name); keyword.Name != "" {
+			recipe.Tags = append(recipe.Tags, keyword.Name)
+		}
+	}
+	setImportSource(recipe, "", tandoor.SourceUrl)
+
+	entry.Recipe = recipe
+	return entry
+}
+
+// newImportRecipe returns an empty recipe with a name and description.
+func newImportRecipe(name string, description string) *model.Recipe {
+	return &model.Recipe{
+		RecipeName:  cleanJsonLdText(name),
+		Description: cleanJsonLdText(description),
+		Ingredients: []model.Ingredient{},
+		Procedure:   []string{},
+	}
+}
+
+// importIngredient builds an ingredient another recipe manager has already split into an amount, unit and
+// food, with its note after the name. An ingredient without an amount is used as needed, and one without a
+// unit is counted whole.
+func importIngredient(amount float64, unit string, food string, note string) model.Ingredient {
+	ingredient := model.Ingredient{Amount: amount, UnitOfMeasurement: unit, IngredientName: strings.TrimSpace(food)}
+	if note = strings.TrimSpace(note); note != "" {
+		ingredient.IngredientName += ", " + note
+	}
+
+	switch {
+	case amount <= 0:
+		ingredient.Amount = 1
+		ingredient.UnitOfMeasurement = UnitAsNeeded
+	case unit == "":
+		ingredient.UnitOfMeasurement = UnitWhole
+	}
+
+	return ingredient
+}
+
+// importLines splits text with an item on each line into its items, dropping blank lines, headings that end
+// in a colon and the numbering of steps.
+func importLines(text string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = cleanJsonLdText(stepNumberPattern.ReplaceAllString(strings.TrimSpace(line), ""))
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// addImportCategories adds categories to a recipe's tags, setting its course from the first that is one.
+func addImportCategories(recipe *model.Recipe, categories []string) {
+	for _, category := range categories {
+		if category = strings.TrimSpace(category); category == "" {
+			continue
+		}
+		recipe.Tags = append(recipe.Tags, category)
+		if recipe.Course == "" {
+			recipe.Course = jsonLdCourse(category)
+		}
+	}
+}
+
+// setImportSource attributes a recipe to an author or site and a URL, dropping a URL that isn't absolute.
+func setImportSource(recipe *model.Recipe, author string, url string) {
+	source := &model.Attribution{Author: strings.TrimSpace(author), Url: strings.TrimSpace(url)}
+	if !strings.HasPrefix(source.Url, "http://") && !strings.HasPrefix(source.Url, "https://") {
+		source.Url = ""
+	}
+	if !source.IsZero() {
+		recipe.Source = source
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+
+	"recipe-generator/internal/api/model"
+)
+
+// Names of the recipe card templates, looked for in the template directory before the built-in ones.
+const (
+	RecipeCardHtmlTemplate     = "recipe.html"
+	RecipeCardMarkdownTemplate = "recipe.md"
+)
+
+// defaultRecipeCardTemplates are the built-in recipe card templates.
+//
+//go:embed templates/recipe.html templates/recipe.md
+var defaultRecipeCardTemplates embed.FS
+
+// recipeCardFuncs are the functions recipe card templates can call besides the built-in ones.
+var recipeCardFuncs = map[string]any{
+	"add":        func(a int, b int) int { return a + b },
+	"amount":     FormatAmount,
+	"ingredient": FormatIngredient,
+	"minutes":    formatTimeText,
+}
+
+// RecipeCardService renders recipes as Markdown and printable HTML recipe cards. Each template is read from
+// the template directory if it has one by that name, so teams can theme the cards, and the built-in one is
+// used otherwise. Templates are read once, on first use.
+type RecipeCardService struct {
+	directory string
+	once      sync.Once
+	html      *htmltemplate.Template
+	markdown  *texttemplate.Template
+	err       error
+}
+
+// NewRecipeCardService creates a new RecipeCardService that looks for templates in directory, or only uses the
+// built-in templates if directory is blank.
+func NewRecipeCardService(directory string) *RecipeCardService {
+	return &RecipeCardService{directory: directory}
+}
+
+// NewRecipeCard gathers what the recipe card templates show about a recipe: its ingredients written as lines,
+// its times written out, and, if it was scaled, the scaled amounts and advice about them.
+//
+// Parameters:
+//   - recipe: The recipe as written, with its ingredients loaded
+//   - scaled: The recipe scaled to different servings, or nil to show it as written
+//   - photoUrl: Address of the recipe's primary photo, or "" if it has none
+//
+// Returns:
+//   - model.RecipeCard: The card to render
+func NewRecipeCard(recipe *model.Recipe, scaled *model.ScaledRecipe, photoUrl string) model.RecipeCard {
+	card := model.RecipeCard{
+		Recipe:           recipe,
+		Servings:         recipe.Servings,
+		OriginalServings: recipe.Servings,
+		Ingredients:      []string{},
+		Steps:            recipe.Procedure,
+		PrepTime:         formatTimeText(recipe.PrepTimeMinutes),
+		CookTime:         formatTimeText(recipe.CookTimeMinutes),
+		PhotoUrl:         photoUrl,
+	}
+
+	if scaled != nil {
+		card.Recipe = scaled.Recipe
+		card.Servings = scaled.Recipe.Servings
+		card.ScaleNotes = scaled.Notes
+	}
+
+	if recipe.PrepTimeMinutes > 0 && recipe.CookTimeMinutes > 0 {
+		card.TotalTime = formatTimeText(recipe.PrepTimeMinutes + recipe.CookTimeMinutes)
+	}
+
+	if yield := card.Recipe.Yield; yield != nil {
+		card.Yield = strings.TrimSpace(FormatAmount(yield.Quantity) + " " + yield.Unit)
+	}
+
+	for _, ingredient := range card.Recipe.Ingredients {
+		card.Ingredients = append(card.Ingredients, FormatIngredient(ingredient))
+	}
+
+	return card
+}
+
+// Html renders a recipe card as a printable HTML page.
+//
+// Parameters:
+//   - card: The recipe card
+//
+// Returns:
+//   - string: The HTML page
+//   - error: An error if a template couldn't be read or parsed, or fails to render
+func (cs *RecipeCardService) Html(card model.RecipeCard) (string, error) {
+	if err := cs.load(); err != nil {
+		return "", err
+	}
+
+	var output bytes.Buffer
+	if err := cs.html.Execute(&output, card); err != nil {
+		log.Printf("Error rendering HTML recipe card: %v", err)
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// Markdown renders a recipe card as Markdown.
+//
+// Parameters:
+//   - card: The recipe card
+//
+// Returns:
+//   - string: The Markdown document
+//   - error: An error if a template couldn't be read or parsed, or fails to render
+func (cs *RecipeCardService) Markdown(card model.RecipeCard) (string, error) {
+	if err := cs.load(); err != nil {
+		return "", err
+	}
+
+	var output bytes.Buffer
+	if err := cs.markdown.Execute(&output, card); err != nil {
+		log.Printf("Error rendering Markdown recipe card: %v", err)
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// private functions
+
+// load reads and parses the templates the first time a card is rendered.
+func (cs *RecipeCardService) load() error {
+	cs.once.Do(func() {
+		var source string
+
+		source, cs.err = cs.readTemplate(RecipeCardHtmlTemplate)
+		if cs.err != nil {
+			return
+		}
+		cs.html, cs.err = htmltemplate.New(RecipeCardHtmlTemplate).Funcs(recipeCardFuncs).Parse(source)
+		if cs.err != nil {
+			log.Printf("Error parsing recipe card template %s: %v", RecipeCardHtmlTemplate, cs.err)
+			return
+		}
+
+		source, cs.err = cs.readTemplate(RecipeCardMarkdownTemplate)
+		if cs.err != nil {
+			return
+		}
+		cs.markdown, cs.err = texttemplate.New(RecipeCardMarkdownTemplate).Funcs(recipeCardFuncs).Parse(source)
+		if cs.err != nil {
+			log.Printf("Error parsing recipe card template %s: %v", RecipeCardMarkdownTemplate, cs.err)
+		}
+	})
+
+	return cs.err
+}
+
+// readTemplate reads a template from the template directory, or the built-in one if the directory doesn't
+// have it.
+func (cs *RecipeCardService) readTemplate(name string) (string, error) {
+	if cs.directory != "" {
+		source, err := os.ReadFile(filepath.Join(cs.directory, name))
+		if err == nil {
+			log.Printf("Using recipe card template %s from %s", name, cs.directory)
+			return string(source), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Error reading recipe card template %s: %v", name, err)
+			return "", err
+		}
+	}
+
+	source, err := defaultRecipeCardTemplates.ReadFile("templates/" + name)
+	if err != nil {
+		return "", err
+	}
+	return string(source), nil
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"recipe-generator/internal/api/model"
+)
+
+// Weights of the parts of a similar recipe's score. Parts that can't be compared are left out and the rest
+// are scaled up to make a score from 0 to 1.
+const (
+	similarIngredientWeight = 0.45
+	similarTagWeight        = 0.2
+	similarTechniqueWeight  = 0.2
+	similarTimeWeight       = 0.15
+)
+
+// MaxSimilarRecipes is the most similar recipes worked out and cached for each recipe.
+const MaxSimilarRecipes = 50
+
+// recommendationIndexTTL is how long the recommendation index is used before it is rebuilt, to pick up
+// recipes added outside the API, such as by the bulk importer.
+const recommendationIndexTTL = 10 * time.Minute
+
+// techniqueKeywords maps words in a procedure to the cooking technique they show.
+var techniqueKeywords = map[string]string{
+	"bake": "baking", "baked": "baking", "baking": "baking",
+	"roast": "roasting", "roasted": "roasting", "roasting": "roasting",
+	"grill": "grilling", "grilled": "grilling", "grilling": "grilling",
+	"broil": "broiling", "broiled": "broiling", "broiling": "broiling",
+	"fry": "frying", "fried": "frying", "frying": "frying", "pan-fry": "frying", "pan-fried": "frying",
+	"deep-fry": "deep-frying", "deep-fried": "deep-frying",
+	"stir-fry": "stir-frying", "stir-fried": "stir-frying",
+	"sauté": "sautéing", "saute": "sautéing", "sautéed": "sautéing", "sauteed": "sautéing",
+	"sear": "searing", "seared": "searing",
+	"simmer": "simmering", "simmered": "simmering", "simmering": "simmering",
+	"boil": "boiling", "boiled": "boiling", "boiling": "boiling",
+	"braise": "braising", "braised": "braising",
+	"steam": "steaming", "steamed": "steaming",
+	"poach": "poaching", "poached": "poaching",
+	"smoke": "smoking", "smoked": "smoking",
+	"toast": "toasting", "toasted": "toasting",
+	"caramelize": "caramelizing", "caramelized": "caramelizing",
+	"marinate": "marinating", "marinated": "marinating",
+	"knead": "kneading", "kneaded": "kneading",
+	"whisk": "whisking", "whip": "whipping", "whipped": "whipping",
+	"blend": "blending", "puree": "blending", "purée": "blending",
+	"chill": "chilling", "refrigerate": "chilling", "freeze": "freezing",
+}
+
+// RecipeLoader loads every recipe with its ingredients, procedure and tags.
+type RecipeLoader func(ctx context.Context) ([]*model.Recipe, error)
+
+// recipeProfile is what the recommendations compare about a recipe.
+type recipeProfile struct {
+	id          int
+	name        string
+	ingredients map[string]bool
+	tags        map[string]bool
+	techniques  map[string]bool
+	minutes     int
+}
+
+// recommendationIndex holds the profiles of every recipe. It isn't changed once it is built, apart from its
+// cache, which is guarded by the service's mutex.
+type recommendationIndex struct {
+	profiles []*recipeProfile
+	byID     map[int]*recipeProfile
+	weights  map[string]float64 // Inverse document frequency of each ingredient, so staples like salt count for little
+	builtAt  time.Time
+	cache    map[int][]model.SimilarRecipe // Results worked out so far, by recipe ID
+}
+
+// RecommendationService recommends recipes similar to a recipe. It keeps every recipe's profile in memory
+// and caches the results for each recipe, so it is cheap enough to call on every recipe view. Call
+// Invalidate whenever recipes change.
+//
+// The index is rebuilt outside the mutex, so requests aren't held up while the recipes load. Only one request
+// rebuilds it at a time; the others wait for that rebuild rather than loading the recipes again.
+type RecommendationService struct {
+	load       RecipeLoader
+	mutex      sync.Mutex
+	index      *recommendationIndex
+	generation int           // Counts calls to Invalidate, so an index loaded before one isn't kept
+	rebuilding chan struct{} // Closed when the rebuild in progress finishes; nil if there isn't one
+}
+
+// NewRecommendationService creates a new RecommendationService that builds its index with load.
+func NewRecommendationService(load RecipeLoader) *RecommendationService {
+	return &RecommendationService{load: load}
+}
+
+// Invalidate drops the index and cached results, so the next request rebuilds them.
+func (rs *RecommendationService) Invalidate() {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	rs.index = nil
+	rs.generation++
+}
+
+// Similar returns the recipes most like a recipe, best first. Recipes are scored on a weighted combination of
+// shared ingredients (45%), tags (20%), cooking techniques found in the procedure (20%) and how close their
+// total times are (15%). Each result explains what it has in common with the recipe. Recipes with no
+// ingredient, tag or technique in common are left out.
+//
+// Parameters:
+//   - ctx: The request context, used if the index has to be rebuilt
+//   - recipeID: The recipe to find similar recipes for
+//   - limit: The most recipes to return, up to MaxSimilarRecipes
+//
+// Returns:
+//   - []model.SimilarRecipe: The similar recipes
+//   - error: model.ErrNotFound if the recipe does not exist, or an error if the index can't be built
+func (rs *RecommendationService) Similar(ctx context.Context, recipeID int, limit int) ([]model.SimilarRecipe, error) {
+	index, err := rs.currentIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.mutex.Lock()
+	results, ok := index.cache[recipeID]
+	rs.mutex.Unlock()
+
+	if !ok {
+		profile, ok := index.byID[recipeID]
+		if !ok {
+			return nil, model.ErrNotFound("recipe")
+		}
+		results = index.similar(profile)
+
+		rs.mutex.Lock()
+		index.cache[recipeID] = results
+		rs.mutex.Unlock()
+	}
+
+	if limit > len(results) {
+		limit = len(results)
+	}
+
+	return append([]model.SimilarRecipe{}, results[:limit]...), nil
+}
+
+// Techniques returns the cooking techniques a procedure uses, such as "baking" or "simmering", in
+// alphabetical order.
+func Techniques(procedure []string) []string {
+	found := make(map[string]bool)
+
+	for _, step := range procedure {
+		words := strings.FieldsFunc(strings.ToLower(step), func(r rune) bool {
+			return !unicode.IsLetter(r) && r != '-'
+		})
+		for _, word := range words {
+			if technique, ok := techniqueKeywords[word]; ok {
+				found[technique] = true
+			}
+		}
+	}
+
+	return sortedKeys(found)
+}
+
+// private functions
+
+// currentIndex returns the recommendation index, rebuilding it if it was invalidated or is out of date. If
+// another request is already rebuilding it, it waits for that rebuild. An index loaded while the service was
+// invalidated is used for this request but not kept.
+func (rs *RecommendationService) currentIndex(ctx context.Context) (*recommendationIndex, error) {
+	for {
+		rs.mutex.Lock()
+		if rs.index != nil && time.Since(rs.index.builtAt) <= recommendationIndexTTL {
+			index := rs.index
+			rs.mutex.Unlock()
+			return index, nil
+		}
+
+		if rs.rebuilding != nil {
+			rebuilding := rs.rebuilding
+			rs.mutex.Unlock()
+
+			select {
+			case <-rebuilding:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		rebuilding := make(chan struct{})
+		rs.rebuilding = rebuilding
+		generation := rs.generation
+		rs.mutex.Unlock()
+
+		var index *recommendationIndex
+		recipes, err := rs.load(ctx)
+		if err == nil {
+			index = buildRecommendationIndex(recipes)
+		}
+
+		rs.mutex.Lock()
+		rs.rebuilding = nil
+		close(rebuilding)
+		if err == nil && generation == rs.generation {
+			rs.index = index
+		}
+		rs.mutex.Unlock()
+
+		return index, err
+	}
+}
+
+// buildRecommendationIndex works out the profile of every recipe and how common each ingredient is.
+func buildRecommendationIndex(recipes []*model.Recipe) *recommendationIndex {
+	index := &recommendationIndex{
+		profiles: make([]*recipeProfile, 0, len(recipes)),
+		byID:     make(map[int]*recipeProfile, len(recipes)),
+		weights:  make(map[string]float64),
+		builtAt:  time.Now(),
+		cache:    make(map[int][]model.SimilarRecipe),
+	}
+
+	counts := make(map[string]int)
+
+	for _, recipe := range recipes {
+		profile := &recipeProfile{
+			id:          recipe.ID,
+			name:        recipe.RecipeName,
+			ingredients: ingredientSet(recipe),
+			tags:        make(map[string]bool),
+			techniques:  make(map[string]bool),
+			minutes:     recipe.PrepTimeMinutes + recipe.CookTimeMinutes,
+		}
+		for _, tag := range model.NormalizeTags(recipe.Tags) {
+			profile.tags[tag] = true
+		}
+		for _, technique := range Techniques(recipe.Procedure) {
+			profile.techniques[technique] = true
+		}
+		for name := range profile.ingredients {
+			counts[name]++
+		}
+
+		index.profiles = append(index.profiles, profile)
+		index.byID[profile.id] = profile
+	}
+
+	for name, count := range counts {
+		index.weights[name] = math.Log(1 + float64(len(recipes))/float64(count))
+	}
+
+	return index
+}
+
+// similar scores every other recipe against a profile and returns the best, up to MaxSimilarRecipes.
+func (ix *recommendationIndex) similar(profile *recipeProfile) []model.SimilarRecipe {
+	results := []model.SimilarRecipe{}
+
+	for _, other := range ix.profiles {
+		if other.id == profile.id {
+			continue
+		}
+		if result, ok := ix.compare(profile, other); ok {
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if len(results) > MaxSimilarRecipes {
+		results = results[:MaxSimilarRecipes]
+	}
+
+	return results
+}
+
+// compare scores a recipe against another and explains the score.
+// It returns false if the recipes have no ingredient, tag or technique in common.
+func (ix *recommendationIndex) compare(profile *recipeProfile, other *recipeProfile) (model.SimilarRecipe, bool) {
+	result := model.SimilarRecipe{
+		RecipeId:          other.id,
+		RecipeName:        other.name,
+		SharedIngredients: sharedKeys(profile.ingredients, other.ingredients),
+		SharedTags:        sharedKeys(profile.tags, other.tags),
+		SharedTechniques:  sharedKeys(profile.techniques, other.techniques),
+	}
+
+	if len(result.SharedIngredients) == 0 && len(result.SharedTags) == 0 && len(result.SharedTechniques) == 0 {
+		return result, false
+	}
+
+	// less common ingredients say more about a recipe, so list them first.
+	sort.SliceStable(result.SharedIngredients, func(i, j int) bool {
+		return ix.weights[result.SharedIngredients[i]] > ix.weights[result.SharedIngredients[j]]
+	})
+
+	score := 0.0
+	total := similarIngredientWeight
+
+	result.Components.Ingredients = RoundAmount(ix.weightedJaccard(profile.ingredients, other.ingredients))
+	score += similarIngredientWeight * result.Components.Ingredients
+
+	if len(profile.tags) > 0 && len(other.tags) > 0 {
+		tags := RoundAmount(jaccard(profile.tags, other.tags))
+		result.Components.Tags = &tags
+		score += similarTagWeight * tags
+		total += similarTagWeight
+	}
+
+	if len(profile.techniques) > 0 && len(other.techniques) > 0 {
+		techniques := RoundAmount(jaccard(profile.techniques, other.techniques))
+		result.Components.Techniques = &techniques
+		score += similarTechniqueWeight * techniques
+		total += similarTechniqueWeight
+	}
+
+	if profile.minutes > 0 && other.minutes > 0 {
+		difference := math.Abs(float64(profile.minutes - other.minutes))
+		closeness := RoundAmount(1 - difference/math.Max(float64(profile.minutes), float64(other.minutes)))
+		result.Components.Time = &closeness
+		score += similarTimeWeight * closeness
+		total += similarTimeWeight
+	}
+
+	result.Score = RoundAmount(score / total)
+	result.Because = because(result, profile.minutes, other.minutes)
+
+	return result, true
+}
+
+// weightedJaccard is the Jaccard overlap of two ingredient sets with each ingredient weighted by how rare it is.
+func (ix *recommendationIndex) weightedJaccard(a map[string]bool, b map[string]bool) float64 {
+	shared, union := 0.0, 0.0
+
+	for name := range a {
+		union += ix.weights[name]
+		if b[name] {
+			shared += ix.weights[name]
+		}
+	}
+	for name := range b {
+		if !a[name] {
+			union += ix.weights[name]
+		}
+	}
+
+	if union == 0 {
+		return 0
+	}
+	return shared / union
+}
+
+// because explains a similar recipe, such as "because it shares lemon, thyme and chicken thigh, is also tagged
+// dinner, also involves roasting, and takes about as long (45 vs 50 minutes)".
+func because(result model.SimilarRecipe, minutes int, otherMinutes int) string {
+	reasons := []string{}
+
+	if count := len(result.SharedIngredients); count > 0 {
+		named := result.SharedIngredients
+		if count > 3 {
+			named = append(append([]string{}, named[:3]...), fmt.Sprintf("%d more ingredients", count-3))
+		}
+		reasons = append(reasons, "it shares "+joinWithAnd(named))
+	}
+	if len(result.SharedTags) > 0 {
+		reasons = append(reasons, "is also tagged "+joinWithAnd(result.SharedTags))
+	}
+	if len(result.SharedTechniques) > 0 {
+		reasons = append(reasons, "also involves "+joinWithAnd(result.SharedTechniques))
+	}
+	if result.Components.Time != nil && *result.Components.Time >= 0.8 {
+		reasons = append(reasons, fmt.Sprintf("takes about as long (%d vs %d minutes)", otherMinutes, minutes))
+	}
+
+	if len(reasons) > 0 && !strings.HasPrefix(reasons[0], "it ") {
+		reasons[0] = "it " + reasons[0]
+	}
+
+	return "because " + joinWithAnd(reasons)
+}
+
+// joinWithAnd joins words as a list in a sentence: "a", "a and b", "a, b and c".
+func joinWithAnd(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+// sharedKeys returns the keys two sets have in common, in alphabetical order.
+func sharedKeys(a map[string]bool, b map[string]bool) []string {
+	shared := make(map[string]bool)
+	for key := range a {
+		if b[key] {
+			shared[key] = true
+		}
+	}
+	return sortedKeys(shared)
+}
+
+// sortedKeys returns the keys of a set in alphabetical order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
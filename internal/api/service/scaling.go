@@ -0,0 +1,214 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// panSizeTolerance is how far apart two pan sizes or depths can be, as a ratio, and still count as the same.
+const panSizeTolerance = 0.05
+
+// Bake time adjustments for pans that are smaller or larger than the recipe's. A smaller pan heats through
+// to the center sooner and a larger one takes longer, even with the batter at the same depth.
+const (
+	smallerPanBakeFactor = 0.85
+	largerPanBakeFactor  = 1.15
+)
+
+// ScalingService scales recipes to a number of servings, a quantity of their yield, or different pans.
+type ScalingService struct{}
+
+// NewScalingService creates a new ScalingService.
+func NewScalingService() *ScalingService {
+	return &ScalingService{}
+}
+
+// Scale works out the scaling factor a request asks for and returns a copy of the recipe with its ingredient
+// amounts, servings and yield multiplied by it.
+//
+// Scaling by pan compares the recipe's pans with the new ones. By area, the batter stays the same depth, which
+// suits sheet cakes and brownies; by volume, the new pans are filled to the same level, which suits loaves and
+// deep cakes. Volume is used when both pans have a depth unless the request says otherwise. A bake time range
+// is suggested from the change in batter depth and pan size: deeper batter takes longer, from the square root
+// of the depth ratio to the full ratio, and a smaller pan can be done sooner and a larger one later.
+//
+// Parameters:
+//   - recipe: The recipe with its ingredients loaded
+//   - request: How to scale it; must be valid
+//
+// Returns:
+//   - model.ScaledRecipe: The scaled copy and how it was scaled
+//   - error: model.ErrInvalidField if the recipe has no servings, yield or pan to scale from
+func (ss *ScalingService) Scale(recipe *model.Recipe, request model.ScaleRequest) (model.ScaledRecipe, error) {
+	result := model.ScaledRecipe{
+		Mode:  request.Mode(),
+		Notes: []string{},
+	}
+
+	switch result.Mode {
+	case model.ScaleModeServings:
+		if recipe.Servings <= 0 {
+			return result, model.ErrInvalidField("servings")
+		}
+		result.Factor = float64(request.Servings) / float64(recipe.Servings)
+
+	case model.ScaleModeYield:
+		if recipe.Yield == nil {
+			return result, model.ErrInvalidField("yield")
+		}
+		result.Factor = request.Yield / recipe.Yield.Quantity
+
+	case model.ScaleModePan:
+		if recipe.Yield == nil || recipe.Yield.Pan == nil {
+			return result, model.ErrInvalidField("pan")
+		}
+
+		from, to := *recipe.Yield.Pan, *request.Pan
+
+		result.Basis = request.Basis
+		if result.Basis == "" {
+			result.Basis = model.ScaleBasisArea
+			if from.Depth > 0 && to.Depth > 0 {
+				result.Basis = model.ScaleBasisVolume
+			}
+		}
+
+		if result.Basis == model.ScaleBasisVolume {
+			if from.Depth <= 0 || to.Depth <= 0 {
+				return result, model.ErrInvalidField("basis")
+			}
+			result.Factor = to.Volume() / from.Volume()
+		} else {
+			result.Factor = to.Area() / from.Area()
+		}
+
+		if recipe.CookTimeMinutes > 0 {
+			result.BakeTime = bakeTimeRange(recipe.CookTimeMinutes, from, to, result.Basis)
+			result.Notes = append(result.Notes, fmt.Sprintf(
+				"Start checking for doneness at %d minutes; the recipe says %d minutes in the original pan.",
+				result.BakeTime.MinMinutes,
+				recipe.CookTimeMinutes,
+			))
+		}
+	}
+
+	result.Recipe = scaleRecipe(recipe, result.Factor, request.Pan)
+	result.Factor = math.Round(result.Factor*1000) / 1000
+
+	for _, ingredient := range result.Recipe.Ingredients {
+		unit := LookupUnit(ingredient.UnitOfMeasurement)
+		if unit.Kind == UnitKindCount && ingredient.Amount != math.Trunc(ingredient.Amount) {
+			result.Notes = append(result.Notes, fmt.Sprintf(
+				"%s comes to %g; round to a whole number, or beat or chop them and measure out part of one.",
+				ingredient.IngredientName,
+				ingredient.Amount,
+			))
+		}
+	}
+
+	if result.Factor > 2 || result.Factor < 0.5 {
+		result.Notes = append(result.Notes,
+			"Leavening, salt and spices don't always scale evenly this far; taste and adjust, and consider making separate batches.")
+	}
+
+	if result.Mode != model.ScaleModePan && recipe.Yield != nil && recipe.Yield.Pan != nil && math.Abs(result.Factor-1) > panSizeTolerance {
+		result.Notes = append(result.Notes, fmt.Sprintf(
+			"The recipe is baked in %s; scale by pan to work out the pans and bake time for this amount.",
+			describePan(*recipe.Yield.Pan),
+		))
+	}
+
+	return result, nil
+}
+
+// private functions
+
+// scaleRecipe returns a copy of a recipe with its ingredient amounts, servings and yield multiplied by factor.
+// The copy's yield uses pan when one is given.
+func scaleRecipe(recipe *model.Recipe, factor float64, pan *model.Pan) *model.Recipe {
+	scaled := *recipe
+
+	scaled.Ingredients = make([]model.Ingredient, len(recipe.Ingredients))
+	for i, ingredient := range recipe.Ingredients {
+		ingredient.Amount = RoundAmount(ingredient.Amount * factor)
+		scaled.Ingredients[i] = ingredient
+	}
+
+	if recipe.Servings > 0 {
+		scaled.Servings = int(math.Max(1, math.Round(float64(recipe.Servings)*factor)))
+	}
+
+	if recipe.Yield != nil {
+		yield := *recipe.Yield
+		yield.Quantity = RoundAmount(yield.Quantity * factor)
+
+		if pan != nil {
+			yield.Pan = pan
+			// a recipe that makes "1 pan" now makes however many pans it is baked in.
+			if Singular(strings.ToLower(yield.Unit)) == "pan" {
+				yield.Quantity = float64(pan.Pans())
+			}
+		}
+
+		scaled.Yield = &yield
+	}
+
+	return &scaled
+}
+
+// bakeTimeRange suggests how long a recipe takes to bake in new pans.
+func bakeTimeRange(cookMinutes int, from model.Pan, to model.Pan, basis string) *model.BakeTimeRange {
+	low, high := float64(cookMinutes), float64(cookMinutes)
+
+	// scaled by area the batter stays the same depth; by volume it is as deep, relative to the pan, as before.
+	if basis == model.ScaleBasisVolume {
+		depth := to.DepthInches() / from.DepthInches()
+		if math.Abs(depth-1) > panSizeTolerance {
+			low = float64(cookMinutes) * math.Min(math.Sqrt(depth), depth)
+			high = float64(cookMinutes) * math.Max(math.Sqrt(depth), depth)
+		}
+	}
+
+	size := to.AreaPerPan() / from.AreaPerPan()
+	if size < 1-panSizeTolerance {
+		low *= smallerPanBakeFactor
+	} else if size > 1+panSizeTolerance {
+		high *= largerPanBakeFactor
+	}
+
+	return &model.BakeTimeRange{
+		MinMinutes: int(math.Max(1, math.Round(low))),
+		MaxMinutes: int(math.Max(1, math.Round(high))),
+	}
+}
+
+// describePan names pans for notes, such as "one 9x13 in pan" or "two 9 in round pans".
+func describePan(pan model.Pan) string {
+	unit := pan.Unit
+	if unit == "" {
+		unit = model.PanUnitInch
+	}
+
+	count := fmt.Sprintf("%d", pan.Pans())
+	switch pan.Pans() {
+	case 1:
+		count = "one"
+	case 2:
+		count = "two"
+	case 3:
+		count = "three"
+	}
+
+	plural := ""
+	if pan.Pans() > 1 {
+		plural = "s"
+	}
+
+	if pan.Shape == model.PanShapeRound {
+		return fmt.Sprintf("%s %g %s round pan%s", count, pan.Diameter, unit, plural)
+	}
+	return fmt.Sprintf("%s %gx%g %s pan%s", count, pan.Width, pan.Length, unit, plural)
+}
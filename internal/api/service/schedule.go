@@ -0,0 +1,694 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"recipe-generator/internal/api/model"
+)
+
+// Kitchen sizes assumed when a ScheduleRequest doesn't give them.
+const (
+	defaultOvens   = 1
+	defaultBurners = 4
+	defaultCooks   = 1
+)
+
+// preheatMinutes is how long an oven takes to come up to temperature. The oven is held at the step's
+// temperature while it preheats, so no other dish can use it at another temperature then.
+const preheatMinutes = 15
+
+// defaultOvenTemperature is the temperature in °F assumed for oven steps that don't give one when their recipe
+// hasn't set one in an earlier step.
+const defaultOvenTemperature = 350
+
+// waitNoteMinutes is how long a dish has to wait between two of its steps before the schedule points it out.
+const waitNoteMinutes = 10
+
+// minimumEstimatedMinutes is the shortest duration estimated for a step.
+const minimumEstimatedMinutes = 5
+
+// estimatedStepMinutes is how long a step with no duration is assumed to take when its recipe has no prep or
+// cook time to share out among its steps.
+var estimatedStepMinutes = map[string]int{
+	model.StepResourceOven:     30,
+	model.StepResourceStovetop: 15,
+	model.StepResourceHands:    10,
+	model.StepResourcePassive:  15,
+}
+
+// stepResourceWords are words in a step's text that say which resource it uses, checked in order.
+var stepResourceWords = []struct {
+	resource string
+	words    []string
+}{
+	{model.StepResourceOven, []string{"bake", "baked", "roast", "broil", "oven"}},
+	{model.StepResourceStovetop, []string{"simmer", "boil", "saute", "sauté", "fry", "sear", "brown", "stovetop", "skillet", "saucepan", "pot", "wok"}},
+	{model.StepResourcePassive, []string{"rest", "chill", "refrigerate", "marinate", "rise", "proof", "cool", "soak", "stand"}},
+}
+
+// stepDurationPattern matches durations in a step's text, such as "25 minutes", "1 hour" or "20-25 mins".
+var stepDurationPattern = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)(?:\s*(?:-|–|to)\s*(\d+(?:\.\d+)?))?\s*(hours?|hrs?|minutes?|mins?)\b`)
+
+// stepClausePattern matches where one clause of a step's text ends and the next begins, such as the ", then " of
+// "Rise 1 hour, then bake 30 minutes".
+var stepClausePattern = regexp.MustCompile(`(?i)[.;]\s+|,?\s+(?:and\s+)?then\s+`)
+
+// preheatPattern matches a clause about preheating the oven.
+var preheatPattern = regexp.MustCompile(`(?i)\bpre-?heat`)
+
+// ovenTemperaturePattern matches oven temperatures in a step's text, such as "425°F", "220 C" or "350 degrees F".
+var ovenTemperaturePattern = regexp.MustCompile(`(?i)(\d{3})\s*°?\s*(?:degrees\s*)?([fc])\b`)
+
+// SchedulingService plans cooking timelines for several recipes at once.
+type SchedulingService struct{}
+
+// NewSchedulingService creates a new SchedulingService.
+func NewSchedulingService() *SchedulingService {
+	return &SchedulingService{}
+}
+
+// scheduleStep is a procedure step being placed on a timeline.
+type scheduleStep struct {
+	recipe      *model.Recipe
+	step        int // From 1
+	instruction string
+	resource    string
+	temperature int
+	minutes     int
+	estimated   bool
+	preheat     bool            // Whether the step only preheats the oven; it takes no time of its own and is placed as passive
+	merged      bool            // Whether a preheat step was merged into the preheat added before the oven step it is for
+	dependsOn   []*scheduleStep // Steps that must finish before this one starts
+	dependents  []*scheduleStep // Steps that wait for this one
+	serve       bool            // Whether the step finishes one of the requested recipes
+	placed      bool
+	start       time.Time
+	end         time.Time
+}
+
+// Plan back-schedules the steps of several recipes from the serve time, so every recipe is ready when the
+// food is served and nothing starts earlier than it has to.
+//
+// Each step waits for the steps it depends on: the previous step unless its timing lists others. Recipes used
+// as components, such as a pizza's dough, are scheduled too and finish before the first step of the recipe
+// that uses them. Durations, resources and oven temperatures come from the steps' timings, then from their
+// text, such as "bake at 425°F for 25 minutes"; steps without a duration share out the recipe's prep or cook
+// time, or are estimated. An oven step that gives no temperature uses the last one its recipe set, and a
+// recipe's own "preheat the oven" step becomes the preheat added before its next oven step.
+//
+// The kitchen limits what can happen at once: each oven holds one temperature at a time, including while it
+// preheats, though dishes at the same temperature share it; each burner holds one pot; and each cook does one
+// hands-on step at a time. Steps are placed latest first, each as late as the steps after it and the kitchen
+// allow, so a dish that can't finish at the serve time finishes earlier and is noted.
+//
+// Parameters:
+//   - recipes: The recipes to cook, with their procedures loaded
+//   - components: The recipes they use as components, as returned by ComponentService.LoadComponents
+//   - request: The serve time and kitchen; must be valid
+//
+// Returns:
+//   - model.Schedule: The timeline
+//   - error: model.ErrInvalidField if the recipes' steps depend on each other in a cycle
+func (ss *SchedulingService) Plan(recipes []*model.Recipe, components map[int]*model.Recipe, request model.ScheduleRequest) (model.Schedule, error) {
+	schedule := model.Schedule{
+		ServeTime: request.ServeTime,
+		StartTime: request.ServeTime,
+		Steps:     []model.ScheduledStep{},
+		Notes:     []string{},
+	}
+
+	ovens := positiveOr(request.Ovens, defaultOvens)
+	burners := positiveOr(request.Burners, defaultBurners)
+	cooks := positiveOr(request.Cooks, defaultCooks)
+
+	// every recipe in the tree, requested ones first, each scheduled once however often it is used.
+	order := []*model.Recipe{}
+	groups := make(map[int][]*scheduleStep)
+	requested := make(map[int]bool)
+
+	var add func(recipe *model.Recipe)
+	add = func(recipe *model.Recipe) {
+		if _, ok := groups[recipe.ID]; ok {
+			return
+		}
+		groups[recipe.ID] = recipeSteps(recipe)
+		order = append(order, recipe)
+
+		for _, ingredient := range recipe.Ingredients {
+			if component, ok := components[componentID(ingredient)]; ok {
+				add(component)
+			}
+		}
+	}
+
+	for _, recipe := range recipes {
+		requested[recipe.ID] = true
+	}
+	for _, recipe := range recipes {
+		add(recipe)
+	}
+
+	all := []*scheduleStep{}
+	for _, recipe := range order {
+		steps := groups[recipe.ID]
+		if len(steps) == 0 {
+			if requested[recipe.ID] {
+				schedule.Notes = append(schedule.Notes, fmt.Sprintf("%s has no procedure, so it isn't on the timeline.", recipe.RecipeName))
+			}
+			continue
+		}
+
+		all = append(all, steps...)
+
+		for _, step := range finalSteps(steps) {
+			step.serve = requested[recipe.ID]
+		}
+
+		// a component is finished before the recipe that uses it starts.
+		for _, ingredient := range recipe.Ingredients {
+			componentSteps := groups[componentID(ingredient)]
+			if len(componentSteps) == 0 || componentID(ingredient) == recipe.ID {
+				continue
+			}
+			for _, last := range finalSteps(componentSteps) {
+				link(last, steps[0])
+			}
+		}
+	}
+
+	estimated := 0
+	for _, step := range all {
+		if step.estimated {
+			estimated++
+		}
+	}
+
+	placed := []*scheduleStep{}
+
+	for len(placed) < len(all) {
+		var next *scheduleStep
+		var nextLatest time.Time
+
+		for _, step := range all {
+			if step.placed || !dependentsPlaced(step) {
+				continue
+			}
+			latest := latestEnd(step, request.ServeTime)
+			if next == nil || latest.After(nextLatest) || (latest.Equal(nextLatest) && step.minutes > next.minutes) {
+				next, nextLatest = step, latest
+			}
+		}
+
+		if next == nil {
+			return schedule, model.ErrInvalidField("recipeIds: the recipes' steps depend on each other in a cycle")
+		}
+
+		next.end = latestFeasibleEnd(next, nextLatest, placed, ovens, burners, cooks)
+		next.start = next.end.Add(-time.Duration(next.minutes) * time.Minute)
+		next.placed = true
+		placed = append(placed, next)
+	}
+
+	preheats := preheatSteps(all)
+
+	for _, step := range all {
+		if step.merged {
+			continue
+		}
+		resource := step.resource
+		if step.preheat {
+			// a preheat step left over is for an oven that is already hot for another dish.
+			resource = model.StepResourceOven
+		}
+		schedule.Steps = append(schedule.Steps, model.ScheduledStep{
+			RecipeId:        step.recipe.ID,
+			RecipeName:      step.recipe.RecipeName,
+			Step:            step.step,
+			Instruction:     step.instruction,
+			Resource:        resource,
+			OvenTemperature: step.temperature,
+			DurationMinutes: step.minutes,
+			Estimated:       step.estimated,
+			Start:           step.start,
+			End:             step.end,
+		})
+	}
+	schedule.Steps = append(schedule.Steps, preheats...)
+
+	sort.SliceStable(schedule.Steps, func(i, j int) bool {
+		return schedule.Steps[i].Start.Before(schedule.Steps[j].Start)
+	})
+
+	if len(schedule.Steps) > 0 {
+		schedule.StartTime = schedule.Steps[0].Start
+	}
+
+	for _, recipe := range order {
+		if !requested[recipe.ID] {
+			continue
+		}
+		var ready time.Time
+		for _, step := range finalSteps(groups[recipe.ID]) {
+			if step.end.After(ready) {
+				ready = step.end
+			}
+		}
+		if early := request.ServeTime.Sub(ready); !ready.IsZero() && early >= time.Minute {
+			schedule.Notes = append(schedule.Notes, fmt.Sprintf(
+				"%s is ready %d minutes before serving to make room in the kitchen; keep it warm.",
+				recipe.RecipeName,
+				int(early.Minutes()),
+			))
+		}
+	}
+
+	for _, step := range all {
+		for _, dependent := range step.dependents {
+			wait := dependent.start.Sub(step.end)
+			if dependent.recipe == step.recipe && wait >= waitNoteMinutes*time.Minute {
+				schedule.Notes = append(schedule.Notes, fmt.Sprintf(
+					"%s waits %d minutes between steps %d and %d to make room in the kitchen.",
+					step.recipe.RecipeName,
+					int(wait.Minutes()),
+					step.step,
+					dependent.step,
+				))
+			}
+		}
+	}
+
+	if estimated > 0 {
+		schedule.Notes = append(schedule.Notes, fmt.Sprintf(
+			"%d of the steps have estimated durations; add step timings to the recipes for a more accurate schedule.",
+			estimated,
+		))
+	}
+
+	return schedule, nil
+}
+
+// private functions
+
+// recipeSteps turns a recipe's procedure into steps, with each step's timing worked out from its StepTiming,
+// its text, or an estimate, and each step depending on the previous one unless its timing says otherwise.
+// Oven steps without a temperature use the last one set by an earlier oven or preheat step.
+func recipeSteps(recipe *model.Recipe) []*scheduleStep {
+	steps := make([]*scheduleStep, len(recipe.Procedure))
+	temperature := defaultOvenTemperature
+
+	for i, instruction := range recipe.Procedure {
+		var timing model.StepTiming
+		if i < len(recipe.StepTimings) {
+			timing = recipe.StepTimings[i]
+		}
+
+		step := &scheduleStep{
+			recipe:      recipe,
+			step:        i + 1,
+			instruction: instruction,
+			resource:    timing.Resource,
+			temperature: timing.OvenTemperature,
+			minutes:     timing.DurationMinutes,
+		}
+
+		if step.resource == "" {
+			step.resource = stepResource(instruction)
+		}
+		if step.minutes == 0 && (timing.Resource == "" || timing.Resource == model.StepResourceOven) && isPreheat(instruction) {
+			step.preheat = true
+			step.resource = model.StepResourcePassive
+		}
+
+		if step.resource == model.StepResourceOven || step.preheat {
+			if step.temperature == 0 {
+				step.temperature = ovenTemperature(instruction)
+			}
+			if step.temperature == 0 {
+				step.temperature = temperature
+			}
+			temperature = step.temperature
+		}
+
+		if step.minutes == 0 && !step.preheat {
+			step.minutes = stepMinutes(instruction, step.resource)
+		}
+
+		if len(timing.DependsOn) > 0 {
+			for _, dependency := range timing.DependsOn {
+				if dependency >= 1 && dependency <= i {
+					link(steps[dependency-1], step)
+				}
+			}
+		} else if i > 0 {
+			link(steps[i-1], step)
+		}
+
+		steps[i] = step
+	}
+
+	estimateMinutes(recipe, steps)
+
+	return steps
+}
+
+// estimateMinutes gives the steps without a duration a share of the recipe's cook time, for oven and stovetop
+// steps, or its prep time, for hands-on steps, after what the other steps already take. Steps that get no share
+// use estimatedStepMinutes.
+func estimateMinutes(recipe *model.Recipe, steps []*scheduleStep) {
+	// oven and stovetop steps share the cook time, hands-on steps the prep time, and passive steps neither.
+	timeFor := func(resource string) string {
+		switch resource {
+		case model.StepResourceOven, model.StepResourceStovetop:
+			return "cook"
+		case model.StepResourceHands:
+			return "prep"
+		}
+		return ""
+	}
+	totals := map[string]int{"cook": recipe.CookTimeMinutes, "prep": recipe.PrepTimeMinutes}
+	known := make(map[string]int)
+	unknown := make(map[string]int)
+
+	for _, step := range steps {
+		if step.preheat {
+			continue
+		}
+		if step.minutes > 0 {
+			known[timeFor(step.resource)] += step.minutes
+		} else {
+			unknown[timeFor(step.resource)]++
+		}
+	}
+
+	for _, step := range steps {
+		if step.minutes > 0 || step.preheat {
+			continue
+		}
+		step.estimated = true
+
+		share := timeFor(step.resource)
+		if remaining := totals[share] - known[share]; share != "" && remaining > 0 {
+			step.minutes = int(math.Max(minimumEstimatedMinutes, math.Round(float64(remaining)/float64(unknown[share]))))
+		} else {
+			step.minutes = estimatedStepMinutes[step.resource]
+		}
+	}
+}
+
+// stepResource works out which resource a step uses from the words in its text; steps that mention none are
+// hands-on.
+func stepResource(instruction string) string {
+	words := strings.FieldsFunc(strings.ToLower(instruction), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && r != 'é'
+	})
+
+	for _, candidate := range stepResourceWords {
+		for _, word := range words {
+			for _, keyword := range candidate.words {
+				if word == keyword || word == keyword+"s" || word == keyword+"ing" || word == keyword+"ed" {
+					return candidate.resource
+				}
+			}
+		}
+	}
+
+	return model.StepResourceHands
+}
+
+// stepMinutes reads how long a step uses its resource from its text: the durations in the clauses about that
+// resource, such as the 30 minutes of "Rise 1-2 hours, then bake 30 minutes" for an oven step, or if they give
+// none, the durations in the clauses about no resource in particular. It returns 0 if the text gives no
+// duration for the step.
+func stepMinutes(instruction string, resource string) int {
+	matching, neutral := 0, 0
+
+	for _, clause := range stepClausePattern.Split(instruction, -1) {
+		switch stepResource(clause) {
+		case resource:
+			matching += durationMinutes(clause)
+		case model.StepResourceHands:
+			neutral += durationMinutes(clause)
+		}
+	}
+
+	if matching > 0 {
+		return matching
+	}
+	return neutral
+}
+
+// durationMinutes adds up the durations in text, using the longer end of ranges such as "20-25 minutes".
+func durationMinutes(text string) int {
+	minutes := 0.0
+
+	for _, match := range stepDurationPattern.FindAllStringSubmatch(text, -1) {
+		amount, _ := strconv.ParseFloat(match[1], 64)
+		if match[2] != "" {
+			amount, _ = strconv.ParseFloat(match[2], 64)
+		}
+		if strings.HasPrefix(strings.ToLower(match[3]), "h") {
+			amount *= 60
+		}
+		minutes += amount
+	}
+
+	return int(math.Round(minutes))
+}
+
+// isPreheat reports whether a step only preheats the oven: it mentions preheating, and no other clause of it
+// is about the oven, as the "roast for 25 minutes" of "Preheat the oven to 400°F. Roast for 25 minutes." is.
+func isPreheat(instruction string) bool {
+	preheat := false
+
+	for _, clause := range stepClausePattern.Split(instruction, -1) {
+		switch {
+		case preheatPattern.MatchString(clause):
+			preheat = true
+		case stepResource(clause) == model.StepResourceOven:
+			return false
+		}
+	}
+
+	return preheat
+}
+
+// ovenTemperature reads the oven temperature in °F from a step's text, converting °C, or returns 0 if the text
+// gives none.
+func ovenTemperature(instruction string) int {
+	match := ovenTemperaturePattern.FindStringSubmatch(instruction)
+	if match == nil {
+		return 0
+	}
+
+	degrees, _ := strconv.Atoi(match[1])
+	if strings.EqualFold(match[2], "c") {
+		return int(math.Round(float64(degrees)*9/5 + 32))
+	}
+	return degrees
+}
+
+// link makes step wait for dependency.
+func link(dependency *scheduleStep, step *scheduleStep) {
+	step.dependsOn = append(step.dependsOn, dependency)
+	dependency.dependents = append(dependency.dependents, step)
+}
+
+// finalSteps returns the steps of a recipe that no other step of it waits for, which finish the recipe.
+func finalSteps(steps []*scheduleStep) []*scheduleStep {
+	final := []*scheduleStep{}
+
+	for _, step := range steps {
+		last := true
+		for _, dependent := range step.dependents {
+			if dependent.recipe == step.recipe {
+				last = false
+				break
+			}
+		}
+		if last {
+			final = append(final, step)
+		}
+	}
+
+	return final
+}
+
+// dependentsPlaced reports whether every step waiting for step is already on the timeline.
+func dependentsPlaced(step *scheduleStep) bool {
+	for _, dependent := range step.dependents {
+		if !dependent.placed {
+			return false
+		}
+	}
+	return true
+}
+
+// latestEnd returns the latest a step can finish: before the steps that wait for it start, and by the serve
+// time if it finishes a requested recipe.
+func latestEnd(step *scheduleStep, serveTime time.Time) time.Time {
+	latest := time.Time{}
+	if step.serve || len(step.dependents) == 0 {
+		latest = serveTime
+	}
+
+	for _, dependent := range step.dependents {
+		if latest.IsZero() || dependent.start.Before(latest) {
+			latest = dependent.start
+		}
+	}
+
+	return latest
+}
+
+// latestFeasibleEnd returns the latest time no later than latest that a step can finish without overfilling
+// the kitchen. A step can always be moved to finish as the earliest conflicting step starts, so the candidates
+// are latest and the start times of the steps already placed.
+func latestFeasibleEnd(step *scheduleStep, latest time.Time, placed []*scheduleStep, ovens int, burners int, cooks int) time.Time {
+	if step.resource == model.StepResourcePassive {
+		return latest
+	}
+
+	candidates := []time.Time{latest}
+	for _, other := range placed {
+		if other.resource == step.resource && other.start.Before(latest) {
+			candidates = append(candidates, other.start)
+			// an oven step's end can butt up against another's preheat.
+			if step.resource == model.StepResourceOven {
+				candidates = append(candidates, occupiedFrom(other))
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].After(candidates[j])
+	})
+
+	for _, end := range candidates {
+		if end.After(latest) {
+			continue
+		}
+		if fits(step, end, placed, ovens, burners, cooks) {
+			return end
+		}
+	}
+
+	return candidates[len(candidates)-1]
+}
+
+// fits reports whether a step finishing at end leaves enough ovens, burners or cooks for the steps already
+// placed that overlap it.
+func fits(step *scheduleStep, end time.Time, placed []*scheduleStep, ovens int, burners int, cooks int) bool {
+	start := end.Add(-time.Duration(step.minutes) * time.Minute)
+	if step.resource == model.StepResourceOven {
+		start = start.Add(-preheatMinutes * time.Minute)
+	}
+
+	overlapping := 0
+	temperatures := map[int]bool{step.temperature: true}
+
+	for _, other := range placed {
+		if other.resource != step.resource {
+			continue
+		}
+		otherStart := other.start
+		if other.resource == model.StepResourceOven {
+			otherStart = occupiedFrom(other)
+		}
+		if !otherStart.Before(end) || !start.Before(other.end) {
+			continue
+		}
+		overlapping++
+		temperatures[other.temperature] = true
+	}
+
+	switch step.resource {
+	case model.StepResourceOven:
+		return len(temperatures) <= ovens
+	case model.StepResourceStovetop:
+		return overlapping < burners
+	default:
+		return overlapping < cooks
+	}
+}
+
+// occupiedFrom returns when an oven step starts holding the oven at its temperature, which is when it starts
+// preheating.
+func occupiedFrom(step *scheduleStep) time.Time {
+	return step.start.Add(-preheatMinutes * time.Minute)
+}
+
+// preheatSteps adds a step to preheat the oven before each oven step, unless the oven is already at the step's
+// temperature for another dish. The recipe's own step to preheat the oven to that temperature, if it has one
+// before the oven step, becomes the added step and is marked merged.
+func preheatSteps(steps []*scheduleStep) []model.ScheduledStep {
+	preheats := []model.ScheduledStep{}
+
+	for _, step := range steps {
+		if step.resource != model.StepResourceOven {
+			continue
+		}
+
+		hot := false
+		for _, other := range steps {
+			if other != step && other.resource == model.StepResourceOven && other.temperature == step.temperature &&
+				other.start.Before(step.start) && !other.end.Before(step.start) {
+				hot = true
+				break
+			}
+		}
+		if hot {
+			continue
+		}
+
+		preheat := model.ScheduledStep{
+			RecipeId:        step.recipe.ID,
+			RecipeName:      step.recipe.RecipeName,
+			Instruction:     fmt.Sprintf("Preheat the oven to %d°F", step.temperature),
+			Resource:        model.StepResourceOven,
+			OvenTemperature: step.temperature,
+			DurationMinutes: preheatMinutes,
+			Start:           occupiedFrom(step),
+			End:             step.start,
+		}
+
+		// the closest of the recipe's own preheat steps before this one.
+		var own *scheduleStep
+		for _, other := range steps {
+			if other.preheat && !other.merged && other.recipe == step.recipe && other.temperature == step.temperature &&
+				other.step < step.step && (own == nil || other.step > own.step) {
+				own = other
+			}
+		}
+		if own != nil {
+			own.merged = true
+			preheat.Step = own.step
+			preheat.Instruction = own.instruction
+		}
+
+		preheats = append(preheats, preheat)
+	}
+
+	return preheats
+}
+
+// componentID returns the component recipe an ingredient uses, or 0 if it is a plain ingredient.
+func componentID(ingredient model.Ingredient) int {
+	if !ingredient.IsComponent() {
+		return 0
+	}
+	return *ingredient.ComponentRecipeId
+}
+
+// positiveOr returns value, or fallback if value isn't positive.
+func positiveOr(value int, fallback int) int {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
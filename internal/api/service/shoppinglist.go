@@ -0,0 +1,136 @@
+package service
+
+import (
+	"sort"
+
+	"recipe-generator/internal/api/model"
+)
+
+// RecipePortion is a recipe and the number of servings of it to make.
+type RecipePortion struct {
+	Recipe   *model.Recipe // Recipe with its ingredients loaded
+	Servings int           // Servings to make, 0 to use the recipe's own servings
+}
+
+// Scale returns the factor to multiply the recipe's ingredient amounts by.
+// Recipes without a servings count can't be scaled, so they are made as written.
+func (p RecipePortion) Scale() float64 {
+	if p.Servings <= 0 || p.Recipe.Servings <= 0 {
+		return 1
+	}
+	return float64(p.Servings) / float64(p.Recipe.Servings)
+}
+
+// ShoppingListService builds consolidated shopping lists from recipes.
+type ShoppingListService struct{}
+
+// NewShoppingListService creates a new ShoppingListService.
+func NewShoppingListService() *ShoppingListService {
+	return &ShoppingListService{}
+}
+
+// shoppingLine accumulates the amount of an ingredient in one kind of unit.
+type shoppingLine struct {
+	item       model.ShoppingListItem
+	unit       Unit    // Largest unit the recipes used, which the total is shown in
+	baseAmount float64 // Total in millilitres, grams or the count unit
+	recipeIDs  map[int]bool
+}
+
+// Build merges the ingredients of the portions into a shopping list.
+// Ingredients with the same name are merged when their units convert into one another,
+// so 1 cup and 4 tbsp of milk become 1.25 cups; an ingredient measured in units that don't convert,
+// such as cups and grams of flour, gets a line for each.
+// Totals are shown in the largest unit any of the recipes used, and each line lists the recipes that need it.
+//
+// Parameters:
+//   - portions: The recipes to shop for, with their ingredients loaded
+//
+// Returns:
+//   - model.ShoppingList: The list grouped by aisle
+func (ss *ShoppingListService) Build(portions []RecipePortion) model.ShoppingList {
+	lines := make(map[string][]*shoppingLine)
+	// names in the order they were first seen, so items with the same name sort stably.
+	names := []string{}
+
+	for _, portion := range portions {
+		scale := portion.Scale()
+
+		for _, ingredient := range portion.Recipe.Ingredients {
+			name := CanonicalIngredientName(ingredient.IngredientName)
+			if name == "" {
+				continue
+			}
+
+			unit := LookupUnit(ingredient.UnitOfMeasurement)
+			line := findLine(lines[name], unit)
+
+			if line == nil {
+				if len(lines[name]) == 0 {
+					names = append(names, name)
+				}
+
+				line = &shoppingLine{
+					item: model.ShoppingListItem{
+						IngredientName: name,
+						Aisle:          AisleFor(name),
+						Recipes:        []model.ShoppingListSource{},
+					},
+					unit:      unit,
+					recipeIDs: make(map[int]bool),
+				}
+				lines[name] = append(lines[name], line)
+			}
+
+			if unit.Size > line.unit.Size {
+				line.unit = unit
+			}
+			line.baseAmount += ingredient.Amount * scale * unit.Size
+
+			if !line.recipeIDs[portion.Recipe.ID] {
+				line.recipeIDs[portion.Recipe.ID] = true
+				line.item.Recipes = append(line.item.Recipes, model.ShoppingListSource{
+					RecipeId:   portion.Recipe.ID,
+					RecipeName: portion.Recipe.RecipeName,
+				})
+			}
+		}
+	}
+
+	byAisle := make(map[string][]model.ShoppingListItem)
+
+	for _, name := range names {
+		for _, line := range lines[name] {
+			line.item.Amount = RoundAmount(line.baseAmount / line.unit.Size)
+			line.item.Unit = line.unit.Name
+			byAisle[line.item.Aisle] = append(byAisle[line.item.Aisle], line.item)
+		}
+	}
+
+	list := model.ShoppingList{Aisles: []model.ShoppingListAisle{}}
+
+	for _, aisle := range Aisles {
+		items := byAisle[aisle]
+		if len(items) == 0 {
+			continue
+		}
+
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].IngredientName < items[j].IngredientName
+		})
+
+		list.Aisles = append(list.Aisles, model.ShoppingListAisle{Aisle: aisle, Items: items})
+	}
+
+	return list
+}
+
+// findLine returns the line an amount in unit can be added to, or nil if there isn't one.
+func findLine(lines []*shoppingLine, unit Unit) *shoppingLine {
+	for _, line := range lines {
+		if line.unit.Convertible(unit) {
+			return line
+		}
+	}
+	return nil
+}
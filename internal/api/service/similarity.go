@@ -0,0 +1,321 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"recipe-generator/internal/api/model"
+)
+
+// Weights of the two parts of the duplicate score. The name counts for more because different recipes often
+// share most of their ingredients, such as pancakes and crepes.
+const (
+	duplicateNameWeight       = 0.6
+	duplicateIngredientWeight = 0.4
+)
+
+// DefaultDuplicateThreshold is the duplicate score at which two recipes are reported as likely duplicates.
+const DefaultDuplicateThreshold = 0.65
+
+// Trigrams returns the set of trigrams of a name the way PostgreSQL's pg_trgm extension makes them: lowercase
+// letters and digits only, each word padded with two spaces in front and one behind.
+func Trigrams(name string) map[string]bool {
+	trigrams := make(map[string]bool)
+
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			trigrams[string(padded[i:i+3])] = true
+		}
+	}
+
+	return trigrams
+}
+
+// NameSimilarity returns how alike two recipe names are, from 0 to 1, as the share of trigrams they have in
+// common. "Banana Bread" and "Banana bread (Mom's)" score about 0.65.
+func NameSimilarity(a string, b string) float64 {
+	return jaccard(Trigrams(a), Trigrams(b))
+}
+
+// IngredientSimilarity returns the Jaccard overlap of two recipes' ingredients, from 0 to 1, comparing
+// canonical ingredient names so "Eggs" and "egg" are the same ingredient.
+func IngredientSimilarity(a *model.Recipe, b *model.Recipe) float64 {
+	return jaccard(ingredientSet(a), ingredientSet(b))
+}
+
+// DuplicateScore scores how likely two recipes are to be the same recipe, combining name trigram similarity
+// with ingredient overlap. When either recipe has no ingredients only the names are compared.
+//
+// Returns:
+//   - model.DuplicateMatch: The scores, with the recipe set to b
+func DuplicateScore(a *model.Recipe, b *model.Recipe) model.DuplicateMatch {
+	match := model.DuplicateMatch{
+		RecipeId:       b.ID,
+		RecipeName:     b.RecipeName,
+		NameSimilarity: NameSimilarity(a.RecipeName, b.RecipeName),
+	}
+
+	if len(a.Ingredients) == 0 || len(b.Ingredients) == 0 {
+		match.Score = match.NameSimilarity
+	} else {
+		match.IngredientSimilarity = IngredientSimilarity(a, b)
+		match.Score = duplicateNameWeight*match.NameSimilarity + duplicateIngredientWeight*match.IngredientSimilarity
+	}
+
+	match.NameSimilarity = RoundAmount(match.NameSimilarity)
+	match.IngredientSimilarity = RoundAmount(match.IngredientSimilarity)
+	match.Score = RoundAmount(match.Score)
+
+	return match
+}
+
+// MinDuplicateNameSimilarity returns the lowest name similarity a recipe can have and still score threshold
+// as a duplicate, which it does only if its ingredients are the same. It is a little lower than that, as
+// scores are rounded, so recipes with less similar names can be left out before they are scored.
+func MinDuplicateNameSimilarity(threshold float64) float64 {
+	return math.Max(0, (threshold-duplicateIngredientWeight-0.01)/duplicateNameWeight)
+}
+
+// FindDuplicates returns the existing recipes that are likely duplicates of a recipe, most alike first.
+//
+// Parameters:
+//   - recipe: The recipe to check, with its ingredients
+//   - existing: The recipes to check it against, with their ingredients
+//   - threshold: The lowest score reported
+func FindDuplicates(recipe *model.Recipe, existing []*model.Recipe, threshold float64) []model.DuplicateMatch {
+	matches := []model.DuplicateMatch{}
+
+	for _, other := range existing {
+		if other.ID == recipe.ID && recipe.ID != 0 {
+			continue
+		}
+		if match := DuplicateScore(recipe, other); match.Score >= threshold {
+			matches = append(matches, match)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches
+}
+
+// DuplicateClusters groups recipes into clusters of likely duplicates. Two recipes are in the same cluster when
+// they score at least threshold against each other, or are both likely duplicates of a third.
+// Recipes without duplicates are left out; clusters are ordered by their lowest recipe ID.
+//
+// Parameters:
+//   - recipes: The recipes to group, with their ingredients, in ID order
+//   - threshold: The lowest score treated as a duplicate
+func DuplicateClusters(recipes []*model.Recipe, threshold float64) []model.DuplicateCluster {
+	parent := make([]int, len(recipes))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	pairs := make(map[int][]model.DuplicatePair)
+	trigrams := make([]map[string]bool, len(recipes))
+	for i, recipe := range recipes {
+		trigrams[i] = Trigrams(recipe.RecipeName)
+	}
+
+	for i := range recipes {
+		for j := i + 1; j < len(recipes); j++ {
+			nameSimilarity := jaccard(trigrams[i], trigrams[j])
+
+			// the score can't reach the threshold on ingredients alone, so skip the overlap when the names are too far apart.
+			if duplicateNameWeight*nameSimilarity+duplicateIngredientWeight < threshold {
+				continue
+			}
+
+			match := DuplicateScore(recipes[i], recipes[j])
+			if match.Score < threshold {
+				continue
+			}
+
+			root, other := find(i), find(j)
+			if root != other {
+				if other < root {
+					root, other = other, root
+				}
+				parent[other] = root
+				pairs[root] = append(pairs[root], pairs[other]...)
+				delete(pairs, other)
+			}
+
+			pairs[root] = append(pairs[root], model.DuplicatePair{
+				RecipeId:             recipes[i].ID,
+				OtherRecipeId:        recipes[j].ID,
+				Score:                match.Score,
+				NameSimilarity:       match.NameSimilarity,
+				IngredientSimilarity: match.IngredientSimilarity,
+			})
+		}
+	}
+
+	members := make(map[int][]model.DuplicateRecipe)
+	roots := []int{}
+	for i, recipe := range recipes {
+		root := find(i)
+		if _, ok := pairs[root]; !ok {
+			continue
+		}
+		if len(members[root]) == 0 {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], model.DuplicateRecipe{RecipeId: recipe.ID, RecipeName: recipe.RecipeName})
+	}
+
+	clusters := make([]model.DuplicateCluster, 0, len(roots))
+	for _, root := range roots {
+		clusters = append(clusters, model.DuplicateCluster{Recipes: members[root], Pairs: pairs[root]})
+	}
+
+	return clusters
+}
+
+// MergeRecipes combines duplicate recipes into one. The kept recipe's name and ID are used, with the most
+// complete ingredient list and procedure among the duplicates: the ingredient list with the most measured
+// ingredients, and the procedure with the most steps. Tags and equipment are combined, the longest description
+// is used, and missing times, servings, yield, attribution, notes, guidance, difficulty, cuisine or course are
+// filled in from the others.
+//
+// Parameters:
+//   - keep: The recipe to keep, with its ingredients, procedure and tags
+//   - duplicates: The recipes merged into it, with their ingredients, procedure and tags
+//
+// Returns:
+//   - *model.Recipe: The merged recipe
+func MergeRecipes(keep *model.Recipe, duplicates []*model.Recipe) *model.Recipe {
+	merged := *keep
+	candidates := append([]*model.Recipe{keep}, duplicates...)
+
+	best := keep
+	for _, candidate := range candidates[1:] {
+		if ingredientCompleteness(candidate) > ingredientCompleteness(best) {
+			best = candidate
+		}
+	}
+	merged.Ingredients = append([]model.Ingredient{}, best.Ingredients...)
+
+	best = keep
+	for _, candidate := range candidates[1:] {
+		if moreCompleteProcedure(candidate, best) {
+			best = candidate
+		}
+	}
+	merged.Procedure = append([]string{}, best.Procedure...)
+	merged.StepTimings = append([]model.StepTiming(nil), best.StepTimings...)
+
+	tags := []string{}
+	equipment := []string{}
+	for _, candidate := range candidates {
+		tags = append(tags, candidate.Tags...)
+		equipment = append(equipment, candidate.Equipment...)
+		if len(candidate.Description) > len(merged.Description) {
+			merged.Description = candidate.Description
+		}
+		if merged.PrepTimeMinutes == 0 {
+			merged.PrepTimeMinutes = candidate.PrepTimeMinutes
+		}
+		if merged.CookTimeMinutes == 0 {
+			merged.CookTimeMinutes = candidate.CookTimeMinutes
+		}
+		if merged.Servings == 0 {
+			merged.Servings = candidate.Servings
+		}
+		if merged.Yield == nil {
+			merged.Yield = candidate.Yield
+		}
+		if merged.Source == nil {
+			merged.Source = candidate.Source
+		}
+		fillMissing(&merged.Notes, candidate.Notes)
+		fillMissing(&merged.MakeAhead, candidate.MakeAhead)
+		fillMissing(&merged.Storage, candidate.Storage)
+		fillMissing(&merged.Freezing, candidate.Freezing)
+		fillMissing(&merged.Difficulty, candidate.Difficulty)
+		fillMissing(&merged.Cuisine, candidate.Cuisine)
+		fillMissing(&merged.Course, candidate.Course)
+	}
+	merged.Tags = model.NormalizeTags(tags)
+	sort.Strings(merged.Tags)
+	merged.Equipment = NormalizeEquipment(equipment)
+
+	return &merged
+}
+
+// private functions
+
+// fillMissing sets field to value if field is empty.
+func fillMissing(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// ingredientSet returns the canonical names of a recipe's ingredients.
+func ingredientSet(recipe *model.Recipe) map[string]bool {
+	set := make(map[string]bool, len(recipe.Ingredients))
+	for _, ingredient := range recipe.Ingredients {
+		if name := CanonicalIngredientName(ingredient.IngredientName); name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// jaccard returns the size of the intersection of two sets over the size of their union.
+// Two empty sets have nothing in common.
+func jaccard(a map[string]bool, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for key := range a {
+		if b[key] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// ingredientCompleteness ranks ingredient lists for merging: ingredients with an amount count double,
+// so a list with quantities beats a longer list without.
+func ingredientCompleteness(recipe *model.Recipe) int {
+	score := 0
+	for _, ingredient := range recipe.Ingredients {
+		score++
+		if ingredient.Amount > 0 {
+			score++
+		}
+	}
+	return score
+}
+
+// moreCompleteProcedure reports whether a's procedure is more complete than b's: more steps, or as many
+// steps with more written in them.
+func moreCompleteProcedure(a *model.Recipe, b *model.Recipe) bool {
+	if len(a.Procedure) != len(b.Procedure) {
+		return len(a.Procedure) > len(b.Procedure)
+	}
+	return len(strings.Join(a.Procedure, "")) > len(strings.Join(b.Procedure, ""))
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"sort"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// siteTemplates are the templates and static files of the static HTML site.
+//
+//go:embed templates/site
+var siteTemplates embed.FS
+
+// siteLink is a link to a page of the static site, with how many recipes it lists if it is a tag or cuisine.
+type siteLink struct {
+	Name  string
+	Url   string // Path from the site's root
+	Count int
+}
+
+// siteRecipe is what the static site's pages show about a recipe.
+type siteRecipe struct {
+	Name        string
+	Description string
+	Url         string // Path of the recipe's page from the site's root
+	Photo       string // Path of the recipe's photo from the site's root, if it has one
+	TotalTime   string
+	Tags        []siteLink
+	Cuisine     *siteLink
+}
+
+// sitePage is what a template of the static site is executed with.
+type sitePage struct {
+	Root      string // Path from the page to the site's root, such as "../"
+	SiteTitle string
+	Title     string
+	Parent    siteLink         // Index the page is listed on, for tag and cuisine pages
+	Recipes   []siteRecipe     // Recipes the page lists
+	Groups    []siteLink       // Tags or cuisines the page lists
+	Recipe    siteRecipe       // Recipe the page shows, for recipe pages
+	Card      model.RecipeCard // Recipe the page shows, for recipe pages
+}
+
+// siteSearchEntry is a recipe in the static site's search index.
+type siteSearchEntry struct {
+	Name        string   `json:"name"`
+	Url         string   `json:"url"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Cuisine     string   `json:"cuisine,omitempty"`
+	Course      string   `json:"course,omitempty"`
+	TotalTime   string   `json:"totalTime,omitempty"`
+	Ingredients []string `json:"ingredients"`
+}
+
+// WriteSite writes a cookbook as a self-contained static HTML site that works offline: a home page listing
+// every recipe with a search box, a page for each recipe with its photo, and index pages of the recipes by tag
+// and by cuisine. Search runs in the browser against search-index.json, a prebuilt index of each recipe's
+// name, description, tags, cuisine, course and ingredients, which search-index.js also sets for when the site
+// is opened from disk.
+//
+// Parameters:
+//   - out: Where to write the site's files
+//   - cookbook: The site's title, and the recipes with their ingredients and procedures and photos
+//
+// Returns:
+//   - error: An error if a template fails to render or a file can't be written
+func WriteSite(out ExportWriter, cookbook model.Cookbook) error {
+	templates, err := parseSiteTemplates()
+	if err != nil {
+		return err
+	}
+
+	photos := exportPhotos(cookbook)
+
+	tagLinks := siteGroupLinks("tags", "tag")
+	cuisineLinks := siteGroupLinks("cuisines", "cuisine")
+	tagRecipes := map[string][]siteRecipe{}
+	cuisineRecipes := map[string][]siteRecipe{}
+
+	recipes := make([]siteRecipe, 0, len(cookbook.Recipes))
+	cards := make([]model.RecipeCard, 0, len(cookbook.Recipes))
+	searchIndex := make([]siteSearchEntry, 0, len(cookbook.Recipes))
+
+	for _, recipe := range cookbook.Recipes {
+		entry := siteRecipe{
+			Name:        recipe.RecipeName,
+			Description: recipe.Description,
+			Url:         fmt.Sprintf("recipes/%d-%s.html", recipe.ID, slugify(recipe.RecipeName, "recipe")),
+		}
+
+		if photo, ok := photos[recipe.ID]; ok {
+			entry.Photo = "images/" + photo.name
+			if err := out.WriteFile(entry.Photo, photo.contents); err != nil {
+				return err
+			}
+		}
+
+		for _, tag := range model.NormalizeTags(recipe.Tags) {
+			entry.Tags = append(entry.Tags, tagLinks(tag))
+		}
+		if cuisine := strings.ToLower(strings.TrimSpace(recipe.Cuisine)); cuisine != "" {
+			link := cuisineLinks(cuisine)
+			entry.Cuisine = &link
+		}
+
+		card := NewRecipeCard(recipe, nil, "")
+		entry.TotalTime = card.TotalTime
+		if entry.TotalTime == "" {
+			entry.TotalTime = formatTimeText(recipe.PrepTimeMinutes + recipe.CookTimeMinutes)
+		}
+
+		for _, tag := range entry.Tags {
+			tagRecipes[tag.Name] = append(tagRecipes[tag.Name], entry)
+		}
+		if entry.Cuisine != nil {
+			cuisineRecipes[entry.Cuisine.Name] = append(cuisineRecipes[entry.Cuisine.Name], entry)
+		}
+
+		search := siteSearchEntry{
+			Name:        recipe.RecipeName,
+			Url:         entry.Url,
+			Description: recipe.Description,
+			Tags:        model.NormalizeTags(recipe.Tags),
+			Cuisine:     recipe.Cuisine,
+			Course:      recipe.Course,
+			TotalTime:   entry.TotalTime,
+			Ingredients: []string{},
+		}
+		for _, ingredient := range recipe.Ingredients {
+			if name := indexName(ingredient); name != "" {
+				search.Ingredients = append(search.Ingredients, name)
+			}
+		}
+
+		recipes = append(recipes, entry)
+		cards = append(cards, card)
+		searchIndex = append(searchIndex, search)
+	}
+
+	home := sitePage{SiteTitle: cookbook.Title, Recipes: recipes}
+	if err := writeSitePage(out, templates, "index.html", "index.html", home); err != nil {
+		return err
+	}
+
+	for i, entry := range recipes {
+		page := sitePage{Root: "../", SiteTitle: cookbook.Title, Title: entry.Name, Recipe: entry, Card: cards[i]}
+		if err := writeSitePage(out, templates, entry.Url, "recipe.html", page); err != nil {
+			return err
+		}
+	}
+
+	groups := []struct {
+		title     string
+		directory string
+		recipes   map[string][]siteRecipe
+		link      func(string) siteLink
+	}{
+		{"Tags", "tags", tagRecipes, tagLinks},
+		{"Cuisines", "cuisines", cuisineRecipes, cuisineLinks},
+	}
+
+	for _, group := range groups {
+		parent := siteLink{Name: group.title, Url: group.directory + "/index.html"}
+		index := sitePage{Root: "../", SiteTitle: cookbook.Title, Title: group.title, Groups: []siteLink{}}
+
+		names := make([]string, 0, len(group.recipes))
+		for name := range group.recipes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			groupRecipes := group.recipes[name]
+			link := group.link(name)
+			link.Count = len(groupRecipes)
+			index.Groups = append(index.Groups, link)
+
+			page := sitePage{Root: "../", SiteTitle: cookbook.Title, Title: name, Parent: parent, Recipes: groupRecipes}
+			if err := writeSitePage(out, templates, link.Url, "list.html", page); err != nil {
+				return err
+			}
+		}
+
+		if err := writeSitePage(out, templates, parent.Url, "groups.html", index); err != nil {
+			return err
+		}
+	}
+
+	searchJson, err := json.Marshal(searchIndex)
+	if err != nil {
+		return err
+	}
+	if err := out.WriteFile("search-index.json", searchJson); err != nil {
+		return err
+	}
+	if err := out.WriteFile("search-index.js", []byte("window.recipeSearchIndex = "+string(searchJson)+";\n")); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"style.css", "search.js"} {
+		contents, err := siteTemplates.ReadFile("templates/site/" + name)
+		if err != nil {
+			return err
+		}
+		if err := out.WriteFile(name, contents); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// private functions
+
+// siteGroupLinks returns a function that gives the link to the page of a tag or cuisine under directory. Each
+// name gets its own page even if its slug is the same as another's, or as the directory's index page.
+func siteGroupLinks(directory string, fallback string) func(string) siteLink {
+	urls := map[string]string{}
+	used := map[string]bool{"index": true}
+
+	return func(name string) siteLink {
+		url, ok := urls[name]
+		if !ok {
+			slug := slugify(name, fallback)
+			for i := 2; used[slug]; i++ {
+				slug = fmt.Sprintf("%s-%d", slugify(name, fallback), i)
+			}
+			used[slug] = true
+			url = directory + "/" + slug + ".html"
+			urls[name] = url
+		}
+		return siteLink{Name: name, Url: url}
+	}
+}
+
+// parseSiteTemplates parses each of the static site's page templates with the shared layout, by file name.
+func parseSiteTemplates() (map[string]*htmltemplate.Template, error) {
+	templates := map[string]*htmltemplate.Template{}
+
+	for _, name := range []string{"index.html", "recipe.html", "list.html", "groups.html"} {
+		tmpl, err := htmltemplate.New("layout").Funcs(recipeCardFuncs).ParseFS(siteTemplates, "templates/site/layout.html", "templates/site/"+name)
+		if err != nil {
+			log.Printf("Error parsing site template %s: %v", name, err)
+			return nil, err
+		}
+		templates[name] = tmpl
+	}
+
+	return templates, nil
+}
+
+// writeSitePage renders a page of the static site with one of its templates and writes it.
+func writeSitePage(out ExportWriter, templates map[string]*htmltemplate.Template, name string, template string, page sitePage) error {
+	var output bytes.Buffer
+	if err := templates[template].ExecuteTemplate(&output, "layout", page); err != nil {
+		log.Printf("Error rendering site page %s: %v", name, err)
+		return err
+	}
+
+	return out.WriteFile(name, output.Bytes())
+}
@@ -0,0 +1,571 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"recipe-generator/internal/api/model"
+)
+
+// Allergens recognised by AllergensFor.
+const (
+	AllergenDairy     = "dairy"
+	AllergenEgg       = "egg"
+	AllergenGluten    = "gluten"
+	AllergenPeanut    = "peanut"
+	AllergenTreeNut   = "tree-nut"
+	AllergenSoy       = "soy"
+	AllergenFish      = "fish"
+	AllergenShellfish = "shellfish"
+	AllergenSesame    = "sesame"
+)
+
+// Ways of cooking that rule out some substitutes, found by RecipeContexts.
+const (
+	SubstitutionContextBaking   = "baking"
+	SubstitutionContextFrying   = "frying"
+	SubstitutionContextWhipping = "whipping"
+)
+
+// contextKeywords are the words in a recipe's name or procedure that show how it is cooked.
+var contextKeywords = map[string][]string{
+	SubstitutionContextBaking:   {"bake", "baked", "baking", "oven"},
+	SubstitutionContextFrying:   {"fry", "fried", "frying", "deep-fry"},
+	SubstitutionContextWhipping: {"whip", "whipped", "whipping", "stiff peaks", "soft peaks"},
+}
+
+// allergens lists the allergens in common ingredients, by canonical name. Like densities, an entry for the end
+// of a name covers longer names, so "whole milk" is dairy; plant-based versions are listed so that "oat milk"
+// doesn't count as dairy.
+var allergens = map[string][]string{
+	"milk":                  {AllergenDairy},
+	"buttermilk":            {AllergenDairy},
+	"evaporated milk":       {AllergenDairy},
+	"butter":                {AllergenDairy},
+	"ghee":                  {AllergenDairy},
+	"cream":                 {AllergenDairy},
+	"sour cream":            {AllergenDairy},
+	"yogurt":                {AllergenDairy},
+	"cheese":                {AllergenDairy},
+	"parmesan":              {AllergenDairy},
+	"mozzarella":            {AllergenDairy},
+	"ricotta":               {AllergenDairy},
+	"egg":                   {AllergenEgg},
+	"egg white":             {AllergenEgg},
+	"egg yolk":              {AllergenEgg},
+	"mayonnaise":            {AllergenEgg},
+	"flour":                 {AllergenGluten},
+	"bread":                 {AllergenGluten},
+	"breadcrumb":            {AllergenGluten},
+	"panko":                 {AllergenGluten},
+	"cracker":               {AllergenGluten},
+	"pasta":                 {AllergenGluten},
+	"spaghetti":             {AllergenGluten},
+	"noodle":                {AllergenGluten},
+	"couscous":              {AllergenGluten},
+	"barley":                {AllergenGluten},
+	"oat":                   {AllergenGluten},
+	"soy sauce":             {AllergenSoy, AllergenGluten},
+	"tamari":                {AllergenSoy},
+	"tofu":                  {AllergenSoy},
+	"miso":                  {AllergenSoy},
+	"edamame":               {AllergenSoy},
+	"soy milk":              {AllergenSoy},
+	"peanut":                {AllergenPeanut},
+	"peanut butter":         {AllergenPeanut},
+	"almond":                {AllergenTreeNut},
+	"almond milk":           {AllergenTreeNut},
+	"almond flour":          {AllergenTreeNut},
+	"walnut":                {AllergenTreeNut},
+	"pecan":                 {AllergenTreeNut},
+	"cashew":                {AllergenTreeNut},
+	"hazelnut":              {AllergenTreeNut},
+	"pistachio":             {AllergenTreeNut},
+	"fish":                  {AllergenFish},
+	"fish sauce":            {AllergenFish},
+	"anchovy":               {AllergenFish},
+	"salmon":                {AllergenFish},
+	"tuna":                  {AllergenFish},
+	"shrimp":                {AllergenShellfish},
+	"prawn":                 {AllergenShellfish},
+	"crab":                  {AllergenShellfish},
+	"lobster":               {AllergenShellfish},
+	"sesame":                {AllergenSesame},
+	"sesame seed":           {AllergenSesame},
+	"sesame oil":            {AllergenSesame},
+	"tahini":                {AllergenSesame},
+	"oat milk":              {},
+	"coconut milk":          {},
+	"coconut cream":         {},
+	"rice milk":             {},
+	"rice flour":            {},
+	"coconut flour":         {},
+	"coconut yogurt":        {},
+	"gluten-free flour":     {},
+	"gluten-free oat":       {},
+	"sunflower seed butter": {},
+	"cocoa butter":          {},
+}
+
+// substitutePart is one ingredient of a substitute, for the amount of the original in substitute.per.
+type substitutePart struct {
+	name   string
+	amount float64
+	unit   string
+}
+
+// substitute is a way to replace an ingredient.
+type substitute struct {
+	per          float64          // Amount of the original ingredient the parts replace
+	perUnit      string           // Unit of that amount
+	replacements []substitutePart // What to use instead
+	notes        string           // How to use it and how it changes the dish
+	notFor       []string         // SubstitutionContext constants the substitute doesn't suit
+	warning      string           // Why, when the recipe is cooked one of those ways
+}
+
+// substitutes is the substitution knowledge base: for each canonical ingredient name, the ways to replace it,
+// most faithful first. Like densities, an entry for the end of a name covers longer names.
+var substitutes = map[string][]substitute{
+	"buttermilk": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"milk", 1, "cup"}, {"lemon juice", 1, "tbsp"}},
+			notes: "Stir the lemon juice into the milk and let it stand 5 minutes to curdle."},
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"plain yogurt", 0.75, "cup"}, {"milk", 0.25, "cup"}},
+			notes: "Whisk until smooth."},
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"oat milk", 1, "cup"}, {"lemon juice", 1, "tbsp"}},
+			notes: "A dairy-free version; let it stand 5 minutes."},
+	},
+	"milk": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"oat milk", 1, "cup"}},
+			notes: "Use unsweetened."},
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"soy milk", 1, "cup"}},
+			notes: "Use unsweetened; the closest plant milk for protein, so it behaves best in custards."},
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"evaporated milk", 0.5, "cup"}, {"water", 0.5, "cup"}}},
+	},
+	"butter": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"coconut oil", 1, "cup"}},
+			notes: "Use refined coconut oil for no coconut taste; solid for creaming, melted otherwise."},
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"vegetable oil", 0.75, "cup"}},
+			notes:  "Fine for sautéing and for batters that use melted butter.",
+			notFor: []string{SubstitutionContextBaking}, warning: "not for baking that creams butter with sugar; the texture will be dense"},
+	},
+	"heavy cream": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"milk", 0.75, "cup"}, {"butter", 0.25, "cup"}},
+			notes:  "Melt the butter and whisk it into the milk. Works in sauces and soups.",
+			notFor: []string{SubstitutionContextWhipping}, warning: "won't whip"},
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"coconut cream", 1, "cup"}},
+			notes: "Chill the can first to whip it. Adds a coconut taste."},
+	},
+	"sour cream": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"greek yogurt", 1, "cup"}},
+			notes: "Use full-fat yogurt and stir it in off the heat so it doesn't split."},
+	},
+	"yogurt": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"sour cream", 1, "cup"}}},
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"coconut yogurt", 1, "cup"}},
+			notes: "A dairy-free version."},
+	},
+	"egg": {
+		{per: 1, perUnit: "", replacements: []substitutePart{{"ground flaxseed", 1, "tbsp"}, {"water", 3, "tbsp"}},
+			notes:  "Mix and let thicken 5 minutes. Binds, but doesn't help a batter rise.",
+			notFor: []string{SubstitutionContextWhipping}, warning: "won't whip like egg whites"},
+		{per: 1, perUnit: "", replacements: []substitutePart{{"unsweetened applesauce", 0.25, "cup"}},
+			notes:  "Adds moisture and a little sweetness; best in cakes and muffins.",
+			notFor: []string{SubstitutionContextFrying, SubstitutionContextWhipping}, warning: "only works in baking"},
+		{per: 1, perUnit: "", replacements: []substitutePart{{"mashed banana", 0.25, "cup"}},
+			notes:  "Tastes of banana; best in pancakes and quick breads.",
+			notFor: []string{SubstitutionContextFrying, SubstitutionContextWhipping}, warning: "only works in baking"},
+	},
+	"mayonnaise": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"greek yogurt", 1, "cup"}},
+			notes: "Tangier; good in dressings and salads."},
+	},
+	"flour": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"gluten-free flour", 1, "cup"}},
+			notes: "Use a cup-for-cup blend with xanthan gum for baking."},
+	},
+	"cake flour": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"all-purpose flour", 0.875, "cup"}, {"cornstarch", 2, "tbsp"}},
+			notes: "Sift together twice."},
+	},
+	"self-rising flour": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"all-purpose flour", 1, "cup"}, {"baking powder", 1.5, "tsp"}, {"salt", 0.25, "tsp"}}},
+	},
+	"cornstarch": {
+		{per: 1, perUnit: "tbsp", replacements: []substitutePart{{"all-purpose flour", 2, "tbsp"}},
+			notes: "Cook a few minutes longer so the sauce doesn't taste floury; it thickens less clearly."},
+	},
+	"baking powder": {
+		{per: 1, perUnit: "tsp", replacements: []substitutePart{{"baking soda", 0.25, "tsp"}, {"cream of tartar", 0.5, "tsp"}},
+			notes: "Bake straight away once mixed."},
+	},
+	"breadcrumb": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"rolled oats", 1, "cup"}},
+			notes: "Pulse briefly in a food processor."},
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"crushed cracker", 1, "cup"}}},
+	},
+	"brown sugar": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"sugar", 1, "cup"}, {"molasses", 1, "tbsp"}},
+			notes: "Rub the molasses into the sugar."},
+	},
+	"honey": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"maple syrup", 1, "cup"}}},
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"sugar", 1.25, "cup"}, {"water", 0.25, "cup"}},
+			notes: "Dissolve the sugar in the water."},
+	},
+	"lemon juice": {
+		{per: 1, perUnit: "tbsp", replacements: []substitutePart{{"lime juice", 1, "tbsp"}}},
+		{per: 1, perUnit: "tbsp", replacements: []substitutePart{{"white vinegar", 0.5, "tbsp"}},
+			notes: "Adds the acidity but not the lemon taste."},
+	},
+	"soy sauce": {
+		{per: 1, perUnit: "tbsp", replacements: []substitutePart{{"tamari", 1, "tbsp"}},
+			notes: "Tamari is usually gluten-free; check the label."},
+		{per: 1, perUnit: "tbsp", replacements: []substitutePart{{"coconut aminos", 1, "tbsp"}},
+			notes: "Sweeter and less salty; add a pinch of salt."},
+	},
+	"garlic": {
+		{per: 1, perUnit: "clove", replacements: []substitutePart{{"garlic powder", 0.125, "tsp"}}},
+	},
+	"white wine": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"chicken broth", 1, "cup"}, {"white wine vinegar", 1, "tbsp"}}},
+	},
+	"parmesan": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"nutritional yeast", 0.5, "cup"}},
+			notes: "A dairy-free version; won't melt."},
+	},
+	"peanut butter": {
+		{per: 1, perUnit: "cup", replacements: []substitutePart{{"sunflower seed butter", 1, "cup"}},
+			notes: "May turn baked goods slightly green, which is harmless."},
+	},
+}
+
+// SubstitutionFilter selects which ingredients of a recipe get substitutes proposed.
+// When both are empty, every ingredient with a known substitute is included.
+type SubstitutionFilter struct {
+	Missing []string // Names of ingredients that are missing
+	Avoid   []string // Allergens, or names of ingredients, to avoid
+}
+
+// SubstitutionService proposes and makes ingredient substitutions.
+type SubstitutionService struct{}
+
+// NewSubstitutionService creates a new SubstitutionService.
+func NewSubstitutionService() *SubstitutionService {
+	return &SubstitutionService{}
+}
+
+// AllergensFor returns the allergens in an ingredient, or nil if none are known.
+//
+// Parameters:
+//   - canonicalName: The ingredient name as returned by CanonicalIngredientName
+func AllergensFor(canonicalName string) []string {
+	words := strings.Fields(canonicalName)
+
+	for i := range words {
+		if found, ok := allergens[strings.Join(words[i:], " ")]; ok {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// RecipeContexts returns how a recipe is cooked, as SubstitutionContext constants, from the words in its name
+// and procedure.
+func RecipeContexts(recipe *model.Recipe) []string {
+	text := " " + strings.ToLower(recipe.RecipeName+" "+strings.Join(recipe.Procedure, " ")) + " "
+	text = strings.NewReplacer(".", " ", ",", " ", ";", " ", ":", " ", "(", " ", ")", " ").Replace(text)
+
+	contexts := []string{}
+	for context, keywords := range contextKeywords {
+		for _, keyword := range keywords {
+			if strings.Contains(text, " "+keyword+" ") {
+				contexts = append(contexts, context)
+				break
+			}
+		}
+	}
+	sort.Strings(contexts)
+
+	return contexts
+}
+
+// Propose lists substitutes for the ingredients of a recipe the filter selects. Substitutes that contain
+// something to avoid are left out, and those that don't suit how the recipe is cooked are marked unsuitable
+// and listed last. Option numbers stay the same whatever the filter, so they can be passed to Apply.
+//
+// Parameters:
+//   - recipe: The recipe with its ingredients and procedure
+//   - filter: The missing ingredients and allergens to avoid
+//
+// Returns:
+//   - model.RecipeSubstitutions: The substitutes for each selected ingredient
+func (ss *SubstitutionService) Propose(recipe *model.Recipe, filter SubstitutionFilter) model.RecipeSubstitutions {
+	contexts := RecipeContexts(recipe)
+
+	missing := make(map[string]bool)
+	for _, name := range filter.Missing {
+		missing[CanonicalIngredientName(name)] = true
+	}
+	avoid := make(map[string]bool)
+	for _, name := range filter.Avoid {
+		avoid[CanonicalIngredientName(name)] = true
+	}
+	everything := len(missing) == 0 && len(avoid) == 0
+
+	result := model.RecipeSubstitutions{
+		RecipeId:      recipe.ID,
+		RecipeName:    recipe.RecipeName,
+		Contexts:      contexts,
+		Substitutions: []model.IngredientSubstitution{},
+	}
+
+	for _, ingredient := range recipe.Ingredients {
+		name := CanonicalIngredientName(ingredient.IngredientName)
+		ingredientAllergens := allergenList(AllergensFor(name))
+
+		reasons := []string{}
+		if missing[name] {
+			reasons = append(reasons, model.SubstitutionReasonMissing)
+		}
+		if avoid[name] {
+			reasons = append(reasons, model.SubstitutionReasonAvoided)
+		}
+		if containsAny(avoid, ingredientAllergens) {
+			reasons = append(reasons, model.SubstitutionReasonAllergen)
+		}
+
+		known := substitutesFor(name)
+		if len(reasons) == 0 && (!everything || len(known) == 0) {
+			continue
+		}
+
+		substitution := model.IngredientSubstitution{
+			IngredientName:    ingredient.IngredientName,
+			Amount:            ingredient.Amount,
+			UnitOfMeasurement: ingredient.UnitOfMeasurement,
+			Reasons:           reasons,
+			Allergens:         ingredientAllergens,
+			Options:           []model.SubstitutionOption{},
+		}
+
+		for number, candidate := range known {
+			option, ok := scaleSubstitute(candidate, number, ingredient, name, contexts)
+			if !ok || containsAvoided(option, avoid) {
+				continue
+			}
+			substitution.Options = append(substitution.Options, option)
+		}
+
+		sort.SliceStable(substitution.Options, func(i, j int) bool {
+			return substitution.Options[i].Suitable && !substitution.Options[j].Suitable
+		})
+
+		result.Substitutions = append(result.Substitutions, substitution)
+	}
+
+	return result
+}
+
+// Apply makes a copy of a recipe with the given substitutions made. Each swapped ingredient is replaced by
+// its substitute's ingredients, scaled to the amount the recipe used, wherever the recipe lists it, such as
+// butter in both a crust and a filling, and the description notes what was swapped. The copy has no ID, so it can be previewed or saved as a new recipe.
+//
+// Parameters:
+//   - recipe: The recipe with its ingredients and procedure; not modified
+//   - swaps: The substitutions to make
+//
+// Returns:
+//   - *model.Recipe: The substituted copy
+//   - error: model.ErrInvalidField if a swap names an ingredient the recipe doesn't have, or an option that
+//     doesn't exist or can't be scaled to the recipe's amount
+func (ss *SubstitutionService) Apply(recipe *model.Recipe, swaps []model.SubstitutionSwap) (*model.Recipe, error) {
+	contexts := RecipeContexts(recipe)
+
+	chosen := make(map[string]int)
+	for _, swap := range swaps {
+		chosen[CanonicalIngredientName(swap.IngredientName)] = swap.Option
+	}
+
+	copied := *recipe
+	copied.ID = 0
+	copied.AverageRating = 0
+	copied.RatingCount = 0
+	copied.Ingredients = []model.Ingredient{}
+	copied.Procedure = append([]string{}, recipe.Procedure...)
+	copied.Tags = append([]string{}, recipe.Tags...)
+	copied.Equipment = append([]string(nil), recipe.Equipment...)
+
+	notes := []string{}
+	noted := make(map[string]bool)
+	used := make(map[string]bool)
+
+	for _, ingredient := range recipe.Ingredients {
+		name := CanonicalIngredientName(ingredient.IngredientName)
+
+		number, ok := chosen[name]
+		if !ok {
+			ingredient.ID = 0
+			ingredient.RecipeId = 0
+			copied.Ingredients = append(copied.Ingredients, ingredient)
+			continue
+		}
+		used[name] = true
+
+		known := substitutesFor(name)
+		if number >= len(known) {
+			return nil, model.ErrInvalidField("swaps.option")
+		}
+
+		option, ok := scaleSubstitute(known[number], number, ingredient, name, contexts)
+		if !ok {
+			return nil, model.ErrInvalidField("swaps.option")
+		}
+
+		parts := make([]string, 0, len(option.Replacements))
+		for _, replacement := range option.Replacements {
+			copied.Ingredients = append(copied.Ingredients, model.Ingredient{
+				Amount:            replacement.Amount,
+				UnitOfMeasurement: replacement.UnitOfMeasurement,
+				IngredientName:    replacement.IngredientName,
+			})
+			parts = append(parts, replacement.IngredientName)
+		}
+
+		note := fmt.Sprintf("%s instead of %s", strings.Join(parts, " and "), ingredient.IngredientName)
+		if option.Notes != "" {
+			note += " (" + strings.TrimSuffix(option.Notes, ".") + ")"
+		}
+		if !noted[note] {
+			noted[note] = true
+			notes = append(notes, note)
+		}
+	}
+
+	for name := range chosen {
+		if !used[name] {
+			return nil, model.ErrInvalidField("swaps.ingredientName")
+		}
+	}
+
+	description := "Substitutions: " + strings.Join(notes, "; ") + "."
+	if copied.Description != "" {
+		description = copied.Description + "\n\n" + description
+	}
+	copied.Description = description
+
+	return &copied, nil
+}
+
+// private functions
+
+// substitutesFor returns the known substitutes for an ingredient, using the entry for the longest end of its name.
+func substitutesFor(canonicalName string) []substitute {
+	words := strings.Fields(canonicalName)
+
+	for i := range words {
+		if found, ok := substitutes[strings.Join(words[i:], " ")]; ok {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// scaleSubstitute works out the amounts of a substitute for an ingredient of a recipe.
+// It returns false if the recipe's amount can't be converted to the unit the substitute is given for.
+// Ingredients without an amount, such as "salt to taste", get substitutes without an amount.
+func scaleSubstitute(candidate substitute, number int, ingredient model.Ingredient, name string, contexts []string) (model.SubstitutionOption, bool) {
+	option := model.SubstitutionOption{
+		Option:       number,
+		Replacements: make([]model.SubstituteAmount, 0, len(candidate.replacements)),
+		Notes:        candidate.notes,
+		Suitable:     true,
+		Allergens:    []string{},
+	}
+
+	scale := 0.0
+	if ingredient.Amount > 0 {
+		amount, approximate, ok := ConvertIngredient(ingredient.Amount, LookupUnit(ingredient.UnitOfMeasurement), LookupUnit(candidate.perUnit), name)
+		if !ok {
+			return option, false
+		}
+		scale = amount / candidate.per
+		option.Approximate = approximate
+	}
+
+	recipeUnit := LookupUnit(ingredient.UnitOfMeasurement)
+
+	seen := make(map[string]bool)
+	for _, part := range candidate.replacements {
+		partName := CanonicalIngredientName(part.name)
+		replacement := model.SubstituteAmount{
+			IngredientName:    part.name,
+			Amount:            RoundAmount(part.amount * scale),
+			UnitOfMeasurement: part.unit,
+		}
+
+		// measure the replacement the way the recipe measures the original, so 3 tbsp of butter is swapped
+		// for 3 tbsp of oil rather than 0.19 cup, unless that makes it a fraction of a unit.
+		if recipeUnit.Kind != UnitKindCount && scale > 0 {
+			amount, approximate, ok := ConvertIngredient(part.amount*scale, LookupUnit(part.unit), recipeUnit, partName)
+			if ok && amount >= 1 {
+				replacement.Amount = RoundAmount(amount)
+				replacement.UnitOfMeasurement = ingredient.UnitOfMeasurement
+				option.Approximate = option.Approximate || approximate
+			}
+		}
+
+		option.Replacements = append(option.Replacements, replacement)
+
+		for _, allergen := range AllergensFor(partName) {
+			if !seen[allergen] {
+				seen[allergen] = true
+				option.Allergens = append(option.Allergens, allergen)
+			}
+		}
+	}
+	sort.Strings(option.Allergens)
+
+	for _, context := range candidate.notFor {
+		for _, recipeContext := range contexts {
+			if context == recipeContext {
+				option.Suitable = false
+				option.Warning = candidate.warning
+			}
+		}
+	}
+
+	return option, true
+}
+
+// containsAvoided reports whether a substitute contains an allergen or ingredient that is to be avoided,
+// in which case it is no use as a substitute.
+func containsAvoided(option model.SubstitutionOption, avoid map[string]bool) bool {
+	if containsAny(avoid, option.Allergens) {
+		return true
+	}
+	for _, replacement := range option.Replacements {
+		if avoid[CanonicalIngredientName(replacement.IngredientName)] {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether any of the values is in the set.
+func containsAny(set map[string]bool, values []string) bool {
+	for _, value := range values {
+		if set[value] {
+			return true
+		}
+	}
+	return false
+}
+
+// allergenList returns a copy of the allergens that is never nil, so it encodes as an empty JSON array.
+func allergenList(found []string) []string {
+	return append([]string{}, found...)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"recipe-generator/internal/api/model"
+)
+
+// maxCombinationCandidates is how many of the best single recipes are tried in combinations.
+// Trying every pair and triple of 12 recipes is 286 combinations.
+const maxCombinationCandidates = 12
+
+// SuggestionService suggests recipes based on what is in the pantry.
+type SuggestionService struct {
+	PantryService *PantryService
+}
+
+// NewSuggestionService creates a new SuggestionService.
+func NewSuggestionService() *SuggestionService {
+	return &SuggestionService{PantryService: NewPantryService()}
+}
+
+// ExpiringItems returns the pantry items that expire between today and withinDays from today, inclusive,
+// soonest first. Items that have already expired are left out.
+func ExpiringItems(pantry []model.PantryItem, today model.Date, withinDays int) []model.PantryItem {
+	last := today.AddDays(withinDays)
+	expiring := []model.PantryItem{}
+
+	for _, item := range pantry {
+		if item.ExpiryDate == nil || item.Quantity <= pantryEpsilon {
+			continue
+		}
+		if item.ExpiryDate.Before(today.Time) || item.ExpiryDate.After(last.Time) {
+			continue
+		}
+		expiring = append(expiring, item)
+	}
+
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiryDate.Before(expiring[j].ExpiryDate.Time)
+	})
+
+	return expiring
+}
+
+// UseSoon ranks recipes by how many soon-to-expire items they use, then by how much of those items they use,
+// then by how much of the recipe the pantry covers. Recipes that use none are left out. It also looks for two
+// or three recipes that together use more of the expiring stock than the best single recipe.
+//
+// Parameters:
+//   - recipes: Candidate recipes with their ingredients, already filtered by dietary labels, in preference order
+//   - pantry: Everything in the pantry
+//   - expiring: The pantry items that expire soon, from ExpiringItems
+//   - limit: The most recipes to return
+//
+// Returns:
+//   - model.UseSoonSuggestions: The ranked recipes and the best combination
+func (ss *SuggestionService) UseSoon(recipes []*model.Recipe, pantry []model.PantryItem, expiring []model.PantryItem, limit int) model.UseSoonSuggestions {
+	result := model.UseSoonSuggestions{Expiring: expiring, Recipes: []model.UseSoonSuggestion{}}
+
+	if len(expiring) == 0 {
+		return result
+	}
+
+	ranked := []*model.Recipe{}
+
+	for _, recipe := range recipes {
+		matches, taken := ss.PantryService.Match(RecipeRequirements(recipe, 0), pantry)
+
+		uses, share := expiringUses(taken, expiring)
+		if len(uses) == 0 {
+			continue
+		}
+
+		suggestion := model.UseSoonSuggestion{
+			RecipeId:      recipe.ID,
+			RecipeName:    recipe.RecipeName,
+			ExpiringUsed:  len(uses),
+			ExpiringShare: RoundAmount(share),
+			Expiring:      uses,
+		}
+
+		for _, match := range matches {
+			if !Covers(match) {
+				suggestion.Missing++
+			}
+		}
+		suggestion.Coverage = RoundAmount(coverage(matches))
+
+		result.Recipes = append(result.Recipes, suggestion)
+		ranked = append(ranked, recipe)
+	}
+
+	order := make([]int, len(result.Recipes))
+	for i := range order {
+		order[i] = i
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := result.Recipes[order[i]], result.Recipes[order[j]]
+		if a.ExpiringUsed != b.ExpiringUsed {
+			return a.ExpiringUsed > b.ExpiringUsed
+		}
+		if a.ExpiringShare != b.ExpiringShare {
+			return a.ExpiringShare > b.ExpiringShare
+		}
+		return a.Coverage > b.Coverage
+	})
+
+	suggestions := make([]model.UseSoonSuggestion, len(order))
+	candidates := make([]*model.Recipe, len(order))
+	for i, index := range order {
+		suggestions[i] = result.Recipes[index]
+		candidates[i] = ranked[index]
+	}
+
+	if len(candidates) > maxCombinationCandidates {
+		candidates = candidates[:maxCombinationCandidates]
+	}
+	if len(suggestions) > 0 {
+		result.Combination = ss.bestCombination(candidates, pantry, expiring, suggestions[0].ExpiringShare)
+	}
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	result.Recipes = suggestions
+
+	return result
+}
+
+// bestCombination tries every pair and triple of candidates and returns the one that uses the most expiring stock,
+// or nil if none uses more than the best single recipe. Recipes in a combination share the pantry, so an item
+// can only be used up once.
+func (ss *SuggestionService) bestCombination(candidates []*model.Recipe, pantry []model.PantryItem, expiring []model.PantryItem, bestSingleShare float64) *model.UseSoonCombination {
+	var best *model.UseSoonCombination
+	bestShare := bestSingleShare
+
+	try := func(combination []*model.Recipe) {
+		requirements := []PantryRequirement{}
+		for _, recipe := range combination {
+			requirements = append(requirements, RecipeRequirements(recipe, 0)...)
+		}
+
+		_, taken := ss.PantryService.Match(requirements, pantry)
+		uses, share := expiringUses(taken, expiring)
+		share = RoundAmount(share)
+
+		// a bigger combination has to use noticeably more to be worth cooking.
+		if share <= bestShare+pantryEpsilon {
+			return
+		}
+
+		sources := make([]model.ShoppingListSource, 0, len(combination))
+		for _, recipe := range combination {
+			sources = append(sources, model.ShoppingListSource{RecipeId: recipe.ID, RecipeName: recipe.RecipeName})
+		}
+
+		best = &model.UseSoonCombination{
+			Recipes:       sources,
+			ExpiringUsed:  len(uses),
+			ExpiringShare: share,
+			Expiring:      uses,
+		}
+		bestShare = share
+	}
+
+	// pairs are tried before triples so a pair wins a tie.
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			try([]*model.Recipe{candidates[i], candidates[j]})
+		}
+	}
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			for k := j + 1; k < len(candidates); k++ {
+				try([]*model.Recipe{candidates[i], candidates[j], candidates[k]})
+			}
+		}
+	}
+
+	return best
+}
+
+// expiringUses reports how much of each expiring item was taken, and the sum of the shares used.
+func expiringUses(taken map[int]float64, expiring []model.PantryItem) ([]model.ExpiringUse, float64) {
+	uses := []model.ExpiringUse{}
+	total := 0.0
+
+	for _, item := range expiring {
+		amount := taken[item.ID]
+		if amount <= pantryEpsilon {
+			continue
+		}
+
+		share := math.Min(amount/item.Quantity, 1)
+		total += share
+
+		uses = append(uses, model.ExpiringUse{
+			PantryItemId:   item.ID,
+			IngredientName: item.IngredientName,
+			ExpiryDate:     *item.ExpiryDate,
+			Used:           RoundAmount(amount),
+			Unit:           item.Unit,
+			Share:          RoundAmount(share),
+		})
+	}
+
+	return uses, total
+}
+
+// coverage returns the fraction of a recipe's ingredients the pantry covers, counting partly covered
+// ingredients by how much of them is covered.
+func coverage(matches []model.PantryMatch) float64 {
+	if len(matches) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, match := range matches {
+		switch {
+		case match.Status != model.PantryMatchMatched:
+		case match.Amount <= pantryEpsilon:
+			total++
+		default:
+			total += math.Min(match.Covered/match.Amount, 1)
+		}
+	}
+
+	return total / float64(len(matches))
+}
@@ -0,0 +1,72 @@
+// Searches the recipes in the prebuilt index as the search box is typed in. Every word typed has to appear in
+// a recipe's name, description, tags, cuisine, course or ingredients; recipes whose names match come first.
+(function () {
+  var input = document.querySelector("form.search input");
+  var results = document.getElementById("search-results");
+  var summary = document.getElementById("search-summary");
+  var list = document.getElementById("search-list");
+  var all = document.getElementById("all-recipes");
+  var index = window.recipeSearchIndex;
+
+  function search(query) {
+    var terms = query.toLowerCase().split(/\s+/).filter(Boolean);
+    if (terms.length === 0) {
+      results.hidden = true;
+      all.hidden = false;
+      return;
+    }
+
+    var matches = [];
+    index.forEach(function (entry) {
+      var name = entry.name.toLowerCase();
+      var text = [name, entry.description || "", (entry.tags || []).join(" "), entry.cuisine || "",
+        entry.course || "", entry.ingredients.join(" ")].join(" ").toLowerCase();
+      if (terms.every(function (term) { return text.indexOf(term) !== -1; })) {
+        var score = terms.filter(function (term) { return name.indexOf(term) !== -1; }).length;
+        matches.push({ entry: entry, score: score });
+      }
+    });
+    matches.sort(function (a, b) { return b.score - a.score || a.entry.name.localeCompare(b.entry.name); });
+
+    list.textContent = "";
+    matches.forEach(function (match) {
+      var item = document.createElement("li");
+      var link = document.createElement("a");
+      link.href = match.entry.url;
+      link.textContent = match.entry.name;
+      item.appendChild(link);
+      if (match.entry.totalTime) {
+        var time = document.createElement("span");
+        time.className = "time";
+        time.textContent = match.entry.totalTime;
+        item.appendChild(time);
+      }
+      list.appendChild(item);
+    });
+
+    summary.textContent = matches.length === 1 ? "1 recipe found" : matches.length + " recipes found";
+    results.hidden = false;
+    all.hidden = true;
+  }
+
+  function start() {
+    var query = new URLSearchParams(window.location.search).get("q") || "";
+    input.value = query;
+    input.addEventListener("input", function () { search(input.value); });
+    input.form.addEventListener("submit", function (event) {
+      event.preventDefault();
+      search(input.value);
+    });
+    search(query);
+  }
+
+  // search-index.js sets the index so search works when the site is opened from disk, where browsers don't
+  // allow fetching files; search-index.json has the same index for when it is served.
+  if (index) {
+    start();
+  } else {
+    fetch("search-index.json")
+      .then(function (response) { return response.json(); })
+      .then(function (entries) { index = entries; start(); });
+  }
+})();
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"math"
+	"strings"
+)
+
+// UnitKind groups units of measurement that can be converted into one another.
+type UnitKind string
+
+// Kinds of units. Count units such as "clove" or "can" only combine with the same unit.
+const (
+	UnitKindVolume UnitKind = "volume"
+	UnitKindMass   UnitKind = "mass"
+	UnitKindCount  UnitKind = "count"
+)
+
+// Unit is a unit of measurement and its size in the base unit of its kind:
+// millilitres for volume and grams for mass. Count units have a size of 1.
+type Unit struct {
+	Name string   // Canonical name of the unit, e.g. "tbsp"
+	Kind UnitKind // Kind of quantity the unit measures
+	Size float64  // Size of one unit in millilitres or grams
+}
+
+// units lists the known units by canonical name.
+var units = map[string]Unit{
+	"tsp":    {Name: "tsp", Kind: UnitKindVolume, Size: 4.92892},
+	"tbsp":   {Name: "tbsp", Kind: UnitKindVolume, Size: 14.7868},
+	"fl oz":  {Name: "fl oz", Kind: UnitKindVolume, Size: 29.5735},
+	"cup":    {Name: "cup", Kind: UnitKindVolume, Size: 236.588},
+	"pint":   {Name: "pint", Kind: UnitKindVolume, Size: 473.176},
+	"quart":  {Name: "quart", Kind: UnitKindVolume, Size: 946.353},
+	"gallon": {Name: "gallon", Kind: UnitKindVolume, Size: 3785.41},
+	"ml":     {Name: "ml", Kind: UnitKindVolume, Size: 1},
+	"l":      {Name: "l", Kind: UnitKindVolume, Size: 1000},
+	"g":      {Name: "g", Kind: UnitKindMass, Size: 1},
+	"kg":     {Name: "kg", Kind: UnitKindMass, Size: 1000},
+	"oz":     {Name: "oz", Kind: UnitKindMass, Size: 28.3495},
+	"lb":     {Name: "lb", Kind: UnitKindMass, Size: 453.592},
+}
+
+// unitAliases maps the spellings recipes use to canonical unit names.
+var unitAliases = map[string]string{
+	"t":            "tsp",
+	"tsp":          "tsp",
+	"tsps":         "tsp",
+	"teaspoon":     "tsp",
+	"teaspoons":    "tsp",
+	"tbsp":         "tbsp",
+	"tbsps":        "tbsp",
+	"tbs":          "tbsp",
+	"tbl":          "tbsp",
+	"tablespoon":   "tbsp",
+	"tablespoons":  "tbsp",
+	"fl oz":        "fl oz",
+	"fl. oz":       "fl oz",
+	"fluid ounce":  "fl oz",
+	"fluid ounces": "fl oz",
+	"c":            "cup",
+	"cup":          "cup",
+	"cups":         "cup",
+	"pt":           "pint",
+	"pint":         "pint",
+	"pints":        "pint",
+	"qt":           "quart",
+	"quart":        "quart",
+	"quarts":       "quart",
+	"gal":          "gallon",
+	"gallon":       "gallon",
+	"gallons":      "gallon",
+	"ml":           "ml",
+	"milliliter":   "ml",
+	"milliliters":  "ml",
+	"millilitre":   "ml",
+	"millilitres":  "ml",
+	"l":            "l",
+	"liter":        "l",
+	"liters":       "l",
+	"litre":        "l",
+	"litres":       "l",
+	"g":            "g",
+	"gram":         "g",
+	"grams":        "g",
+	"kg":           "kg",
+	"kilogram":     "kg",
+	"kilograms":    "kg",
+	"oz":           "oz",
+	"ounce":        "oz",
+	"ounces":       "oz",
+	"lb":           "lb",
+	"lbs":          "lb",
+	"pound":        "lb",
+	"pounds":       "lb",
+	"":             "",
+	"each":         "",
+	"whole":        "",
+	"piece":        "",
+	"pieces":       "",
+	"unit":         "",
+	"units":        "",
+	"item":         "",
+	"items":        "",
+}
+
+// LookupUnit returns the unit a recipe's unit of measurement refers to.
+// Units that aren't volumes or masses are count units, named by their singular form,
+// so "cloves" and "clove" are the same unit and "whole" or a blank unit is the unnamed count unit.
+func LookupUnit(unitOfMeasurement string) Unit {
+	// a capital T is the usual abbreviation for a tablespoon, and a lowercase t for a teaspoon.
+	if strings.TrimSpace(unitOfMeasurement) == "T" {
+		return units["tbsp"]
+	}
+
+	name := strings.ToLower(strings.TrimSpace(unitOfMeasurement))
+	name = strings.TrimSuffix(name, ".")
+	name = strings.Join(strings.Fields(name), " ")
+
+	if canonical, ok := unitAliases[name]; ok {
+		if unit, ok := units[canonical]; ok {
+			return unit
+		}
+		name = canonical
+	} else {
+		name = Singular(name)
+	}
+
+	return Unit{Name: name, Kind: UnitKindCount, Size: 1}
+}
+
+// Convertible reports whether amounts in the two units can be added together.
+func (u Unit) Convertible(other Unit) bool {
+	if u.Kind != other.Kind {
+		return false
+	}
+	if u.Kind == UnitKindCount {
+		return u.Name == other.Name
+	}
+	return true
+}
+
+// Convert converts an amount in unit from to unit to.
+// The units must be Convertible.
+func Convert(amount float64, from Unit, to Unit) float64 {
+	return amount * from.Size / to.Size
+}
+
+// RoundAmount rounds an amount to two decimal places for display.
+func RoundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// CanonicalIngredientName returns the form of an ingredient name used to match the same ingredient
+// across recipes: lowercase, with single spaces and each word in the singular, so "Eggs" and "egg" match.
+func CanonicalIngredientName(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	for i, word := range words {
+		words[i] = Singular(strings.Trim(word, ",.;:"))
+	}
+	return strings.Join(words, " ")
+}
+
+// invariantWords are ingredient words that end like plurals but have no singular form.
+var invariantWords = map[string]bool{
+	"molasses":  true,
+	"grits":     true,
+	"swiss":     true,
+	"hummus":    true,
+	"asparagus": true,
+}
+
+// Singular returns the singular form of an English word using simple suffix rules.
+// It handles the common ingredient plurals ("tomatoes", "berries", "cloves") and leaves
+// words that only look plural, such as "asparagus" or "molasses", alone.
+func Singular(word string) string {
+	switch {
+	case invariantWords[word], len(word) <= 3:
+		return word
+	case strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "oes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"), strings.HasSuffix(word, "xes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
@@ -0,0 +1,6 @@
+/* an ingredient can be another recipe, such as 1 batch of pizza dough in a pizza.
+   the amount is in batches, servings or the component recipe's yield unit. */
+ALTER TABLE ingredients
+ADD COLUMN component_recipe_id INT NULL REFERENCES recipes(id);
+
+CREATE INDEX ingredients_component_recipe_id_idx ON ingredients (component_recipe_id);
@@ -0,0 +1,4 @@
+/* optional structured timing of a step for cooking schedules: its duration, the resource it occupies
+   (oven, stovetop, hands or passive), its oven temperature and the steps it depends on. */
+ALTER TABLE procedure_steps
+ADD COLUMN timing JSONB NULL;
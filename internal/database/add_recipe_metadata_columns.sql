@@ -0,0 +1,20 @@
+/* where a recipe comes from, and what to know besides the procedure: notes, make-ahead, storage and freezing
+   guidance, and how hard it is, its cuisine and its course. every column is optional. */
+ALTER TABLE recipes
+ADD COLUMN source_author VARCHAR(255) NULL,
+ADD COLUMN source_cookbook VARCHAR(255) NULL,
+ADD COLUMN source_page INT NULL CHECK (source_page > 0),
+ADD COLUMN source_url TEXT NULL,
+ADD COLUMN source_family_member VARCHAR(255) NULL,
+ADD COLUMN notes TEXT NULL,
+ADD COLUMN make_ahead TEXT NULL,
+ADD COLUMN storage TEXT NULL,
+ADD COLUMN freezing TEXT NULL,
+ADD COLUMN difficulty VARCHAR(16) NULL CHECK (difficulty IN ('easy', 'medium', 'hard')),
+ADD COLUMN cuisine VARCHAR(64) NULL,
+ADD COLUMN course VARCHAR(32) NULL CHECK (
+    course IN ('breakfast', 'appetizer', 'soup', 'salad', 'main', 'side', 'dessert', 'snack', 'bread', 'sauce', 'drink')
+);
+
+CREATE INDEX recipes_cuisine_idx ON recipes (cuisine);
+CREATE INDEX recipes_course_idx ON recipes (course)
@@ -0,0 +1,12 @@
+/* what a recipe makes besides servings, such as 24 cookies, and the pans it is baked in.
+   the pan columns are only set for recipes baked in pans. */
+ALTER TABLE recipes
+ADD COLUMN yield_quantity DOUBLE PRECISION NULL CHECK (yield_quantity > 0),
+ADD COLUMN yield_unit VARCHAR(255) NULL,
+ADD COLUMN pan_shape VARCHAR(32) NULL CHECK (pan_shape IN ('rectangular', 'round')),
+ADD COLUMN pan_length DOUBLE PRECISION NULL,
+ADD COLUMN pan_width DOUBLE PRECISION NULL,
+ADD COLUMN pan_diameter DOUBLE PRECISION NULL,
+ADD COLUMN pan_depth DOUBLE PRECISION NULL,
+ADD COLUMN pan_count INT NULL,
+ADD COLUMN pan_unit VARCHAR(8) NULL;
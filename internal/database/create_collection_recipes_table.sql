@@ -0,0 +1,12 @@
+CREATE TABLE collection_recipes (
+    id SERIAL PRIMARY KEY,
+    collection_id INT REFERENCES collections(id) ON DELETE CASCADE NOT NULL,
+    recipe_id INT REFERENCES recipes(id) ON DELETE CASCADE NOT NULL,
+    position INT NOT NULL,
+    notes TEXT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date DATE DEFAULT CURRENT_DATE NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date DATE NOT NULL,
+    UNIQUE (collection_id, recipe_id)
+)
@@ -0,0 +1,10 @@
+CREATE TABLE collection_shares (
+    collection_id INT REFERENCES collections(id) ON DELETE CASCADE NOT NULL,
+    user_id INT REFERENCES users(id) NOT NULL,
+    permission VARCHAR(16) NOT NULL CHECK (permission IN ('read', 'edit')),
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date DATE DEFAULT CURRENT_DATE NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date DATE NOT NULL,
+    PRIMARY KEY (collection_id, user_id)
+)
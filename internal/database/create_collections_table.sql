@@ -0,0 +1,11 @@
+CREATE TABLE collections (
+    id SERIAL PRIMARY KEY,
+    owner_id INT REFERENCES users(id) NOT NULL,
+    collection_name VARCHAR(255) NOT NULL,
+    description TEXT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date DATE DEFAULT CURRENT_DATE NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date DATE NOT NULL,
+    UNIQUE (owner_id, collection_name)
+)
@@ -0,0 +1,15 @@
+CREATE TABLE cook_logs (
+    id SERIAL PRIMARY KEY,
+    recipe_id INT REFERENCES recipes(id) ON DELETE CASCADE NOT NULL,
+    user_id INT REFERENCES users(id) NOT NULL,
+    cooked_date DATE NOT NULL,
+    servings_made INT NULL,
+    tweaks TEXT NULL,
+    rating SMALLINT NULL CHECK (rating BETWEEN 1 AND 5),
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date TIMESTAMP NOT NULL
+);
+
+CREATE INDEX cook_logs_user_id_cooked_date_idx ON cook_logs (user_id, cooked_date)
@@ -0,0 +1,21 @@
+/* a PDF cookbook generated in the background from a collection or a tag filter. the file is written under
+   RECIPE_IMAGES_LOCATION/cookbooks once the job is done. */
+CREATE TABLE cookbook_jobs (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    collection_id INT REFERENCES collections(id) ON DELETE SET NULL NULL,
+    tags TEXT[] DEFAULT '{}' NOT NULL,
+    layout VARCHAR(10) DEFAULT 'single' NOT NULL CHECK (layout IN ('single', 'two-up')),
+    status VARCHAR(10) DEFAULT 'pending' NOT NULL CHECK (status IN ('pending', 'running', 'done', 'failed')),
+    recipe_count INT DEFAULT 0 NOT NULL,
+    page_count INT DEFAULT 0 NOT NULL,
+    error TEXT NULL,
+    filepath VARCHAR(255) NULL,
+    size_bytes BIGINT DEFAULT 0 NOT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date TIMESTAMP NOT NULL,
+    completed_date TIMESTAMP NULL
+)
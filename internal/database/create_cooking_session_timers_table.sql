@@ -0,0 +1,18 @@
+/* named timers of a cooking session. a running timer goes off at ends_at; a paused one keeps the seconds it
+   had left in remaining_seconds. */
+CREATE TABLE cooking_session_timers (
+    id SERIAL PRIMARY KEY,
+    cooking_session_id INT REFERENCES cooking_sessions(id) ON DELETE CASCADE NOT NULL,
+    timer_name VARCHAR(255) NOT NULL,
+    step INT NULL,
+    duration_seconds INT NOT NULL CHECK (duration_seconds > 0),
+    status VARCHAR(16) NOT NULL CHECK (status IN ('running', 'paused', 'cancelled', 'finished')),
+    remaining_seconds INT NOT NULL CHECK (remaining_seconds >= 0),
+    ends_at TIMESTAMP NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date TIMESTAMP NOT NULL
+);
+
+CREATE INDEX cooking_session_timers_cooking_session_id_idx ON cooking_session_timers (cooking_session_id, id)
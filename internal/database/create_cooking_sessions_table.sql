@@ -0,0 +1,15 @@
+/* a guided cooking session following a recipe step by step. like a meal plan, it belongs to exactly one of a
+   user or a household, so everyone in the household can follow along on their own devices.
+   updated_date is the last activity; idle sessions expire. */
+CREATE TABLE cooking_sessions (
+    id SERIAL PRIMARY KEY,
+    recipe_id INT REFERENCES recipes(id) ON DELETE CASCADE NOT NULL,
+    user_id INT REFERENCES users(id) NULL,
+    household_id INT REFERENCES households(id) ON DELETE CASCADE NULL,
+    current_step INT DEFAULT 1 NOT NULL CHECK (current_step >= 1),
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date TIMESTAMP NOT NULL,
+    CHECK ((user_id IS NULL) <> (household_id IS NULL))
+)
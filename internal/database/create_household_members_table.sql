@@ -0,0 +1,7 @@
+CREATE TABLE household_members (
+    household_id INT REFERENCES households(id) ON DELETE CASCADE NOT NULL,
+    user_id INT REFERENCES users(id) NOT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date DATE DEFAULT CURRENT_DATE NOT NULL,
+    PRIMARY KEY (household_id, user_id)
+)
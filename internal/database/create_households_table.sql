@@ -0,0 +1,8 @@
+CREATE TABLE households (
+    id SERIAL PRIMARY KEY,
+    household_name VARCHAR(255) NOT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date DATE DEFAULT CURRENT_DATE NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date DATE NOT NULL
+)
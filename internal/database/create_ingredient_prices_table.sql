@@ -0,0 +1,17 @@
+/* observed ingredient prices. every observation is kept as price history, and the most recent one for an
+   ingredient is its current price. ingredient names are stored in their canonical form, e.g. "egg". */
+CREATE TABLE ingredient_prices (
+    id SERIAL PRIMARY KEY,
+    ingredient_name VARCHAR(255) NOT NULL,
+    price DOUBLE PRECISION NOT NULL CHECK (price >= 0),
+    quantity DOUBLE PRECISION NOT NULL CHECK (quantity > 0),
+    unit VARCHAR(255) NULL,
+    store VARCHAR(255) NULL,
+    observed_date DATE NOT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date TIMESTAMP NOT NULL
+);
+
+CREATE INDEX ingredient_prices_ingredient_name_idx ON ingredient_prices (ingredient_name, observed_date DESC)
@@ -0,0 +1,19 @@
+/* a meal plan entry belongs to exactly one of a user's personal plan or a household's shared plan. */
+CREATE TABLE meal_plans (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) NULL,
+    household_id INT REFERENCES households(id) ON DELETE CASCADE NULL,
+    plan_date DATE NOT NULL,
+    meal_slot VARCHAR(16) NOT NULL CHECK (meal_slot IN ('breakfast', 'lunch', 'dinner', 'snack')),
+    recipe_id INT REFERENCES recipes(id) ON DELETE CASCADE NOT NULL,
+    servings INT NULL,
+    notes TEXT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date DATE DEFAULT CURRENT_DATE NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date DATE NOT NULL,
+    CHECK ((user_id IS NULL) <> (household_id IS NULL))
+);
+
+CREATE INDEX meal_plans_user_id_plan_date_idx ON meal_plans (user_id, plan_date);
+CREATE INDEX meal_plans_household_id_plan_date_idx ON meal_plans (household_id, plan_date)
@@ -0,0 +1,18 @@
+/* like meal plans, a pantry belongs to exactly one of a user or a household. */
+CREATE TABLE pantry_items (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) NULL,
+    household_id INT REFERENCES households(id) ON DELETE CASCADE NULL,
+    ingredient_name VARCHAR(255) NOT NULL,
+    quantity DOUBLE PRECISION NOT NULL CHECK (quantity >= 0),
+    unit VARCHAR(255) NULL,
+    expiry_date DATE NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date TIMESTAMP NOT NULL,
+    CHECK ((user_id IS NULL) <> (household_id IS NULL))
+);
+
+CREATE INDEX pantry_items_user_id_idx ON pantry_items (user_id);
+CREATE INDEX pantry_items_household_id_idx ON pantry_items (household_id)
@@ -0,0 +1,5 @@
+/* trigram similarity of recipe names, so a submitted recipe is only scored against recipes with a similar name
+   when checking it for duplicates. the index lets the % operator find those recipes without reading them all. */
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX recipes_recipe_name_trgm_idx ON recipes USING gin (recipe_name gin_trgm_ops)
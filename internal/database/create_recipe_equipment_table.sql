@@ -0,0 +1,11 @@
+/* the equipment a recipe needs, such as "stand mixer" or "9x13 pan". detected from the procedure's text when
+   a recipe is submitted, and editable afterwards. */
+CREATE TABLE recipe_equipment (
+    recipe_id INT REFERENCES recipes(id) ON DELETE CASCADE NOT NULL,
+    equipment VARCHAR(64) NOT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date DATE DEFAULT CURRENT_DATE NOT NULL,
+    PRIMARY KEY (recipe_id, equipment)
+);
+
+CREATE INDEX recipe_equipment_equipment_idx ON recipe_equipment (equipment)
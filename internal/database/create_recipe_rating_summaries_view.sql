@@ -0,0 +1,8 @@
+/* average rating and number of ratings per recipe. recipes without reviews have no row. */
+CREATE VIEW recipe_rating_summaries AS
+SELECT
+    recipe_id,
+    AVG(rating)::DOUBLE PRECISION AS average_rating,
+    COUNT(*)::INT AS rating_count
+FROM recipe_reviews
+GROUP BY recipe_id
@@ -0,0 +1,10 @@
+/* free-form labels on recipes, such as dietary labels like "vegetarian" or "gluten-free". */
+CREATE TABLE recipe_tags (
+    recipe_id INT REFERENCES recipes(id) ON DELETE CASCADE NOT NULL,
+    tag VARCHAR(64) NOT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date DATE DEFAULT CURRENT_DATE NOT NULL,
+    PRIMARY KEY (recipe_id, tag)
+);
+
+CREATE INDEX recipe_tags_tag_idx ON recipe_tags (tag)
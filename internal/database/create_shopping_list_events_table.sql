@@ -0,0 +1,12 @@
+/* every change to a shopping list, replayed to clients that reconnect with a Last-Event-ID. */
+CREATE TABLE shopping_list_events (
+    id BIGSERIAL PRIMARY KEY,
+    shopping_list_id INT REFERENCES shopping_lists(id) ON DELETE CASCADE NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    item_id INT NOT NULL,
+    item JSONB NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX shopping_list_events_shopping_list_id_idx ON shopping_list_events (shopping_list_id, id)
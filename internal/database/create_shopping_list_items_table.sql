@@ -0,0 +1,22 @@
+/* version goes up by one on every change so clients can send the version they saw and get a conflict if it moved on. */
+CREATE TABLE shopping_list_items (
+    id SERIAL PRIMARY KEY,
+    shopping_list_id INT REFERENCES shopping_lists(id) ON DELETE CASCADE NOT NULL,
+    ingredient_name VARCHAR(255) NOT NULL,
+    amount DOUBLE PRECISION NULL,
+    unit VARCHAR(255) NULL,
+    aisle VARCHAR(64) NOT NULL,
+    recipes JSONB DEFAULT '[]' NOT NULL,
+    manual BOOLEAN DEFAULT FALSE NOT NULL,
+    position INT NOT NULL,
+    checked BOOLEAN DEFAULT FALSE NOT NULL,
+    checked_by INT REFERENCES users(id) NULL,
+    checked_date TIMESTAMP NULL,
+    version INT DEFAULT 1 NOT NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date TIMESTAMP NOT NULL
+);
+
+CREATE INDEX shopping_list_items_shopping_list_id_idx ON shopping_list_items (shopping_list_id, position)
@@ -0,0 +1,12 @@
+/* like meal plans, a shopping list belongs to exactly one of a user or a household. */
+CREATE TABLE shopping_lists (
+    id SERIAL PRIMARY KEY,
+    list_name VARCHAR(255) NOT NULL,
+    user_id INT REFERENCES users(id) NULL,
+    household_id INT REFERENCES households(id) ON DELETE CASCADE NULL,
+    created_by INT REFERENCES users(id) NOT NULL,
+    created_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_by INT REFERENCES users(id) NOT NULL,
+    updated_date TIMESTAMP NOT NULL,
+    CHECK ((user_id IS NULL) <> (household_id IS NULL))
+)
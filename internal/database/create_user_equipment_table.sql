@@ -0,0 +1,7 @@
+/* the equipment a user has in their kitchen, so recipes needing anything else can be left out. */
+CREATE TABLE user_equipment (
+    user_id INT REFERENCES users(id) ON DELETE CASCADE NOT NULL,
+    equipment VARCHAR(64) NOT NULL,
+    created_date DATE DEFAULT CURRENT_DATE NOT NULL,
+    PRIMARY KEY (user_id, equipment)
+)